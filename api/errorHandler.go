@@ -1,8 +1,11 @@
 package api
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 
 	validation "github.com/go-ozzo/ozzo-validation/v3"
 
@@ -17,6 +20,14 @@ type ValidationErrorResponse struct {
 	ValidationErrors map[string]string `json:"validationErrors,omitempty"`
 }
 
+// Note: This model is shared between API versions. Therefore any change here is breaking for all API versions.
+type AuthorizationErrorResponse struct {
+	Message     string `json:"message"`
+	Permission  string `json:"permission"`
+	Namespace   string `json:"namespace,omitempty"`
+	Environment string `json:"environment,omitempty"`
+}
+
 func ErrorHandler(err error, c echo.Context) {
 	var (
 		code          = http.StatusInternalServerError
@@ -62,6 +73,26 @@ func ErrorHandler(err error, c echo.Context) {
 		}
 	}
 
+	if authorizationError, ok := err.(*core.AuthorizationError); ok {
+		internalError = nil
+		code = http.StatusForbidden
+		jsonResponse = &AuthorizationErrorResponse{
+			Message:     authorizationError.Error(),
+			Permission:  authorizationError.Permission,
+			Namespace:   authorizationError.Scope.Namespace,
+			Environment: authorizationError.Scope.Environment,
+		}
+	}
+
+	// Retryable errors may be wrapped by services
+	var retryableError *core.RetryableError
+	if errors.As(err, &retryableError) {
+		internalError = nil
+		code = http.StatusServiceUnavailable
+		jsonResponse = echo.Map{"message": retryableError.Message}
+		c.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(retryableError.RetryAfter.Seconds()))))
+	}
+
 	// Checking Response().Committed is required to prevent duplicate log entries
 	// I could not figure out a way to repro this in a unit test so tests will still pass if removed
 	if !c.Response().Committed {
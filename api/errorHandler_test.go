@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation/v3"
 	"github.com/riser-platform/riser-server/pkg/core"
@@ -133,6 +134,24 @@ func Test_ErrorHandler_WhenValidationErrorNoFields(t *testing.T) {
 	assert.Len(t, jsonResponse.ValidationErrors, 0)
 }
 
+func Test_ErrorHandler_WhenAuthorizationError_Returns403(t *testing.T) {
+	logBuf := &bytes.Buffer{}
+	ctx, rec := errorHandlerTestSetup(logBuf)
+
+	err := &core.AuthorizationError{Permission: "deploy", Scope: core.AuthorizationScope{Namespace: "myns", Environment: "prod"}}
+
+	ErrorHandler(err, ctx)
+
+	assert.Empty(t, logBuf)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	jsonResponse := AuthorizationErrorResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &jsonResponse), rec.Body.String())
+	assert.Equal(t, `You do not have the "deploy" permission in namespace "myns" in environment "prod"`, jsonResponse.Message)
+	assert.Equal(t, "deploy", jsonResponse.Permission)
+	assert.Equal(t, "myns", jsonResponse.Namespace)
+	assert.Equal(t, "prod", jsonResponse.Environment)
+}
+
 func Test_ErrorHandler_WhenValidationErrorIsNil(t *testing.T) {
 	logBuf := &bytes.Buffer{}
 	ctx, rec := errorHandlerTestSetup(logBuf)
@@ -189,3 +208,17 @@ func unmarshalErrorLogLine(t *testing.T, line string) map[string]interface{} {
 	require.NoError(t, json.Unmarshal([]byte(line), &jsonLog), line)
 	return jsonLog
 }
+
+func Test_ErrorHandler_RetryableError(t *testing.T) {
+	logBuf := &bytes.Buffer{}
+	ctx, rec := errorHandlerTestSetup(logBuf)
+
+	err := errors.Wrap(&core.RetryableError{Message: "busy", RetryAfter: 1500 * time.Millisecond}, "wrapped")
+
+	ErrorHandler(err, ctx)
+
+	assert.Empty(t, logBuf)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+	assert.Equal(t, "{\"message\":\"busy\"}\n", rec.Body.String())
+}
@@ -18,6 +18,8 @@ func PostApp(c echo.Context, appService app.Service) error {
 		return err
 	}
 
+	setAuditResourceName(c, string(newAppRequest.Name))
+	setAuditAppName(c, string(newAppRequest.Name))
 	createdApp, err := appService.Create(core.NewNamespacedName(string(newAppRequest.Name), string(newAppRequest.Namespace)))
 	if err != nil {
 		return err
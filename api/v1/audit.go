@@ -0,0 +1,214 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/environment"
+	"github.com/riser-platform/riser-server/pkg/state"
+)
+
+const (
+	auditContextKey = "auditContext"
+
+	auditDefaultLimit = 100
+	auditMaxLimit     = 1000
+)
+
+// auditContext collects the details of a request that are only known to the handler
+type auditContext struct {
+	entry      *core.AuditEntry
+	committers []*state.GitCommitter
+}
+
+// audit returns middleware that records the request in the audit log. It must be added before authorize so that denied requests are recorded.
+func audit(audits core.AuditRepository, action string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			auditCtx := &auditContext{
+				entry: &core.AuditEntry{
+					Id:      uuid.New(),
+					Created: time.Now().UTC(),
+					Action:  action,
+					Doc: core.AuditDoc{
+						Method: c.Request().Method,
+						Path:   c.Path(),
+						DryRun: c.QueryParam("dryRun") == "true",
+					},
+				},
+			}
+			c.Set(auditContextKey, auditCtx)
+
+			// Handle the error here so that the response status is known
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+
+			entry := auditCtx.entry
+			entry.Username = currentUsername(c)
+			entry.StatusCode = c.Response().Status
+			if scope, ok := c.Get(authorizationScopeKey).(*core.AuthorizationScope); ok {
+				entry.Namespace = scope.Namespace
+				entry.Environment = scope.Environment
+			}
+			if entry.ResourceName == "" {
+				entry.ResourceName = resourceNameFromPath(c)
+			}
+			entry.Doc.CommitShas = []string{}
+			for _, committer := range auditCtx.committers {
+				entry.Doc.CommitShas = append(entry.Doc.CommitShas, committer.CommitShas...)
+			}
+
+			err := audits.Create(entry)
+			if err != nil {
+				c.Logger().Error(errors.Wrap(err, "Error saving audit entry"))
+			}
+			return nil
+		}
+	}
+}
+
+func resourceNameFromPath(c echo.Context) string {
+	for _, param := range []string{"deploymentName", "appName", "username", "id"} {
+		if value := c.Param(param); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// setAuditResourceName sets the name of the resource for requests where it is not in the path
+func setAuditResourceName(c echo.Context, name string) {
+	if auditCtx, ok := c.Get(auditContextKey).(*auditContext); ok {
+		auditCtx.entry.ResourceName = name
+	}
+}
+
+// setAuditAppName sets the app that the resource belongs to
+func setAuditAppName(c echo.Context, name string) {
+	if auditCtx, ok := c.Get(auditContextKey).(*auditContext); ok {
+		auditCtx.entry.AppName = name
+	}
+}
+
+// setAuditAppForDeployment sets the app that owns a deployment. The app is not recorded when the deployment does not exist.
+func setAuditAppForDeployment(c echo.Context, reservations core.DeploymentReservationRepository, apps core.AppRepository, name *core.NamespacedName) {
+	if _, ok := c.Get(auditContextKey).(*auditContext); !ok {
+		return
+	}
+
+	reservation, err := reservations.GetByName(name)
+	if err == nil {
+		var app *core.App
+		app, err = apps.Get(reservation.AppId)
+		if err == nil {
+			setAuditAppName(c, app.Name)
+			return
+		}
+	}
+	if err != core.ErrNotFound {
+		c.Logger().Error(errors.Wrap(err, fmt.Sprintf("Error retrieving the app for deployment %q", name)))
+	}
+}
+
+// newCommitter returns a committer for the environment's state. Git commits are recorded in the audit log.
+func newCommitter(c echo.Context, stateBackend environment.StateBackend, envName string) (state.Committer, error) {
+	committer, err := stateBackend.NewCommitter(c.Request().Context(), envName)
+	if err != nil {
+		return nil, err
+	}
+	if gitCommitter, ok := committer.(*state.GitCommitter); ok {
+		if auditCtx, ok := c.Get(auditContextKey).(*auditContext); ok {
+			auditCtx.committers = append(auditCtx.committers, gitCommitter)
+		}
+	}
+	return committer, nil
+}
+
+func ListAudit(c echo.Context, audits core.AuditRepository) error {
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		return err
+	}
+
+	entries, err := audits.Find(filter)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, mapAuditEntryArrayFromDomain(entries))
+}
+
+func parseAuditFilter(c echo.Context) (*core.AuditFilter, error) {
+	filter := &core.AuditFilter{
+		Username:    c.QueryParam("username"),
+		Namespace:   c.QueryParam("namespace"),
+		Environment: c.QueryParam("environment"),
+		AppName:     c.QueryParam("app"),
+		Limit:       auditDefaultLimit,
+	}
+
+	var err error
+	filter.From, err = parseTimeQueryParam(c, "from")
+	if err != nil {
+		return nil, err
+	}
+	filter.To, err = parseTimeQueryParam(c, "to")
+	if err != nil {
+		return nil, err
+	}
+
+	if limitParam := c.QueryParam("limit"); limitParam != "" {
+		filter.Limit, err = strconv.Atoi(limitParam)
+		if err != nil || filter.Limit < 1 || filter.Limit > auditMaxLimit {
+			return nil, core.NewValidationErrorMessage("limit must be a number between 1 and 1000")
+		}
+	}
+
+	return filter, nil
+}
+
+func parseTimeQueryParam(c echo.Context, name string) (*time.Time, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, core.NewValidationErrorMessage(fmt.Sprintf("%s must be an RFC3339 timestamp (e.g. 2020-10-01T12:00:00Z)", name))
+	}
+	return &parsed, nil
+}
+
+func mapAuditEntryArrayFromDomain(domainArray []core.AuditEntry) []model.AuditEntry {
+	modelArray := []model.AuditEntry{}
+	for idx := range domainArray {
+		modelArray = append(modelArray, mapAuditEntryFromDomain(&domainArray[idx]))
+	}
+	return modelArray
+}
+
+func mapAuditEntryFromDomain(domain *core.AuditEntry) model.AuditEntry {
+	return model.AuditEntry{
+		Id:          domain.Id,
+		Created:     domain.Created,
+		Username:    domain.Username,
+		Action:      domain.Action,
+		Namespace:   domain.Namespace,
+		Environment: domain.Environment,
+		Name:        domain.ResourceName,
+		App:         domain.AppName,
+		Method:      domain.Doc.Method,
+		Path:        domain.Doc.Path,
+		StatusCode:  domain.StatusCode,
+		CommitShas:  domain.Doc.CommitShas,
+		DryRun:      domain.Doc.DryRun,
+	}
+}
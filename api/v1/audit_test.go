@@ -0,0 +1,272 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/environment"
+	"github.com/riser-platform/riser-server/pkg/git"
+	"github.com/riser-platform/riser-server/pkg/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_audit(t *testing.T) {
+	req := httptest.NewRequest(http.MethodDelete, "/?dryRun=true", nil)
+	ctx, _ := newContextWithRecorder(req)
+	ctx.SetPath("/deployments/:envName/:namespace/:deploymentName")
+	ctx.SetParamNames("envName", "namespace", "deploymentName")
+	ctx.SetParamValues("prod", "myns", "mydep")
+
+	var created *core.AuditEntry
+	audits := &core.FakeAuditRepository{
+		CreateFn: func(entry *core.AuditEntry) error {
+			created = entry
+			return nil
+		},
+	}
+	gitRepo := &git.FakeRepo{
+		ResetHardRemoteFn: func(context.Context) error { return nil },
+		CommitFn:          func(string, []core.ResourceFile, *core.CommitMeta) error { return nil },
+		PushFn:            func(context.Context) error { return nil },
+		HeadShaFn:         func() (string, error) { return "abc123", nil },
+	}
+
+	err := audit(audits, "deployment.delete")(func(c echo.Context) error {
+		c.Set("username", &core.User{Username: "myuser"})
+		c.Set(authorizationScopeKey, &core.AuthorizationScope{Namespace: "myns", Environment: "prod"})
+		stateBackend := &environment.FakeStateBackend{
+			NewCommitterFn: func(ctx context.Context, envName string) (state.Committer, error) {
+				assert.Equal(t, c.Request().Context(), ctx)
+				assert.Equal(t, "prod", envName)
+				return state.NewGitCommitter(ctx, gitRepo), nil
+			},
+		}
+		committer, err := newCommitter(c, stateBackend, "prod")
+		require.NoError(t, err)
+		require.NoError(t, committer.Commit("test", nil, nil))
+		return c.JSON(http.StatusAccepted, model.APIResponse{})
+	})(ctx)
+
+	assert.NoError(t, err)
+	require.Equal(t, 1, audits.CreateCallCount)
+	assert.NotEqual(t, uuid.Nil, created.Id)
+	assert.Equal(t, "myuser", created.Username)
+	assert.Equal(t, "deployment.delete", created.Action)
+	assert.Equal(t, "myns", created.Namespace)
+	assert.Equal(t, "prod", created.Environment)
+	assert.Equal(t, "mydep", created.ResourceName)
+	assert.Equal(t, http.StatusAccepted, created.StatusCode)
+	assert.Equal(t, http.MethodDelete, created.Doc.Method)
+	assert.Equal(t, "/deployments/:envName/:namespace/:deploymentName", created.Doc.Path)
+	assert.Equal(t, []string{"abc123"}, created.Doc.CommitShas)
+	assert.True(t, created.Doc.DryRun)
+}
+
+func Test_audit_RecordsErrors(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	ctx, rec := newContextWithRecorder(req)
+
+	var created *core.AuditEntry
+	audits := &core.FakeAuditRepository{
+		CreateFn: func(entry *core.AuditEntry) error {
+			created = entry
+			return nil
+		},
+	}
+
+	err := audit(audits, "app.create")(func(c echo.Context) error {
+		setAuditResourceName(c, "myapp")
+		setAuditAppName(c, "myapp")
+		return echo.NewHTTPError(http.StatusForbidden, "nope")
+	})(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, http.StatusForbidden, created.StatusCode)
+	assert.Equal(t, "myapp", created.ResourceName)
+	assert.Equal(t, "myapp", created.AppName)
+	assert.Empty(t, created.Doc.CommitShas)
+}
+
+func Test_audit_CreateError_DoesNotFailRequest(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	ctx, rec := newContextWithRecorder(req)
+
+	audits := &core.FakeAuditRepository{
+		CreateFn: func(entry *core.AuditEntry) error {
+			return errors.New("test")
+		},
+	}
+
+	err := audit(audits, "app.create")(func(c echo.Context) error {
+		return c.JSON(http.StatusCreated, model.APIResponse{})
+	})(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
+
+func Test_setAuditAppForDeployment(t *testing.T) {
+	req := httptest.NewRequest(http.MethodDelete, "/", nil)
+	ctx, _ := newContextWithRecorder(req)
+	appId := uuid.New()
+
+	var created *core.AuditEntry
+	audits := &core.FakeAuditRepository{
+		CreateFn: func(entry *core.AuditEntry) error {
+			created = entry
+			return nil
+		},
+	}
+	reservations := &core.FakeDeploymentReservationRepository{
+		GetByNameFn: func(name *core.NamespacedName) (*core.DeploymentReservation, error) {
+			assert.Equal(t, core.NewNamespacedName("mydep", "myns"), name)
+			return &core.DeploymentReservation{AppId: appId}, nil
+		},
+	}
+	apps := &core.FakeAppRepository{
+		GetFn: func(id uuid.UUID) (*core.App, error) {
+			assert.Equal(t, appId, id)
+			return &core.App{Id: appId, Name: "myapp", Namespace: "myns"}, nil
+		},
+	}
+
+	err := audit(audits, "deployment.delete")(func(c echo.Context) error {
+		setAuditResourceName(c, "mydep")
+		setAuditAppForDeployment(c, reservations, apps, core.NewNamespacedName("mydep", "myns"))
+		return c.JSON(http.StatusAccepted, model.APIResponse{})
+	})(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "mydep", created.ResourceName)
+	assert.Equal(t, "myapp", created.AppName)
+}
+
+func Test_setAuditAppForDeployment_NotFound(t *testing.T) {
+	req := httptest.NewRequest(http.MethodDelete, "/", nil)
+	ctx, _ := newContextWithRecorder(req)
+
+	var created *core.AuditEntry
+	audits := &core.FakeAuditRepository{
+		CreateFn: func(entry *core.AuditEntry) error {
+			created = entry
+			return nil
+		},
+	}
+	reservations := &core.FakeDeploymentReservationRepository{
+		GetByNameFn: func(*core.NamespacedName) (*core.DeploymentReservation, error) {
+			return nil, core.ErrNotFound
+		},
+	}
+
+	err := audit(audits, "deployment.delete")(func(c echo.Context) error {
+		setAuditAppForDeployment(c, reservations, &core.FakeAppRepository{}, core.NewNamespacedName("mydep", "myns"))
+		return c.JSON(http.StatusNotFound, model.APIResponse{})
+	})(ctx)
+
+	assert.NoError(t, err)
+	assert.Empty(t, created.AppName)
+}
+
+func Test_setAuditAppForDeployment_NotAudited(t *testing.T) {
+	ctx, _ := newContextWithRecorder(httptest.NewRequest(http.MethodDelete, "/", nil))
+	reservations := &core.FakeDeploymentReservationRepository{}
+
+	assert.NotPanics(t, func() {
+		setAuditAppForDeployment(ctx, reservations, &core.FakeAppRepository{}, core.NewNamespacedName("mydep", "myns"))
+	})
+}
+
+func Test_parseAuditFilter(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/?username=myuser&namespace=myns&environment=prod&app=myapp&from=2020-10-01T00:00:00Z&to=2020-10-02T00:00:00Z&limit=10", nil)
+	ctx, _ := newContextWithRecorder(req)
+
+	result, err := parseAuditFilter(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, "myuser", result.Username)
+	assert.Equal(t, "myns", result.Namespace)
+	assert.Equal(t, "prod", result.Environment)
+	assert.Equal(t, "myapp", result.AppName)
+	assert.Equal(t, time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC), *result.From)
+	assert.Equal(t, time.Date(2020, 10, 2, 0, 0, 0, 0, time.UTC), *result.To)
+	assert.Equal(t, 10, result.Limit)
+}
+
+func Test_parseAuditFilter_Defaults(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	ctx, _ := newContextWithRecorder(req)
+
+	result, err := parseAuditFilter(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, &core.AuditFilter{Limit: auditDefaultLimit}, result)
+}
+
+func Test_parseAuditFilter_Invalid(t *testing.T) {
+	tt := []struct {
+		query    string
+		expected string
+	}{
+		{"from=yesterday", "from must be an RFC3339 timestamp (e.g. 2020-10-01T12:00:00Z)"},
+		{"to=2020-10-01", "to must be an RFC3339 timestamp (e.g. 2020-10-01T12:00:00Z)"},
+		{"limit=0", "limit must be a number between 1 and 1000"},
+		{"limit=1001", "limit must be a number between 1 and 1000"},
+		{"limit=all", "limit must be a number between 1 and 1000"},
+	}
+
+	for _, test := range tt {
+		req := httptest.NewRequest(http.MethodGet, "/?"+test.query, nil)
+		ctx, _ := newContextWithRecorder(req)
+
+		result, err := parseAuditFilter(ctx)
+
+		assert.Nil(t, result)
+		assert.IsType(t, &core.ValidationError{}, err, test.query)
+		assert.Equal(t, test.expected, err.Error(), test.query)
+	}
+}
+
+func Test_mapAuditEntryFromDomain(t *testing.T) {
+	domain := &core.AuditEntry{
+		Id:           uuid.New(),
+		Created:      time.Now(),
+		Username:     "myuser",
+		Action:       "secret.save",
+		Namespace:    "myns",
+		Environment:  "prod",
+		ResourceName: "mysecret",
+		AppName:      "myapp",
+		StatusCode:   http.StatusOK,
+		Doc: core.AuditDoc{
+			Method:     http.MethodPut,
+			Path:       "/api/v1/secrets",
+			CommitShas: []string{"abc123"},
+		},
+	}
+
+	result := mapAuditEntryFromDomain(domain)
+
+	assert.Equal(t, domain.Id, result.Id)
+	assert.Equal(t, domain.Created, result.Created)
+	assert.Equal(t, "myuser", result.Username)
+	assert.Equal(t, "secret.save", result.Action)
+	assert.Equal(t, "myns", result.Namespace)
+	assert.Equal(t, "prod", result.Environment)
+	assert.Equal(t, "mysecret", result.Name)
+	assert.Equal(t, "myapp", result.App)
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	assert.Equal(t, http.MethodPut, result.Method)
+	assert.Equal(t, "/api/v1/secrets", result.Path)
+	assert.Equal(t, []string{"abc123"}, result.CommitShas)
+	assert.False(t, result.DryRun)
+}
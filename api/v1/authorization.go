@@ -0,0 +1,193 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/riser-platform/riser-server/api"
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/authorization"
+	"github.com/riser-platform/riser-server/pkg/core"
+)
+
+// authorizationScopeKey is the context key for the scope of an authorized request
+const authorizationScopeKey = "authorizationScope"
+
+// scopeFunc returns the namespace and environment that a request acts on
+type scopeFunc func(c echo.Context) (*core.AuthorizationScope, error)
+
+// authorize returns middleware that requires the current user to have a permission in the scope of the request
+func authorize(authorizationService authorization.Service, permission string, scope scopeFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			user, ok := c.Get("username").(*core.User)
+			if !ok {
+				return echo.ErrUnauthorized
+			}
+
+			authorizationScope, err := scope(c)
+			if err != nil {
+				return err
+			}
+			c.Set(authorizationScopeKey, authorizationScope)
+
+			err = authorizationService.Authorize(user, permission, authorizationScope)
+			if err != nil {
+				return err
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// unscoped is for requests that do not act on a specific namespace or environment. Only role bindings that apply to all namespaces
+// and environments are in scope.
+func unscoped(echo.Context) (*core.AuthorizationScope, error) {
+	return &core.AuthorizationScope{}, nil
+}
+
+// pathScope uses the namespace and envName path parameters
+func pathScope(c echo.Context) (*core.AuthorizationScope, error) {
+	return &core.AuthorizationScope{Namespace: c.Param("namespace"), Environment: c.Param("envName")}, nil
+}
+
+func deploymentRequestScope(c echo.Context) (*core.AuthorizationScope, error) {
+	deploymentRequest := &model.SaveDeploymentRequest{}
+	err := peekBody(c, deploymentRequest)
+	if err != nil {
+		return nil, err
+	}
+	return &core.AuthorizationScope{Namespace: string(deploymentRequest.App.Namespace), Environment: deploymentRequest.Environment}, nil
+}
+
+func promoteRequestScope(c echo.Context) (*core.AuthorizationScope, error) {
+	promotionRequest := &model.PromoteDeploymentRequest{}
+	err := peekBody(c, promotionRequest)
+	if err != nil {
+		return nil, err
+	}
+	return &core.AuthorizationScope{Namespace: string(promotionRequest.Namespace), Environment: promotionRequest.TargetEnvironment}, nil
+}
+
+func secretRequestScope(c echo.Context) (*core.AuthorizationScope, error) {
+	unsealedSecret := &model.UnsealedSecret{}
+	err := peekBody(c, unsealedSecret)
+	if err != nil {
+		return nil, err
+	}
+	return &core.AuthorizationScope{Namespace: string(unsealedSecret.Namespace), Environment: unsealedSecret.Environment}, nil
+}
+
+func newAppRequestScope(c echo.Context) (*core.AuthorizationScope, error) {
+	newApp := &model.NewApp{}
+	err := peekBody(c, newApp)
+	if err != nil {
+		return nil, err
+	}
+	return &core.AuthorizationScope{Namespace: string(newApp.Namespace)}, nil
+}
+
+func appConfigRequestScope(c echo.Context) (*core.AuthorizationScope, error) {
+	appConfig := &model.AppConfigWithOverrides{}
+	err := peekBody(c, appConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &core.AuthorizationScope{Namespace: string(appConfig.Namespace)}, nil
+}
+
+// peekBody decodes the request body without consuming it so that the handler is able to bind the same body
+func peekBody(c echo.Context, i interface{}) error {
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return err
+	}
+	c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+	err = json.Unmarshal(body, i)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body").SetInternal(err)
+	}
+
+	if modelWithDefaults, ok := i.(api.DefaultApplier); ok {
+		return modelWithDefaults.ApplyDefaults()
+	}
+
+	return nil
+}
+
+func ListRoleBindings(c echo.Context, roleBindings core.RoleBindingRepository) error {
+	domainArray, err := roleBindings.List()
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, mapRoleBindingArrayFromDomain(domainArray))
+}
+
+func PostRoleBinding(c echo.Context, authorizationService authorization.Service) error {
+	newRoleBinding := &model.NewRoleBinding{}
+	err := c.Bind(newRoleBinding)
+	if err != nil {
+		return err
+	}
+
+	binding := &core.RoleBinding{
+		Username:    newRoleBinding.Username,
+		Group:       newRoleBinding.Group,
+		Role:        newRoleBinding.Role,
+		Namespace:   newRoleBinding.Namespace,
+		Environment: newRoleBinding.Environment,
+	}
+	if binding.Group == "" {
+		setAuditResourceName(c, newRoleBinding.Username)
+	} else {
+		setAuditResourceName(c, newRoleBinding.Group)
+	}
+	err = authorizationService.Grant(binding)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, mapRoleBindingFromDomain(binding))
+}
+
+func DeleteRoleBinding(c echo.Context, authorizationService authorization.Service) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return core.NewValidationError("Invalid role binding id", err)
+	}
+
+	err = authorizationService.Revoke(id)
+	if err != nil {
+		if err == core.ErrNotFound {
+			return c.JSON(http.StatusNotFound, model.APIResponse{Message: "Role binding not found"})
+		}
+		return err
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{Message: "Role binding revoked"})
+}
+
+func mapRoleBindingArrayFromDomain(domainArray []core.RoleBinding) []model.RoleBinding {
+	modelArray := []model.RoleBinding{}
+	for idx := range domainArray {
+		modelArray = append(modelArray, mapRoleBindingFromDomain(&domainArray[idx]))
+	}
+	return modelArray
+}
+
+func mapRoleBindingFromDomain(domain *core.RoleBinding) model.RoleBinding {
+	return model.RoleBinding{
+		Id:          domain.Id,
+		Username:    domain.Username,
+		Group:       domain.Group,
+		Role:        domain.Role,
+		Namespace:   domain.Namespace,
+		Environment: domain.Environment,
+	}
+}
@@ -0,0 +1,222 @@
+package v1
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/authorization"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_authorize(t *testing.T) {
+	user := &core.User{Username: "myuser"}
+	req := httptest.NewRequest(http.MethodDelete, "/", nil)
+	ctx, _ := newContextWithRecorder(req)
+	ctx.Set("username", user)
+	ctx.SetParamNames("envName", "namespace")
+	ctx.SetParamValues("prod", "myns")
+
+	authorizationService := &authorization.FakeService{
+		AuthorizeFn: func(userArg *core.User, permission string, scope *core.AuthorizationScope) error {
+			assert.Equal(t, user, userArg)
+			assert.Equal(t, authorization.PermissionDeploy, permission)
+			assert.Equal(t, &core.AuthorizationScope{Namespace: "myns", Environment: "prod"}, scope)
+			return nil
+		},
+	}
+
+	nextCalled := false
+	err := authorize(authorizationService, authorization.PermissionDeploy, pathScope)(func(echo.Context) error {
+		nextCalled = true
+		return nil
+	})(ctx)
+
+	assert.NoError(t, err)
+	assert.True(t, nextCalled)
+	assert.Equal(t, 1, authorizationService.AuthorizeCallCount)
+}
+
+func Test_authorize_WhenNotAuthorized(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	ctx, _ := newContextWithRecorder(req)
+	ctx.Set("username", &core.User{})
+
+	authErr := &core.AuthorizationError{Permission: authorization.PermissionAdmin}
+	authorizationService := &authorization.FakeService{
+		AuthorizeFn: func(*core.User, string, *core.AuthorizationScope) error {
+			return authErr
+		},
+	}
+
+	err := authorize(authorizationService, authorization.PermissionAdmin, unscoped)(func(echo.Context) error {
+		require.Fail(t, "next should not be called")
+		return nil
+	})(ctx)
+
+	assert.Equal(t, authErr, err)
+}
+
+func Test_authorize_WhenNoUser(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	ctx, _ := newContextWithRecorder(req)
+
+	err := authorize(&authorization.FakeService{}, authorization.PermissionAdmin, unscoped)(func(echo.Context) error {
+		require.Fail(t, "next should not be called")
+		return nil
+	})(ctx)
+
+	assert.Equal(t, echo.ErrUnauthorized, err)
+}
+
+func Test_authorize_NamespaceViewer_Unscoped(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	ctx, _ := newContextWithRecorder(req)
+	ctx.Set("username", &core.User{Id: uuid.New()})
+	roleBindings := &core.FakeRoleBindingRepository{
+		FindByUserFn: func(uuid.UUID, []string) ([]core.RoleBinding, error) {
+			return []core.RoleBinding{{Role: model.RoleViewer, Namespace: "myns"}}, nil
+		},
+	}
+
+	// e.g. listing the apps in every namespace
+	err := authorize(authorization.NewService(nil, roleBindings), authorization.PermissionRead, unscoped)(func(echo.Context) error {
+		require.Fail(t, "next should not be called")
+		return nil
+	})(ctx)
+
+	assert.Equal(t, &core.AuthorizationError{Permission: authorization.PermissionRead}, err)
+}
+
+func Test_appConfigRequestScope(t *testing.T) {
+	appConfig := model.AppConfigWithOverrides{
+		AppConfig: model.AppConfig{Namespace: "myns"},
+	}
+	req := httptest.NewRequest(http.MethodPost, "/", safeMarshal(appConfig))
+	ctx, _ := newContextWithRecorder(req)
+
+	scope, err := appConfigRequestScope(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &core.AuthorizationScope{Namespace: "myns"}, scope)
+}
+
+func Test_deploymentRequestScope(t *testing.T) {
+	deploymentRequest := model.SaveDeploymentRequest{
+		DeploymentMeta: model.DeploymentMeta{Environment: "prod"},
+	}
+	req := httptest.NewRequest(http.MethodPost, "/", safeMarshal(deploymentRequest))
+	ctx, _ := newContextWithRecorder(req)
+
+	scope, err := deploymentRequestScope(ctx)
+
+	assert.NoError(t, err)
+	// Uses the default namespace when one is not specified
+	assert.Equal(t, &core.AuthorizationScope{Namespace: "apps", Environment: "prod"}, scope)
+
+	// The body must be available to the handler
+	body, err := io.ReadAll(ctx.Request().Body)
+	require.NoError(t, err)
+	bodyRequest := model.SaveDeploymentRequest{}
+	require.NoError(t, json.Unmarshal(body, &bodyRequest))
+	assert.Equal(t, "prod", bodyRequest.Environment)
+}
+
+func Test_deploymentRequestScope_InvalidBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	ctx, _ := newContextWithRecorder(req)
+
+	_, err := deploymentRequestScope(ctx)
+
+	require.IsType(t, &echo.HTTPError{}, err)
+	assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+}
+
+func Test_PostRoleBinding(t *testing.T) {
+	newRoleBinding := model.NewRoleBinding{Username: "myuser", Role: model.RoleDeployer, Namespace: "myns"}
+	req := httptest.NewRequest(http.MethodPost, "/", safeMarshal(newRoleBinding))
+	req.Header.Add("Content-Type", "application/json")
+	ctx, rec := newContextWithRecorder(req)
+
+	authorizationService := &authorization.FakeService{
+		GrantFn: func(binding *core.RoleBinding) error {
+			assert.Equal(t, "myuser", binding.Username)
+			assert.Equal(t, model.RoleDeployer, binding.Role)
+			assert.Equal(t, "myns", binding.Namespace)
+			assert.Empty(t, binding.Environment)
+			binding.Id = uuid.New()
+			return nil
+		},
+	}
+
+	err := PostRoleBinding(ctx, authorizationService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	response := model.RoleBinding{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.NotEqual(t, uuid.Nil, response.Id)
+	assert.Equal(t, "myuser", response.Username)
+}
+
+func Test_PostRoleBinding_Group(t *testing.T) {
+	newRoleBinding := model.NewRoleBinding{Group: "dev", Role: model.RoleViewer}
+	req := httptest.NewRequest(http.MethodPost, "/", safeMarshal(newRoleBinding))
+	req.Header.Add("Content-Type", "application/json")
+	ctx, rec := newContextWithRecorder(req)
+
+	authorizationService := &authorization.FakeService{
+		GrantFn: func(binding *core.RoleBinding) error {
+			assert.Empty(t, binding.Username)
+			assert.Equal(t, "dev", binding.Group)
+			binding.Id = uuid.New()
+			return nil
+		},
+	}
+
+	err := PostRoleBinding(ctx, authorizationService)
+
+	assert.NoError(t, err)
+	response := model.RoleBinding{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "dev", response.Group)
+}
+
+func Test_DeleteRoleBinding(t *testing.T) {
+	id := uuid.New()
+	req := httptest.NewRequest(http.MethodDelete, "/", nil)
+	ctx, rec := newContextWithRecorder(req)
+	ctx.SetParamNames("id")
+	ctx.SetParamValues(id.String())
+
+	authorizationService := &authorization.FakeService{
+		RevokeFn: func(idArg uuid.UUID) error {
+			assert.Equal(t, id, idArg)
+			return core.ErrNotFound
+		},
+	}
+
+	err := DeleteRoleBinding(ctx, authorizationService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func Test_mapRoleBindingFromDomain(t *testing.T) {
+	domain := &core.RoleBinding{Id: uuid.New(), UserId: uuid.New(), Username: "myuser", Role: model.RoleViewer, Namespace: "myns", Environment: "prod"}
+
+	result := mapRoleBindingFromDomain(domain)
+
+	assert.Equal(t, domain.Id, result.Id)
+	assert.Equal(t, "myuser", result.Username)
+	assert.Equal(t, model.RoleViewer, result.Role)
+	assert.Equal(t, "myns", result.Namespace)
+	assert.Equal(t, "prod", result.Environment)
+}
@@ -2,6 +2,7 @@ package v1
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -20,7 +21,7 @@ import (
 )
 
 // TODO: Refactor and add unit test coverage
-func PostDeployment(c echo.Context, repoCache *environment.RepoCache, appService app.Service, deploymentService deployment.Service, environmentService environment.Service) error {
+func PostDeployment(c echo.Context, stateBackend environment.StateBackend, appService app.Service, deploymentService deployment.Service, environmentService environment.Service) error {
 	deploymentRequest := &model.SaveDeploymentRequest{}
 	err := c.Bind(deploymentRequest)
 	if err != nil {
@@ -38,6 +39,9 @@ func PostDeployment(c echo.Context, repoCache *environment.RepoCache, appService
 	if err != nil {
 		return err
 	}
+	newDeployment.DeployedBy = currentUsername(c)
+	setAuditResourceName(c, newDeployment.Name)
+	setAuditAppName(c, string(deploymentRequest.App.Name))
 
 	err = appService.CheckID(deploymentRequest.App.AppConfig.Id, core.NewNamespacedName(string(deploymentRequest.App.Name), string(deploymentRequest.App.Namespace)))
 	if err != nil {
@@ -49,11 +53,10 @@ func PostDeployment(c echo.Context, repoCache *environment.RepoCache, appService
 	if isDryRun {
 		committer = state.NewDryRunCommitter()
 	} else {
-		gitRepo, err := repoCache.GetRepo(newDeployment.EnvironmentName)
+		committer, err = newCommitter(c, stateBackend, newDeployment.EnvironmentName)
 		if err != nil {
 			return err
 		}
-		committer = state.NewGitCommitter(gitRepo)
 	}
 
 	riserRevision, err := deploymentService.Update(newDeployment, committer, isDryRun)
@@ -65,28 +68,119 @@ func PostDeployment(c echo.Context, repoCache *environment.RepoCache, appService
 	}
 
 	if isDryRun {
-		dryRunCommitter := committer.(*state.DryRunCommitter)
-		return c.JSON(http.StatusAccepted, model.SaveDeploymentResponse{
-
-			Message:       "Dry run: changes not applied",
-			DryRunCommits: mapDryRunCommitsFromDomain(dryRunCommitter.Commits),
-		})
+		return dryRunDeploymentResponse(c, stateBackend, newDeployment.EnvironmentName, committer.(*state.DryRunCommitter))
 	}
 
 	return c.JSON(http.StatusAccepted, model.SaveDeploymentResponse{RiserRevision: riserRevision, Message: "Deployment requested"})
 }
 
-func DeleteDeployment(c echo.Context, repoCache *environment.RepoCache, deploymentService deployment.Service) error {
+func PostDeploymentRollback(c echo.Context, stateBackend environment.StateBackend, deploymentService deployment.Service, environmentService environment.Service, reservations core.DeploymentReservationRepository, apps core.AppRepository) error {
+	envName := c.Param("envName")
+	name := core.NewNamespacedName(c.Param("deploymentName"), c.Param("namespace"))
+	setAuditAppForDeployment(c, reservations, apps, name)
+	err := environmentService.ValidateDeployable(envName)
+	if err != nil {
+		return err
+	}
+
+	rollbackRequest := &model.RollbackRequest{}
+	err = c.Bind(rollbackRequest)
+	if err != nil {
+		return err
+	}
+
+	isDryRun := c.QueryParam("dryRun") == "true"
+
+	var committer state.Committer
+
+	if isDryRun {
+		committer = state.NewDryRunCommitter()
+	} else {
+		committer, err = newCommitter(c, stateBackend, envName)
+		if err != nil {
+			return err
+		}
+	}
+
+	riserRevision, err := deploymentService.Rollback(
+		name,
+		envName,
+		rollbackRequest.RiserRevision,
+		currentUsername(c),
+		committer,
+		isDryRun)
+	if err != nil {
+		if err == git.ErrNoChanges {
+			return c.JSON(http.StatusOK, model.SaveDeploymentResponse{Message: "No changes to deploy"})
+		}
+		return err
+	}
+
+	if isDryRun {
+		return dryRunDeploymentResponse(c, stateBackend, envName, committer.(*state.DryRunCommitter))
+	}
+
+	return c.JSON(http.StatusAccepted, model.SaveDeploymentResponse{RiserRevision: riserRevision, Message: "Rollback requested"})
+}
+
+func PostDeploymentPromotion(c echo.Context, stateBackend environment.StateBackend, deploymentService deployment.Service, environmentService environment.Service, reservations core.DeploymentReservationRepository, apps core.AppRepository) error {
+	promotionRequest := &model.PromoteDeploymentRequest{}
+	err := c.Bind(promotionRequest)
+	if err != nil {
+		return err
+	}
+
+	for _, envName := range []string{promotionRequest.SourceEnvironment, promotionRequest.TargetEnvironment} {
+		err = environmentService.ValidateDeployable(envName)
+		if err != nil {
+			return err
+		}
+	}
+
+	isDryRun := c.QueryParam("dryRun") == "true"
+	setAuditResourceName(c, promotionRequest.Name)
+	setAuditAppForDeployment(c, reservations, apps, core.NewNamespacedName(promotionRequest.Name, string(promotionRequest.Namespace)))
+
+	var committer state.Committer
+
+	if isDryRun {
+		committer = state.NewDryRunCommitter()
+	} else {
+		committer, err = newCommitter(c, stateBackend, promotionRequest.TargetEnvironment)
+		if err != nil {
+			return err
+		}
+	}
+
+	riserRevision, err := deploymentService.Promote(mapPromoteDeploymentRequestToDomain(promotionRequest, currentUsername(c)), committer, isDryRun)
+	if err != nil {
+		if err == git.ErrNoChanges {
+			return c.JSON(http.StatusOK, model.SaveDeploymentResponse{Message: "No changes to deploy"})
+		}
+		return err
+	}
+
+	if isDryRun {
+		return dryRunDeploymentResponse(c, stateBackend, promotionRequest.TargetEnvironment, committer.(*state.DryRunCommitter))
+	}
+
+	return c.JSON(http.StatusAccepted, model.SaveDeploymentResponse{RiserRevision: riserRevision, Message: "Promotion requested"})
+}
+
+func DeleteDeployment(c echo.Context, stateBackend environment.StateBackend, deploymentService deployment.Service, reservations core.DeploymentReservationRepository, apps core.AppRepository) error {
 	envName := c.Param("envName")
-	gitRepo, err := repoCache.GetRepo(envName)
+	name := core.NewNamespacedName(c.Param("deploymentName"), c.Param("namespace"))
+	setAuditAppForDeployment(c, reservations, apps, name)
+	committer, err := newCommitter(c, stateBackend, envName)
 	if err != nil {
 		return err
 	}
 
 	err = deploymentService.Delete(
-		core.NewNamespacedName(c.Param("deploymentName"), c.Param("namespace")),
+		name,
 		envName,
-		state.NewGitCommitter(gitRepo))
+		currentUsername(c),
+		committer)
 
 	if err != nil {
 		if err == git.ErrNoChanges {
@@ -117,6 +211,64 @@ func PutDeploymentStatus(c echo.Context, deployments core.DeploymentRepository)
 	return err
 }
 
+func GetDeploymentRevisions(c echo.Context, revisions core.DeploymentRevisionRepository) error {
+	domainRevisions, err := revisions.ListByDeployment(
+		core.NewNamespacedName(c.Param("deploymentName"), c.Param("namespace")), c.Param("envName"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, mapDeploymentRevisionArrayFromDomain(domainRevisions))
+}
+
+func mapDeploymentRevisionArrayFromDomain(domainArray []core.DeploymentRevision) []model.DeploymentRevision {
+	revisions := []model.DeploymentRevision{}
+	for _, domain := range domainArray {
+		revision := model.DeploymentRevision{
+			RiserRevision: domain.RiserRevision,
+			Docker:        model.DeploymentDocker{Tag: domain.Doc.Docker.Tag},
+			App:           domain.Doc.App,
+			Traffic:       []model.TrafficRule{},
+			DeployedBy:    domain.Doc.DeployedBy,
+			Created:       domain.Doc.Created,
+		}
+		for _, rule := range domain.Doc.Traffic {
+			revision.Traffic = append(revision.Traffic, model.TrafficRule{RiserRevision: rule.RiserRevision, Percent: rule.Percent})
+		}
+		revisions = append(revisions, revision)
+	}
+
+	return revisions
+}
+
+// dryRunDeploymentResponse returns the commits that a dry run would have made. When diff=true each commit includes a unified diff
+// against the current state of the environment.
+func dryRunDeploymentResponse(c echo.Context, stateBackend environment.StateBackend, envName string, committer *state.DryRunCommitter) error {
+	response := model.SaveDeploymentResponse{
+		Message:       "Dry run: changes not applied",
+		DryRunCommits: mapDryRunCommitsFromDomain(committer.Commits),
+	}
+
+	if c.QueryParam("diff") == "true" {
+		reader, err := stateBackend.NewReader(c.Request().Context(), envName)
+		if err != nil {
+			return err
+		}
+		diffs, err := state.DiffCommits(reader, committer.Commits)
+		if err != nil {
+			return err
+		}
+		hasChanges := false
+		for idx, diff := range diffs {
+			response.DryRunCommits[idx].Diff = diff
+			hasChanges = hasChanges || diff != ""
+		}
+		response.HasChanges = &hasChanges
+	}
+
+	return c.JSON(http.StatusAccepted, response)
+}
+
 func mapDryRunCommitsFromDomain(commits []state.DryRunCommit) []model.DryRunCommit {
 	out := []model.DryRunCommit{}
 	for _, commit := range commits {
@@ -132,6 +284,16 @@ func mapDryRunCommitsFromDomain(commits []state.DryRunCommit) []model.DryRunComm
 	return out
 }
 
+func mapPromoteDeploymentRequestToDomain(promotionRequest *model.PromoteDeploymentRequest, deployedBy string) *core.DeploymentPromotion {
+	return &core.DeploymentPromotion{
+		Name:                  core.NewNamespacedName(promotionRequest.Name, string(promotionRequest.Namespace)),
+		SourceEnvironmentName: promotionRequest.SourceEnvironment,
+		TargetEnvironmentName: promotionRequest.TargetEnvironment,
+		ManualRollout:         promotionRequest.ManualRollout,
+		DeployedBy:            deployedBy,
+	}
+}
+
 func mapDeploymentRequestToDomain(deploymentRequest *model.SaveDeploymentRequest) (*core.DeploymentConfig, error) {
 	app, err := deploymentRequest.App.ApplyOverrides(deploymentRequest.Environment)
 	if err != nil {
@@ -144,7 +306,19 @@ func mapDeploymentRequestToDomain(deploymentRequest *model.SaveDeploymentRequest
 		Docker: core.DeploymentDocker{
 			Tag: deploymentRequest.Docker.Tag,
 		},
-		App:           app,
-		ManualRollout: deploymentRequest.ManualRollout,
+		App:              app,
+		AppWithOverrides: deploymentRequest.App,
+		ManualRollout:    deploymentRequest.ManualRollout,
+		Rollout:          mapRolloutStrategyToDomain(deploymentRequest.Rollout),
 	}, nil
 }
+
+func mapRolloutStrategyToDomain(strategy *model.RolloutStrategy) *core.RolloutStrategy {
+	if strategy == nil {
+		return nil
+	}
+	return &core.RolloutStrategy{
+		Steps: strategy.Steps,
+		Pause: time.Duration(strategy.PauseSeconds) * time.Second,
+	}
+}
@@ -1,10 +1,12 @@
 package v1
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
@@ -31,12 +33,12 @@ func Test_DeleteDeployment(t *testing.T) {
 	ctx.SetParamValues("dev")
 
 	deploymentService := &deployment.FakeService{
-		DeleteFn: func(name *core.NamespacedName, envName string, committer state.Committer) error {
+		DeleteFn: func(name *core.NamespacedName, envName string, deletedBy string, committer state.Committer) error {
 			return nil
 		},
 	}
 
-	err := DeleteDeployment(ctx, environment.NewFakeRepoCache(), deploymentService)
+	err := DeleteDeployment(ctx, environment.NewFakeStateBackend(), deploymentService, nil, nil)
 
 	assert.NoError(t, err)
 	assert.Equal(t, 1, deploymentService.DeleteCallCount)
@@ -54,12 +56,12 @@ func Test_DeleteDeployment_NothingToDelete(t *testing.T) {
 	ctx.SetParamValues("dev")
 
 	deploymentService := &deployment.FakeService{
-		DeleteFn: func(name *core.NamespacedName, envName string, committer state.Committer) error {
+		DeleteFn: func(name *core.NamespacedName, envName string, deletedBy string, committer state.Committer) error {
 			return git.ErrNoChanges
 		},
 	}
 
-	err := DeleteDeployment(ctx, environment.NewFakeRepoCache(), deploymentService)
+	err := DeleteDeployment(ctx, environment.NewFakeStateBackend(), deploymentService, nil, nil)
 
 	assert.NoError(t, err)
 	assert.Equal(t, http.StatusNotFound, rec.Result().StatusCode)
@@ -68,6 +70,205 @@ func Test_DeleteDeployment_NothingToDelete(t *testing.T) {
 	assert.Equal(t, "Deployment not found", apiResponse.Message)
 }
 
+func Test_PostDeploymentRollback(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/deployments/dev/myns/mydep/rollback", safeMarshal(&model.RollbackRequest{RiserRevision: 2}))
+	req.Header.Add("CONTENT-TYPE", "application/json")
+	ctx, rec := newContextWithRecorder(req)
+	ctx.SetParamNames("envName", "namespace", "deploymentName")
+	ctx.SetParamValues("dev", "myns", "mydep")
+	ctx.Set("username", &core.User{Username: "myuser"})
+
+	deploymentService := &deployment.FakeService{
+		RollbackFn: func(name *core.NamespacedName, envName string, riserRevision int64, deployedBy string, committer state.Committer, dryRun bool) (int64, error) {
+			assert.Equal(t, core.NewNamespacedName("mydep", "myns"), name)
+			assert.Equal(t, "dev", envName)
+			assert.EqualValues(t, 2, riserRevision)
+			assert.Equal(t, "myuser", deployedBy)
+			assert.IsType(t, &state.GitCommitter{}, committer)
+			assert.False(t, dryRun)
+			return 4, nil
+		},
+	}
+
+	environmentService := &environment.FakeService{
+		ValidateDeployableFn: func(envName string) error {
+			return nil
+		},
+	}
+
+	err := PostDeploymentRollback(ctx, environment.NewFakeStateBackend(), deploymentService, environmentService, nil, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, deploymentService.RollbackCallCount)
+	assert.Equal(t, http.StatusAccepted, rec.Result().StatusCode)
+	response := model.SaveDeploymentResponse{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "Rollback requested", response.Message)
+	assert.EqualValues(t, 4, response.RiserRevision)
+}
+
+func Test_PostDeploymentRollback_DryRun(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/deployments/dev/myns/mydep/rollback?dryRun=true", safeMarshal(&model.RollbackRequest{RiserRevision: 2}))
+	req.Header.Add("CONTENT-TYPE", "application/json")
+	ctx, rec := newContextWithRecorder(req)
+	ctx.SetParamNames("envName", "namespace", "deploymentName")
+	ctx.SetParamValues("dev", "myns", "mydep")
+
+	deploymentService := &deployment.FakeService{
+		RollbackFn: func(name *core.NamespacedName, envName string, riserRevision int64, deployedBy string, committer state.Committer, dryRun bool) (int64, error) {
+			assert.True(t, dryRun)
+			return 0, committer.Commit("dry run", []core.ResourceFile{{Name: "file1"}}, nil)
+		},
+	}
+
+	environmentService := &environment.FakeService{
+		ValidateDeployableFn: func(envName string) error {
+			return nil
+		},
+	}
+
+	err := PostDeploymentRollback(ctx, environment.NewFakeStateBackend(), deploymentService, environmentService, nil, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, rec.Result().StatusCode)
+	response := model.SaveDeploymentResponse{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "Dry run: changes not applied", response.Message)
+	require.Len(t, response.DryRunCommits, 1)
+	assert.Equal(t, "file1", response.DryRunCommits[0].Files[0].Name)
+}
+
+func newDryRunDiffTest(t *testing.T, query string, currentContents string) (echo.Context, *httptest.ResponseRecorder, *environment.FakeStateBackend) {
+	req := httptest.NewRequest(http.MethodPost, "/deployments/dev/myns/mydep/rollback"+query, safeMarshal(&model.RollbackRequest{RiserRevision: 2}))
+	req.Header.Add("CONTENT-TYPE", "application/json")
+	ctx, rec := newContextWithRecorder(req)
+	ctx.SetParamNames("envName", "namespace", "deploymentName")
+	ctx.SetParamValues("dev", "myns", "mydep")
+
+	stateBackend := environment.NewFakeStateBackend()
+	stateBackend.NewReaderFn = func(_ context.Context, envName string) (state.Reader, error) {
+		assert.Equal(t, "dev", envName)
+		return state.NewGitCommitter(context.Background(), &git.FakeRepo{
+			ResetHardRemoteFn: func(context.Context) error {
+				return nil
+			},
+			ReadFilesFn: func(dir string) ([]core.ResourceFile, error) {
+				if dir != "riser-config" {
+					return []core.ResourceFile{}, nil
+				}
+				return []core.ResourceFile{{Name: "riser-config/myns/mydep.yaml", Contents: []byte(currentContents)}}, nil
+			},
+		}), nil
+	}
+	return ctx, rec, stateBackend
+}
+
+func newDryRunDiffTestServices() (*deployment.FakeService, *environment.FakeService) {
+	deploymentService := &deployment.FakeService{
+		RollbackFn: func(name *core.NamespacedName, envName string, riserRevision int64, deployedBy string, committer state.Committer, dryRun bool) (int64, error) {
+			return 0, committer.Commit("dry run", []core.ResourceFile{{Name: "riser-config/myns/mydep.yaml", Contents: []byte("a: 2\n")}}, nil)
+		},
+	}
+	environmentService := &environment.FakeService{
+		ValidateDeployableFn: func(envName string) error {
+			return nil
+		},
+	}
+	return deploymentService, environmentService
+}
+
+func Test_PostDeploymentRollback_DryRun_Diff(t *testing.T) {
+	ctx, rec, stateBackend := newDryRunDiffTest(t, "?dryRun=true&diff=true", "a: 1\n")
+	deploymentService, environmentService := newDryRunDiffTestServices()
+
+	err := PostDeploymentRollback(ctx, stateBackend, deploymentService, environmentService, nil, nil)
+
+	require.NoError(t, err)
+	response := model.SaveDeploymentResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.NotNil(t, response.HasChanges)
+	assert.True(t, *response.HasChanges)
+	require.Len(t, response.DryRunCommits, 1)
+	assert.Equal(t, "--- a/riser-config/myns/mydep.yaml\n+++ b/riser-config/myns/mydep.yaml\n@@ -1 +1 @@\n-a: 1\n+a: 2\n", response.DryRunCommits[0].Diff)
+	assert.Equal(t, 1, stateBackend.NewReaderCallCount)
+}
+
+func Test_PostDeploymentRollback_DryRun_Diff_NoChanges(t *testing.T) {
+	ctx, rec, stateBackend := newDryRunDiffTest(t, "?dryRun=true&diff=true", "a: 2\n")
+	deploymentService, environmentService := newDryRunDiffTestServices()
+
+	err := PostDeploymentRollback(ctx, stateBackend, deploymentService, environmentService, nil, nil)
+
+	require.NoError(t, err)
+	response := model.SaveDeploymentResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.NotNil(t, response.HasChanges)
+	assert.False(t, *response.HasChanges)
+	assert.Empty(t, response.DryRunCommits[0].Diff)
+}
+
+func Test_PostDeploymentRollback_DryRun_NoDiff(t *testing.T) {
+	ctx, rec, stateBackend := newDryRunDiffTest(t, "?dryRun=true", "a: 1\n")
+	deploymentService, environmentService := newDryRunDiffTestServices()
+
+	err := PostDeploymentRollback(ctx, stateBackend, deploymentService, environmentService, nil, nil)
+
+	require.NoError(t, err)
+	response := model.SaveDeploymentResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Nil(t, response.HasChanges)
+	assert.Empty(t, response.DryRunCommits[0].Diff)
+	assert.Equal(t, 0, stateBackend.NewReaderCallCount)
+}
+
+func Test_PostDeploymentPromotion(t *testing.T) {
+	promotionRequest := &model.PromoteDeploymentRequest{
+		Name:              "mydep",
+		Namespace:         "myns",
+		SourceEnvironment: "dev",
+		TargetEnvironment: "prod",
+		ManualRollout:     true,
+	}
+	req := httptest.NewRequest(http.MethodPost, "/deployments/promote", safeMarshal(promotionRequest))
+	req.Header.Add("CONTENT-TYPE", "application/json")
+	ctx, rec := newContextWithRecorder(req)
+	ctx.Set("username", &core.User{Username: "myuser"})
+
+	deploymentService := &deployment.FakeService{
+		PromoteFn: func(promotion *core.DeploymentPromotion, committer state.Committer, dryRun bool) (int64, error) {
+			assert.Equal(t, &core.DeploymentPromotion{
+				Name:                  core.NewNamespacedName("mydep", "myns"),
+				SourceEnvironmentName: "dev",
+				TargetEnvironmentName: "prod",
+				ManualRollout:         true,
+				DeployedBy:            "myuser",
+			}, promotion)
+			assert.IsType(t, &state.GitCommitter{}, committer)
+			assert.False(t, dryRun)
+			return 2, nil
+		},
+	}
+
+	validatedEnvs := []string{}
+	environmentService := &environment.FakeService{
+		ValidateDeployableFn: func(envName string) error {
+			validatedEnvs = append(validatedEnvs, envName)
+			return nil
+		},
+	}
+
+	err := PostDeploymentPromotion(ctx, environment.NewFakeStateBackend(), deploymentService, environmentService, nil, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dev", "prod"}, validatedEnvs)
+	assert.Equal(t, 1, deploymentService.PromoteCallCount)
+	assert.Equal(t, http.StatusAccepted, rec.Result().StatusCode)
+	response := model.SaveDeploymentResponse{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "Promotion requested", response.Message)
+	assert.EqualValues(t, 2, response.RiserRevision)
+}
+
 func Test_PutDeploymentStatus_UpdatesStatus(t *testing.T) {
 	deploymentStatus := &model.DeploymentStatusMutable{
 		ObservedRiserRevision: 1,
@@ -134,6 +335,44 @@ func Test_PutDeploymentStatus_ReturnsErr(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func Test_GetDeploymentRevisions(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/deployments/dev/myns/mydep/revisions", nil)
+	ctx, rec := newContextWithRecorder(req)
+	ctx.SetParamNames("envName", "namespace", "deploymentName")
+	ctx.SetParamValues("dev", "myns", "mydep")
+
+	revisionRepository := &core.FakeDeploymentRevisionRepository{
+		ListByDeploymentFn: func(name *core.NamespacedName, envName string) ([]core.DeploymentRevision, error) {
+			assert.Equal(t, core.NewNamespacedName("mydep", "myns"), name)
+			assert.Equal(t, "dev", envName)
+			return []core.DeploymentRevision{
+				{
+					RiserRevision: 2,
+					Doc: core.DeploymentRevisionDoc{
+						Docker:     core.DeploymentDocker{Tag: "v2"},
+						App:        &model.AppConfig{Name: "myapp"},
+						Traffic:    core.TrafficConfig{{RiserRevision: 2, RevisionName: "mydep-2", Percent: 100}},
+						DeployedBy: "myuser",
+					},
+				},
+			}, nil
+		},
+	}
+
+	err := GetDeploymentRevisions(ctx, revisionRepository)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Result().StatusCode)
+	revisions := []model.DeploymentRevision{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &revisions))
+	require.Len(t, revisions, 1)
+	assert.EqualValues(t, 2, revisions[0].RiserRevision)
+	assert.Equal(t, "v2", revisions[0].Docker.Tag)
+	assert.EqualValues(t, "myapp", revisions[0].App.Name)
+	assert.Equal(t, []model.TrafficRule{{RiserRevision: 2, Percent: 100}}, revisions[0].Traffic)
+	assert.Equal(t, "myuser", revisions[0].DeployedBy)
+}
+
 func Test_mapDryRunCommitsFromDomain(t *testing.T) {
 	commits := []state.DryRunCommit{
 		{
@@ -193,9 +432,31 @@ func Test_mapDeploymentRequestToDomain(t *testing.T) {
 	assert.Equal(t, "myenv", result.EnvironmentName)
 	assert.Equal(t, "mytag", result.Docker.Tag)
 	assert.Equal(t, request.App.AppConfig, *result.App)
+	assert.Equal(t, request.App, result.AppWithOverrides)
 	assert.True(t, result.ManualRollout)
 }
 
+func Test_mapDeploymentRequestToDomain_Rollout(t *testing.T) {
+	request := &model.SaveDeploymentRequest{
+		DeploymentMeta: model.DeploymentMeta{
+			Name:        "mydeployment",
+			Environment: "myenv",
+			Rollout: &model.RolloutStrategy{
+				Steps:        []int{10, 50, 100},
+				PauseSeconds: 90,
+			},
+		},
+		App: &model.AppConfigWithOverrides{},
+	}
+
+	result, err := mapDeploymentRequestToDomain(request)
+
+	assert.NoError(t, err)
+	require.NotNil(t, result.Rollout)
+	assert.Equal(t, []int{10, 50, 100}, result.Rollout.Steps)
+	assert.Equal(t, 90*time.Second, result.Rollout.Pause)
+}
+
 func Test_mapDeploymentRequestToDomain_Overrides(t *testing.T) {
 	request := &model.SaveDeploymentRequest{
 		DeploymentMeta: model.DeploymentMeta{
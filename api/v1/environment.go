@@ -1,7 +1,9 @@
 package v1
 
 import (
+	"fmt"
 	"net/http"
+	"reflect"
 
 	validation "github.com/go-ozzo/ozzo-validation/v3"
 
@@ -10,9 +12,11 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/riser-platform/riser-server/pkg/core"
 	"github.com/riser-platform/riser-server/pkg/environment"
+	"github.com/riser-platform/riser-server/pkg/reconcile"
+	"github.com/riser-platform/riser-server/pkg/state"
 )
 
-// TODO: Once RBAC is implemented this should be limited to the controller.
+// PostEnvironmentPing is limited to users with the environment-controller role (see routes)
 func PostEnvironmentPing(c echo.Context, environmentService environment.Service) error {
 	envName := c.Param("envName")
 	err := validateEnvironmentName(envName)
@@ -33,7 +37,8 @@ func GetEnvironmentConfig(c echo.Context, environmentService environment.Service
 	return c.JSON(http.StatusOK, mapEnvironmentConfigFromDomain(envConfig))
 }
 
-func PutEnvironmentConfig(c echo.Context, environmentService environment.Service) error {
+// PutEnvironmentConfig reconciles the environment when the state repo or the rendering settings (renderer, kustomize) change
+func PutEnvironmentConfig(c echo.Context, stateBackend environment.StateBackend, environmentService environment.Service, reconcileService reconcile.Service) error {
 	environmentConfig := &model.EnvironmentConfig{}
 	err := c.Bind(environmentConfig)
 	if err != nil {
@@ -47,12 +52,159 @@ func PutEnvironmentConfig(c echo.Context, environmentService environment.Service
 		return err
 	}
 
+	existingConfig, err := environmentService.GetConfig(envName)
+	if err != nil {
+		return err
+	}
+	existingStateRepo := existingConfig.StateRepo
+	existingRenderer := existingConfig.Renderer
+	existingKustomize := existingConfig.KustomizeEnabled()
+
 	err = environmentService.SetConfig(envName, mapEnvironmentConfigToDomain(environmentConfig))
 	if err != nil {
 		return err
 	}
 
-	return c.NoContent(http.StatusAccepted)
+	updatedConfig, err := environmentService.GetConfig(envName)
+	if err != nil {
+		return err
+	}
+
+	stateRepoChanged := !reflect.DeepEqual(existingStateRepo, updatedConfig.StateRepo)
+	renderingChanged := existingRenderer != updatedConfig.Renderer || existingKustomize != updatedConfig.KustomizeEnabled()
+	if !stateRepoChanged && !renderingChanged {
+		return c.NoContent(http.StatusAccepted)
+	}
+
+	committer, err := newCommitter(c, stateBackend, envName)
+	if err != nil {
+		return err
+	}
+
+	result, err := reconcileService.Reconcile(envName, currentUsername(c), committer)
+	if err != nil {
+		return err
+	}
+
+	message := "The environment's state repo was changed. The environment was reconciled to the new state repo."
+	if !stateRepoChanged {
+		message = "The environment's rendering settings were changed. The environment was reconciled with the new settings. " +
+			"Run garbage collection to remove the files that are no longer rendered."
+	}
+	return c.JSON(http.StatusAccepted, mapReconcileResultFromDomain(message, result))
+}
+
+// PostEnvironmentReconcile regenerates the state of an environment from the database
+func PostEnvironmentReconcile(c echo.Context, stateBackend environment.StateBackend, environmentService environment.Service, reconcileService reconcile.Service) error {
+	envName := c.Param("envName")
+	err := environmentService.ValidateDeployable(envName)
+	if err != nil {
+		return err
+	}
+
+	isDryRun := c.QueryParam("dryRun") == "true"
+
+	var committer state.Committer
+	if isDryRun {
+		committer = state.NewDryRunCommitter()
+	} else {
+		committer, err = newCommitter(c, stateBackend, envName)
+		if err != nil {
+			return err
+		}
+	}
+
+	result, err := reconcileService.Reconcile(envName, currentUsername(c), committer)
+	if err != nil {
+		return err
+	}
+
+	if isDryRun {
+		response := mapReconcileResultFromDomain("Dry run: changes not applied", result)
+		response.DryRunCommits = mapDryRunCommitsFromDomain(committer.(*state.DryRunCommitter).Commits)
+		return c.JSON(http.StatusOK, response)
+	}
+
+	message := fmt.Sprintf("Environment %q reconciled", envName)
+	if result.NoChanges {
+		message = fmt.Sprintf("Environment %q is already up-to-date", envName)
+	}
+	return c.JSON(http.StatusAccepted, mapReconcileResultFromDomain(message, result))
+}
+
+// PostEnvironmentGC removes the files that riser no longer references from the state of an environment. Use dryRun=true for a
+// report of what would be removed.
+func PostEnvironmentGC(c echo.Context, stateBackend environment.StateBackend, environmentService environment.Service, reconcileService reconcile.Service) error {
+	envName := c.Param("envName")
+	err := environmentService.ValidateDeployable(envName)
+	if err != nil {
+		return err
+	}
+
+	isDryRun := c.QueryParam("dryRun") == "true"
+
+	var committer state.Committer
+	if isDryRun {
+		committer = state.NewDryRunCommitter()
+	} else {
+		committer, err = newCommitter(c, stateBackend, envName)
+		if err != nil {
+			return err
+		}
+	}
+
+	reader, err := stateBackend.NewReader(c.Request().Context(), envName)
+	if err != nil {
+		return err
+	}
+
+	result, err := reconcileService.CollectGarbage(envName, currentUsername(c), reader, committer)
+	if err != nil {
+		return err
+	}
+
+	if isDryRun {
+		response := mapGarbageCollectionResultFromDomain("Dry run: changes not applied", result)
+		response.DryRunCommits = mapDryRunCommitsFromDomain(committer.(*state.DryRunCommitter).Commits)
+		return c.JSON(http.StatusOK, response)
+	}
+
+	message := fmt.Sprintf("Removed %d files from environment %q", len(result.Deleted), envName)
+	if result.NoChanges {
+		message = fmt.Sprintf("Environment %q has nothing to collect", envName)
+	}
+	return c.JSON(http.StatusAccepted, mapGarbageCollectionResultFromDomain(message, result))
+}
+
+// GetEnvironmentDrift returns the last drift report for an environment. Drift is detected immediately when refresh=true or when
+// no report has been saved yet.
+func GetEnvironmentDrift(c echo.Context, stateBackend environment.StateBackend, environmentService environment.Service, reconcileService reconcile.Service) error {
+	envName := c.Param("envName")
+	err := environmentService.ValidateDeployable(envName)
+	if err != nil {
+		return err
+	}
+
+	var report *core.DriftReport
+	if c.QueryParam("refresh") != "true" {
+		report, err = reconcileService.GetDriftReport(envName)
+		if err != nil && err != core.ErrNotFound {
+			return err
+		}
+	}
+
+	if report == nil {
+		reader, err := stateBackend.NewReader(c.Request().Context(), envName)
+		if err != nil {
+			return err
+		}
+		report, err = reconcileService.DetectDrift(envName, reader)
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.JSON(http.StatusOK, mapDriftReportFromDomain(report))
 }
 
 func ListEnvironments(c echo.Context, environmentRepository core.EnvironmentRepository) error {
@@ -89,15 +241,119 @@ func mapEnvironmentMetaArrayFromDomain(domainArray []core.Environment) []model.E
 }
 
 func mapEnvironmentConfigToDomain(in *model.EnvironmentConfig) *core.EnvironmentConfig {
-	return &core.EnvironmentConfig{
+	out := &core.EnvironmentConfig{
 		SealedSecretCert:  in.SealedSecretCert,
 		PublicGatewayHost: in.PublicGatewayHost,
+		Renderer:          in.Renderer,
+		Kustomize:         in.Kustomize,
+		CertificateIssuer: in.CertificateIssuer,
+	}
+	if in.StateRepo != nil {
+		out.StateRepo = &core.EnvironmentStateRepo{
+			URL:            in.StateRepo.URL,
+			Branch:         in.StateRepo.Branch,
+			CredentialsRef: in.StateRepo.CredentialsRef,
+		}
+	}
+	if in.Resources != nil {
+		out.Resources = &core.EnvironmentResources{
+			DefaultRequests: mapEnvironmentResourceQuantitiesToDomain(in.Resources.DefaultRequests),
+			DefaultLimits:   mapEnvironmentResourceQuantitiesToDomain(in.Resources.DefaultLimits),
+			MaxLimits:       mapEnvironmentResourceQuantitiesToDomain(in.Resources.MaxLimits),
+		}
+	}
+	return out
+}
+
+func mapEnvironmentResourceQuantitiesToDomain(in *model.EnvironmentResourceQuantities) *core.EnvironmentResourceQuantities {
+	if in == nil {
+		return nil
+	}
+	return &core.EnvironmentResourceQuantities{
+		CpuCores:           in.CpuCores,
+		MemoryMB:           in.MemoryMB,
+		EphemeralStorageMB: in.EphemeralStorageMB,
 	}
 }
 
 func mapEnvironmentConfigFromDomain(in *core.EnvironmentConfig) *model.EnvironmentConfig {
-	return &model.EnvironmentConfig{
+	out := &model.EnvironmentConfig{
 		SealedSecretCert:  in.SealedSecretCert,
 		PublicGatewayHost: in.PublicGatewayHost,
+		Renderer:          in.Renderer,
+		Kustomize:         in.Kustomize,
+		CertificateIssuer: in.CertificateIssuer,
+	}
+	if in.StateRepo != nil {
+		out.StateRepo = &model.EnvironmentStateRepo{
+			URL:            in.StateRepo.URL,
+			Branch:         in.StateRepo.Branch,
+			CredentialsRef: in.StateRepo.CredentialsRef,
+		}
+	}
+	if in.Resources != nil {
+		out.Resources = &model.EnvironmentResources{
+			DefaultRequests: mapEnvironmentResourceQuantitiesFromDomain(in.Resources.DefaultRequests),
+			DefaultLimits:   mapEnvironmentResourceQuantitiesFromDomain(in.Resources.DefaultLimits),
+			MaxLimits:       mapEnvironmentResourceQuantitiesFromDomain(in.Resources.MaxLimits),
+		}
+	}
+	return out
+}
+
+func mapEnvironmentResourceQuantitiesFromDomain(in *core.EnvironmentResourceQuantities) *model.EnvironmentResourceQuantities {
+	if in == nil {
+		return nil
+	}
+	return &model.EnvironmentResourceQuantities{
+		CpuCores:           in.CpuCores,
+		MemoryMB:           in.MemoryMB,
+		EphemeralStorageMB: in.EphemeralStorageMB,
+	}
+}
+
+func mapGarbageCollectionResultFromDomain(message string, in *core.GarbageCollectionResult) model.GarbageCollectionResponse {
+	return model.GarbageCollectionResponse{
+		Message:           message,
+		Deleted:           in.Deleted,
+		SkippedNamespaces: in.SkippedNamespaces,
+	}
+}
+
+func mapDriftReportFromDomain(in *core.DriftReport) model.DriftReport {
+	out := model.DriftReport{
+		EnvironmentName:    in.EnvironmentName,
+		Created:            in.Created,
+		HasDrift:           in.Doc.HasDrift(),
+		Missing:            in.Doc.Missing,
+		Unexpected:         in.Doc.Unexpected,
+		Changed:            []model.DriftedFile{},
+		SkippedDeployments: []string{},
+		SkippedSecrets:     []string{},
+	}
+	for _, file := range in.Doc.Changed {
+		out.Changed = append(out.Changed, model.DriftedFile{Name: file.Name, Diff: file.Diff})
+	}
+	for _, name := range in.Doc.SkippedDeployments {
+		out.SkippedDeployments = append(out.SkippedDeployments, name.String())
+	}
+	for _, secret := range in.Doc.SkippedSecrets {
+		out.SkippedSecrets = append(out.SkippedSecrets, fmt.Sprintf("%s/%s", &secret.App, secret.Name))
+	}
+	return out
+}
+
+func mapReconcileResultFromDomain(message string, in *core.ReconcileResult) model.ReconcileResponse {
+	out := model.ReconcileResponse{
+		Message:            message,
+		SkippedDeployments: []string{},
+		SkippedSecrets:     []string{},
+	}
+	for _, name := range in.SkippedDeployments {
+		out.SkippedDeployments = append(out.SkippedDeployments, name.String())
+	}
+	for _, secretMeta := range in.SkippedSecrets {
+		out.SkippedSecrets = append(out.SkippedSecrets, fmt.Sprintf("%s/%s", secretMeta.App, secretMeta.Name))
 	}
+	return out
 }
@@ -1,12 +1,22 @@
 package v1
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/environment"
+	"github.com/riser-platform/riser-server/pkg/reconcile"
+	"github.com/riser-platform/riser-server/pkg/state"
 
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
 	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/util"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_mapEnvironmentMetaFromDomain(t *testing.T) {
@@ -36,24 +46,451 @@ func Test_mapEnvironmentConfigToDomain(t *testing.T) {
 	config := &model.EnvironmentConfig{
 		SealedSecretCert:  []byte{0x1},
 		PublicGatewayHost: "myhost",
+		StateRepo:         &model.EnvironmentStateRepo{URL: "git@my.org/state", Branch: "mybranch", CredentialsRef: "mycreds"},
+		Renderer:          "kubernetes",
+		Kustomize:         util.PtrBool(true),
+		CertificateIssuer: "letsencrypt",
 	}
 
 	result := mapEnvironmentConfigToDomain(config)
 
 	assert.Equal(t, []byte{0x1}, result.SealedSecretCert)
 	assert.Equal(t, "myhost", result.PublicGatewayHost)
+	assert.Equal(t, &core.EnvironmentStateRepo{URL: "git@my.org/state", Branch: "mybranch", CredentialsRef: "mycreds"}, result.StateRepo)
+	assert.Equal(t, "kubernetes", result.Renderer)
+	assert.Equal(t, util.PtrBool(true), result.Kustomize)
+	assert.Equal(t, "letsencrypt", result.CertificateIssuer)
+}
+
+func Test_mapEnvironmentConfigToDomain_NoStateRepo(t *testing.T) {
+	result := mapEnvironmentConfigToDomain(&model.EnvironmentConfig{})
+
+	assert.Nil(t, result.StateRepo)
+}
+
+func Test_mapEnvironmentConfigToDomain_Resources(t *testing.T) {
+	cpuCores := float32(0.5)
+	memoryMB := int32(512)
+	config := &model.EnvironmentConfig{
+		Resources: &model.EnvironmentResources{
+			DefaultRequests: &model.EnvironmentResourceQuantities{CpuCores: &cpuCores},
+			MaxLimits:       &model.EnvironmentResourceQuantities{MemoryMB: &memoryMB},
+		},
+	}
+
+	result := mapEnvironmentConfigToDomain(config)
+
+	assert.Equal(t, &core.EnvironmentResources{
+		DefaultRequests: &core.EnvironmentResourceQuantities{CpuCores: &cpuCores},
+		MaxLimits:       &core.EnvironmentResourceQuantities{MemoryMB: &memoryMB},
+	}, result.Resources)
 }
 
 func Test_mapEnvironmentConfigFromDomain(t *testing.T) {
 	domain := &core.EnvironmentConfig{
 		SealedSecretCert:  []byte{0x1},
 		PublicGatewayHost: "myhost",
+		StateRepo:         &core.EnvironmentStateRepo{URL: "git@my.org/state", Branch: "mybranch", CredentialsRef: "mycreds"},
+		Renderer:          "kubernetes",
+		Kustomize:         util.PtrBool(true),
+		CertificateIssuer: "letsencrypt",
 	}
 
 	result := mapEnvironmentConfigFromDomain(domain)
 
 	assert.Equal(t, []byte{0x1}, result.SealedSecretCert)
 	assert.Equal(t, "myhost", result.PublicGatewayHost)
+	assert.Equal(t, &model.EnvironmentStateRepo{URL: "git@my.org/state", Branch: "mybranch", CredentialsRef: "mycreds"}, result.StateRepo)
+	assert.Equal(t, "kubernetes", result.Renderer)
+	assert.Equal(t, util.PtrBool(true), result.Kustomize)
+	assert.Equal(t, "letsencrypt", result.CertificateIssuer)
+}
+
+func Test_mapEnvironmentConfigFromDomain_Resources(t *testing.T) {
+	storageMB := int32(1024)
+	domain := &core.EnvironmentConfig{
+		Resources: &core.EnvironmentResources{
+			DefaultLimits: &core.EnvironmentResourceQuantities{EphemeralStorageMB: &storageMB},
+		},
+	}
+
+	result := mapEnvironmentConfigFromDomain(domain)
+
+	assert.Equal(t, &model.EnvironmentResources{
+		DefaultLimits: &model.EnvironmentResourceQuantities{EphemeralStorageMB: &storageMB},
+	}, result.Resources)
+}
+
+func newPutEnvironmentConfigTest(t *testing.T, config *model.EnvironmentConfig, existing *core.EnvironmentConfig) (echo.Context, *httptest.ResponseRecorder, *environment.FakeService) {
+	req := httptest.NewRequest(http.MethodPut, "/environments/dev/config", safeMarshal(config))
+	req.Header.Add("CONTENT-TYPE", "application/json")
+	ctx, rec := newContextWithRecorder(req)
+	ctx.SetParamNames("envName")
+	ctx.SetParamValues("dev")
+
+	current := existing
+	environmentService := &environment.FakeService{
+		GetConfigFn: func(envName string) (*core.EnvironmentConfig, error) {
+			assert.Equal(t, "dev", envName)
+			return current, nil
+		},
+		SetConfigFn: func(envName string, updated *core.EnvironmentConfig) error {
+			assert.Equal(t, "dev", envName)
+			assert.Equal(t, mapEnvironmentConfigToDomain(config), updated)
+			current = updated
+			return nil
+		},
+	}
+	return ctx, rec, environmentService
+}
+
+func Test_PutEnvironmentConfig(t *testing.T) {
+	config := &model.EnvironmentConfig{PublicGatewayHost: "myhost"}
+	ctx, rec, environmentService := newPutEnvironmentConfigTest(t, config, &core.EnvironmentConfig{})
+	reconcileService := &reconcile.FakeService{}
+
+	err := PutEnvironmentConfig(ctx, environment.NewFakeStateBackend(), environmentService, reconcileService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, rec.Result().StatusCode)
+	assert.Equal(t, 1, environmentService.SetConfigCallCount)
+	assert.Equal(t, 0, reconcileService.ReconcileCallCount)
+}
+
+func Test_PutEnvironmentConfig_StateRepoUnchanged(t *testing.T) {
+	config := &model.EnvironmentConfig{StateRepo: &model.EnvironmentStateRepo{URL: "git@my.org/state"}}
+	ctx, rec, environmentService := newPutEnvironmentConfigTest(t, config,
+		&core.EnvironmentConfig{StateRepo: &core.EnvironmentStateRepo{URL: "git@my.org/state"}})
+	reconcileService := &reconcile.FakeService{}
+
+	err := PutEnvironmentConfig(ctx, environment.NewFakeStateBackend(), environmentService, reconcileService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, rec.Result().StatusCode)
+	assert.Equal(t, 0, reconcileService.ReconcileCallCount)
+}
+
+func Test_PutEnvironmentConfig_StateRepoChanged(t *testing.T) {
+	config := &model.EnvironmentConfig{StateRepo: &model.EnvironmentStateRepo{URL: "git@my.org/newstate"}}
+	ctx, rec, environmentService := newPutEnvironmentConfigTest(t, config,
+		&core.EnvironmentConfig{StateRepo: &core.EnvironmentStateRepo{URL: "git@my.org/state"}})
+	stateBackend := environment.NewFakeStateBackend()
+	reconcileService := &reconcile.FakeService{
+		ReconcileFn: func(envName string, reconciledBy string, committer state.Committer) (*core.ReconcileResult, error) {
+			assert.Equal(t, "dev", envName)
+			assert.NotNil(t, committer)
+			return &core.ReconcileResult{SkippedDeployments: []core.NamespacedName{*core.NewNamespacedName("myapp", "myns")}}, nil
+		},
+	}
+
+	err := PutEnvironmentConfig(ctx, stateBackend, environmentService, reconcileService)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, rec.Result().StatusCode)
+	assert.Equal(t, 1, reconcileService.ReconcileCallCount)
+	assert.Equal(t, 1, stateBackend.NewCommitterCallCount)
+	response := model.ReconcileResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, []string{"myapp.myns"}, response.SkippedDeployments)
+}
+
+func Test_PutEnvironmentConfig_RendererChanged(t *testing.T) {
+	config := &model.EnvironmentConfig{Renderer: model.EnvironmentRenderer_Kubernetes}
+	ctx, rec, environmentService := newPutEnvironmentConfigTest(t, config, &core.EnvironmentConfig{})
+	stateBackend := environment.NewFakeStateBackend()
+	reconcileService := &reconcile.FakeService{
+		ReconcileFn: func(envName string, reconciledBy string, committer state.Committer) (*core.ReconcileResult, error) {
+			assert.Equal(t, "dev", envName)
+			return &core.ReconcileResult{}, nil
+		},
+	}
+
+	err := PutEnvironmentConfig(ctx, stateBackend, environmentService, reconcileService)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, rec.Result().StatusCode)
+	assert.Equal(t, 1, reconcileService.ReconcileCallCount)
+	response := model.ReconcileResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Contains(t, response.Message, "rendering settings were changed")
+}
+
+func Test_PutEnvironmentConfig_KustomizeChanged(t *testing.T) {
+	config := &model.EnvironmentConfig{Kustomize: util.PtrBool(true)}
+	ctx, rec, environmentService := newPutEnvironmentConfigTest(t, config, &core.EnvironmentConfig{})
+	reconcileService := &reconcile.FakeService{
+		ReconcileFn: func(envName string, reconciledBy string, committer state.Committer) (*core.ReconcileResult, error) {
+			return &core.ReconcileResult{}, nil
+		},
+	}
+
+	err := PutEnvironmentConfig(ctx, environment.NewFakeStateBackend(), environmentService, reconcileService)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, rec.Result().StatusCode)
+	assert.Equal(t, 1, reconcileService.ReconcileCallCount)
+}
+
+func Test_PutEnvironmentConfig_KustomizeDisabled(t *testing.T) {
+	config := &model.EnvironmentConfig{Kustomize: util.PtrBool(false)}
+	ctx, rec, environmentService := newPutEnvironmentConfigTest(t, config, &core.EnvironmentConfig{Kustomize: util.PtrBool(true)})
+	reconcileService := &reconcile.FakeService{
+		ReconcileFn: func(envName string, reconciledBy string, committer state.Committer) (*core.ReconcileResult, error) {
+			return &core.ReconcileResult{}, nil
+		},
+	}
+
+	err := PutEnvironmentConfig(ctx, environment.NewFakeStateBackend(), environmentService, reconcileService)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, rec.Result().StatusCode)
+	assert.Equal(t, 1, reconcileService.ReconcileCallCount)
+}
+
+// newEnvironmentTest returns a request context for the "dev" environment
+func newEnvironmentTest(method, target string) (echo.Context, *httptest.ResponseRecorder, *environment.FakeService) {
+	req := httptest.NewRequest(method, target, nil)
+	ctx, rec := newContextWithRecorder(req)
+	ctx.SetParamNames("envName")
+	ctx.SetParamValues("dev")
+	environmentService := &environment.FakeService{
+		ValidateDeployableFn: func(string) error {
+			return nil
+		},
+	}
+	return ctx, rec, environmentService
+}
+
+func Test_PostEnvironmentReconcile(t *testing.T) {
+	ctx, rec, environmentService := newEnvironmentTest(http.MethodPost, "/environments/dev/reconcile")
+	stateBackend := environment.NewFakeStateBackend()
+	reconcileService := &reconcile.FakeService{
+		ReconcileFn: func(envName string, reconciledBy string, committer state.Committer) (*core.ReconcileResult, error) {
+			assert.Equal(t, "dev", envName)
+			assert.IsType(t, &state.GitCommitter{}, committer)
+			return &core.ReconcileResult{
+				SkippedDeployments: []core.NamespacedName{},
+				SkippedSecrets:     []core.SecretMeta{{App: core.NewNamespacedName("myapp", "myns"), Name: "mysecret"}},
+			}, nil
+		},
+	}
+
+	err := PostEnvironmentReconcile(ctx, stateBackend, environmentService, reconcileService)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, rec.Result().StatusCode)
+	response := model.ReconcileResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, `Environment "dev" reconciled`, response.Message)
+	assert.Empty(t, response.SkippedDeployments)
+	assert.Equal(t, []string{"myapp.myns/mysecret"}, response.SkippedSecrets)
+	assert.Equal(t, 1, stateBackend.NewCommitterCallCount)
+}
+
+func Test_PostEnvironmentReconcile_NoChanges(t *testing.T) {
+	ctx, rec, environmentService := newEnvironmentTest(http.MethodPost, "/environments/dev/reconcile")
+	reconcileService := &reconcile.FakeService{
+		ReconcileFn: func(string, string, state.Committer) (*core.ReconcileResult, error) {
+			return &core.ReconcileResult{NoChanges: true}, nil
+		},
+	}
+
+	err := PostEnvironmentReconcile(ctx, environment.NewFakeStateBackend(), environmentService, reconcileService)
+
+	require.NoError(t, err)
+	assert.Contains(t, rec.Body.String(), `Environment \"dev\" is already up-to-date`)
+}
+
+func Test_PostEnvironmentReconcile_DryRun(t *testing.T) {
+	ctx, rec, environmentService := newEnvironmentTest(http.MethodPost, "/environments/dev/reconcile?dryRun=true")
+	stateBackend := environment.NewFakeStateBackend()
+	reconcileService := &reconcile.FakeService{
+		ReconcileFn: func(envName string, reconciledBy string, committer state.Committer) (*core.ReconcileResult, error) {
+			err := committer.Commit("test", []core.ResourceFile{{Name: "file1", Contents: []byte("contents")}}, nil)
+			return &core.ReconcileResult{}, err
+		},
+	}
+
+	err := PostEnvironmentReconcile(ctx, stateBackend, environmentService, reconcileService)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Result().StatusCode)
+	response := model.ReconcileResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "Dry run: changes not applied", response.Message)
+	require.Len(t, response.DryRunCommits, 1)
+	assert.Equal(t, "file1", response.DryRunCommits[0].Files[0].Name)
+	assert.Equal(t, 0, stateBackend.NewCommitterCallCount)
+}
+
+func Test_PostEnvironmentReconcile_InvalidEnvironment(t *testing.T) {
+	ctx, _, environmentService := newEnvironmentTest(http.MethodPost, "/environments/dev/reconcile")
+	environmentService.ValidateDeployableFn = func(string) error {
+		return errors.New("invalid")
+	}
+	reconcileService := &reconcile.FakeService{}
+
+	err := PostEnvironmentReconcile(ctx, environment.NewFakeStateBackend(), environmentService, reconcileService)
+
+	assert.Equal(t, "invalid", err.Error())
+	assert.Equal(t, 0, reconcileService.ReconcileCallCount)
+}
+
+func Test_PostEnvironmentGC(t *testing.T) {
+	ctx, rec, environmentService := newEnvironmentTest(http.MethodPost, "/environments/dev/gc")
+	stateBackend := environment.NewFakeStateBackend()
+	reconcileService := &reconcile.FakeService{
+		CollectGarbageFn: func(envName string, collectedBy string, reader state.Reader, committer state.Committer) (*core.GarbageCollectionResult, error) {
+			assert.Equal(t, "dev", envName)
+			assert.IsType(t, &state.GitCommitter{}, committer)
+			return &core.GarbageCollectionResult{Deleted: []string{"a.yaml", "b.yaml"}, SkippedNamespaces: []string{"myns"}}, nil
+		},
+	}
+
+	err := PostEnvironmentGC(ctx, stateBackend, environmentService, reconcileService)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, rec.Result().StatusCode)
+	response := model.GarbageCollectionResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, `Removed 2 files from environment "dev"`, response.Message)
+	assert.Equal(t, []string{"a.yaml", "b.yaml"}, response.Deleted)
+	assert.Equal(t, []string{"myns"}, response.SkippedNamespaces)
+	assert.Equal(t, 1, stateBackend.NewCommitterCallCount)
+	assert.Equal(t, 1, stateBackend.NewReaderCallCount)
+}
+
+func Test_PostEnvironmentGC_NoChanges(t *testing.T) {
+	ctx, rec, environmentService := newEnvironmentTest(http.MethodPost, "/environments/dev/gc")
+	reconcileService := &reconcile.FakeService{
+		CollectGarbageFn: func(string, string, state.Reader, state.Committer) (*core.GarbageCollectionResult, error) {
+			return &core.GarbageCollectionResult{Deleted: []string{}, NoChanges: true}, nil
+		},
+	}
+
+	err := PostEnvironmentGC(ctx, environment.NewFakeStateBackend(), environmentService, reconcileService)
+
+	require.NoError(t, err)
+	assert.Contains(t, rec.Body.String(), `Environment \"dev\" has nothing to collect`)
+}
+
+func Test_PostEnvironmentGC_DryRun(t *testing.T) {
+	ctx, rec, environmentService := newEnvironmentTest(http.MethodPost, "/environments/dev/gc?dryRun=true")
+	stateBackend := environment.NewFakeStateBackend()
+	reconcileService := &reconcile.FakeService{
+		CollectGarbageFn: func(envName string, collectedBy string, reader state.Reader, committer state.Committer) (*core.GarbageCollectionResult, error) {
+			err := committer.Commit("test", []core.ResourceFile{{Name: "a.yaml", Delete: true}}, nil)
+			return &core.GarbageCollectionResult{Deleted: []string{"a.yaml"}}, err
+		},
+	}
+
+	err := PostEnvironmentGC(ctx, stateBackend, environmentService, reconcileService)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Result().StatusCode)
+	response := model.GarbageCollectionResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "Dry run: changes not applied", response.Message)
+	assert.Equal(t, []string{"a.yaml"}, response.Deleted)
+	require.Len(t, response.DryRunCommits, 1)
+	assert.Equal(t, 0, stateBackend.NewCommitterCallCount)
+}
+
+func Test_PostEnvironmentGC_InvalidEnvironment(t *testing.T) {
+	ctx, _, environmentService := newEnvironmentTest(http.MethodPost, "/environments/dev/gc")
+	environmentService.ValidateDeployableFn = func(string) error {
+		return errors.New("invalid")
+	}
+	reconcileService := &reconcile.FakeService{}
+
+	err := PostEnvironmentGC(ctx, environment.NewFakeStateBackend(), environmentService, reconcileService)
+
+	assert.Equal(t, "invalid", err.Error())
+	assert.Equal(t, 0, reconcileService.CollectGarbageCallCount)
+}
+
+func Test_GetEnvironmentDrift(t *testing.T) {
+	ctx, rec, environmentService := newEnvironmentTest(http.MethodGet, "/environments/dev/drift")
+	stateBackend := environment.NewFakeStateBackend()
+	reconcileService := &reconcile.FakeService{
+		GetDriftReportFn: func(envName string) (*core.DriftReport, error) {
+			assert.Equal(t, "dev", envName)
+			return &core.DriftReport{
+				EnvironmentName: "dev",
+				Doc: core.DriftReportDoc{
+					Missing:            []string{"missing.yaml"},
+					Unexpected:         []string{},
+					Changed:            []core.DriftedFile{{Name: "changed.yaml", Diff: "diff"}},
+					SkippedDeployments: []core.NamespacedName{*core.NewNamespacedName("myapp", "myns")},
+					SkippedSecrets:     []core.DriftSkippedSecret{{App: *core.NewNamespacedName("myapp", "myns"), Name: "mysecret"}},
+				},
+			}, nil
+		},
+	}
+
+	err := GetEnvironmentDrift(ctx, stateBackend, environmentService, reconcileService)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Result().StatusCode)
+	response := model.DriftReport{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "dev", response.EnvironmentName)
+	assert.True(t, response.HasDrift)
+	assert.Equal(t, []string{"missing.yaml"}, response.Missing)
+	assert.Equal(t, []model.DriftedFile{{Name: "changed.yaml", Diff: "diff"}}, response.Changed)
+	assert.Equal(t, []string{"myapp.myns"}, response.SkippedDeployments)
+	assert.Equal(t, []string{"myapp.myns/mysecret"}, response.SkippedSecrets)
+	assert.Equal(t, 0, reconcileService.DetectDriftCallCount)
+}
+
+func Test_GetEnvironmentDrift_NoReport_Detects(t *testing.T) {
+	ctx, rec, environmentService := newEnvironmentTest(http.MethodGet, "/environments/dev/drift")
+	stateBackend := environment.NewFakeStateBackend()
+	reconcileService := &reconcile.FakeService{
+		GetDriftReportFn: func(string) (*core.DriftReport, error) {
+			return nil, core.ErrNotFound
+		},
+		DetectDriftFn: func(envName string, reader state.Reader) (*core.DriftReport, error) {
+			assert.Equal(t, "dev", envName)
+			return &core.DriftReport{EnvironmentName: "dev"}, nil
+		},
+	}
+
+	err := GetEnvironmentDrift(ctx, stateBackend, environmentService, reconcileService)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Result().StatusCode)
+	assert.Equal(t, 1, reconcileService.DetectDriftCallCount)
+	assert.Equal(t, 1, stateBackend.NewReaderCallCount)
+}
+
+func Test_GetEnvironmentDrift_Refresh(t *testing.T) {
+	ctx, _, environmentService := newEnvironmentTest(http.MethodGet, "/environments/dev/drift?refresh=true")
+	reconcileService := &reconcile.FakeService{
+		DetectDriftFn: func(string, state.Reader) (*core.DriftReport, error) {
+			return &core.DriftReport{EnvironmentName: "dev"}, nil
+		},
+	}
+
+	err := GetEnvironmentDrift(ctx, environment.NewFakeStateBackend(), environmentService, reconcileService)
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, reconcileService.GetDriftReportCallCount)
+	assert.Equal(t, 1, reconcileService.DetectDriftCallCount)
+}
+
+func Test_GetEnvironmentDrift_GetErr(t *testing.T) {
+	ctx, _, environmentService := newEnvironmentTest(http.MethodGet, "/environments/dev/drift")
+	reconcileService := &reconcile.FakeService{
+		GetDriftReportFn: func(string) (*core.DriftReport, error) {
+			return nil, errors.New("test")
+		},
+	}
+
+	err := GetEnvironmentDrift(ctx, environment.NewFakeStateBackend(), environmentService, reconcileService)
+
+	assert.Equal(t, "test", err.Error())
+	assert.Equal(t, 0, reconcileService.DetectDriftCallCount)
 }
 
 func Test_validateEnvironmentName_Error(t *testing.T) {
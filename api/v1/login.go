@@ -1,8 +1,11 @@
 package v1
 
 import (
+	"strings"
+
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
+	"github.com/riser-platform/riser-server/pkg/core"
 	"github.com/riser-platform/riser-server/pkg/login"
 )
 
@@ -18,3 +21,29 @@ func loginWithApiKey(c echo.Context, loginService login.Service, apikey string)
 	c.Set("username", username)
 	return true, nil
 }
+
+func loginWithToken(c echo.Context, loginService login.Service, token string) (bool, error) {
+	user, err := loginService.LoginWithToken(token)
+	if err != nil {
+		if err == login.ErrInvalidLogin {
+			return false, nil
+		}
+
+		return false, errors.Wrap(err, "Error logging in with token")
+	}
+	c.Set("username", user)
+	return true, nil
+}
+
+// isBearerAuth returns true when the request uses the "Bearer" authorization scheme
+func isBearerAuth(c echo.Context) bool {
+	return strings.HasPrefix(strings.ToLower(c.Request().Header.Get(echo.HeaderAuthorization)), "bearer ")
+}
+
+// currentUsername returns the username of the authenticated user or an empty string if the request is not authenticated
+func currentUsername(c echo.Context) string {
+	if user, ok := c.Get("username").(*core.User); ok {
+		return user.Username
+	}
+	return ""
+}
@@ -0,0 +1,29 @@
+package v1
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_isBearerAuth(t *testing.T) {
+	tt := []struct {
+		header   string
+		expected bool
+	}{
+		{"Bearer mytoken", true},
+		{"bearer mytoken", true},
+		{"Apikey: mykey", false},
+		{"", false},
+	}
+
+	for _, test := range tt {
+		req := httptest.NewRequest(echo.GET, "/", nil)
+		req.Header.Set(echo.HeaderAuthorization, test.header)
+		ctx, _ := newContextWithRecorder(req)
+
+		assert.Equal(t, test.expected, isBearerAuth(ctx), test.header)
+	}
+}
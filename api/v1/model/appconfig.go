@@ -3,6 +3,7 @@ package model
 import (
 	"fmt"
 	"regexp"
+	"strconv"
 
 	"github.com/docker/distribution/reference"
 	validation "github.com/go-ozzo/ozzo-validation/v3"
@@ -17,6 +18,28 @@ const (
 	AppExposeScope_Cluster  = "cluster"
 )
 
+const (
+	AppAutoscaleMetric_Concurrency = "concurrency"
+	AppAutoscaleMetric_Rps         = "rps"
+	AppAutoscaleMetric_Cpu         = "cpu"
+)
+
+const (
+	// AppRequestMaxTimeoutSeconds matches the default max-revision-timeout-seconds in KNative
+	AppRequestMaxTimeoutSeconds = 600
+	// AppRequestMaxContainerConcurrency matches the default container-concurrency-max-limit in KNative
+	AppRequestMaxContainerConcurrency = 1000
+	// AppAutoscaleMaxScaleDownDelaySeconds matches the maximum scale down delay in KNative and the maximum stabilization window for a HorizontalPodAutoscaler
+	AppAutoscaleMaxScaleDownDelaySeconds = 3600
+)
+
+const (
+	AppHealthCheckMode_HttpGet   = "httpGet"
+	AppHealthCheckMode_TcpSocket = "tcpSocket"
+	AppHealthCheckMode_Grpc      = "grpc"
+	AppHealthCheckMode_Exec      = "exec"
+)
+
 var (
 	// Put all static app config defaults here
 	appConfigDefaults = &AppConfig{
@@ -29,6 +52,8 @@ var (
 
 	envVarKeyPattern      = regexp.MustCompile("^[A-Z][A-Z0-9_]*$")
 	envVarKeyRiserPattern = regexp.MustCompile("^RISER_")
+	accessPathPattern     = regexp.MustCompile(`^[/*]`)
+	domainPattern         = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
 )
 
 // TODO: Move outside the API and into a separate module. The AppConfig should version independently of the API via a Version field on the root AppConfig object
@@ -41,13 +66,31 @@ type AppConfigWithOverrides struct {
 	Overrides map[string]OverrideableAppConfig `json:"environmentOverrides,omitempty"`
 }
 
+// ApplyOverrides returns the app config for an environment. An environment's overrides replace every overrideable property, including
+// properties that the overrides do not set, with the exception of env which is merged. The container entrypoint and domains are
+// inherited when the overrides do not set them.
 func (cfg *AppConfigWithOverrides) ApplyOverrides(envName string) (*AppConfig, error) {
 	app := cfg.AppConfig
 	if overrideApp, ok := cfg.Overrides[envName]; ok {
+		base := app.OverrideableAppConfig
 		err := mergo.Merge(&app.OverrideableAppConfig, overrideApp, mergo.WithOverride, mergo.WithOverwriteWithEmptyValue)
 		if err != nil {
 			return nil, err
 		}
+		// The container entrypoint and domains are only overridden when set so that overriding other properties does not change what the
+		// container runs or where it is routed
+		if overrideApp.Command == nil {
+			app.Command = base.Command
+		}
+		if overrideApp.Args == nil {
+			app.Args = base.Args
+		}
+		if overrideApp.WorkingDir == "" {
+			app.WorkingDir = base.WorkingDir
+		}
+		if overrideApp.Domains == nil {
+			app.Domains = base.Domains
+		}
 	}
 
 	return &app, nil
@@ -55,9 +98,13 @@ func (cfg *AppConfigWithOverrides) ApplyOverrides(envName string) (*AppConfig, e
 
 // AppConfig is the root of the application config object graph without environment overrides
 type AppConfig struct {
-	Id                    uuid.UUID             `json:"id"`
-	Name                  AppName               `json:"name"`
-	Namespace             NamespaceName         `json:"namespace"`
+	Id        uuid.UUID     `json:"id"`
+	Name      AppName       `json:"name"`
+	Namespace NamespaceName `json:"namespace"`
+	// Access restricts which apps within the mesh may call the app. It is not overrideable so that an environment override can never
+	// remove the restriction. With KNative an app with access always runs at least one instance (autoscale.min) since the KNative activator, which
+	// would proxy requests for any caller, is kept out of its request path.
+	Access                *AppConfigAccess      `json:"access,omitempty"`
 	Expose                *AppConfigExpose      `json:"expose,omitempty"`
 	HealthCheck           *AppConfigHealthCheck `json:"healthcheck,omitempty"`
 	Image                 string                `json:"image"`
@@ -66,14 +113,55 @@ type AppConfig struct {
 
 // OverrideableAppConfig contains properties that are overrideable
 type OverrideableAppConfig struct {
-	Autoscale   *AppConfigAutoscale           `json:"autoscale,omitempty"`
+	Autoscale *AppConfigAutoscale `json:"autoscale,omitempty"`
+	// Command overrides the image's entrypoint
+	Command []string `json:"command,omitempty"`
+	// Args overrides the image's cmd
+	Args []string `json:"args,omitempty"`
+	// Domains are custom domains that route to the deployment in addition to its generated hostname
+	Domains     []string                      `json:"domains,omitempty"`
 	Environment map[string]intstr.IntOrString `json:"env,omitempty"`
+	Request     *AppConfigRequest             `json:"request,omitempty"`
 	Resources   *AppConfigResources           `json:"resources,omitempty"`
+	// WorkingDir overrides the image's working directory
+	WorkingDir string `json:"workingDir,omitempty"`
 }
 
 type AppConfigAutoscale struct {
 	Min *int `json:"min,omitempty"`
 	Max *int `json:"max,omitempty"`
+	// Metric is one of the AppAutoscaleMetric values (concurrency = default)
+	Metric string `json:"metric,omitempty"`
+	// Target is the value of the metric that the autoscaler tries to maintain for each instance. The cpu metric target is a percentage.
+	Target *int `json:"target,omitempty"`
+	// ScaleDownDelaySeconds is how long the demand must be lower before scaling down
+	ScaleDownDelaySeconds *int `json:"scaleDownDelaySeconds,omitempty"`
+	// PanicWindowPercentage is the percentage of the stable window used to detect a spike in demand. Not supported with the cpu metric.
+	PanicWindowPercentage *int `json:"panicWindowPercentage,omitempty"`
+}
+
+type AppConfigRequest struct {
+	// ContainerConcurrency is the maximum number of requests that each instance handles at once (0 = unlimited)
+	ContainerConcurrency *int64 `json:"containerConcurrency,omitempty"`
+	TimeoutSeconds       *int64 `json:"timeoutSeconds,omitempty"`
+}
+
+// AppConfigAccess allows only the listed sources to call the app. All callers within the mesh are allowed when access is not set.
+type AppConfigAccess struct {
+	// From are the sources that may call the app. No callers within the mesh are allowed when empty.
+	From []AppConfigAccessSource `json:"from"`
+}
+
+type AppConfigAccessSource struct {
+	// App is the name of an app that may call this app. Every app in the namespace may call this app when empty. An app is identified
+	// by its own service account, which every app runs as.
+	App string `json:"app,omitempty"`
+	// Namespace of the source. Defaults to the app's namespace.
+	Namespace string `json:"namespace,omitempty"`
+	// Paths that the source may call. Supports a prefix or suffix wildcard (e.g. /api/*). Any path may be called when empty.
+	Paths []string `json:"paths,omitempty"`
+	// Methods are the HTTP methods that the source may use. Any method may be used when empty.
+	Methods []string `json:"methods,omitempty"`
 }
 
 type AppConfigExpose struct {
@@ -82,14 +170,87 @@ type AppConfigExpose struct {
 	Scope         string `json:"scope,omitempty"`
 }
 
-// Mode is not yet implemented (httpGet = default)
+// AppConfigHealthCheck configures the readiness probe along with optional liveness and startup probes.
+// Probes check the expose.containerPort unless the mode is exec. Unset timing fields use the platform's defaults.
 type AppConfigHealthCheck struct {
+	// Mode is one of the AppHealthCheckMode values (httpGet = default)
+	Mode string `json:"mode,omitempty"`
+	// Path is required for the httpGet mode
 	Path string `json:"path,omitempty"`
+	// Command is required for the exec mode
+	Command []string `json:"command,omitempty"`
+	// GrpcService is the service name sent in the health check request when using the grpc mode
+	GrpcService         string          `json:"grpcService,omitempty"`
+	InitialDelaySeconds *int32          `json:"initialDelaySeconds,omitempty"`
+	PeriodSeconds       *int32          `json:"periodSeconds,omitempty"`
+	TimeoutSeconds      *int32          `json:"timeoutSeconds,omitempty"`
+	FailureThreshold    *int32          `json:"failureThreshold,omitempty"`
+	Liveness            *AppConfigProbe `json:"liveness,omitempty"`
+	Startup             *AppConfigProbe `json:"startup,omitempty"`
+}
+
+type AppConfigProbe struct {
+	Mode                string   `json:"mode,omitempty"`
+	Path                string   `json:"path,omitempty"`
+	Command             []string `json:"command,omitempty"`
+	GrpcService         string   `json:"grpcService,omitempty"`
+	InitialDelaySeconds *int32   `json:"initialDelaySeconds,omitempty"`
+	PeriodSeconds       *int32   `json:"periodSeconds,omitempty"`
+	TimeoutSeconds      *int32   `json:"timeoutSeconds,omitempty"`
+	FailureThreshold    *int32   `json:"failureThreshold,omitempty"`
+}
+
+// Readiness returns the readiness probe. Its fields are set at the root of the healthcheck for backwards compatibility.
+func (healthCheck *AppConfigHealthCheck) Readiness() *AppConfigProbe {
+	return &AppConfigProbe{
+		Mode:                healthCheck.Mode,
+		Path:                healthCheck.Path,
+		Command:             healthCheck.Command,
+		GrpcService:         healthCheck.GrpcService,
+		InitialDelaySeconds: healthCheck.InitialDelaySeconds,
+		PeriodSeconds:       healthCheck.PeriodSeconds,
+		TimeoutSeconds:      healthCheck.TimeoutSeconds,
+		FailureThreshold:    healthCheck.FailureThreshold,
+	}
+}
+
+// HttpPaths returns the distinct paths of the probes that use the httpGet mode
+func (healthCheck *AppConfigHealthCheck) HttpPaths() []string {
+	paths := []string{}
+	for _, probe := range []*AppConfigProbe{healthCheck.Readiness(), healthCheck.Liveness, healthCheck.Startup} {
+		if probe != nil && probe.IsHttpGet() && probe.Path != "" && !containsString(paths, probe.Path) {
+			paths = append(paths, probe.Path)
+		}
+	}
+	return paths
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
 }
 
+func (probe *AppConfigProbe) IsHttpGet() bool {
+	return probe.Mode == "" || probe.Mode == AppHealthCheckMode_HttpGet
+}
+
+// AppConfigResources are the resource limits for each instance. Unset values use the environment's defaults.
 type AppConfigResources struct {
-	CpuCores *float32 `json:"cpuCores,omitempty"`
-	MemoryMB *int32   `json:"memoryMB,omitempty"`
+	CpuCores           *float32 `json:"cpuCores,omitempty"`
+	MemoryMB           *int32   `json:"memoryMB,omitempty"`
+	EphemeralStorageMB *int32   `json:"ephemeralStorageMB,omitempty"`
+	// Requests are the resources reserved for each instance. Unset requests use the environment's defaults, otherwise the limit.
+	Requests *AppConfigResourceRequests `json:"requests,omitempty"`
+}
+
+type AppConfigResourceRequests struct {
+	CpuCores           *float32 `json:"cpuCores,omitempty"`
+	MemoryMB           *int32   `json:"memoryMB,omitempty"`
+	EphemeralStorageMB *int32   `json:"ephemeralStorageMB,omitempty"`
 }
 
 // ApplyDefaults sets any unset values with their defaults
@@ -104,6 +265,9 @@ func (appConfig AppConfig) Validate() error {
 		validation.Field(&appConfig.Id, validation.By(validId)),
 		validation.Field(&appConfig.Image, validation.Required, validation.By(validDockerImageWithoutTagOrDigest)),
 		validation.Field(&appConfig.Expose, validation.Required),
+		validation.Field(&appConfig.Command, validation.Each(validation.Required)),
+		validation.Field(&appConfig.WorkingDir, validation.Match(regexp.MustCompile("^/")).Error("must be an absolute path")),
+		validation.Field(&appConfig.Domains, validation.By(validDomains)),
 	)
 
 	// Break out each struct so that we can have better error messages than the default
@@ -122,6 +286,27 @@ func (appConfig AppConfig) Validate() error {
 					fmt.Sprintf("must be one of: %s, %s", AppExposeScope_External, AppExposeScope_Cluster))),
 		)
 		validationErrors = mergeValidationErrors(validationErrors, exposeErr, "expose")
+
+		if len(appConfig.Domains) > 0 && appConfig.Expose.Scope == AppExposeScope_Cluster {
+			validationErrors = mergeValidationErrors(validationErrors,
+				validation.Errors{"domains": errors.New("requires expose.scope to be external")}, "")
+		}
+	}
+
+	if appConfig.Access != nil {
+		for i := range appConfig.Access.From {
+			validationErrors = mergeValidationErrors(validationErrors, validateAccessSource(&appConfig.Access.From[i]), fmt.Sprintf("access.from.%d", i))
+		}
+	}
+
+	if appConfig.HealthCheck != nil {
+		validationErrors = mergeValidationErrors(validationErrors, validateProbe(appConfig.HealthCheck.Readiness()), "healthcheck")
+		if appConfig.HealthCheck.Liveness != nil {
+			validationErrors = mergeValidationErrors(validationErrors, validateProbe(appConfig.HealthCheck.Liveness), "healthcheck.liveness")
+		}
+		if appConfig.HealthCheck.Startup != nil {
+			validationErrors = mergeValidationErrors(validationErrors, validateProbe(appConfig.HealthCheck.Startup), "healthcheck.startup")
+		}
 	}
 
 	if appConfig.Autoscale != nil {
@@ -129,18 +314,101 @@ func (appConfig AppConfig) Validate() error {
 		if appConfig.Autoscale.Min != nil {
 			maxMinRule = validation.Min(*appConfig.Autoscale.Min).Error("must be greater than or equal to autoscale.min")
 		}
+		targetRules := []validation.Rule{validation.NilOrNotEmpty.Error("must be no less than 1"), validation.Min(1)}
+		panicWindowRules := []validation.Rule{validation.NilOrNotEmpty.Error("must be no less than 1"), validation.Min(1), validation.Max(100)}
+		if appConfig.Autoscale.Metric == AppAutoscaleMetric_Cpu {
+			targetRules = append(targetRules, validation.Max(100))
+			panicWindowRules = append(panicWindowRules, validation.By(notSupportedWithCpuMetric))
+		}
 		autoscaleErr := validation.ValidateStruct(appConfig.Autoscale,
 			validation.Field(&appConfig.Autoscale.Min, validation.Min(0)),
 			// We have to customize the NilOrEmpty error to match "Min since "Min" does not get applied to nillable 0 value
 			validation.Field(&appConfig.Autoscale.Max, validation.NilOrNotEmpty.Error("must be no less than 1"), maxMinRule),
+			validation.Field(&appConfig.Autoscale.Metric,
+				validation.In(AppAutoscaleMetric_Concurrency, AppAutoscaleMetric_Rps, AppAutoscaleMetric_Cpu).Error(
+					fmt.Sprintf("must be one of: %s, %s, %s", AppAutoscaleMetric_Concurrency, AppAutoscaleMetric_Rps, AppAutoscaleMetric_Cpu))),
+			validation.Field(&appConfig.Autoscale.Target, targetRules...),
+			validation.Field(&appConfig.Autoscale.ScaleDownDelaySeconds, validation.Min(0), validation.Max(AppAutoscaleMaxScaleDownDelaySeconds)),
+			validation.Field(&appConfig.Autoscale.PanicWindowPercentage, panicWindowRules...),
 		)
 
 		validationErrors = mergeValidationErrors(validationErrors, autoscaleErr, "autoscale")
 	}
 
+	if appConfig.Resources != nil {
+		resourcesErr := validation.ValidateStruct(appConfig.Resources,
+			validation.Field(&appConfig.Resources.EphemeralStorageMB, validation.NilOrNotEmpty.Error("must be no less than 1"), validation.Min(1)),
+		)
+		validationErrors = mergeValidationErrors(validationErrors, resourcesErr, "resources")
+
+		if appConfig.Resources.Requests != nil {
+			requests := appConfig.Resources.Requests
+			cpuRules := []validation.Rule{validation.NilOrNotEmpty.Error("must be greater than 0"), validation.Min(float32(0)).Error("must be greater than 0")}
+			if appConfig.Resources.CpuCores != nil {
+				cpuRules = append(cpuRules, validation.Max(*appConfig.Resources.CpuCores).Error("must be less than or equal to resources.cpuCores"))
+			}
+			requestsErr := validation.ValidateStruct(requests,
+				validation.Field(&requests.CpuCores, cpuRules...),
+				validation.Field(&requests.MemoryMB, resourceRequestRules(appConfig.Resources.MemoryMB, "resources.memoryMB")...),
+				validation.Field(&requests.EphemeralStorageMB, resourceRequestRules(appConfig.Resources.EphemeralStorageMB, "resources.ephemeralStorageMB")...),
+			)
+			validationErrors = mergeValidationErrors(validationErrors, requestsErr, "resources.requests")
+		}
+	}
+
+	if appConfig.Request != nil {
+		requestErr := validation.ValidateStruct(appConfig.Request,
+			validation.Field(&appConfig.Request.ContainerConcurrency, validation.Min(0), validation.Max(AppRequestMaxContainerConcurrency)),
+			validation.Field(&appConfig.Request.TimeoutSeconds,
+				validation.NilOrNotEmpty.Error("must be no less than 1"), validation.Min(1), validation.Max(AppRequestMaxTimeoutSeconds)),
+		)
+
+		validationErrors = mergeValidationErrors(validationErrors, requestErr, "request")
+	}
+
 	return validationErrors
 }
 
+func validateProbe(probe *AppConfigProbe) error {
+	pathRules := []validation.Rule{}
+	if probe.IsHttpGet() {
+		pathRules = append(pathRules, validation.Required.Error("is required for the httpGet mode"))
+	}
+	commandRules := []validation.Rule{}
+	if probe.Mode == AppHealthCheckMode_Exec {
+		commandRules = append(commandRules, validation.Required.Error("is required for the exec mode"))
+	}
+
+	return validation.ValidateStruct(probe,
+		validation.Field(&probe.Mode,
+			validation.In(AppHealthCheckMode_HttpGet, AppHealthCheckMode_TcpSocket, AppHealthCheckMode_Grpc, AppHealthCheckMode_Exec).Error(
+				fmt.Sprintf("must be one of: %s, %s, %s, %s",
+					AppHealthCheckMode_HttpGet, AppHealthCheckMode_TcpSocket, AppHealthCheckMode_Grpc, AppHealthCheckMode_Exec))),
+		validation.Field(&probe.Path, pathRules...),
+		validation.Field(&probe.Command, commandRules...),
+		validation.Field(&probe.InitialDelaySeconds, validation.Min(0)),
+		validation.Field(&probe.PeriodSeconds, validation.NilOrNotEmpty.Error("must be no less than 1"), validation.Min(1)),
+		validation.Field(&probe.TimeoutSeconds, validation.NilOrNotEmpty.Error("must be no less than 1"), validation.Min(1)),
+		validation.Field(&probe.FailureThreshold, validation.NilOrNotEmpty.Error("must be no less than 1"), validation.Min(1)),
+	)
+}
+
+// resourceRequestRules ensures that a resource request is not greater than its limit
+func resourceRequestRules(limit *int32, limitField string) []validation.Rule {
+	rules := []validation.Rule{validation.NilOrNotEmpty.Error("must be no less than 1"), validation.Min(1)}
+	if limit != nil {
+		rules = append(rules, validation.Max(*limit).Error(fmt.Sprintf("must be less than or equal to %s", limitField)))
+	}
+	return rules
+}
+
+func notSupportedWithCpuMetric(value interface{}) error {
+	if validation.IsEmpty(value) {
+		return nil
+	}
+	return errors.New("is not supported with the cpu metric")
+}
+
 func validDockerImageWithoutTagOrDigest(value interface{}) error {
 	dockerImageURL, _ := value.(string)
 	named, err := reference.ParseNormalizedNamed(dockerImageURL)
@@ -182,6 +450,34 @@ func validateEnvKeyNoRiserPrefix(v interface{}) error {
 	return nil
 }
 
+func validateAccessSource(source *AppConfigAccessSource) error {
+	return validation.ValidateStruct(source,
+		validation.Field(&source.App, RulesNamingIdentifier()...),
+		validation.Field(&source.Namespace, RulesNamingIdentifier()...),
+		validation.Field(&source.Paths, validation.Each(validation.Required,
+			validation.Match(accessPathPattern).Error("must start with / or a wildcard (*)"))),
+		validation.Field(&source.Methods, validation.Each(
+			validation.In("GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS").Error(
+				"must be one of: GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS"))),
+	)
+}
+
+func validDomains(value interface{}) error {
+	validationErrors := validation.Errors{}
+	domains, _ := value.([]string)
+	for i, domain := range domains {
+		if len(domain) > 253 || !domainPattern.MatchString(domain) {
+			validationErrors[strconv.Itoa(i)] = errors.New("must be a valid lowercase domain")
+		} else if containsString(domains[:i], domain) {
+			validationErrors[strconv.Itoa(i)] = errors.New("must be unique")
+		}
+	}
+	if len(validationErrors) > 0 {
+		return validationErrors
+	}
+	return nil
+}
+
 func validId(v interface{}) error {
 	id, _ := v.(uuid.UUID)
 	if id == uuid.Nil {
@@ -148,6 +148,290 @@ func Test_AppConfig_ValidateAutoscaleMax_NilMin(t *testing.T) {
 	assert.Equal(t, "must be no less than 1", validationErrors["autoscale.max"].Error())
 }
 
+func Test_AppConfig_ValidateHealthCheck(t *testing.T) {
+	var tests = []struct {
+		healthCheck *AppConfigHealthCheck
+		errors      map[string]string
+	}{
+		{&AppConfigHealthCheck{Path: "/health"}, nil},
+		{&AppConfigHealthCheck{Mode: AppHealthCheckMode_HttpGet, Path: "/health"}, nil},
+		{&AppConfigHealthCheck{Mode: AppHealthCheckMode_TcpSocket}, nil},
+		{&AppConfigHealthCheck{Mode: AppHealthCheckMode_Grpc, GrpcService: "myservice"}, nil},
+		{&AppConfigHealthCheck{Mode: AppHealthCheckMode_Exec, Command: []string{"cat", "/tmp/healthy"}}, nil},
+		{&AppConfigHealthCheck{}, map[string]string{"healthcheck.path": "is required for the httpGet mode"}},
+		{&AppConfigHealthCheck{Mode: AppHealthCheckMode_Exec}, map[string]string{"healthcheck.command": "is required for the exec mode"}},
+		{&AppConfigHealthCheck{Mode: "http"}, map[string]string{
+			"healthcheck.mode": "must be one of: httpGet, tcpSocket, grpc, exec"}},
+		{&AppConfigHealthCheck{
+			Mode:     AppHealthCheckMode_TcpSocket,
+			Liveness: &AppConfigProbe{Mode: AppHealthCheckMode_HttpGet},
+			Startup:  &AppConfigProbe{Mode: AppHealthCheckMode_Exec},
+		}, map[string]string{
+			"healthcheck.liveness.path":   "is required for the httpGet mode",
+			"healthcheck.startup.command": "is required for the exec mode",
+		}},
+	}
+
+	for _, tt := range tests {
+		appConfig := createMinAppConfig()
+		appConfig.HealthCheck = tt.healthCheck
+		err := appConfig.Validate()
+
+		if tt.errors == nil {
+			assert.NoError(t, err)
+		} else {
+			require.IsType(t, validation.Errors{}, err)
+			validationErrors := err.(validation.Errors)
+			assert.Len(t, validationErrors, len(tt.errors))
+			for key, message := range tt.errors {
+				require.Contains(t, validationErrors, key)
+				assert.Equal(t, message, validationErrors[key].Error())
+			}
+		}
+	}
+}
+
+func Test_AppConfig_ValidateHealthCheckTiming(t *testing.T) {
+	negative := int32(-1)
+	zero := int32(0)
+	appConfig := createMinAppConfig()
+	appConfig.HealthCheck = &AppConfigHealthCheck{
+		Path:                "/health",
+		InitialDelaySeconds: &negative,
+		PeriodSeconds:       &zero,
+		Liveness: &AppConfigProbe{
+			Mode:             AppHealthCheckMode_TcpSocket,
+			TimeoutSeconds:   &zero,
+			FailureThreshold: &negative,
+		},
+	}
+	err := appConfig.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 4)
+	assert.Equal(t, "must be no less than 0", validationErrors["healthcheck.initialDelaySeconds"].Error())
+	assert.Equal(t, "must be no less than 1", validationErrors["healthcheck.periodSeconds"].Error())
+	assert.Equal(t, "must be no less than 1", validationErrors["healthcheck.liveness.timeoutSeconds"].Error())
+	assert.Equal(t, "must be no less than 1", validationErrors["healthcheck.liveness.failureThreshold"].Error())
+}
+
+func Test_AppConfigHealthCheck_HttpPaths(t *testing.T) {
+	healthCheck := &AppConfigHealthCheck{
+		Mode: AppHealthCheckMode_TcpSocket,
+		Path: "/ignored",
+		Liveness: &AppConfigProbe{
+			Path: "/live",
+		},
+		Startup: &AppConfigProbe{
+			Mode: AppHealthCheckMode_HttpGet,
+			Path: "/startup",
+		},
+	}
+
+	assert.Equal(t, []string{"/live", "/startup"}, healthCheck.HttpPaths())
+}
+
+func Test_AppConfigHealthCheck_HttpPaths_Distinct(t *testing.T) {
+	healthCheck := &AppConfigHealthCheck{
+		Path: "/health",
+		Liveness: &AppConfigProbe{
+			Path: "/health",
+		},
+	}
+
+	assert.Equal(t, []string{"/health"}, healthCheck.HttpPaths())
+}
+
+func Test_AppConfig_ValidateContainer(t *testing.T) {
+	appConfig := createMinAppConfig()
+	appConfig.Command = []string{"myapp", ""}
+	appConfig.Args = []string{""}
+	appConfig.WorkingDir = "relative/dir"
+	err := appConfig.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 2)
+	assert.Equal(t, "1: cannot be blank.", validationErrors["command"].Error())
+	assert.Equal(t, "must be an absolute path", validationErrors["workingDir"].Error())
+}
+
+func Test_AppConfig_ValidateContainer_Valid(t *testing.T) {
+	appConfig := createMinAppConfig()
+	appConfig.Command = []string{"myapp", "worker"}
+	appConfig.Args = []string{"--verbose"}
+	appConfig.WorkingDir = "/app"
+
+	assert.NoError(t, appConfig.Validate())
+}
+
+func Test_AppConfig_ValidateDomains(t *testing.T) {
+	appConfig := createMinAppConfig()
+	appConfig.Domains = []string{"myapp.example.com", "MyApp.example.com", "nodot", "-bad.example.com", "myapp.example.com"}
+	err := appConfig.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 1)
+	assert.Equal(t,
+		"1: must be a valid lowercase domain; 2: must be a valid lowercase domain; 3: must be a valid lowercase domain; 4: must be unique.",
+		validationErrors["domains"].Error())
+}
+
+func Test_AppConfig_ValidateDomains_Valid(t *testing.T) {
+	appConfig := createMinAppConfig()
+	appConfig.Domains = []string{"myapp.example.com", "www.my-app.example.co.uk"}
+
+	assert.NoError(t, appConfig.Validate())
+}
+
+func Test_AppConfig_ValidateDomains_RequiresExternalScope(t *testing.T) {
+	appConfig := createMinAppConfig()
+	appConfig.Domains = []string{"myapp.example.com"}
+	appConfig.Expose.Scope = AppExposeScope_Cluster
+	err := appConfig.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 1)
+	assert.Equal(t, "requires expose.scope to be external", validationErrors["domains"].Error())
+}
+
+func Test_AppConfig_ValidateAccess(t *testing.T) {
+	appConfig := createMinAppConfig()
+	appConfig.Access = &AppConfigAccess{
+		From: []AppConfigAccessSource{
+			{App: "otherapp", Namespace: "otherns", Paths: []string{"/api/*", "*.json"}, Methods: []string{"GET", "POST"}},
+			{Namespace: "Bad_NS", Paths: []string{"api", ""}, Methods: []string{"get"}},
+		},
+	}
+	err := appConfig.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 3)
+	assert.Equal(t, "must be lowercase, alphanumeric, and start with a letter", validationErrors["access.from.1.namespace"].Error())
+	assert.Equal(t, "0: must start with / or a wildcard (*); 1: cannot be blank.", validationErrors["access.from.1.paths"].Error())
+	assert.Equal(t, "0: must be one of: GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS.", validationErrors["access.from.1.methods"].Error())
+}
+
+func Test_AppConfig_ValidateAccess_NoSources(t *testing.T) {
+	appConfig := createMinAppConfig()
+	appConfig.Access = &AppConfigAccess{From: []AppConfigAccessSource{}}
+
+	assert.NoError(t, appConfig.Validate())
+}
+
+func Test_AppConfig_ValidateAutoscaleMetric(t *testing.T) {
+	var tests = []struct {
+		autoscale *AppConfigAutoscale
+		errors    map[string]string
+	}{
+		{&AppConfigAutoscale{Metric: AppAutoscaleMetric_Concurrency, Target: ptrInt(10), PanicWindowPercentage: ptrInt(10)}, nil},
+		{&AppConfigAutoscale{Metric: AppAutoscaleMetric_Rps, Target: ptrInt(200), ScaleDownDelaySeconds: ptrInt(0)}, nil},
+		{&AppConfigAutoscale{Metric: AppAutoscaleMetric_Cpu, Target: ptrInt(75), ScaleDownDelaySeconds: ptrInt(3600)}, nil},
+		{&AppConfigAutoscale{Metric: "memory"}, map[string]string{"autoscale.metric": "must be one of: concurrency, rps, cpu"}},
+		{&AppConfigAutoscale{Target: ptrInt(0)}, map[string]string{"autoscale.target": "must be no less than 1"}},
+		{&AppConfigAutoscale{Metric: AppAutoscaleMetric_Cpu, Target: ptrInt(101)}, map[string]string{"autoscale.target": "must be no greater than 100"}},
+		{&AppConfigAutoscale{ScaleDownDelaySeconds: ptrInt(3601)}, map[string]string{"autoscale.scaleDownDelaySeconds": "must be no greater than 3600"}},
+		{&AppConfigAutoscale{PanicWindowPercentage: ptrInt(0)}, map[string]string{"autoscale.panicWindowPercentage": "must be no less than 1"}},
+		{&AppConfigAutoscale{PanicWindowPercentage: ptrInt(101)}, map[string]string{"autoscale.panicWindowPercentage": "must be no greater than 100"}},
+		{&AppConfigAutoscale{Metric: AppAutoscaleMetric_Cpu, PanicWindowPercentage: ptrInt(10)},
+			map[string]string{"autoscale.panicWindowPercentage": "is not supported with the cpu metric"}},
+	}
+
+	for _, tt := range tests {
+		appConfig := createMinAppConfig()
+		appConfig.Autoscale = tt.autoscale
+		err := appConfig.Validate()
+
+		if tt.errors == nil {
+			assert.NoError(t, err)
+		} else {
+			require.IsType(t, validation.Errors{}, err)
+			validationErrors := err.(validation.Errors)
+			assert.Len(t, validationErrors, len(tt.errors))
+			for key, message := range tt.errors {
+				require.Contains(t, validationErrors, key)
+				assert.Equal(t, message, validationErrors[key].Error())
+			}
+		}
+	}
+}
+
+func Test_AppConfig_ValidateRequest(t *testing.T) {
+	var tests = []struct {
+		request *AppConfigRequest
+		errors  map[string]string
+	}{
+		{&AppConfigRequest{ContainerConcurrency: ptrInt64(0), TimeoutSeconds: ptrInt64(600)}, nil},
+		{&AppConfigRequest{ContainerConcurrency: ptrInt64(1000)}, nil},
+		{&AppConfigRequest{ContainerConcurrency: ptrInt64(-1)}, map[string]string{"request.containerConcurrency": "must be no less than 0"}},
+		{&AppConfigRequest{ContainerConcurrency: ptrInt64(1001)}, map[string]string{"request.containerConcurrency": "must be no greater than 1000"}},
+		{&AppConfigRequest{TimeoutSeconds: ptrInt64(0)}, map[string]string{"request.timeoutSeconds": "must be no less than 1"}},
+		{&AppConfigRequest{TimeoutSeconds: ptrInt64(601)}, map[string]string{"request.timeoutSeconds": "must be no greater than 600"}},
+	}
+
+	for _, tt := range tests {
+		appConfig := createMinAppConfig()
+		appConfig.Request = tt.request
+		err := appConfig.Validate()
+
+		if tt.errors == nil {
+			assert.NoError(t, err)
+		} else {
+			require.IsType(t, validation.Errors{}, err)
+			validationErrors := err.(validation.Errors)
+			assert.Len(t, validationErrors, len(tt.errors))
+			for key, message := range tt.errors {
+				require.Contains(t, validationErrors, key)
+				assert.Equal(t, message, validationErrors[key].Error())
+			}
+		}
+	}
+}
+
+func Test_AppConfig_ValidateResources(t *testing.T) {
+	var tests = []struct {
+		resources *AppConfigResources
+		errors    map[string]string
+	}{
+		{&AppConfigResources{CpuCores: ptrFloat32(1), MemoryMB: ptrInt32(512), EphemeralStorageMB: ptrInt32(1024),
+			Requests: &AppConfigResourceRequests{CpuCores: ptrFloat32(1), MemoryMB: ptrInt32(256), EphemeralStorageMB: ptrInt32(1024)}}, nil},
+		{&AppConfigResources{Requests: &AppConfigResourceRequests{CpuCores: ptrFloat32(0.1), MemoryMB: ptrInt32(256)}}, nil},
+		{&AppConfigResources{EphemeralStorageMB: ptrInt32(0)}, map[string]string{"resources.ephemeralStorageMB": "must be no less than 1"}},
+		{&AppConfigResources{Requests: &AppConfigResourceRequests{CpuCores: ptrFloat32(0), MemoryMB: ptrInt32(-1)}}, map[string]string{
+			"resources.requests.cpuCores": "must be greater than 0",
+			"resources.requests.memoryMB": "must be no less than 1",
+		}},
+		{&AppConfigResources{CpuCores: ptrFloat32(0.5), MemoryMB: ptrInt32(256), EphemeralStorageMB: ptrInt32(100),
+			Requests: &AppConfigResourceRequests{CpuCores: ptrFloat32(1), MemoryMB: ptrInt32(512), EphemeralStorageMB: ptrInt32(200)}}, map[string]string{
+			"resources.requests.cpuCores":           "must be less than or equal to resources.cpuCores",
+			"resources.requests.memoryMB":           "must be less than or equal to resources.memoryMB",
+			"resources.requests.ephemeralStorageMB": "must be less than or equal to resources.ephemeralStorageMB",
+		}},
+	}
+
+	for _, tt := range tests {
+		appConfig := createMinAppConfig()
+		appConfig.Resources = tt.resources
+		err := appConfig.Validate()
+
+		if tt.errors == nil {
+			assert.NoError(t, err)
+		} else {
+			require.IsType(t, validation.Errors{}, err)
+			validationErrors := err.(validation.Errors)
+			assert.Len(t, validationErrors, len(tt.errors))
+			for key, message := range tt.errors {
+				require.Contains(t, validationErrors, key)
+				assert.Equal(t, message, validationErrors[key].Error())
+			}
+		}
+	}
+}
+
 // Note: We may not allow registry to be set here - it may be dictated by an admin on a per environment basis instead.
 var imageTests = []struct {
 	image string
@@ -314,6 +598,89 @@ func Test_ApplyOverrides_WithOverrides(t *testing.T) {
 	assert.Equal(t, appConfig.Resources.CpuCores, &cpuCores)
 }
 
+func Test_ApplyOverrides_Container(t *testing.T) {
+	appConfig := &AppConfigWithOverrides{
+		AppConfig: AppConfig{
+			Name: "myapp",
+			OverrideableAppConfig: OverrideableAppConfig{
+				Command:    []string{"myapp"},
+				Args:       []string{"web"},
+				WorkingDir: "/app",
+			},
+		},
+		Overrides: map[string]OverrideableAppConfig{
+			"dev": {
+				Args: []string{"web", "--debug"},
+			},
+		},
+	}
+
+	result, err := appConfig.ApplyOverrides("dev")
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"myapp"}, result.Command)
+	assert.Equal(t, []string{"web", "--debug"}, result.Args)
+	assert.Equal(t, "/app", result.WorkingDir)
+	// Ensure that we don't mutate the original config
+	assert.Equal(t, []string{"web"}, appConfig.Args)
+}
+
+func Test_ApplyOverrides_Domains(t *testing.T) {
+	appConfig := &AppConfigWithOverrides{
+		AppConfig: AppConfig{
+			Name: "myapp",
+			OverrideableAppConfig: OverrideableAppConfig{
+				Domains: []string{"myapp.example.com"},
+			},
+		},
+		Overrides: map[string]OverrideableAppConfig{
+			"dev": {
+				Domains: []string{"myapp.dev.example.com"},
+			},
+			"staging": {
+				Command: []string{"myapp"},
+			},
+		},
+	}
+
+	dev, err := appConfig.ApplyOverrides("dev")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"myapp.dev.example.com"}, dev.Domains)
+
+	staging, err := appConfig.ApplyOverrides("staging")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"myapp.example.com"}, staging.Domains)
+}
+
+func Test_ApplyOverrides_InheritsContainerAndDomains(t *testing.T) {
+	maxReplicas := 5
+	appConfig := &AppConfigWithOverrides{
+		AppConfig: AppConfig{
+			Name: "myapp",
+			OverrideableAppConfig: OverrideableAppConfig{
+				Command:    []string{"myapp"},
+				Args:       []string{"web"},
+				WorkingDir: "/app",
+				Domains:    []string{"myapp.example.com"},
+			},
+		},
+		Overrides: map[string]OverrideableAppConfig{
+			"prod": {
+				Autoscale: &AppConfigAutoscale{Max: &maxReplicas},
+			},
+		},
+	}
+
+	result, err := appConfig.ApplyOverrides("prod")
+
+	require.NoError(t, err)
+	assert.Equal(t, 5, *result.Autoscale.Max)
+	assert.Equal(t, []string{"myapp"}, result.Command)
+	assert.Equal(t, []string{"web"}, result.Args)
+	assert.Equal(t, "/app", result.WorkingDir)
+	assert.Equal(t, []string{"myapp.example.com"}, result.Domains)
+}
+
 func Test_AppConfig_ValidateExposeScope(t *testing.T) {
 	var tests = []struct {
 		scope string
@@ -394,3 +761,19 @@ func createMinAppConfig() *AppConfig {
 	_ = copier.Copy(appConfig, minimumValidAppConfig)
 	return appConfig
 }
+
+func ptrInt(v int) *int {
+	return &v
+}
+
+func ptrInt64(v int64) *int64 {
+	return &v
+}
+
+func ptrInt32(v int32) *int32 {
+	return &v
+}
+
+func ptrFloat32(v float32) *float32 {
+	return &v
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AuditEntry struct {
+	Id          uuid.UUID `json:"id"`
+	Created     time.Time `json:"created"`
+	Username    string    `json:"username"`
+	Action      string    `json:"action"`
+	Namespace   string    `json:"namespace,omitempty"`
+	Environment string    `json:"environment,omitempty"`
+	// Name is the name of the app, deployment, or other resource acted on
+	Name string `json:"name,omitempty"`
+	// App is the app that the resource belongs to
+	App        string   `json:"app,omitempty"`
+	Method     string   `json:"method"`
+	Path       string   `json:"path"`
+	StatusCode int      `json:"statusCode"`
+	CommitShas []string `json:"commitShas,omitempty"`
+	DryRun     bool     `json:"dryRun,omitempty"`
+}
+
+// AuditFilter is passed as query parameters when listing audit entries. Empty fields are not filtered.
+type AuditFilter struct {
+	Username    string
+	Namespace   string
+	Environment string
+	// App is the app that the audited resource belongs to
+	App   string
+	From  *time.Time
+	To    *time.Time
+	Limit int
+}
@@ -1,6 +1,11 @@
 package model
 
-import validation "github.com/go-ozzo/ozzo-validation/v3"
+import (
+	"errors"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v3"
+)
 
 type SaveDeploymentRequest struct {
 	DeploymentMeta `json:",inline"`
@@ -24,11 +29,15 @@ type SaveDeploymentResponse struct {
 	RiserRevision int64          `json:"riserRevision"`
 	Message       string         `json:"message"`
 	DryRunCommits []DryRunCommit `json:"dryRunCommits,omitempty"`
+	// HasChanges is only set for a dry run with diff=true. False indicates that the state would not change.
+	HasChanges *bool `json:"hasChanges,omitempty"`
 }
 
 type DryRunCommit struct {
 	Message string       `json:"message"`
 	Files   []DryRunFile `json:"files"`
+	// Diff is a unified diff of the commit against the current state. Only set for a dry run with diff=true.
+	Diff string `json:"diff,omitempty"`
 }
 
 type DryRunFile struct {
@@ -43,15 +52,68 @@ type DeploymentMeta struct {
 	Environment   string           `json:"environment"`
 	Docker        DeploymentDocker `json:"docker"`
 	ManualRollout bool             `json:"manualRollout"`
+	// Rollout progressively shifts traffic to the new revision. Omit to route all traffic to the new revision immediately.
+	Rollout *RolloutStrategy `json:"rollout,omitempty"`
 }
 
 func (d DeploymentMeta) Validate() error {
 	return validation.ValidateStruct(&d,
 		// There's a separate RuneLength rule here to reserve 8 characters for the deployment prefix (e.g. for myapp: r100-myapp)
 		validation.Field(&d.Name, append(RulesNamingIdentifier(), validation.RuneLength(3, 55), validation.Required)...),
-		validation.Field(&d.Environment, validation.Required))
+		validation.Field(&d.Environment, validation.Required),
+		validation.Field(&d.Rollout, validation.By(func(interface{}) error {
+			if d.ManualRollout && d.Rollout != nil {
+				return errors.New("may not be specified with manualRollout")
+			}
+			return nil
+		})))
 }
 
 type DeploymentDocker struct {
 	Tag string `json:"tag"`
 }
+
+// DeploymentRevision contains the configuration that was deployed for a riser revision
+type DeploymentRevision struct {
+	RiserRevision int64            `json:"riserRevision"`
+	Docker        DeploymentDocker `json:"docker"`
+	// App is the app config with environment overrides applied
+	App        *AppConfig    `json:"app"`
+	Traffic    []TrafficRule `json:"traffic"`
+	DeployedBy string        `json:"deployedBy,omitempty"`
+	Created    time.Time     `json:"created"`
+}
+
+type RollbackRequest struct {
+	// RiserRevision is the revision whose config is deployed as a new revision
+	RiserRevision int64 `json:"riserRevision"`
+}
+
+func (r RollbackRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.RiserRevision, validation.Required, validation.Min(1)))
+}
+
+type PromoteDeploymentRequest struct {
+	Name              string        `json:"name"`
+	Namespace         NamespaceName `json:"namespace"`
+	SourceEnvironment string        `json:"sourceEnvironment"`
+	TargetEnvironment string        `json:"targetEnvironment"`
+	ManualRollout     bool          `json:"manualRollout"`
+}
+
+func (d *PromoteDeploymentRequest) ApplyDefaults() error {
+	if d.Namespace == "" {
+		d.Namespace = appConfigDefaults.Namespace
+	}
+	return nil
+}
+
+func (d PromoteDeploymentRequest) Validate() error {
+	return validation.ValidateStruct(&d,
+		validation.Field(&d.Name, append(RulesNamingIdentifier(), validation.Required)...),
+		validation.Field(&d.Namespace),
+		validation.Field(&d.SourceEnvironment, validation.Required),
+		validation.Field(&d.TargetEnvironment, validation.Required,
+			validation.NotIn(d.SourceEnvironment).Error("must be different from the sourceEnvironment")))
+}
@@ -67,6 +67,75 @@ func Test_DeploymentRequest_ValidateEmptyApp(t *testing.T) {
 	assert.IsType(t, validation.Errors{}, err)
 }
 
+func Test_DeploymentRequest_ValidateRollout(t *testing.T) {
+	model := createMinDeploymentRequest()
+	model.Rollout = &RolloutStrategy{Steps: []int{10, 100}}
+
+	err := model.Validate()
+
+	assert.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 1)
+	assert.Equal(t, "pauseSeconds: cannot be blank.", validationErrors["rollout"].Error())
+}
+
+func Test_DeploymentRequest_ValidateRollout_WithManualRollout(t *testing.T) {
+	model := createMinDeploymentRequest()
+	model.ManualRollout = true
+	model.Rollout = &RolloutStrategy{Steps: []int{10, 100}, PauseSeconds: 60}
+
+	err := model.Validate()
+
+	assert.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 1)
+	assert.Equal(t, "may not be specified with manualRollout", validationErrors["rollout"].Error())
+}
+
+func Test_RollbackRequest_Validate(t *testing.T) {
+	assert.NoError(t, RollbackRequest{RiserRevision: 1}.Validate())
+
+	err := RollbackRequest{}.Validate()
+
+	assert.IsType(t, validation.Errors{}, err)
+	assertFieldsRequired(t, err.(validation.Errors), "riserRevision")
+}
+
+func Test_PromoteDeploymentRequest_ApplyDefaults(t *testing.T) {
+	model := &PromoteDeploymentRequest{}
+
+	err := model.ApplyDefaults()
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, "apps", model.Namespace)
+}
+
+func Test_PromoteDeploymentRequest_Validate(t *testing.T) {
+	model := PromoteDeploymentRequest{Name: "mydep", Namespace: "apps", SourceEnvironment: "dev", TargetEnvironment: "prod"}
+
+	assert.NoError(t, model.Validate())
+}
+
+func Test_PromoteDeploymentRequest_ValidateRequired(t *testing.T) {
+	err := PromoteDeploymentRequest{}.Validate()
+
+	assert.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 4)
+	assertFieldsRequired(t, validationErrors, "name", "namespace", "sourceEnvironment", "targetEnvironment")
+}
+
+func Test_PromoteDeploymentRequest_ValidateSameEnvironment(t *testing.T) {
+	model := PromoteDeploymentRequest{Name: "mydep", Namespace: "apps", SourceEnvironment: "dev", TargetEnvironment: "dev"}
+
+	err := model.Validate()
+
+	assert.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 1)
+	assert.Equal(t, "must be different from the sourceEnvironment", validationErrors["targetEnvironment"].Error())
+}
+
 func createMinDeploymentRequest() *SaveDeploymentRequest {
 	model := &SaveDeploymentRequest{}
 	_ = copier.Copy(model, minimumValidDeploymentRequest)
@@ -1,5 +1,24 @@
 package model
 
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v3"
+)
+
+// branchNameRegex is intentionally stricter than git's rules for ref names
+var branchNameRegex = regexp.MustCompile("^[A-Za-z0-9][A-Za-z0-9._/-]*$")
+
+const (
+	// EnvironmentRenderer_KNative renders deployments as KNative services. This is the default.
+	EnvironmentRenderer_KNative = "knative"
+	// EnvironmentRenderer_Kubernetes renders deployments as plain Kubernetes deployments with Istio traffic splitting for clusters
+	// that cannot run KNative. Traffic is only routed within the mesh so apps must use the cluster expose scope.
+	EnvironmentRenderer_Kubernetes = "kubernetes"
+)
+
 type EnvironmentMeta struct {
 	Name string
 }
@@ -7,4 +26,135 @@ type EnvironmentMeta struct {
 type EnvironmentConfig struct {
 	SealedSecretCert  []byte `json:"sealedSecretCert,omitempty"`
 	PublicGatewayHost string `json:"publicGatewayHost,omitempty"`
+	// StateRepo replaces the environment's state repo. An empty state repo removes it so that the server's state repo is used.
+	StateRepo *EnvironmentStateRepo `json:"stateRepo,omitempty"`
+	// Renderer determines the resources that deployments are rendered as. Defaults to EnvironmentRenderer_KNative. Empty leaves the
+	// renderer unchanged.
+	Renderer string `json:"renderer,omitempty"`
+	// Kustomize maintains a kustomization.yaml in the riser managed folders of the state repo. Nil leaves the setting unchanged.
+	Kustomize *bool `json:"kustomize,omitempty"`
+	// Resources are the defaults for apps that do not specify resources and the maximum resources that an app may use. Resources
+	// replace the environment's resources as a whole. Empty resources remove them.
+	Resources *EnvironmentResources `json:"resources,omitempty"`
+	// CertificateIssuer is the name of the cert-manager ClusterIssuer that issues certificates for app domains
+	CertificateIssuer string `json:"certificateIssuer,omitempty"`
+}
+
+type EnvironmentResources struct {
+	DefaultRequests *EnvironmentResourceQuantities `json:"defaultRequests,omitempty"`
+	DefaultLimits   *EnvironmentResourceQuantities `json:"defaultLimits,omitempty"`
+	// MaxLimits are the maximum requests and limits for an app. An app without a limit uses the maximum as its limit.
+	MaxLimits *EnvironmentResourceQuantities `json:"maxLimits,omitempty"`
+}
+
+type EnvironmentResourceQuantities struct {
+	CpuCores           *float32 `json:"cpuCores,omitempty"`
+	MemoryMB           *int32   `json:"memoryMB,omitempty"`
+	EphemeralStorageMB *int32   `json:"ephemeralStorageMB,omitempty"`
+}
+
+// EnvironmentStateRepo is a git repo that stores the state for a single environment instead of the server's default state repo
+type EnvironmentStateRepo struct {
+	URL string `json:"url"`
+	// Branch defaults to the environment name
+	Branch string `json:"branch,omitempty"`
+	// CredentialsRef is the name of git credentials that have been provided to the server
+	CredentialsRef string `json:"credentialsRef,omitempty"`
+}
+
+type ReconcileResponse struct {
+	Message string `json:"message"`
+	// SkippedDeployments must be redeployed before they can be reconciled
+	SkippedDeployments []string `json:"skippedDeployments"`
+	// SkippedSecrets must be saved again before they can be reconciled
+	SkippedSecrets []string       `json:"skippedSecrets"`
+	DryRunCommits  []DryRunCommit `json:"dryRunCommits,omitempty"`
+}
+
+type GarbageCollectionResponse struct {
+	Message string `json:"message"`
+	// Deleted are the files that were (or would be for a dry run) removed from the state repo
+	Deleted []string `json:"deleted"`
+	// SkippedNamespaces contain revisions that were deployed before their secret revisions were recorded. Their sealed secrets are
+	// not collected.
+	SkippedNamespaces []string       `json:"skippedNamespaces"`
+	DryRunCommits     []DryRunCommit `json:"dryRunCommits,omitempty"`
+}
+
+type DriftReport struct {
+	EnvironmentName string    `json:"environmentName"`
+	Created         time.Time `json:"created"`
+	// HasDrift is true when the state repo differs from the state that riser expects
+	HasDrift bool `json:"hasDrift"`
+	// Missing are files that riser expects but are not in the state repo
+	Missing []string `json:"missing"`
+	// Unexpected are files in a riser managed folder of the state repo that riser does not expect (e.g. orphaned files)
+	Unexpected []string      `json:"unexpected"`
+	Changed    []DriftedFile `json:"changed"`
+	// SkippedDeployments and SkippedSecrets could not be checked. See ReconcileResponse.
+	SkippedDeployments []string `json:"skippedDeployments"`
+	SkippedSecrets     []string `json:"skippedSecrets"`
+}
+
+type DriftedFile struct {
+	Name string `json:"name"`
+	// Diff is a unified diff from the contents of the state repo to the expected contents
+	Diff string `json:"diff"`
+}
+
+func (v EnvironmentConfig) Validate() error {
+	return validation.ValidateStruct(&v,
+		validation.Field(&v.StateRepo),
+		validation.Field(&v.Renderer, validation.In(EnvironmentRenderer_KNative, EnvironmentRenderer_Kubernetes).Error(
+			fmt.Sprintf("must be one of: %s, %s", EnvironmentRenderer_KNative, EnvironmentRenderer_Kubernetes))),
+		validation.Field(&v.Resources))
+}
+
+func (v EnvironmentResources) Validate() error {
+	defaultRules := []validation.Rule{}
+	if v.MaxLimits != nil {
+		defaultRules = append(defaultRules, validation.By(notGreaterThanQuantities(v.MaxLimits, "maxLimits")))
+	}
+	return validation.ValidateStruct(&v,
+		validation.Field(&v.DefaultRequests, defaultRules...),
+		validation.Field(&v.DefaultLimits, defaultRules...),
+		validation.Field(&v.MaxLimits))
+}
+
+func (v EnvironmentResourceQuantities) Validate() error {
+	return validation.ValidateStruct(&v,
+		validation.Field(&v.CpuCores, validation.NilOrNotEmpty.Error("must be greater than 0"), validation.Min(float32(0)).Error("must be greater than 0")),
+		validation.Field(&v.MemoryMB, validation.NilOrNotEmpty.Error("must be no less than 1"), validation.Min(1)),
+		validation.Field(&v.EphemeralStorageMB, validation.NilOrNotEmpty.Error("must be no less than 1"), validation.Min(1)))
+}
+
+// notGreaterThanQuantities ensures that each quantity is not greater than the same quantity in max
+func notGreaterThanQuantities(max *EnvironmentResourceQuantities, maxField string) validation.RuleFunc {
+	return func(value interface{}) error {
+		quantities, _ := value.(*EnvironmentResourceQuantities)
+		if quantities == nil {
+			return nil
+		}
+		validationErrors := validation.Errors{}
+		if quantities.CpuCores != nil && max.CpuCores != nil && *quantities.CpuCores > *max.CpuCores {
+			validationErrors["cpuCores"] = fmt.Errorf("must be less than or equal to %s.cpuCores", maxField)
+		}
+		if quantities.MemoryMB != nil && max.MemoryMB != nil && *quantities.MemoryMB > *max.MemoryMB {
+			validationErrors["memoryMB"] = fmt.Errorf("must be less than or equal to %s.memoryMB", maxField)
+		}
+		if quantities.EphemeralStorageMB != nil && max.EphemeralStorageMB != nil && *quantities.EphemeralStorageMB > *max.EphemeralStorageMB {
+			validationErrors["ephemeralStorageMB"] = fmt.Errorf("must be less than or equal to %s.ephemeralStorageMB", maxField)
+		}
+		return validationErrors.Filter()
+	}
+}
+
+func (v EnvironmentStateRepo) Validate() error {
+	if v == (EnvironmentStateRepo{}) {
+		return nil
+	}
+	return validation.ValidateStruct(&v,
+		validation.Field(&v.URL, validation.Required),
+		validation.Field(&v.Branch, validation.Match(branchNameRegex).Error("must be a valid branch name")),
+		validation.Field(&v.CredentialsRef, RulesNamingIdentifier()...))
 }
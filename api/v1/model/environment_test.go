@@ -0,0 +1,75 @@
+package model
+
+import (
+	"testing"
+
+	validation "github.com/go-ozzo/ozzo-validation/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EnvironmentConfig_Validate(t *testing.T) {
+	assert.NoError(t, EnvironmentConfig{}.Validate())
+	assert.NoError(t, EnvironmentConfig{StateRepo: &EnvironmentStateRepo{URL: "git@tempuri.org:org/state"}}.Validate())
+	assert.NoError(t, EnvironmentConfig{
+		StateRepo: &EnvironmentStateRepo{URL: "git@tempuri.org:org/state", Branch: "state/prod", CredentialsRef: "prod-state"},
+	}.Validate())
+	// Removes the state repo
+	assert.NoError(t, EnvironmentConfig{StateRepo: &EnvironmentStateRepo{}}.Validate())
+	assert.NoError(t, EnvironmentConfig{Renderer: EnvironmentRenderer_KNative}.Validate())
+	assert.NoError(t, EnvironmentConfig{Renderer: EnvironmentRenderer_Kubernetes}.Validate())
+}
+
+func Test_EnvironmentConfig_ValidateRenderer(t *testing.T) {
+	err := EnvironmentConfig{Renderer: "bad"}.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	assert.Equal(t, "must be one of: knative, kubernetes", err.(validation.Errors)["renderer"].Error())
+}
+
+func Test_EnvironmentConfig_ValidateStateRepo(t *testing.T) {
+	err := EnvironmentConfig{StateRepo: &EnvironmentStateRepo{Branch: "-bad", CredentialsRef: "../bad"}}.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	stateRepoErrors := err.(validation.Errors)["stateRepo"].(validation.Errors)
+	assert.Len(t, stateRepoErrors, 3)
+	assert.Equal(t, "cannot be blank", stateRepoErrors["url"].Error())
+	assert.Equal(t, "must be a valid branch name", stateRepoErrors["branch"].Error())
+	assert.Equal(t, "must be lowercase, alphanumeric, and start with a letter", stateRepoErrors["credentialsRef"].Error())
+}
+
+func Test_EnvironmentConfig_ValidateResources(t *testing.T) {
+	cpuCores := float32(1)
+	maxCpuCores := float32(2)
+	memoryMB := int32(512)
+	maxMemoryMB := int32(256)
+	zero := int32(0)
+	err := EnvironmentConfig{
+		Resources: &EnvironmentResources{
+			DefaultRequests: &EnvironmentResourceQuantities{CpuCores: &cpuCores, MemoryMB: &memoryMB},
+			DefaultLimits:   &EnvironmentResourceQuantities{CpuCores: &maxCpuCores},
+			MaxLimits:       &EnvironmentResourceQuantities{CpuCores: &maxCpuCores, MemoryMB: &maxMemoryMB, EphemeralStorageMB: &zero},
+		},
+	}.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	resourcesErrors := err.(validation.Errors)["resources"].(validation.Errors)
+	assert.Len(t, resourcesErrors, 2)
+	defaultRequestsErrors := resourcesErrors["defaultRequests"].(validation.Errors)
+	assert.Len(t, defaultRequestsErrors, 1)
+	assert.Equal(t, "must be less than or equal to maxLimits.memoryMB", defaultRequestsErrors["memoryMB"].Error())
+	maxLimitsErrors := resourcesErrors["maxLimits"].(validation.Errors)
+	assert.Len(t, maxLimitsErrors, 1)
+	assert.Equal(t, "must be no less than 1", maxLimitsErrors["ephemeralStorageMB"].Error())
+}
+
+func Test_EnvironmentConfig_ValidateResources_Valid(t *testing.T) {
+	cpuCores := float32(0.5)
+	memoryMB := int32(512)
+	assert.NoError(t, EnvironmentConfig{
+		Resources: &EnvironmentResources{
+			DefaultRequests: &EnvironmentResourceQuantities{CpuCores: &cpuCores},
+			DefaultLimits:   &EnvironmentResourceQuantities{MemoryMB: &memoryMB},
+		},
+	}.Validate())
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"errors"
+
+	validation "github.com/go-ozzo/ozzo-validation/v3"
+	"github.com/google/uuid"
+)
+
+const (
+	// RoleViewer may view all resources in scope
+	RoleViewer = "viewer"
+	// RoleDeployer may deploy, roll out, and manage secrets in scope
+	RoleDeployer = "deployer"
+	// RoleNamespaceAdmin may deploy and manage apps in scope
+	RoleNamespaceAdmin = "namespace-admin"
+	// RoleEnvironmentController may report status for environments in scope. This is typically used by the riser controller.
+	RoleEnvironmentController = "environment-controller"
+	// RoleServerAdmin may perform any action, including managing role bindings. This role may not be scoped.
+	RoleServerAdmin = "server-admin"
+)
+
+var Roles = []interface{}{RoleViewer, RoleDeployer, RoleNamespaceAdmin, RoleEnvironmentController, RoleServerAdmin}
+
+// RoleBinding grants a role to either a user or to the members of an OIDC group
+type RoleBinding struct {
+	Id       uuid.UUID `json:"id"`
+	Username string    `json:"username,omitempty"`
+	Group    string    `json:"group,omitempty"`
+	Role     string    `json:"role"`
+	// Namespace limits the role to a namespace. Empty applies to all namespaces.
+	Namespace string `json:"namespace,omitempty"`
+	// Environment limits the role to an environment. Empty applies to all environments.
+	Environment string `json:"environment,omitempty"`
+}
+
+type NewRoleBinding struct {
+	Username string `json:"username,omitempty"`
+	// Group grants the role to the users whose OIDC token contains the group. Either a username or a group is required.
+	Group       string `json:"group,omitempty"`
+	Role        string `json:"role"`
+	Namespace   string `json:"namespace,omitempty"`
+	Environment string `json:"environment,omitempty"`
+}
+
+func (v NewRoleBinding) Validate() error {
+	return validation.ValidateStruct(&v,
+		validation.Field(&v.Username, validation.By(func(interface{}) error {
+			if v.Group == "" {
+				return validation.Required.Validate(v.Username)
+			}
+			if v.Username != "" {
+				return errors.New("may not be specified with a group")
+			}
+			return nil
+		})),
+		validation.Field(&v.Group, validation.RuneLength(1, 255)),
+		validation.Field(&v.Role, validation.Required, validation.In(Roles...)),
+		validation.Field(&v.Namespace, validation.By(func(interface{}) error {
+			if v.Namespace != "" {
+				if v.Role == RoleServerAdmin {
+					return errors.New("may not be specified for the server-admin role")
+				}
+				return NamespaceName(v.Namespace).Validate()
+			}
+			return nil
+		})),
+		validation.Field(&v.Environment, validation.By(func(interface{}) error {
+			if v.Environment != "" && v.Role == RoleServerAdmin {
+				return errors.New("may not be specified for the server-admin role")
+			}
+			return nil
+		})))
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"testing"
+
+	validation "github.com/go-ozzo/ozzo-validation/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewRoleBinding_Validate(t *testing.T) {
+	model := NewRoleBinding{Username: "myuser", Role: RoleDeployer, Namespace: "myns", Environment: "prod"}
+
+	assert.NoError(t, model.Validate())
+}
+
+func Test_NewRoleBinding_ValidateRequired(t *testing.T) {
+	err := NewRoleBinding{}.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 2)
+	assertFieldsRequired(t, validationErrors, "username", "role")
+}
+
+func Test_NewRoleBinding_ValidateRole(t *testing.T) {
+	err := NewRoleBinding{Username: "myuser", Role: "superuser"}.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 1)
+	assert.Equal(t, "must be a valid value", validationErrors["role"].Error())
+}
+
+func Test_NewRoleBinding_ValidateServerAdminScope(t *testing.T) {
+	err := NewRoleBinding{Username: "myuser", Role: RoleServerAdmin, Namespace: "myns", Environment: "prod"}.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 2)
+	assert.Equal(t, "may not be specified for the server-admin role", validationErrors["namespace"].Error())
+	assert.Equal(t, "may not be specified for the server-admin role", validationErrors["environment"].Error())
+}
+
+func Test_NewRoleBinding_ValidateGroup(t *testing.T) {
+	assert.NoError(t, NewRoleBinding{Group: "dev", Role: RoleDeployer}.Validate())
+
+	err := NewRoleBinding{Username: "myuser", Group: "dev", Role: RoleDeployer}.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 1)
+	assert.Equal(t, "may not be specified with a group", validationErrors["username"].Error())
+}
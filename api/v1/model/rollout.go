@@ -56,3 +56,32 @@ func (trafficRule *TrafficRule) Validate() error {
 		validation.Field(&trafficRule.Percent, validation.Min(0), validation.Max(100)),
 	)
 }
+
+// RolloutStrategy progressively shifts traffic to a new revision. Traffic only advances to the next step while the new revision
+// reports a status of "Ready". The rollout is aborted and traffic is restored if the new revision reports a status of "Unhealthy".
+type RolloutStrategy struct {
+	// Steps are the percentages of traffic routed to the new revision at each step. The final step must be 100.
+	Steps []int `json:"steps"`
+	// PauseSeconds is the time to wait between steps
+	PauseSeconds int `json:"pauseSeconds"`
+}
+
+func (strategy RolloutStrategy) Validate() error {
+	return validation.ValidateStruct(&strategy,
+		validation.Field(&strategy.Steps,
+			validation.Required.Error("must specify one or more steps"),
+			validation.By(func(interface{}) error {
+				previous := 0
+				for _, percent := range strategy.Steps {
+					if percent <= previous || percent > 100 {
+						return errors.New("step percentages must be increasing and between 1 and 100")
+					}
+					previous = percent
+				}
+				if previous != 100 {
+					return errors.New("the final step must be 100")
+				}
+				return nil
+			})),
+		validation.Field(&strategy.PauseSeconds, validation.Required, validation.Min(1)))
+}
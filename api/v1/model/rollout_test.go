@@ -103,3 +103,41 @@ func Test_RolloutRequest_ValidateTrafficRule(t *testing.T) {
 	assert.Equal(t, "must be no greater than 100", validationErrors["traffic[0].percent"].Error())
 	assert.Equal(t, "must be no less than 0", validationErrors["traffic[1].percent"].Error())
 }
+
+func Test_RolloutStrategy_Validate(t *testing.T) {
+	strategy := RolloutStrategy{Steps: []int{10, 50, 100}, PauseSeconds: 60}
+
+	assert.NoError(t, strategy.Validate())
+}
+
+func Test_RolloutStrategy_ValidateRequired(t *testing.T) {
+	err := RolloutStrategy{}.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 2)
+	assert.Equal(t, "must specify one or more steps", validationErrors["steps"].Error())
+	assert.Equal(t, "cannot be blank", validationErrors["pauseSeconds"].Error())
+}
+
+func Test_RolloutStrategy_ValidateSteps(t *testing.T) {
+	tt := []struct {
+		steps    []int
+		expected string
+	}{
+		{[]int{50, 10, 100}, "step percentages must be increasing and between 1 and 100"},
+		{[]int{0, 100}, "step percentages must be increasing and between 1 and 100"},
+		{[]int{50, 50, 100}, "step percentages must be increasing and between 1 and 100"},
+		{[]int{50, 110}, "step percentages must be increasing and between 1 and 100"},
+		{[]int{10, 50}, "the final step must be 100"},
+	}
+
+	for _, test := range tt {
+		err := RolloutStrategy{Steps: test.steps, PauseSeconds: 1}.Validate()
+
+		require.IsType(t, validation.Errors{}, err)
+		validationErrors := err.(validation.Errors)
+		assert.Len(t, validationErrors, 1)
+		assert.Equal(t, test.expected, validationErrors["steps"].Error(), "steps: %v", test.steps)
+	}
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v3"
+	"github.com/google/uuid"
+)
+
+type User struct {
+	Id       uuid.UUID  `json:"id"`
+	Username string     `json:"username"`
+	Email    string     `json:"email,omitempty"`
+	Created  time.Time  `json:"created"`
+	Disabled *time.Time `json:"disabled,omitempty"`
+}
+
+type NewUser struct {
+	Username string `json:"username"`
+}
+
+func (v NewUser) Validate() error {
+	return validation.ValidateStruct(&v,
+		validation.Field(&v.Username, append(RulesNamingIdentifier(), validation.Required, validation.RuneLength(3, 32))...))
+}
+
+// ApiKey is the metadata for an API key. The key itself is only returned once when it is created.
+type ApiKey struct {
+	Id       uuid.UUID  `json:"id"`
+	Name     string     `json:"name"`
+	Created  time.Time  `json:"created"`
+	LastUsed *time.Time `json:"lastUsed,omitempty"`
+	Expires  *time.Time `json:"expires,omitempty"`
+}
+
+type NewApiKey struct {
+	Name string `json:"name"`
+	// Expires is optional. Omit for a key that does not expire.
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+func (v NewApiKey) Validate() error {
+	return validation.ValidateStruct(&v,
+		validation.Field(&v.Name, append(RulesNamingIdentifier(), validation.Required)...),
+		validation.Field(&v.Expires, validation.By(func(interface{}) error {
+			if v.Expires != nil && !v.Expires.After(time.Now()) {
+				return errors.New("must be in the future")
+			}
+			return nil
+		})))
+}
+
+type NewApiKeyResponse struct {
+	ApiKey `json:",inline"`
+	// PlainTextKey is only returned when the key is created. Store it securely as it cannot be retrieved again.
+	PlainTextKey string `json:"plainTextKey"`
+}
+
+// OidcIdentity identifies a user with the issuer and subject of their OIDC token
+type OidcIdentity struct {
+	Issuer  string `json:"issuer"`
+	Subject string `json:"subject"`
+}
+
+func (v OidcIdentity) Validate() error {
+	return validation.ValidateStruct(&v,
+		validation.Field(&v.Issuer, validation.Required, validation.RuneLength(1, 2048)),
+		validation.Field(&v.Subject, validation.Required, validation.RuneLength(1, 255)))
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewUser_Validate(t *testing.T) {
+	assert.NoError(t, NewUser{Username: "myuser"}.Validate())
+}
+
+func Test_NewUser_ValidateUsername(t *testing.T) {
+	err := NewUser{Username: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"}.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 1)
+	assert.Equal(t, "the length must be between 3 and 32", validationErrors["username"].Error())
+}
+
+func Test_NewUser_ValidateRequired(t *testing.T) {
+	err := NewUser{}.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	assertFieldsRequired(t, err.(validation.Errors), "username")
+}
+
+func Test_NewApiKey_Validate(t *testing.T) {
+	expires := time.Now().Add(time.Hour)
+
+	assert.NoError(t, NewApiKey{Name: "ci-key"}.Validate())
+	assert.NoError(t, NewApiKey{Name: "ci-key", Expires: &expires}.Validate())
+}
+
+func Test_NewApiKey_ValidateExpires(t *testing.T) {
+	expires := time.Now().Add(-time.Hour)
+
+	err := NewApiKey{Name: "ci-key", Expires: &expires}.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 1)
+	assert.Equal(t, "must be in the future", validationErrors["expires"].Error())
+}
+
+func Test_NewApiKey_ValidateRequired(t *testing.T) {
+	err := NewApiKey{}.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	assertFieldsRequired(t, err.(validation.Errors), "name")
+}
+
+func Test_OidcIdentity_Validate(t *testing.T) {
+	assert.NoError(t, OidcIdentity{Issuer: "https://issuer", Subject: "1234"}.Validate())
+
+	err := OidcIdentity{}.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 2)
+	assertFieldsRequired(t, validationErrors, "issuer", "subject")
+}
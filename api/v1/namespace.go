@@ -16,6 +16,7 @@ func PostNamespace(c echo.Context, namespaceService namespace.Service) error {
 		return err
 	}
 
+	setAuditResourceName(c, string(ns.Name))
 	return namespaceService.Create(string(ns.Name))
 }
 
@@ -7,8 +7,6 @@ import (
 	"github.com/riser-platform/riser-server/pkg/environment"
 	"github.com/riser-platform/riser-server/pkg/git"
 
-	"github.com/riser-platform/riser-server/pkg/state"
-
 	validation "github.com/go-ozzo/ozzo-validation/v3"
 	"github.com/labstack/echo/v4"
 	"github.com/riser-platform/riser-server/api/v1/model"
@@ -16,12 +14,13 @@ import (
 	"github.com/riser-platform/riser-server/pkg/rollout"
 )
 
-func PutRollout(c echo.Context, rolloutService rollout.Service, environmentService environment.Service, repoCache *environment.RepoCache) error {
+func PutRollout(c echo.Context, rolloutService rollout.Service, environmentService environment.Service, stateBackend environment.StateBackend, reservations core.DeploymentReservationRepository, apps core.AppRepository) error {
 	rolloutRequest := &model.RolloutRequest{}
 
 	deploymentName := c.Param("deploymentName")
 	namespace := c.Param("namespace")
 	envName := c.Param("envName")
+	setAuditAppForDeployment(c, reservations, apps, core.NewNamespacedName(deploymentName, namespace))
 
 	// Validate environment before binding otherwise the client gets a confusing error about route rules when they pass in an invalid environment
 	err := environmentService.ValidateDeployable(envName)
@@ -39,14 +38,15 @@ func PutRollout(c echo.Context, rolloutService rollout.Service, environmentServi
 		return core.NewValidationError("Invalid rollout request", err)
 	}
 
-	stateRepo, err := repoCache.GetRepo(envName)
+	committer, err := newCommitter(c, stateBackend, envName)
 	if err != nil {
 		return err
 	}
 
 	err = rolloutService.UpdateTraffic(core.NewNamespacedName(deploymentName, namespace), envName,
 		mapTrafficRulesToDomain(deploymentName, rolloutRequest.Traffic),
-		state.NewGitCommitter(stateRepo))
+		currentUsername(c),
+		committer)
 	if err != nil {
 		if err == git.ErrNoChanges {
 			return c.JSON(http.StatusOK, model.APIResponse{Message: "No changes to rollout"})
@@ -24,7 +24,7 @@ func Test_PutRollout_ValidatesEnvironment(t *testing.T) {
 		},
 	}
 
-	err := PutRollout(ctx, nil, service, nil)
+	err := PutRollout(ctx, nil, service, nil, nil, nil)
 
 	assert.Equal(t, "test", err.Error())
 }
@@ -41,7 +41,7 @@ func Test_PutRollout_ValidatesTraffic(t *testing.T) {
 		},
 	}
 
-	err := PutRollout(ctx, nil, service, nil)
+	err := PutRollout(ctx, nil, service, nil, nil, nil)
 
 	assert.Equal(t, "Invalid rollout request: traffic: must specify one or more traffic rules.", err.Error())
 }
@@ -4,25 +4,32 @@ import (
 	"database/sql"
 
 	"github.com/riser-platform/riser-server/pkg/deploymentreservation"
+	"github.com/riser-platform/riser-server/pkg/domainclaim"
 	"github.com/riser-platform/riser-server/pkg/environment"
 
 	"github.com/riser-platform/riser-server/pkg/namespace"
+	"github.com/riser-platform/riser-server/pkg/oidc"
 
 	"github.com/riser-platform/riser-server/pkg/rollout"
 
 	"github.com/labstack/echo/v4/middleware"
 
 	"github.com/riser-platform/riser-server/pkg/app"
+	"github.com/riser-platform/riser-server/pkg/authorization"
 	"github.com/riser-platform/riser-server/pkg/deployment"
 	"github.com/riser-platform/riser-server/pkg/deploymentstatus"
 	"github.com/riser-platform/riser-server/pkg/login"
 	"github.com/riser-platform/riser-server/pkg/postgres"
+	"github.com/riser-platform/riser-server/pkg/reconcile"
 	"github.com/riser-platform/riser-server/pkg/secret"
+	"github.com/riser-platform/riser-server/pkg/user"
 
 	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
 )
 
-func RegisterRoutes(e *echo.Echo, repoCache *environment.RepoCache, db *sql.DB) {
+// RegisterRoutes registers the v1 routes. tokenVerifier may be nil when OIDC authentication is not configured.
+func RegisterRoutes(e *echo.Echo, stateBackend environment.StateBackend, db *sql.DB, tokenVerifier oidc.Verifier) {
 	v1 := e.Group("/api/v1")
 
 	// TODO: Refactor dependency management
@@ -36,16 +43,24 @@ func RegisterRoutes(e *echo.Echo, repoCache *environment.RepoCache, db *sql.DB)
 	secretMetaRepository := postgres.NewSecretMetaRepository(db)
 	secretService := secret.NewService(secretMetaRepository, environmentRepository)
 	deploymentReservationService := deploymentreservation.NewService(deploymentReservationRepository)
+	domainClaimService := domainclaim.NewService(postgres.NewDomainClaimRepository(db))
 	deploymentRepository := postgres.NewDeploymentRepository(db)
-	deploymentService := deployment.NewService(appRepository, namespaceService, secretMetaRepository, environmentRepository, deploymentRepository, deploymentReservationService)
+	deploymentRevisionRepository := postgres.NewDeploymentRevisionRepository(db)
+	rolloutRepository := postgres.NewRolloutRepository(db)
+	deploymentService := deployment.NewService(appRepository, namespaceService, secretMetaRepository, environmentRepository, deploymentRepository, deploymentRevisionRepository, rolloutRepository, deploymentReservationService, domainClaimService, logrus.StandardLogger())
 	deploymentStatusService := deploymentstatus.NewService(deploymentRepository, environmentService)
-	rolloutService := rollout.NewService(appRepository, deploymentRepository)
+	reconcileService := reconcile.NewService(deploymentService, secretService, postgres.NewDriftReportRepository(db), environmentRepository)
+	rolloutService := rollout.NewService(appRepository, deploymentRepository, environmentRepository)
 	userRepository := postgres.NewUserRepository(db)
 	apiKeyRepository := postgres.NewApiKeyRepository(db)
-	loginService := login.NewService(userRepository, apiKeyRepository)
+	loginService := login.NewService(userRepository, apiKeyRepository, tokenVerifier)
+	roleBindingRepository := postgres.NewRoleBindingRepository(db)
+	authorizationService := authorization.NewService(userRepository, roleBindingRepository)
+	userService := user.NewService(userRepository, apiKeyRepository)
+	auditRepository := postgres.NewAuditRepository(db)
 
 	e.Use(middleware.KeyAuthWithConfig(middleware.KeyAuthConfig{
-		// We will probably use the "Bearer" scheme for OIDC
+		Skipper: isBearerAuth,
 		// Hack: Add colon as the old client used a colon. Echo used to support parsing without specifying the colon but a breaking change was introduced
 		AuthScheme: "Apikey:",
 		Validator: func(apikey string, c echo.Context) (bool, error) {
@@ -53,74 +68,152 @@ func RegisterRoutes(e *echo.Echo, repoCache *environment.RepoCache, db *sql.DB)
 		},
 	}))
 
+	e.Use(middleware.KeyAuthWithConfig(middleware.KeyAuthConfig{
+		Skipper: func(c echo.Context) bool {
+			return !isBearerAuth(c)
+		},
+		AuthScheme: "Bearer",
+		Validator: func(token string, c echo.Context) (bool, error) {
+			return loginWithToken(c, loginService, token)
+		},
+	}))
+
 	v1.GET("/apps", func(c echo.Context) error {
 		return ListApps(c, appRepository)
-	})
+	}, authorize(authorizationService, authorization.PermissionRead, unscoped))
 
 	v1.GET("/apps/:namespace/:appName", func(c echo.Context) error {
 		return GetApp(c, appRepository)
-	})
+	}, authorize(authorizationService, authorization.PermissionRead, pathScope))
 
 	v1.GET("/apps/:namespace/:appName/status", func(c echo.Context) error {
 		return GetAppStatus(c, appService, deploymentStatusService)
-	})
+	}, authorize(authorizationService, authorization.PermissionRead, pathScope))
 
 	v1.POST("/apps", func(c echo.Context) error {
 		return PostApp(c, appService)
-	})
+	}, audit(auditRepository, "app.create"), authorize(authorizationService, authorization.PermissionManageApps, newAppRequestScope))
 
 	v1.POST("/deployments", func(c echo.Context) error {
-		return PostDeployment(c, repoCache, appService, deploymentService, environmentService)
-	})
+		return PostDeployment(c, stateBackend, appService, deploymentService, environmentService)
+	}, audit(auditRepository, "deployment.save"), authorize(authorizationService, authorization.PermissionDeploy, deploymentRequestScope))
 	v1.PUT("/deployments", func(c echo.Context) error {
-		return PostDeployment(c, repoCache, appService, deploymentService, environmentService)
-	})
+		return PostDeployment(c, stateBackend, appService, deploymentService, environmentService)
+	}, audit(auditRepository, "deployment.save"), authorize(authorizationService, authorization.PermissionDeploy, deploymentRequestScope))
+
+	v1.POST("/deployments/promote", func(c echo.Context) error {
+		return PostDeploymentPromotion(c, stateBackend, deploymentService, environmentService, deploymentReservationRepository, appRepository)
+	}, audit(auditRepository, "deployment.promote"), authorize(authorizationService, authorization.PermissionDeploy, promoteRequestScope))
 
 	v1.DELETE("/deployments/:envName/:namespace/:deploymentName", func(c echo.Context) error {
-		return DeleteDeployment(c, repoCache, deploymentService)
-	})
+		return DeleteDeployment(c, stateBackend, deploymentService, deploymentReservationRepository, appRepository)
+	}, audit(auditRepository, "deployment.delete"), authorize(authorizationService, authorization.PermissionDeploy, pathScope))
 
 	v1.PUT("/deployments/:envName/:namespace/:deploymentName/status", func(c echo.Context) error {
 		return PutDeploymentStatus(c, deploymentRepository)
-	})
+	}, authorize(authorizationService, authorization.PermissionReportStatus, pathScope))
+
+	v1.GET("/deployments/:envName/:namespace/:deploymentName/revisions", func(c echo.Context) error {
+		return GetDeploymentRevisions(c, deploymentRevisionRepository)
+	}, authorize(authorizationService, authorization.PermissionRead, pathScope))
+
+	v1.POST("/deployments/:envName/:namespace/:deploymentName/rollback", func(c echo.Context) error {
+		return PostDeploymentRollback(c, stateBackend, deploymentService, environmentService, deploymentReservationRepository, appRepository)
+	}, audit(auditRepository, "deployment.rollback"), authorize(authorizationService, authorization.PermissionDeploy, pathScope))
 
 	v1.PUT("/rollout/:envName/:namespace/:deploymentName", func(c echo.Context) error {
-		return PutRollout(c, rolloutService, environmentService, repoCache)
-	})
+		return PutRollout(c, rolloutService, environmentService, stateBackend, deploymentReservationRepository, appRepository)
+	}, audit(auditRepository, "rollout.update"), authorize(authorizationService, authorization.PermissionDeploy, pathScope))
 
 	v1.PUT("/secrets", func(c echo.Context) error {
-		return PutSecret(c, repoCache, secretService, environmentService)
-	})
+		return PutSecret(c, stateBackend, secretService, environmentService)
+	}, audit(auditRepository, "secret.save"), authorize(authorizationService, authorization.PermissionDeploy, secretRequestScope))
 
 	v1.GET("/secrets/:envName/:namespace/:appName", func(c echo.Context) error {
 		return GetSecrets(c, secretMetaRepository, environmentService)
-	})
+	}, authorize(authorizationService, authorization.PermissionRead, pathScope))
 
 	v1.GET("/namespaces", func(c echo.Context) error {
 		return GetNamespaces(c, namespaceRepository)
-	})
+	}, authorize(authorizationService, authorization.PermissionRead, unscoped))
 
 	v1.POST("/namespaces", func(c echo.Context) error {
 		return PostNamespace(c, namespaceService)
-	})
+	}, audit(auditRepository, "namespace.create"), authorize(authorizationService, authorization.PermissionAdmin, unscoped))
 
 	v1.GET("/environments/:envName/config", func(c echo.Context) error {
 		return GetEnvironmentConfig(c, environmentService)
-	})
+	}, authorize(authorizationService, authorization.PermissionRead, pathScope))
 
 	v1.PUT("/environments/:envName/config", func(c echo.Context) error {
-		return PutEnvironmentConfig(c, environmentService)
-	})
+		return PutEnvironmentConfig(c, stateBackend, environmentService, reconcileService)
+	}, audit(auditRepository, "environment.config.update"), authorize(authorizationService, authorization.PermissionAdmin, pathScope))
+
+	v1.POST("/environments/:envName/reconcile", func(c echo.Context) error {
+		return PostEnvironmentReconcile(c, stateBackend, environmentService, reconcileService)
+	}, audit(auditRepository, "environment.reconcile"), authorize(authorizationService, authorization.PermissionAdmin, pathScope))
+
+	v1.POST("/environments/:envName/gc", func(c echo.Context) error {
+		return PostEnvironmentGC(c, stateBackend, environmentService, reconcileService)
+	}, audit(auditRepository, "environment.gc"), authorize(authorizationService, authorization.PermissionAdmin, pathScope))
+
+	v1.GET("/environments/:envName/drift", func(c echo.Context) error {
+		return GetEnvironmentDrift(c, stateBackend, environmentService, reconcileService)
+	}, authorize(authorizationService, authorization.PermissionRead, pathScope))
 
 	v1.POST("/environments/:envName/ping", func(c echo.Context) error {
 		return PostEnvironmentPing(c, environmentService)
-	})
+	}, authorize(authorizationService, authorization.PermissionReportStatus, pathScope))
 
 	v1.GET("/environments", func(c echo.Context) error {
 		return ListEnvironments(c, environmentRepository)
-	})
+	}, authorize(authorizationService, authorization.PermissionRead, unscoped))
 
 	v1.POST("/validate/appconfig", func(c echo.Context) error {
 		return PostValidateAppConfig(c, appService, environmentService)
-	})
+	}, authorize(authorizationService, authorization.PermissionRead, appConfigRequestScope))
+
+	v1.GET("/rolebindings", func(c echo.Context) error {
+		return ListRoleBindings(c, roleBindingRepository)
+	}, authorize(authorizationService, authorization.PermissionAdmin, unscoped))
+
+	v1.POST("/rolebindings", func(c echo.Context) error {
+		return PostRoleBinding(c, authorizationService)
+	}, audit(auditRepository, "rolebinding.create"), authorize(authorizationService, authorization.PermissionAdmin, unscoped))
+
+	v1.DELETE("/rolebindings/:id", func(c echo.Context) error {
+		return DeleteRoleBinding(c, authorizationService)
+	}, audit(auditRepository, "rolebinding.delete"), authorize(authorizationService, authorization.PermissionAdmin, unscoped))
+
+	v1.GET("/users", func(c echo.Context) error {
+		return ListUsers(c, userRepository)
+	}, authorize(authorizationService, authorization.PermissionAdmin, unscoped))
+
+	v1.POST("/users", func(c echo.Context) error {
+		return PostUser(c, userService)
+	}, audit(auditRepository, "user.create"), authorize(authorizationService, authorization.PermissionAdmin, unscoped))
+
+	v1.POST("/users/:username/disable", func(c echo.Context) error {
+		return PostUserDisable(c, userService)
+	}, audit(auditRepository, "user.disable"), authorize(authorizationService, authorization.PermissionAdmin, unscoped))
+
+	v1.PUT("/users/:username/oidc", func(c echo.Context) error {
+		return PutUserOidcIdentity(c, userService)
+	}, audit(auditRepository, "user.oidc.link"), authorize(authorizationService, authorization.PermissionAdmin, unscoped))
+
+	v1.GET("/users/:username/apikeys", func(c echo.Context) error {
+		return ListApiKeys(c, userService)
+	}, authorize(authorizationService, authorization.PermissionAdmin, unscoped))
+
+	v1.POST("/users/:username/apikeys", func(c echo.Context) error {
+		return PostApiKey(c, userService)
+	}, audit(auditRepository, "apikey.create"), authorize(authorizationService, authorization.PermissionAdmin, unscoped))
+
+	v1.DELETE("/users/:username/apikeys/:id", func(c echo.Context) error {
+		return DeleteApiKey(c, userService)
+	}, audit(auditRepository, "apikey.delete"), authorize(authorizationService, authorization.PermissionAdmin, unscoped))
+
+	v1.GET("/audit", func(c echo.Context) error {
+		return ListAudit(c, auditRepository)
+	}, authorize(authorizationService, authorization.PermissionAdmin, unscoped))
 }
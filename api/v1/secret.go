@@ -10,30 +10,32 @@ import (
 	"github.com/pkg/errors"
 	"github.com/riser-platform/riser-server/api/v1/model"
 	"github.com/riser-platform/riser-server/pkg/secret"
-	"github.com/riser-platform/riser-server/pkg/state"
 )
 
-func PutSecret(c echo.Context, repoCache *environment.RepoCache, secretService secret.Service, environmentService environment.Service) error {
+func PutSecret(c echo.Context, stateBackend environment.StateBackend, secretService secret.Service, environmentService environment.Service) error {
 	unsealedSecret := &model.UnsealedSecret{}
 	err := c.Bind(unsealedSecret)
 	if err != nil {
 		return errors.Wrap(err, "Error binding secret")
 	}
 
+	setAuditResourceName(c, unsealedSecret.Name)
+	setAuditAppName(c, string(unsealedSecret.AppName))
 	err = environmentService.ValidateDeployable(unsealedSecret.Environment)
 	if err != nil {
 		return err
 	}
 
-	stateRepo, err := repoCache.GetRepo(unsealedSecret.Environment)
+	committer, err := newCommitter(c, stateBackend, unsealedSecret.Environment)
 	if err != nil {
-		return nil
+		return err
 	}
 
 	err = secretService.SealAndSave(
 		unsealedSecret.PlainText,
 		mapSecretMetaFromModel(&unsealedSecret.SecretMeta),
-		state.NewGitCommitter(stateRepo))
+		currentUsername(c),
+		committer)
 	if err == core.ErrConflictNewerVersion {
 		return echo.NewHTTPError(http.StatusConflict, "A newer revision of the secret was saved while attempting to save this secret. This is usually caused by a race condition due to another user saving the secret at the same time.")
 	}
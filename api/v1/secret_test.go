@@ -35,7 +35,7 @@ func Test_PutSecret(t *testing.T) {
 	ctx, rec := newContextWithRecorder(req)
 
 	secretService := &secret.FakeService{
-		SealAndSaveFn: func(plaintextSecret string, secretMeta *core.SecretMeta, committer state.Committer) error {
+		SealAndSaveFn: func(plaintextSecret string, secretMeta *core.SecretMeta, savedBy string, committer state.Committer) error {
 			assert.Equal(t, "myplain", plaintextSecret)
 			assert.Equal(t, secretMeta, mapSecretMetaFromModel(&unsealed.SecretMeta))
 			return nil
@@ -49,7 +49,7 @@ func Test_PutSecret(t *testing.T) {
 		},
 	}
 
-	err := PutSecret(ctx, environment.NewFakeRepoCache(), secretService, environmentService)
+	err := PutSecret(ctx, environment.NewFakeStateBackend(), secretService, environmentService)
 
 	assert.NoError(t, err)
 	assert.Equal(t, http.StatusOK, rec.Result().StatusCode)
@@ -73,7 +73,7 @@ func Test_PutSecret_WhenRevisionConflict(t *testing.T) {
 	ctx, _ := newContextWithRecorder(req)
 
 	secretService := &secret.FakeService{
-		SealAndSaveFn: func(plaintextSecret string, secretMeta *core.SecretMeta, committer state.Committer) error {
+		SealAndSaveFn: func(plaintextSecret string, secretMeta *core.SecretMeta, savedBy string, committer state.Committer) error {
 			return core.ErrConflictNewerVersion
 		},
 	}
@@ -84,7 +84,7 @@ func Test_PutSecret_WhenRevisionConflict(t *testing.T) {
 		},
 	}
 
-	err := PutSecret(ctx, environment.NewFakeRepoCache(), secretService, environmentService)
+	err := PutSecret(ctx, environment.NewFakeStateBackend(), secretService, environmentService)
 	require.IsType(t, &echo.HTTPError{}, err)
 	httpErr := err.(*echo.HTTPError)
 	assert.Equal(t, "A newer revision of the secret was saved while attempting to save this secret. This is usually caused by a race condition due to another user saving the secret at the same time.", httpErr.Message)
@@ -0,0 +1,140 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/user"
+)
+
+func ListUsers(c echo.Context, users core.UserRepository) error {
+	domainArray, err := users.List()
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, mapUserArrayFromDomain(domainArray))
+}
+
+func PostUser(c echo.Context, userService user.Service) error {
+	newUser := &model.NewUser{}
+	err := c.Bind(newUser)
+	if err != nil {
+		return err
+	}
+
+	setAuditResourceName(c, newUser.Username)
+	domain, err := userService.Create(newUser.Username)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, mapUserFromDomain(domain))
+}
+
+func PostUserDisable(c echo.Context, userService user.Service) error {
+	err := userService.Disable(c.Param("username"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{Message: "User disabled"})
+}
+
+// PutUserOidcIdentity links an existing user to an OIDC identity
+func PutUserOidcIdentity(c echo.Context, userService user.Service) error {
+	identity := &model.OidcIdentity{}
+	err := c.Bind(identity)
+	if err != nil {
+		return err
+	}
+
+	err = userService.LinkOidcIdentity(c.Param("username"), &core.OidcIdentity{Issuer: identity.Issuer, Subject: identity.Subject})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{Message: "User linked to the OIDC identity"})
+}
+
+func ListApiKeys(c echo.Context, userService user.Service) error {
+	domainArray, err := userService.ListApiKeys(c.Param("username"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, mapApiKeyArrayFromDomain(domainArray))
+}
+
+func PostApiKey(c echo.Context, userService user.Service) error {
+	newApiKey := &model.NewApiKey{}
+	err := c.Bind(newApiKey)
+	if err != nil {
+		return err
+	}
+
+	domain, apiKeyPlainText, err := userService.CreateApiKey(c.Param("username"), newApiKey.Name, newApiKey.Expires)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, model.NewApiKeyResponse{
+		ApiKey:       mapApiKeyFromDomain(domain),
+		PlainTextKey: apiKeyPlainText,
+	})
+}
+
+func DeleteApiKey(c echo.Context, userService user.Service) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return core.NewValidationError("Invalid API key id", err)
+	}
+
+	err = userService.RevokeApiKey(c.Param("username"), id)
+	if err != nil {
+		if err == core.ErrNotFound {
+			return c.JSON(http.StatusNotFound, model.APIResponse{Message: "API key not found"})
+		}
+		return err
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{Message: "API key revoked"})
+}
+
+func mapUserArrayFromDomain(domainArray []core.User) []model.User {
+	modelArray := []model.User{}
+	for idx := range domainArray {
+		modelArray = append(modelArray, mapUserFromDomain(&domainArray[idx]))
+	}
+	return modelArray
+}
+
+func mapUserFromDomain(domain *core.User) model.User {
+	return model.User{
+		Id:       domain.Id,
+		Username: domain.Username,
+		Email:    domain.Doc.Email,
+		Created:  domain.Doc.Created,
+		Disabled: domain.DisabledAt,
+	}
+}
+
+func mapApiKeyArrayFromDomain(domainArray []core.ApiKey) []model.ApiKey {
+	modelArray := []model.ApiKey{}
+	for idx := range domainArray {
+		modelArray = append(modelArray, mapApiKeyFromDomain(&domainArray[idx]))
+	}
+	return modelArray
+}
+
+// mapApiKeyFromDomain never maps the key hash
+func mapApiKeyFromDomain(domain *core.ApiKey) model.ApiKey {
+	return model.ApiKey{
+		Id:       domain.Id,
+		Name:     domain.Name,
+		Created:  domain.Created,
+		LastUsed: domain.LastUsed,
+		Expires:  domain.Expires,
+	}
+}
@@ -0,0 +1,134 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_PostUser(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", safeMarshal(model.NewUser{Username: "myuser"}))
+	req.Header.Add("Content-Type", "application/json")
+	ctx, rec := newContextWithRecorder(req)
+
+	userService := &user.FakeService{
+		CreateFn: func(username string) (*core.User, error) {
+			assert.Equal(t, "myuser", username)
+			return &core.User{Id: uuid.New(), Username: username}, nil
+		},
+	}
+
+	err := PostUser(ctx, userService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	response := model.User{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "myuser", response.Username)
+}
+
+func Test_PutUserOidcIdentity(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPut, "/", safeMarshal(model.OidcIdentity{Issuer: "https://issuer", Subject: "1234"}))
+	req.Header.Add("Content-Type", "application/json")
+	ctx, rec := newContextWithRecorder(req)
+	ctx.SetParamNames("username")
+	ctx.SetParamValues("myuser")
+
+	userService := &user.FakeService{
+		LinkOidcIdentityFn: func(username string, identity *core.OidcIdentity) error {
+			assert.Equal(t, "myuser", username)
+			assert.Equal(t, &core.OidcIdentity{Issuer: "https://issuer", Subject: "1234"}, identity)
+			return nil
+		},
+	}
+
+	err := PutUserOidcIdentity(ctx, userService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 1, userService.LinkOidcIdentityCallCount)
+}
+
+func Test_PostApiKey(t *testing.T) {
+	expires := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	req := httptest.NewRequest(http.MethodPost, "/", safeMarshal(model.NewApiKey{Name: "ci-key", Expires: &expires}))
+	req.Header.Add("Content-Type", "application/json")
+	ctx, rec := newContextWithRecorder(req)
+	ctx.SetParamNames("username")
+	ctx.SetParamValues("myuser")
+
+	userService := &user.FakeService{
+		CreateApiKeyFn: func(username string, name string, expiresArg *time.Time) (*core.ApiKey, string, error) {
+			assert.Equal(t, "myuser", username)
+			assert.Equal(t, "ci-key", name)
+			assert.True(t, expires.Equal(*expiresArg))
+			return &core.ApiKey{Id: uuid.New(), Name: name, KeyHash: []byte("hash"), Expires: expiresArg}, "plaintext", nil
+		},
+	}
+
+	err := PostApiKey(ctx, userService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	response := model.NewApiKeyResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "ci-key", response.Name)
+	assert.Equal(t, "plaintext", response.PlainTextKey)
+	assert.NotContains(t, rec.Body.String(), "hash")
+}
+
+func Test_DeleteApiKey_NotFound(t *testing.T) {
+	id := uuid.New()
+	req := httptest.NewRequest(http.MethodDelete, "/", nil)
+	ctx, rec := newContextWithRecorder(req)
+	ctx.SetParamNames("username", "id")
+	ctx.SetParamValues("myuser", id.String())
+
+	userService := &user.FakeService{
+		RevokeApiKeyFn: func(username string, idArg uuid.UUID) error {
+			assert.Equal(t, "myuser", username)
+			assert.Equal(t, id, idArg)
+			return core.ErrNotFound
+		},
+	}
+
+	err := DeleteApiKey(ctx, userService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func Test_mapApiKeyFromDomain(t *testing.T) {
+	lastUsed := time.Now()
+	domain := &core.ApiKey{Id: uuid.New(), UserId: uuid.New(), Name: "ci-key", KeyHash: []byte("hash"), Created: time.Now(), LastUsed: &lastUsed}
+
+	result := mapApiKeyFromDomain(domain)
+
+	assert.Equal(t, domain.Id, result.Id)
+	assert.Equal(t, "ci-key", result.Name)
+	assert.Equal(t, domain.Created, result.Created)
+	assert.Equal(t, &lastUsed, result.LastUsed)
+	assert.Nil(t, result.Expires)
+}
+
+func Test_mapUserFromDomain(t *testing.T) {
+	disabled := time.Now()
+	domain := &core.User{Id: uuid.New(), Username: "myuser", DisabledAt: &disabled, Doc: core.UserDoc{Created: time.Now(), Email: "user@example.com"}}
+
+	result := mapUserFromDomain(domain)
+
+	assert.Equal(t, domain.Id, result.Id)
+	assert.Equal(t, "myuser", result.Username)
+	assert.Equal(t, "user@example.com", result.Email)
+	assert.Equal(t, domain.Doc.Created, result.Created)
+	assert.Equal(t, &disabled, result.Disabled)
+}
@@ -1,11 +1,26 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/authorization"
+	"github.com/riser-platform/riser-server/pkg/deployment"
+	"github.com/riser-platform/riser-server/pkg/deploymentreservation"
+	"github.com/riser-platform/riser-server/pkg/domainclaim"
+	"github.com/riser-platform/riser-server/pkg/reconcile"
+	"github.com/riser-platform/riser-server/pkg/rollout"
+	"github.com/riser-platform/riser-server/pkg/secret"
 
 	"github.com/riser-platform/riser-server/pkg/environment"
+	"github.com/riser-platform/riser-server/pkg/git"
 
 	"github.com/riser-platform/riser-server/pkg/namespace"
+	"github.com/riser-platform/riser-server/pkg/oidc"
 	"github.com/riser-platform/riser-server/pkg/util"
 
 	"github.com/riser-platform/riser-server/api"
@@ -35,6 +50,8 @@ const envPrefix = "RISER"
 // DotEnv file typically used For local development
 const dotEnvFile = ".env"
 
+const oidcHttpTimeout = 10 * time.Second
+
 var logger = logrus.StandardLogger()
 
 func main() {
@@ -67,15 +84,42 @@ func main() {
 		exitIfError(err, "Error performing Postgres migrations")
 	}
 
-	repoSettings := environment.RepoSettings{
-		URL:        rc.GitUrl,
-		BaseGitDir: rc.GitDir,
-	}
-	repoCache := environment.NewBranchPerEnvRepoCache(repoSettings)
+	stateBackend, err := environment.NewStateBackend(environment.StateBackendSettings{
+		Type: rc.StateBackend,
+		Repo: environment.RepoSettings{
+			URL:         rc.GitUrl,
+			Branch:      rc.GitBranch,
+			BaseGitDir:  rc.GitDir,
+			LockTimeout: rc.GitLockTimeout,
+			Backend:     rc.GitBackend,
+			Timeout:     rc.GitTimeout,
+			Auth: git.Auth{
+				SSHKeyPath: rc.GitSshKeyPath,
+				Username:   rc.GitUsername,
+				Password:   rc.GitPassword,
+			},
+			InMemory:       rc.GitInMemory,
+			CredentialsDir: rc.GitCredentialsDir,
+		},
+		FileDir:      rc.StateDir,
+		Environments: postgres.NewEnvironmentRepository(postgresDb),
+	})
+	exitIfError(err, "Error initializing state backend")
 
 	bootstrapApiKey(postgresDb, &rc)
 	bootstrapDefaultNamespace(postgresDb)
 
+	go startRolloutEngine(postgresDb, stateBackend, &rc)
+	if rc.DriftInterval > 0 {
+		go startDriftDetector(postgresDb, stateBackend, &rc)
+	}
+	if rc.GcInterval > 0 {
+		go startGarbageCollector(postgresDb, stateBackend, &rc)
+	}
+
+	tokenVerifier, err := newTokenVerifier(&rc)
+	exitIfError(err, "Error initializing OIDC")
+
 	e := echo.New()
 	e.HideBanner = true
 
@@ -84,11 +128,81 @@ func main() {
 	e.HTTPErrorHandler = api.ErrorHandler
 	e.Binder = &api.DataBinder{}
 
-	apiv1.RegisterRoutes(e, repoCache, postgresDb)
+	apiv1.RegisterRoutes(e, stateBackend, postgresDb, tokenVerifier)
 	err = e.Start(rc.BindAddress)
 	exitIfError(err, "Error starting server")
 }
 
+func startRolloutEngine(db *sql.DB, stateBackend environment.StateBackend, rc *core.RuntimeConfig) {
+	deploymentRepository := postgres.NewDeploymentRepository(db)
+	rolloutService := rollout.NewService(postgres.NewAppRepository(db), deploymentRepository, postgres.NewEnvironmentRepository(db))
+	engine := rollout.NewEngine(postgres.NewRolloutRepository(db), deploymentRepository, rolloutService,
+		stateBackend.NewCommitter, logger)
+	engine.Run(context.Background(), rc.RolloutInterval)
+}
+
+func startDriftDetector(db *sql.DB, stateBackend environment.StateBackend, rc *core.RuntimeConfig) {
+	detector := reconcile.NewDriftDetector(postgres.NewEnvironmentRepository(db), newReconcileService(db), stateBackend.NewReader, logger)
+	detector.Run(context.Background(), rc.DriftInterval)
+}
+
+func startGarbageCollector(db *sql.DB, stateBackend environment.StateBackend, rc *core.RuntimeConfig) {
+	collector := reconcile.NewGarbageCollector(postgres.NewEnvironmentRepository(db), newReconcileService(db),
+		stateBackend.NewReader, stateBackend.NewCommitter, logger)
+	collector.Run(context.Background(), rc.GcInterval)
+}
+
+func newReconcileService(db *sql.DB) reconcile.Service {
+	environmentRepository := postgres.NewEnvironmentRepository(db)
+	secretMetaRepository := postgres.NewSecretMetaRepository(db)
+	deploymentService := deployment.NewService(
+		postgres.NewAppRepository(db),
+		namespace.NewService(postgres.NewNamespaceRepository(db), environmentRepository),
+		secretMetaRepository,
+		environmentRepository,
+		postgres.NewDeploymentRepository(db),
+		postgres.NewDeploymentRevisionRepository(db),
+		postgres.NewRolloutRepository(db),
+		deploymentreservation.NewService(postgres.NewDeploymentReservationRepository(db)),
+		domainclaim.NewService(postgres.NewDomainClaimRepository(db)),
+		logger)
+	return reconcile.NewService(deploymentService, secret.NewService(secretMetaRepository, environmentRepository),
+		postgres.NewDriftReportRepository(db), environmentRepository)
+}
+
+// newTokenVerifier returns nil when OIDC is not configured
+func newTokenVerifier(rc *core.RuntimeConfig) (oidc.Verifier, error) {
+	if rc.OidcIssuerUrl == "" {
+		return nil, nil
+	}
+
+	if rc.OidcAudience == "" {
+		return nil, errors.New("RISER_OIDC_AUDIENCE is required when RISER_OIDC_ISSUER_URL is specified")
+	}
+
+	var keySet oidc.KeySet
+	if rc.OidcJwksFile != "" {
+		fileKeySet, err := oidc.NewFileKeySet(rc.OidcJwksFile)
+		if err != nil {
+			return nil, err
+		}
+		keySet = fileKeySet
+	} else if rc.OidcJwksUrl != "" {
+		keySet = oidc.NewRemoteKeySet(rc.OidcJwksUrl, &http.Client{Timeout: oidcHttpTimeout})
+	} else {
+		return nil, errors.New("RISER_OIDC_JWKS_URL or RISER_OIDC_JWKS_FILE is required when RISER_OIDC_ISSUER_URL is specified")
+	}
+
+	logger.Infof("OIDC authentication enabled for issuer %s", rc.OidcIssuerUrl)
+	return oidc.NewVerifier(oidc.VerifierConfig{
+		Issuer:        rc.OidcIssuerUrl,
+		Audience:      rc.OidcAudience,
+		UsernameClaim: rc.OidcUsernameClaim,
+		EmailClaim:    rc.OidcEmailClaim,
+		GroupsClaim:   rc.OidcGroupsClaim,
+	}, keySet), nil
+}
+
 func bootstrapDefaultNamespace(db *sql.DB) {
 	namespaceService := namespace.NewService(postgres.NewNamespaceRepository(db), postgres.NewEnvironmentRepository(db))
 	err := namespaceService.EnsureDefaultNamespace()
@@ -96,7 +210,7 @@ func bootstrapDefaultNamespace(db *sql.DB) {
 }
 
 func bootstrapApiKey(db *sql.DB, rc *core.RuntimeConfig) {
-	loginService := login.NewService(postgres.NewUserRepository(db), postgres.NewApiKeyRepository(db))
+	loginService := login.NewService(postgres.NewUserRepository(db), postgres.NewApiKeyRepository(db), nil)
 	err := loginService.BootstrapRootUser(rc.BootstrapApikey)
 	if err != nil {
 		if err == login.ErrRootUserExists {
@@ -104,6 +218,10 @@ func bootstrapApiKey(db *sql.DB, rc *core.RuntimeConfig) {
 		} else {
 			exitIfError(err, "Unable to bootstrap API KEY")
 		}
+	} else if rc.BootstrapApikey != "" {
+		authorizationService := authorization.NewService(postgres.NewUserRepository(db), postgres.NewRoleBindingRepository(db))
+		err = authorizationService.Grant(&core.RoleBinding{Username: login.RootUsername, Role: model.RoleServerAdmin})
+		exitIfError(err, "Unable to grant the server-admin role to the root user")
 	}
 }
 
@@ -0,0 +1,11 @@
+CREATE TABLE deployment_revision
+(
+  id uuid NOT NULL,
+  deployment_id uuid NOT NULL REFERENCES deployment(id),
+  riser_revision integer NOT NULL,
+  doc jsonb NOT NULL,
+  PRIMARY KEY(id)
+);
+
+-- A failed deployment rolls back the revision, so the same riser_revision may be recorded again by a later deployment
+CREATE UNIQUE INDEX ix_deployment_revision_riser_revision ON deployment_revision(deployment_id, riser_revision);
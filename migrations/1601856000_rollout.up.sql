@@ -0,0 +1,12 @@
+CREATE TABLE rollout
+(
+  deployment_id uuid NOT NULL REFERENCES deployment(id),
+  riser_revision integer NOT NULL,
+  status character varying(16) NOT NULL,
+  next_step_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  doc jsonb NOT NULL,
+  -- Only the rollout of the latest riser revision is tracked. A new deployment replaces any previous rollout.
+  PRIMARY KEY(deployment_id)
+);
+
+CREATE INDEX ix_rollout_status ON rollout(status);
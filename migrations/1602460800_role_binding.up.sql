@@ -0,0 +1,17 @@
+CREATE TABLE role_binding
+(
+  id uuid NOT NULL,
+  riser_user_id uuid NOT NULL REFERENCES riser_user(id),
+  role character varying(32) NOT NULL,
+  -- An empty namespace or environment applies the role to all namespaces or environments
+  namespace character varying(63) NOT NULL DEFAULT(''),
+  environment_name character varying(63) NOT NULL DEFAULT(''),
+  PRIMARY KEY(id)
+);
+
+CREATE UNIQUE INDEX ix_role_binding_riser_user_role ON role_binding(riser_user_id, role, namespace, environment_name);
+
+-- Users prior to role based access control had unrestricted access
+INSERT INTO role_binding (id, riser_user_id, role)
+SELECT md5(random()::text || clock_timestamp()::text)::uuid, id, 'server-admin'
+FROM riser_user;
@@ -0,0 +1,17 @@
+ALTER TABLE riser_user ADD COLUMN disabled_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE apikey ADD COLUMN id uuid;
+ALTER TABLE apikey ADD COLUMN name character varying(63);
+ALTER TABLE apikey ADD COLUMN created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT(now());
+ALTER TABLE apikey ADD COLUMN last_used_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE apikey ADD COLUMN expires_at TIMESTAMP WITH TIME ZONE;
+
+-- Keys created prior to key management have no name
+UPDATE apikey SET id = md5(random()::text || clock_timestamp()::text)::uuid;
+UPDATE apikey SET name = 'legacy-' || substr(id::text, 1, 8);
+
+ALTER TABLE apikey ALTER COLUMN id SET NOT NULL;
+ALTER TABLE apikey ALTER COLUMN name SET NOT NULL;
+ALTER TABLE apikey ADD PRIMARY KEY (id);
+
+CREATE UNIQUE INDEX ix_apikey_riser_user_id_name ON apikey(riser_user_id, name);
@@ -0,0 +1,5 @@
+/* Users provisioned by an OIDC login are bound to the issuer and subject of their token rather than their username */
+ALTER TABLE riser_user ADD COLUMN oidc_issuer character varying(2048);
+ALTER TABLE riser_user ADD COLUMN oidc_subject character varying(255);
+
+CREATE UNIQUE INDEX ix_riser_user_oidc_issuer_subject ON riser_user(oidc_issuer, oidc_subject);
@@ -0,0 +1,19 @@
+CREATE TABLE audit_log
+(
+  id uuid NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  /* Not a reference to riser_user so that entries outlive the user */
+  username character varying(254) NOT NULL,
+  action character varying(63) NOT NULL,
+  namespace character varying(63) NOT NULL DEFAULT '',
+  environment_name character varying(63) NOT NULL DEFAULT '',
+  resource_name character varying(254) NOT NULL DEFAULT '',
+  status_code integer NOT NULL,
+  doc jsonb NOT NULL,
+  PRIMARY KEY(id)
+);
+
+CREATE INDEX ix_audit_log_created_at ON audit_log(created_at);
+CREATE INDEX ix_audit_log_username ON audit_log(username);
+CREATE INDEX ix_audit_log_resource ON audit_log(namespace, resource_name);
+CREATE INDEX ix_audit_log_environment_name ON audit_log(environment_name);
@@ -0,0 +1,2 @@
+/* The sealed ciphertext of the committed revision so that the sealed secret can be rendered again. NULL for secrets committed before this was recorded. */
+ALTER TABLE secret_meta ADD COLUMN committed_ciphertext bytea;
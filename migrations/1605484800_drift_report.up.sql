@@ -0,0 +1,8 @@
+CREATE TABLE drift_report
+(
+  environment_name character varying(63) NOT NULL REFERENCES environment(name),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  doc jsonb NOT NULL,
+  -- Only the latest report is kept for each environment
+  PRIMARY KEY(environment_name)
+);
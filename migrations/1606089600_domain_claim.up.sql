@@ -0,0 +1,21 @@
+-- A domain belongs to a single app across all environments
+CREATE TABLE domain_owner
+(
+  domain character varying(253) PRIMARY KEY,
+  app_id uuid NOT NULL REFERENCES app(id),
+  UNIQUE(domain, app_id)
+);
+
+CREATE TABLE domain_claim
+(
+  domain character varying(253) NOT NULL,
+  environment_name character varying(63) NOT NULL REFERENCES environment(name),
+  app_id uuid NOT NULL,
+  deployment_name character varying(63) NOT NULL,
+  namespace character varying(63) NOT NULL REFERENCES namespace(name),
+  -- A domain may only route to a single deployment in each environment
+  PRIMARY KEY(domain, environment_name),
+  FOREIGN KEY(domain, app_id) REFERENCES domain_owner(domain, app_id)
+);
+
+CREATE INDEX ix_domain_claim_deployment ON domain_claim(deployment_name, namespace, environment_name);
@@ -0,0 +1,3 @@
+/* A server processes a rollout only while it holds the lease so that multiple servers do not advance the same rollout */
+ALTER TABLE rollout ADD COLUMN lease_holder character varying(36);
+ALTER TABLE rollout ADD COLUMN lease_expires_at TIMESTAMP WITH TIME ZONE;
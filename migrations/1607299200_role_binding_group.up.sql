@@ -0,0 +1,6 @@
+/* A role may be granted to the members of an OIDC group rather than to a user */
+ALTER TABLE role_binding ALTER COLUMN riser_user_id DROP NOT NULL;
+ALTER TABLE role_binding ADD COLUMN group_name character varying(255);
+ALTER TABLE role_binding ADD CONSTRAINT ck_role_binding_user_or_group CHECK ((riser_user_id IS NULL) <> (group_name IS NULL));
+
+CREATE UNIQUE INDEX ix_role_binding_group_name_role ON role_binding(group_name, role, namespace, environment_name);
@@ -0,0 +1,17 @@
+/* The app is recorded separately from the resource name since a deployment or secret is named independently of its app */
+ALTER TABLE audit_log ADD COLUMN app_name character varying(63) NOT NULL DEFAULT '';
+
+UPDATE audit_log SET app_name = resource_name WHERE action = 'app.create';
+
+UPDATE audit_log SET app_name = app.name
+FROM deployment_reservation
+INNER JOIN app ON app.id = deployment_reservation.app_id
+WHERE
+  (audit_log.action LIKE 'deployment.%' OR audit_log.action = 'rollout.update')
+  AND deployment_reservation.name = audit_log.resource_name
+  AND deployment_reservation.namespace = audit_log.namespace;
+
+/* Secrets were recorded with the app as the resource name */
+UPDATE audit_log SET app_name = resource_name, resource_name = '' WHERE action = 'secret.save';
+
+CREATE INDEX ix_audit_log_app ON audit_log(namespace, app_name);
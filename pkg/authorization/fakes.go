@@ -0,0 +1,30 @@
+package authorization
+
+import (
+	"github.com/google/uuid"
+	"github.com/riser-platform/riser-server/pkg/core"
+)
+
+type FakeService struct {
+	AuthorizeFn        func(user *core.User, permission string, scope *core.AuthorizationScope) error
+	AuthorizeCallCount int
+	GrantFn            func(binding *core.RoleBinding) error
+	GrantCallCount     int
+	RevokeFn           func(id uuid.UUID) error
+	RevokeCallCount    int
+}
+
+func (fake *FakeService) Authorize(user *core.User, permission string, scope *core.AuthorizationScope) error {
+	fake.AuthorizeCallCount++
+	return fake.AuthorizeFn(user, permission, scope)
+}
+
+func (fake *FakeService) Grant(binding *core.RoleBinding) error {
+	fake.GrantCallCount++
+	return fake.GrantFn(binding)
+}
+
+func (fake *FakeService) Revoke(id uuid.UUID) error {
+	fake.RevokeCallCount++
+	return fake.RevokeFn(id)
+}
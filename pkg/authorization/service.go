@@ -0,0 +1,109 @@
+package authorization
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/core"
+)
+
+const (
+	// PermissionRead permits viewing resources
+	PermissionRead = "read"
+	// PermissionDeploy permits deployments, rollouts, and secrets
+	PermissionDeploy = "deploy"
+	// PermissionManageApps permits creating apps
+	PermissionManageApps = "manage-apps"
+	// PermissionReportStatus permits reporting environment and deployment status
+	PermissionReportStatus = "report-status"
+	// PermissionAdmin permits managing namespaces, environment config, and role bindings
+	PermissionAdmin = "admin"
+)
+
+var rolePermissions = map[string][]string{
+	model.RoleViewer:                {PermissionRead},
+	model.RoleDeployer:              {PermissionRead, PermissionDeploy},
+	model.RoleNamespaceAdmin:        {PermissionRead, PermissionDeploy, PermissionManageApps},
+	model.RoleEnvironmentController: {PermissionRead, PermissionReportStatus},
+	model.RoleServerAdmin:           {PermissionRead, PermissionDeploy, PermissionManageApps, PermissionReportStatus, PermissionAdmin},
+}
+
+type Service interface {
+	// Authorize returns an AuthorizationError if neither the user nor one of the user's groups has a role binding with the permission
+	// in the scope
+	Authorize(user *core.User, permission string, scope *core.AuthorizationScope) error
+	// Grant creates a role binding for the user specified by the binding's Username or for the binding's Group
+	Grant(binding *core.RoleBinding) error
+	Revoke(id uuid.UUID) error
+}
+
+type service struct {
+	users        core.UserRepository
+	roleBindings core.RoleBindingRepository
+}
+
+func NewService(users core.UserRepository, roleBindings core.RoleBindingRepository) Service {
+	return &service{users, roleBindings}
+}
+
+func (s *service) Authorize(user *core.User, permission string, scope *core.AuthorizationScope) error {
+	bindings, err := s.roleBindings.FindByUser(user.Id, user.Groups)
+	if err != nil {
+		return errors.Wrap(err, "Error retrieving role bindings")
+	}
+
+	for _, binding := range bindings {
+		if bindingInScope(&binding, scope) && roleHasPermission(binding.Role, permission) {
+			return nil
+		}
+	}
+
+	return &core.AuthorizationError{Permission: permission, Scope: *scope}
+}
+
+func (s *service) Grant(binding *core.RoleBinding) error {
+	// Groups are managed by the OIDC issuer
+	if binding.Group == "" {
+		user, err := s.users.GetByUsername(binding.Username)
+		if err != nil {
+			if err == core.ErrNotFound {
+				return core.NewValidationErrorMessage(fmt.Sprintf("The user %q does not exist", binding.Username))
+			}
+			return errors.Wrap(err, "Error retrieving user")
+		}
+		binding.UserId = user.Id
+	}
+
+	if binding.Id == uuid.Nil {
+		binding.Id = uuid.New()
+	}
+
+	return s.roleBindings.Create(binding)
+}
+
+func (s *service) Revoke(id uuid.UUID) error {
+	return s.roleBindings.Delete(id)
+}
+
+// bindingInScope returns true when the binding applies to the scope. An empty namespace or environment on the binding applies to all.
+// An empty namespace or environment on the scope (e.g. listing the apps in every namespace) is only satisfied by a binding that
+// applies to all namespaces or environments.
+func bindingInScope(binding *core.RoleBinding, scope *core.AuthorizationScope) bool {
+	return scopeMatches(binding.Namespace, scope.Namespace) && scopeMatches(binding.Environment, scope.Environment)
+}
+
+func scopeMatches(bindingValue, scopeValue string) bool {
+	return bindingValue == "" || bindingValue == scopeValue
+}
+
+func roleHasPermission(role string, permission string) bool {
+	for _, rolePermission := range rolePermissions[role] {
+		if rolePermission == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package authorization
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Authorize(t *testing.T) {
+	tests := []struct {
+		name       string
+		bindings   []core.RoleBinding
+		permission string
+		scope      core.AuthorizationScope
+		allowed    bool
+	}{
+		{"no bindings", nil, PermissionRead, core.AuthorizationScope{}, false},
+		{"server-admin", []core.RoleBinding{{Role: model.RoleServerAdmin}}, PermissionAdmin, core.AuthorizationScope{Namespace: "myns", Environment: "prod"}, true},
+		{"viewer cannot deploy", []core.RoleBinding{{Role: model.RoleViewer}}, PermissionDeploy, core.AuthorizationScope{Namespace: "myns", Environment: "prod"}, false},
+		{"deployer in namespace", []core.RoleBinding{{Role: model.RoleDeployer, Namespace: "myns"}}, PermissionDeploy, core.AuthorizationScope{Namespace: "myns", Environment: "prod"}, true},
+		{"deployer in other namespace", []core.RoleBinding{{Role: model.RoleDeployer, Namespace: "other"}}, PermissionDeploy, core.AuthorizationScope{Namespace: "myns", Environment: "prod"}, false},
+		{"deployer in environment", []core.RoleBinding{{Role: model.RoleDeployer, Namespace: "myns", Environment: "dev"}}, PermissionDeploy, core.AuthorizationScope{Namespace: "myns", Environment: "dev"}, true},
+		{"deployer in other environment", []core.RoleBinding{{Role: model.RoleDeployer, Namespace: "myns", Environment: "dev"}}, PermissionDeploy, core.AuthorizationScope{Namespace: "myns", Environment: "prod"}, false},
+		{"unscoped action", []core.RoleBinding{{Role: model.RoleViewer}}, PermissionRead, core.AuthorizationScope{}, true},
+		{"namespace viewer cannot view all namespaces", []core.RoleBinding{{Role: model.RoleViewer, Namespace: "myns"}}, PermissionRead, core.AuthorizationScope{}, false},
+		{"environment viewer cannot view all environments", []core.RoleBinding{{Role: model.RoleViewer, Environment: "prod"}}, PermissionRead, core.AuthorizationScope{Namespace: "myns"}, false},
+		{"namespace viewer cannot view environment", []core.RoleBinding{{Role: model.RoleViewer, Namespace: "myns"}}, PermissionRead, core.AuthorizationScope{Environment: "prod"}, false},
+		{"namespaced server-admin cannot admin all namespaces", []core.RoleBinding{{Role: model.RoleServerAdmin, Namespace: "myns"}}, PermissionAdmin, core.AuthorizationScope{}, false},
+		{"namespace-admin", []core.RoleBinding{{Role: model.RoleNamespaceAdmin, Namespace: "myns"}}, PermissionManageApps, core.AuthorizationScope{Namespace: "myns"}, true},
+		{"namespace-admin cannot admin", []core.RoleBinding{{Role: model.RoleNamespaceAdmin, Namespace: "myns"}}, PermissionAdmin, core.AuthorizationScope{Namespace: "myns"}, false},
+		{"environment-controller", []core.RoleBinding{{Role: model.RoleEnvironmentController, Environment: "prod"}}, PermissionReportStatus, core.AuthorizationScope{Namespace: "myns", Environment: "prod"}, true},
+		{"environment-controller cannot deploy", []core.RoleBinding{{Role: model.RoleEnvironmentController, Environment: "prod"}}, PermissionDeploy, core.AuthorizationScope{Namespace: "myns", Environment: "prod"}, false},
+		{"group", []core.RoleBinding{{Role: model.RoleDeployer, Group: "dev", Namespace: "myns"}}, PermissionDeploy, core.AuthorizationScope{Namespace: "myns", Environment: "dev"}, true},
+		{"multiple bindings", []core.RoleBinding{{Role: model.RoleViewer}, {Role: model.RoleDeployer, Environment: "dev"}}, PermissionDeploy, core.AuthorizationScope{Namespace: "myns", Environment: "dev"}, true},
+	}
+
+	user := &core.User{Id: uuid.New(), Username: "myuser", Groups: []string{"dev"}}
+	for _, test := range tests {
+		roleBindings := &core.FakeRoleBindingRepository{
+			FindByUserFn: func(userId uuid.UUID, groups []string) ([]core.RoleBinding, error) {
+				assert.Equal(t, user.Id, userId)
+				assert.Equal(t, user.Groups, groups)
+				return test.bindings, nil
+			},
+		}
+		svc := NewService(nil, roleBindings)
+
+		err := svc.Authorize(user, test.permission, &test.scope)
+
+		if test.allowed {
+			assert.NoError(t, err, test.name)
+		} else {
+			assert.Equal(t, &core.AuthorizationError{Permission: test.permission, Scope: test.scope}, err, test.name)
+		}
+	}
+}
+
+func Test_Authorize_ReturnsErr(t *testing.T) {
+	roleBindings := &core.FakeRoleBindingRepository{
+		FindByUserFn: func(uuid.UUID, []string) ([]core.RoleBinding, error) {
+			return nil, errors.New("test")
+		},
+	}
+	svc := NewService(nil, roleBindings)
+
+	err := svc.Authorize(&core.User{}, PermissionRead, &core.AuthorizationScope{})
+
+	assert.Equal(t, "Error retrieving role bindings: test", err.Error())
+}
+
+func Test_Grant(t *testing.T) {
+	userId := uuid.New()
+	users := &core.FakeUserRepository{
+		GetByUsernameFn: func(username string) (*core.User, error) {
+			assert.Equal(t, "myuser", username)
+			return &core.User{Id: userId, Username: username}, nil
+		},
+	}
+	roleBindings := &core.FakeRoleBindingRepository{
+		CreateFn: func(binding *core.RoleBinding) error {
+			assert.NotEqual(t, uuid.Nil, binding.Id)
+			assert.Equal(t, userId, binding.UserId)
+			assert.Equal(t, model.RoleDeployer, binding.Role)
+			assert.Equal(t, "myns", binding.Namespace)
+			return nil
+		},
+	}
+	svc := NewService(users, roleBindings)
+
+	err := svc.Grant(&core.RoleBinding{Username: "myuser", Role: model.RoleDeployer, Namespace: "myns"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, roleBindings.CreateCallCount)
+}
+
+func Test_Grant_Group(t *testing.T) {
+	roleBindings := &core.FakeRoleBindingRepository{
+		CreateFn: func(binding *core.RoleBinding) error {
+			assert.NotEqual(t, uuid.Nil, binding.Id)
+			assert.Equal(t, uuid.Nil, binding.UserId)
+			assert.Equal(t, "dev", binding.Group)
+			return nil
+		},
+	}
+	svc := NewService(&core.FakeUserRepository{}, roleBindings)
+
+	err := svc.Grant(&core.RoleBinding{Group: "dev", Role: model.RoleDeployer})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, roleBindings.CreateCallCount)
+}
+
+func Test_Grant_UserDoesNotExist(t *testing.T) {
+	users := &core.FakeUserRepository{
+		GetByUsernameFn: func(string) (*core.User, error) {
+			return nil, core.ErrNotFound
+		},
+	}
+	svc := NewService(users, &core.FakeRoleBindingRepository{})
+
+	err := svc.Grant(&core.RoleBinding{Username: "myuser", Role: model.RoleDeployer})
+
+	assert.IsType(t, &core.ValidationError{}, err)
+	assert.Equal(t, `The user "myuser" does not exist`, err.Error())
+}
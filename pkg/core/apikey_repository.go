@@ -4,20 +4,38 @@ import "github.com/google/uuid"
 
 type ApiKeyRepository interface {
 	GetByUserId(userId uuid.UUID) ([]ApiKey, error)
-	Create(userId uuid.UUID, keyHash []byte) error
+	Create(apiKey *ApiKey) error
+	// Delete deletes the API key. Returns ErrNotFound if the key does not exist for the user.
+	Delete(userId uuid.UUID, id uuid.UUID) error
+	// UpdateLastUsed records that the key was used to login
+	UpdateLastUsed(keyHash []byte) error
 }
 
 type FakeApiKeyRepository struct {
-	GetByUserIdFn   func(uuid.UUID) ([]ApiKey, error)
-	CreateFn        func(uuid.UUID, []byte) error
-	CreateCallCount int
+	GetByUserIdFn           func(uuid.UUID) ([]ApiKey, error)
+	CreateFn                func(*ApiKey) error
+	CreateCallCount         int
+	DeleteFn                func(userId uuid.UUID, id uuid.UUID) error
+	DeleteCallCount         int
+	UpdateLastUsedFn        func(keyHash []byte) error
+	UpdateLastUsedCallCount int
 }
 
 func (r *FakeApiKeyRepository) GetByUserId(userId uuid.UUID) ([]ApiKey, error) {
 	return r.GetByUserIdFn(userId)
 }
 
-func (r *FakeApiKeyRepository) Create(userId uuid.UUID, keyHash []byte) error {
+func (r *FakeApiKeyRepository) Create(apiKey *ApiKey) error {
 	r.CreateCallCount++
-	return r.CreateFn(userId, keyHash)
+	return r.CreateFn(apiKey)
+}
+
+func (r *FakeApiKeyRepository) Delete(userId uuid.UUID, id uuid.UUID) error {
+	r.DeleteCallCount++
+	return r.DeleteFn(userId, id)
+}
+
+func (r *FakeApiKeyRepository) UpdateLastUsed(keyHash []byte) error {
+	r.UpdateLastUsedCallCount++
+	return r.UpdateLastUsedFn(keyHash)
 }
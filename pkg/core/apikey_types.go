@@ -1,12 +1,22 @@
 package core
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 const (
 	LoginTypeAPIKey = "APIKey"
 )
 
 type ApiKey struct {
-	UserId  uuid.UUID `json:"userId"`
-	KeyHash []byte    `json:"keyHash"`
+	Id       uuid.UUID  `json:"id"`
+	UserId   uuid.UUID  `json:"userId"`
+	Name     string     `json:"name"`
+	KeyHash  []byte     `json:"keyHash"`
+	Created  time.Time  `json:"created"`
+	LastUsed *time.Time `json:"lastUsed"`
+	// Expires is optional. The key may not be used to login after it expires.
+	Expires *time.Time `json:"expires"`
 }
@@ -0,0 +1,22 @@
+package core
+
+type AuditRepository interface {
+	Create(entry *AuditEntry) error
+	// Find returns entries matching the filter, newest first
+	Find(filter *AuditFilter) ([]AuditEntry, error)
+}
+
+type FakeAuditRepository struct {
+	CreateFn        func(entry *AuditEntry) error
+	CreateCallCount int
+	FindFn          func(filter *AuditFilter) ([]AuditEntry, error)
+}
+
+func (fake *FakeAuditRepository) Create(entry *AuditEntry) error {
+	fake.CreateCallCount++
+	return fake.CreateFn(entry)
+}
+
+func (fake *FakeAuditRepository) Find(filter *AuditFilter) ([]AuditEntry, error) {
+	return fake.FindFn(filter)
+}
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditEntry records a mutating API request
+type AuditEntry struct {
+	Id       uuid.UUID
+	Created  time.Time
+	Username string
+	// Action describes what was requested (e.g. "deployment.save")
+	Action      string
+	Namespace   string
+	Environment string
+	// ResourceName is the name of the app, deployment, or other resource acted on. Empty when not applicable.
+	ResourceName string
+	// AppName is the app that the resource belongs to. Empty when not applicable.
+	AppName    string
+	StatusCode int
+	Doc        AuditDoc
+}
+
+type AuditDoc struct {
+	Method string `json:"method"`
+	// Path is the route path (e.g. /api/v1/deployments/:envName/:namespace/:deploymentName)
+	Path string `json:"path"`
+	// CommitShas are the state repo commits made by the request
+	CommitShas []string `json:"commitShas,omitempty"`
+	DryRun     bool     `json:"dryRun,omitempty"`
+}
+
+// AuditFilter filters audit entries. Empty fields are not filtered.
+type AuditFilter struct {
+	Username    string
+	Namespace   string
+	Environment string
+	AppName     string
+	From        *time.Time
+	To          *time.Time
+	Limit       int
+}
+
+// Needed for sql.Scanner interface
+func (a *AuditDoc) Value() (driver.Value, error) {
+	return json.Marshal(a)
+}
+
+// Needed for sql.Scanner interface
+func (a *AuditDoc) Scan(value interface{}) error {
+	return jsonbSqlUnmarshal(value, &a)
+}
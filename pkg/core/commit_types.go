@@ -0,0 +1,12 @@
+package core
+
+// CommitMeta describes who requested a change to the state repo and what it applies to. It is recorded as the commit author and trailers.
+type CommitMeta struct {
+	// Username is the user that requested the change. Empty for changes made by riser itself (e.g. automated rollouts).
+	Username      string
+	App           string
+	Namespace     string
+	Deployment    string
+	Environment   string
+	RiserRevision int64
+}
@@ -8,28 +8,36 @@ type DeploymentRepository interface {
 	GetByReservation(reservationId uuid.UUID, envName string) (*Deployment, error)
 	GetByName(name *NamespacedName, envName string) (*Deployment, error)
 	FindByApp(appId uuid.UUID) ([]Deployment, error)
+	FindByEnvironment(envName string) ([]Deployment, error)
 	UpdateStatus(name *NamespacedName, envName string, status *DeploymentStatus) error
 	UpdateTraffic(name *NamespacedName, envName string, riserRevision int64, traffic TrafficConfig) error
+	// UpdateRenderedConfig records the config that a riser revision was rendered from
+	UpdateRenderedConfig(name *NamespacedName, envName string, riserRevision int64, config *DeploymentConfig) error
 	IncrementRevision(name *NamespacedName, envName string) (int64, error)
 	RollbackRevision(name *NamespacedName, envName string, failedRevision int64) (int64, error)
 }
 
 type FakeDeploymentRepository struct {
-	CreateFn                   func(newDeployment *DeploymentRecord) error
-	CreateCallCount            int
-	DeleteFn                   func(name *NamespacedName, envName string) error
-	DeleteCallCount            int
-	GetByNameFn                func(name *NamespacedName, envName string) (*Deployment, error)
-	GetByReservationFn         func(reservationId uuid.UUID, envName string) (*Deployment, error)
-	GetByReservationCallCount  int
-	FindByAppFn                func(uuid.UUID) ([]Deployment, error)
-	IncrementRevisionFn        func(name *NamespacedName, envName string) (int64, error)
-	IncrementRevisionCallCount int
-	RollbackRevisionFn         func(name *NamespacedName, envName string, failedRevision int64) (int64, error)
-	UpdateStatusFn             func(name *NamespacedName, envName string, status *DeploymentStatus) error
-	UpdateStatusCallCount      int
-	UpdateTrafficFn            func(name *NamespacedName, envName string, riserRevision int64, traffic TrafficConfig) error
-	UpdateTrafficCallCount     int
+	CreateFn                      func(newDeployment *DeploymentRecord) error
+	CreateCallCount               int
+	DeleteFn                      func(name *NamespacedName, envName string) error
+	DeleteCallCount               int
+	GetByNameFn                   func(name *NamespacedName, envName string) (*Deployment, error)
+	GetByNameCallCount            int
+	GetByReservationFn            func(reservationId uuid.UUID, envName string) (*Deployment, error)
+	GetByReservationCallCount     int
+	FindByAppFn                   func(uuid.UUID) ([]Deployment, error)
+	FindByEnvironmentFn           func(envName string) ([]Deployment, error)
+	IncrementRevisionFn           func(name *NamespacedName, envName string) (int64, error)
+	IncrementRevisionCallCount    int
+	RollbackRevisionFn            func(name *NamespacedName, envName string, failedRevision int64) (int64, error)
+	RollbackRevisionCallCount     int
+	UpdateStatusFn                func(name *NamespacedName, envName string, status *DeploymentStatus) error
+	UpdateStatusCallCount         int
+	UpdateTrafficFn               func(name *NamespacedName, envName string, riserRevision int64, traffic TrafficConfig) error
+	UpdateTrafficCallCount        int
+	UpdateRenderedConfigFn        func(name *NamespacedName, envName string, riserRevision int64, config *DeploymentConfig) error
+	UpdateRenderedConfigCallCount int
 }
 
 func (f *FakeDeploymentRepository) Create(newDeployment *DeploymentRecord) error {
@@ -43,6 +51,7 @@ func (f *FakeDeploymentRepository) Delete(name *NamespacedName, envName string)
 }
 
 func (f *FakeDeploymentRepository) GetByName(name *NamespacedName, envName string) (*Deployment, error) {
+	f.GetByNameCallCount++
 	return f.GetByNameFn(name, envName)
 }
 
@@ -55,12 +64,17 @@ func (fake *FakeDeploymentRepository) FindByApp(appId uuid.UUID) ([]Deployment,
 	return fake.FindByAppFn(appId)
 }
 
+func (fake *FakeDeploymentRepository) FindByEnvironment(envName string) ([]Deployment, error) {
+	return fake.FindByEnvironmentFn(envName)
+}
+
 func (fake *FakeDeploymentRepository) IncrementRevision(name *NamespacedName, envName string) (int64, error) {
 	fake.IncrementRevisionCallCount++
 	return fake.IncrementRevisionFn(name, envName)
 }
 
 func (fake *FakeDeploymentRepository) RollbackRevision(name *NamespacedName, envName string, failedRevision int64) (int64, error) {
+	fake.RollbackRevisionCallCount++
 	return fake.RollbackRevisionFn(name, envName, failedRevision)
 }
 
@@ -73,3 +87,8 @@ func (fake *FakeDeploymentRepository) UpdateTraffic(name *NamespacedName, envNam
 	fake.UpdateTrafficCallCount++
 	return fake.UpdateTrafficFn(name, envName, riserRevision, traffic)
 }
+
+func (fake *FakeDeploymentRepository) UpdateRenderedConfig(name *NamespacedName, envName string, riserRevision int64, config *DeploymentConfig) error {
+	fake.UpdateRenderedConfigCallCount++
+	return fake.UpdateRenderedConfigFn(name, envName, riserRevision, config)
+}
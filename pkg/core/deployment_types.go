@@ -26,15 +26,31 @@ type DeploymentRecord struct {
 	Doc           DeploymentDoc
 }
 
+// DeploymentConfig is serialized as the last rendered config of a deployment (see DeploymentDoc)
 type DeploymentConfig struct {
-	Name            string
-	Namespace       string
-	EnvironmentName string
-	Docker          DeploymentDocker
+	Name            string           `json:"name"`
+	Namespace       string           `json:"namespace"`
+	EnvironmentName string           `json:"environmentName"`
+	Docker          DeploymentDocker `json:"docker"`
 	// TODO: Move to core and remove api/v1/model dependency
-	App           *model.AppConfig
-	Traffic       TrafficConfig
-	ManualRollout bool
+	App *model.AppConfig `json:"app"`
+	// AppWithOverrides is the app config before environment overrides are applied. It is used to promote a deployment to another environment.
+	AppWithOverrides *model.AppConfigWithOverrides `json:"appWithOverrides,omitempty"`
+	Traffic          TrafficConfig                 `json:"traffic"`
+	ManualRollout    bool                          `json:"manualRollout"`
+	// Rollout is the strategy for progressively shifting traffic to the new revision. Nil when traffic is not progressively shifted.
+	Rollout *RolloutStrategy `json:"rollout,omitempty"`
+	// DeployedBy is the username of the user that requested the deployment
+	DeployedBy string `json:"deployedBy"`
+}
+
+// DeploymentPromotion represents a request to deploy the current revision of a deployment to another environment
+type DeploymentPromotion struct {
+	Name                  *NamespacedName
+	SourceEnvironmentName string
+	TargetEnvironmentName string
+	ManualRollout         bool
+	DeployedBy            string
 }
 
 type DeploymentDocker struct {
@@ -50,9 +66,29 @@ type TrafficConfigRule struct {
 	Percent       int    `json:"percent"`
 }
 
+// RetiredRevisions returns the riser revisions in the previous traffic config that are not in the traffic config
+func (a TrafficConfig) RetiredRevisions(previous TrafficConfig) []int64 {
+	seen := map[int64]bool{}
+	for _, rule := range a {
+		seen[rule.RiserRevision] = true
+	}
+
+	retired := []int64{}
+	for _, rule := range previous {
+		if !seen[rule.RiserRevision] {
+			seen[rule.RiserRevision] = true
+			retired = append(retired, rule.RiserRevision)
+		}
+	}
+	return retired
+}
+
 type DeploymentDoc struct {
 	Status  *DeploymentStatus   `json:"status,omitempty"`
 	Traffic []TrafficConfigRule `json:"traffic"`
+	// LastRenderedConfig is the config that the current riser revision was rendered from. It is used to regenerate the state of an
+	// environment. Traffic is not kept up-to-date (use Traffic instead). Nil for deployments rendered before this was recorded.
+	LastRenderedConfig *DeploymentConfig `json:"lastRenderedConfig,omitempty"`
 }
 
 type DeploymentStatus struct {
@@ -84,6 +120,9 @@ type DeploymentContext struct {
 	RiserRevision     int64
 	Secrets           []SecretMeta
 	ManualRollout     bool
+	// RetiredRevisions are the riser revisions that stopped receiving traffic. Renderers that render a separate set of resources for
+	// each revision delete the resources of retired revisions.
+	RetiredRevisions []int64
 }
 
 // Needed for sql.Scanner interface
@@ -101,6 +140,11 @@ func (a *DeploymentStatus) Value() (driver.Value, error) {
 	return json.Marshal(a)
 }
 
+// Needed for sql.Scanner interface. Normally this is only needed on the "Doc" object but we need this here since we do last rendered config only updates.
+func (a *DeploymentConfig) Value() (driver.Value, error) {
+	return json.Marshal(a)
+}
+
 // Needed for sql.Scanner interface. Normally this is only needed on the "Doc" object but we need this here since we do traffic only updates.
 func (a TrafficConfig) Value() (driver.Value, error) {
 	return json.Marshal(a)
@@ -0,0 +1,15 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TrafficConfig_RetiredRevisions(t *testing.T) {
+	traffic := TrafficConfig{{RiserRevision: 3, Percent: 0}, {RiserRevision: 1, Percent: 100}}
+	previous := TrafficConfig{{RiserRevision: 2, Percent: 0}, {RiserRevision: 1, Percent: 100}, {RiserRevision: 2, Percent: 0}}
+
+	assert.Equal(t, []int64{2}, traffic.RetiredRevisions(previous))
+	assert.Empty(t, previous.RetiredRevisions(nil))
+}
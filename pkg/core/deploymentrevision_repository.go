@@ -0,0 +1,32 @@
+package core
+
+type DeploymentRevisionRepository interface {
+	// Save saves the revision for a deployment. Saving an existing riser revision overwrites it.
+	Save(name *NamespacedName, envName string, revision *DeploymentRevision) error
+	GetByRevision(name *NamespacedName, envName string, riserRevision int64) (*DeploymentRevision, error)
+	ListByDeployment(name *NamespacedName, envName string) ([]DeploymentRevision, error)
+}
+
+type FakeDeploymentRevisionRepository struct {
+	SaveFn                    func(name *NamespacedName, envName string, revision *DeploymentRevision) error
+	SaveCallCount             int
+	GetByRevisionFn           func(name *NamespacedName, envName string, riserRevision int64) (*DeploymentRevision, error)
+	GetByRevisionCallCount    int
+	ListByDeploymentFn        func(name *NamespacedName, envName string) ([]DeploymentRevision, error)
+	ListByDeploymentCallCount int
+}
+
+func (fake *FakeDeploymentRevisionRepository) Save(name *NamespacedName, envName string, revision *DeploymentRevision) error {
+	fake.SaveCallCount++
+	return fake.SaveFn(name, envName, revision)
+}
+
+func (fake *FakeDeploymentRevisionRepository) ListByDeployment(name *NamespacedName, envName string) ([]DeploymentRevision, error) {
+	fake.ListByDeploymentCallCount++
+	return fake.ListByDeploymentFn(name, envName)
+}
+
+func (fake *FakeDeploymentRevisionRepository) GetByRevision(name *NamespacedName, envName string, riserRevision int64) (*DeploymentRevision, error) {
+	fake.GetByRevisionCallCount++
+	return fake.GetByRevisionFn(name, envName, riserRevision)
+}
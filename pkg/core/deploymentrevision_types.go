@@ -0,0 +1,45 @@
+package core
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/riser-platform/riser-server/api/v1/model"
+)
+
+// DeploymentRevision represents the configuration that was deployed for a given riser revision
+type DeploymentRevision struct {
+	Id            uuid.UUID
+	RiserRevision int64
+	Doc           DeploymentRevisionDoc
+}
+
+type DeploymentRevisionDoc struct {
+	Docker DeploymentDocker `json:"docker"`
+	// App is the app config with environment overrides applied
+	App *model.AppConfig `json:"app"`
+	// AppWithOverrides is the app config before environment overrides were applied
+	AppWithOverrides *model.AppConfigWithOverrides `json:"appWithOverrides,omitempty"`
+	Traffic          TrafficConfig                 `json:"traffic"`
+	DeployedBy       string                        `json:"deployedBy"`
+	Created          time.Time                     `json:"created"`
+	// Secrets are the secret revisions that the revision references. Nil for revisions deployed before this was recorded.
+	Secrets []DeploymentRevisionSecret `json:"secrets"`
+}
+
+type DeploymentRevisionSecret struct {
+	Name     string `json:"name"`
+	Revision int64  `json:"revision"`
+}
+
+// Needed for sql.Scanner interface
+func (a *DeploymentRevisionDoc) Value() (driver.Value, error) {
+	return json.Marshal(a)
+}
+
+// Needed for sql.Scanner interface
+func (a *DeploymentRevisionDoc) Scan(value interface{}) error {
+	return jsonbSqlUnmarshal(value, &a)
+}
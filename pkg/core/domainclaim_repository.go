@@ -0,0 +1,34 @@
+package core
+
+type DomainClaimRepository interface {
+	// FindByDomain returns the claims for a domain across all environments
+	FindByDomain(domain string) ([]DomainClaim, error)
+	// Create claims a domain. Returns ErrDomainClaimed if the domain is claimed by another app in any environment, or by another
+	// deployment in the claim's environment. Creating a claim that already exists is a no-op.
+	Create(claim *DomainClaim) error
+	// ReleaseByDeployment releases the deployment's claims in an environment except for the domains in keepDomains. A domain no longer
+	// belongs to its app once all of its claims are released.
+	ReleaseByDeployment(deploymentName *NamespacedName, envName string, keepDomains []string) error
+}
+
+type FakeDomainClaimRepository struct {
+	FindByDomainFn               func(domain string) ([]DomainClaim, error)
+	CreateFn                     func(claim *DomainClaim) error
+	CreateCallCount              int
+	ReleaseByDeploymentFn        func(deploymentName *NamespacedName, envName string, keepDomains []string) error
+	ReleaseByDeploymentCallCount int
+}
+
+func (f *FakeDomainClaimRepository) FindByDomain(domain string) ([]DomainClaim, error) {
+	return f.FindByDomainFn(domain)
+}
+
+func (f *FakeDomainClaimRepository) Create(claim *DomainClaim) error {
+	f.CreateCallCount++
+	return f.CreateFn(claim)
+}
+
+func (f *FakeDomainClaimRepository) ReleaseByDeployment(deploymentName *NamespacedName, envName string, keepDomains []string) error {
+	f.ReleaseByDeploymentCallCount++
+	return f.ReleaseByDeploymentFn(deploymentName, envName, keepDomains)
+}
@@ -0,0 +1,14 @@
+package core
+
+import (
+	"github.com/google/uuid"
+)
+
+// DomainClaim represents a custom domain that routes to a deployment in an environment
+type DomainClaim struct {
+	Domain          string
+	AppId           uuid.UUID
+	EnvironmentName string
+	DeploymentName  string
+	Namespace       string
+}
@@ -0,0 +1,25 @@
+package core
+
+type DriftReportRepository interface {
+	// Save saves the report for an environment, replacing any previous report for the environment
+	Save(report *DriftReport) error
+	// Get returns the latest report for an environment
+	Get(envName string) (*DriftReport, error)
+}
+
+type FakeDriftReportRepository struct {
+	SaveFn        func(report *DriftReport) error
+	SaveCallCount int
+	GetFn         func(envName string) (*DriftReport, error)
+	GetCallCount  int
+}
+
+func (fake *FakeDriftReportRepository) Save(report *DriftReport) error {
+	fake.SaveCallCount++
+	return fake.SaveFn(report)
+}
+
+func (fake *FakeDriftReportRepository) Get(envName string) (*DriftReport, error) {
+	fake.GetCallCount++
+	return fake.GetFn(envName)
+}
@@ -0,0 +1,52 @@
+package core
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// DriftReport describes the differences between the state that riser expects in an environment and the state repo
+type DriftReport struct {
+	EnvironmentName string
+	Created         time.Time
+	Doc             DriftReportDoc
+}
+
+type DriftReportDoc struct {
+	// Missing are files that riser expects but are not in the state repo
+	Missing []string `json:"missing"`
+	// Unexpected are files in a riser managed folder of the state repo that riser does not expect (e.g. orphaned files)
+	Unexpected []string `json:"unexpected"`
+	// Changed are files whose contents in the state repo differ from what riser expects
+	Changed []DriftedFile `json:"changed"`
+	// SkippedDeployments and SkippedSecrets could not be rendered (see ReconcileResult) and are excluded from the report
+	SkippedDeployments []NamespacedName     `json:"skippedDeployments"`
+	SkippedSecrets     []DriftSkippedSecret `json:"skippedSecrets"`
+}
+
+type DriftSkippedSecret struct {
+	App  NamespacedName `json:"app"`
+	Name string         `json:"name"`
+}
+
+type DriftedFile struct {
+	Name string `json:"name"`
+	// Diff is a unified diff from the contents of the state repo to the expected contents
+	Diff string `json:"diff"`
+}
+
+// HasDrift returns true when the state repo differs from the expected state
+func (a *DriftReportDoc) HasDrift() bool {
+	return len(a.Missing) > 0 || len(a.Unexpected) > 0 || len(a.Changed) > 0
+}
+
+// Needed for sql.Scanner interface
+func (a *DriftReportDoc) Value() (driver.Value, error) {
+	return json.Marshal(a)
+}
+
+// Needed for sql.Scanner interface
+func (a *DriftReportDoc) Scan(value interface{}) error {
+	return jsonbSqlUnmarshal(value, &a)
+}
@@ -19,6 +19,46 @@ type EnvironmentDoc struct {
 type EnvironmentConfig struct {
 	SealedSecretCert  []byte `json:"sealedSecretCert"`
 	PublicGatewayHost string `json:"publicGatewayHost"`
+	// StateRepo overrides the server's state backend for this environment. Nil uses the server's state backend.
+	StateRepo *EnvironmentStateRepo `json:"stateRepo,omitempty"`
+	// Renderer is one of the model.EnvironmentRenderer values. Empty uses the KNative renderer.
+	Renderer string `json:"renderer,omitempty"`
+	// Kustomize maintains a kustomization.yaml in the riser managed folders of the state repo so that the state can be consumed by
+	// Kustomize. Nil is the same as false.
+	Kustomize *bool `json:"kustomize,omitempty"`
+	// Resources are the defaults for apps that do not specify resources and the maximum resources that an app may use
+	Resources *EnvironmentResources `json:"resources,omitempty"`
+	// CertificateIssuer is the name of the cert-manager ClusterIssuer that issues certificates for app domains. Apps may not use
+	// custom domains when empty.
+	CertificateIssuer string `json:"certificateIssuer,omitempty"`
+}
+
+// KustomizeEnabled returns true when the environment's kustomization files are maintained (see Kustomize)
+func (config *EnvironmentConfig) KustomizeEnabled() bool {
+	return config.Kustomize != nil && *config.Kustomize
+}
+
+type EnvironmentResources struct {
+	DefaultRequests *EnvironmentResourceQuantities `json:"defaultRequests,omitempty"`
+	DefaultLimits   *EnvironmentResourceQuantities `json:"defaultLimits,omitempty"`
+	// MaxLimits are enforced when deploying. An app without a limit uses the maximum as its limit.
+	MaxLimits *EnvironmentResourceQuantities `json:"maxLimits,omitempty"`
+}
+
+type EnvironmentResourceQuantities struct {
+	CpuCores           *float32 `json:"cpuCores,omitempty"`
+	MemoryMB           *int32   `json:"memoryMB,omitempty"`
+	EphemeralStorageMB *int32   `json:"ephemeralStorageMB,omitempty"`
+}
+
+// EnvironmentStateRepo is a git repo that stores the state for a single environment
+type EnvironmentStateRepo struct {
+	URL string `json:"url"`
+	// Branch defaults to the environment name
+	Branch string `json:"branch,omitempty"`
+	// CredentialsRef is the name of credentials that are provided to the server (see RuntimeConfig.GitCredentialsDir). Credentials
+	// are never stored in the database.
+	CredentialsRef string `json:"credentialsRef,omitempty"`
 }
 
 // Needed for sql.Scanner interface
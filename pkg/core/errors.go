@@ -2,12 +2,14 @@ package core
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 )
 
 var ErrNotFound = errors.New("the object could not be found")
 var ErrConflictNewerVersion = errors.New("a newer version of the object exists")
+var ErrDomainClaimed = errors.New("the domain is already claimed")
 
 // ValidationError provides an error consumable by a client. This is safe to return to the API as the errorHandler is aware of this error
 type ValidationError struct {
@@ -35,3 +37,32 @@ func (e *ValidationError) Error() string {
 
 	return e.Message
 }
+
+// AuthorizationError indicates that a user does not have permission to perform an action. This is safe to return to the API as the
+// errorHandler is aware of this error
+type AuthorizationError struct {
+	Permission string
+	Scope      AuthorizationScope
+}
+
+func (e *AuthorizationError) Error() string {
+	message := fmt.Sprintf("You do not have the %q permission", e.Permission)
+	if e.Scope.Namespace != "" {
+		message = fmt.Sprintf("%s in namespace %q", message, e.Scope.Namespace)
+	}
+	if e.Scope.Environment != "" {
+		message = fmt.Sprintf("%s in environment %q", message, e.Scope.Environment)
+	}
+	return message
+}
+
+// RetryableError indicates a temporary condition where the request may be retried. This is safe to return to the API as the
+// errorHandler is aware of this error
+type RetryableError struct {
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *RetryableError) Error() string {
+	return e.Message
+}
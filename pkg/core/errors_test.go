@@ -17,3 +17,20 @@ func Test_NewValidationError(t *testing.T) {
 	assert.Equal(t, "msg", validationError.Message)
 	assert.Equal(t, err, validationError.ValidationError)
 }
+
+func Test_AuthorizationError_Error(t *testing.T) {
+	tests := []struct {
+		scope    AuthorizationScope
+		expected string
+	}{
+		{AuthorizationScope{}, `You do not have the "deploy" permission`},
+		{AuthorizationScope{Namespace: "myns"}, `You do not have the "deploy" permission in namespace "myns"`},
+		{AuthorizationScope{Environment: "prod"}, `You do not have the "deploy" permission in environment "prod"`},
+		{AuthorizationScope{Namespace: "myns", Environment: "prod"}, `You do not have the "deploy" permission in namespace "myns" in environment "prod"`},
+	}
+
+	for _, test := range tests {
+		err := &AuthorizationError{Permission: "deploy", Scope: test.scope}
+		assert.Equal(t, test.expected, err.Error())
+	}
+}
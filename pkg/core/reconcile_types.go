@@ -0,0 +1,22 @@
+package core
+
+// ReconcileResult is the outcome of regenerating the state of an environment
+type ReconcileResult struct {
+	// SkippedDeployments were rendered before their config was recorded and must be redeployed
+	SkippedDeployments []NamespacedName
+	// SkippedSecrets were committed before their ciphertext was recorded and must be saved again
+	SkippedSecrets []SecretMeta
+	// NoChanges is true when the state was already up-to-date
+	NoChanges bool
+}
+
+// GarbageCollectionResult is the outcome of removing the files in an environment that riser no longer references
+type GarbageCollectionResult struct {
+	// Deleted are the files that were removed from the state repo
+	Deleted []string
+	// SkippedNamespaces contain revisions that were deployed before their secret revisions were recorded. Their sealed secrets are
+	// not collected until those revisions are no longer live.
+	SkippedNamespaces []string
+	// NoChanges is true when there was nothing to collect
+	NoChanges bool
+}
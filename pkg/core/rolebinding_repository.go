@@ -0,0 +1,41 @@
+package core
+
+import "github.com/google/uuid"
+
+type RoleBindingRepository interface {
+	// Create creates the role binding. Creating a role binding that already exists is a NOOP.
+	Create(binding *RoleBinding) error
+	Delete(id uuid.UUID) error
+	// FindByUser returns the role bindings of the user and of the user's groups
+	FindByUser(userId uuid.UUID, groups []string) ([]RoleBinding, error)
+	List() ([]RoleBinding, error)
+}
+
+type FakeRoleBindingRepository struct {
+	CreateFn            func(binding *RoleBinding) error
+	CreateCallCount     int
+	DeleteFn            func(id uuid.UUID) error
+	DeleteCallCount     int
+	FindByUserFn        func(userId uuid.UUID, groups []string) ([]RoleBinding, error)
+	FindByUserCallCount int
+	ListFn              func() ([]RoleBinding, error)
+}
+
+func (fake *FakeRoleBindingRepository) Create(binding *RoleBinding) error {
+	fake.CreateCallCount++
+	return fake.CreateFn(binding)
+}
+
+func (fake *FakeRoleBindingRepository) Delete(id uuid.UUID) error {
+	fake.DeleteCallCount++
+	return fake.DeleteFn(id)
+}
+
+func (fake *FakeRoleBindingRepository) FindByUser(userId uuid.UUID, groups []string) ([]RoleBinding, error) {
+	fake.FindByUserCallCount++
+	return fake.FindByUserFn(userId, groups)
+}
+
+func (fake *FakeRoleBindingRepository) List() ([]RoleBinding, error) {
+	return fake.ListFn()
+}
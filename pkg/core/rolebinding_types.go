@@ -0,0 +1,25 @@
+package core
+
+import "github.com/google/uuid"
+
+// RoleBinding grants a role to a user or to the members of a group. The role applies to all namespaces and/or environments when
+// either is empty.
+type RoleBinding struct {
+	Id uuid.UUID
+	// UserId is empty when the role is granted to a group
+	UserId uuid.UUID
+	// Username is populated when reading role bindings
+	Username string
+	// Group is empty when the role is granted to a user
+	Group       string
+	Role        string
+	Namespace   string
+	Environment string
+}
+
+// AuthorizationScope is the namespace and environment that an action is performed in. An empty namespace or environment
+// means that the action is not specific to a namespace or environment and therefore requires a role binding that applies to all.
+type AuthorizationScope struct {
+	Namespace   string
+	Environment string
+}
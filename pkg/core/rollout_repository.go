@@ -0,0 +1,37 @@
+package core
+
+import "time"
+
+type RolloutRepository interface {
+	// Save saves the rollout for a deployment, replacing any previous rollout for the deployment
+	Save(rollout *Rollout) error
+	// UpdateProgress updates the status and progress of a rollout. Returns ErrConflictNewerVersion if the rollout was replaced.
+	UpdateProgress(rollout *Rollout) error
+	// LeaseInProgress leases and returns all rollouts with a status of RolloutStatusInProgress that are not leased by another holder.
+	// The lease of a rollout already held by the holder is renewed.
+	LeaseInProgress(holder string, duration time.Duration) ([]Rollout, error)
+}
+
+type FakeRolloutRepository struct {
+	SaveFn                   func(rollout *Rollout) error
+	SaveCallCount            int
+	UpdateProgressFn         func(rollout *Rollout) error
+	UpdateProgressCallCount  int
+	LeaseInProgressFn        func(holder string, duration time.Duration) ([]Rollout, error)
+	LeaseInProgressCallCount int
+}
+
+func (fake *FakeRolloutRepository) Save(rollout *Rollout) error {
+	fake.SaveCallCount++
+	return fake.SaveFn(rollout)
+}
+
+func (fake *FakeRolloutRepository) UpdateProgress(rollout *Rollout) error {
+	fake.UpdateProgressCallCount++
+	return fake.UpdateProgressFn(rollout)
+}
+
+func (fake *FakeRolloutRepository) LeaseInProgress(holder string, duration time.Duration) ([]Rollout, error) {
+	fake.LeaseInProgressCallCount++
+	return fake.LeaseInProgressFn(holder, duration)
+}
@@ -0,0 +1,49 @@
+package core
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+const (
+	RolloutStatusInProgress = "InProgress"
+	RolloutStatusCompleted  = "Completed"
+	RolloutStatusAborted    = "Aborted"
+)
+
+// Rollout represents the progress of an automated rollout of a riser revision
+type Rollout struct {
+	Name            *NamespacedName
+	EnvironmentName string
+	RiserRevision   int64
+	Status          string
+	// NextStepAt is the earliest time that the rollout may advance to the next step
+	NextStepAt time.Time
+	Doc        RolloutDoc
+}
+
+type RolloutDoc struct {
+	Strategy RolloutStrategy `json:"strategy"`
+	// PreviousTraffic is the traffic prior to the rollout. Traffic is restored to this when the rollout is aborted.
+	PreviousTraffic TrafficConfig `json:"previousTraffic"`
+	// CurrentStep is the index of the last step applied. -1 indicates that no step has been applied.
+	CurrentStep int `json:"currentStep"`
+	// Reason describes why the rollout was aborted
+	Reason string `json:"reason,omitempty"`
+}
+
+type RolloutStrategy struct {
+	Steps []int         `json:"steps"`
+	Pause time.Duration `json:"pause"`
+}
+
+// Needed for sql.Scanner interface
+func (a *RolloutDoc) Value() (driver.Value, error) {
+	return json.Marshal(a)
+}
+
+// Needed for sql.Scanner interface
+func (a *RolloutDoc) Scan(value interface{}) error {
+	return jsonbSqlUnmarshal(value, &a)
+}
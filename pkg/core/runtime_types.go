@@ -1,16 +1,61 @@
 package core
 
+import "time"
+
 // RuntimeConfig provides config for the server.
 type RuntimeConfig struct {
 	BootstrapApikey string `split_words:"true"`
 	BindAddress     string `split_words:"true" default:":8000"`
 	DeveloperMode   bool   `split_words:"true"`
-	GitUrl          string `split_words:"true" required:"true"`
+	// StateBackend is one of "branch-per-env", "dir-per-env", or "filesystem"
+	StateBackend string `split_words:"true" default:"branch-per-env"`
+	// StateDir is the root directory for the filesystem state backend
+	StateDir string `split_words:"true"`
+	// GitUrl is required for the git state backends
+	GitUrl string `split_words:"true"`
 	// GitDir is the temp directory to store the contents of the state repo. Warning: this directory is deleted on Riser server startup
-	GitDir                   string `split_words:"true" default:"/tmp/riser/git/"`
+	GitDir string `split_words:"true" default:"/tmp/riser/git/"`
+	// GitBranch is only used by the dir-per-env state backend. The branch-per-env backend uses a branch for each environment.
 	GitBranch                string `split_words:"true" default:"main"`
 	PostgresUrl              string `split_words:"true" default:"postgres://postgres.riser-system.svc.cluster.local/riserdb?sslmode=disable&connect_timeout=3"`
 	PostgresUsername         string `split_words:"true" required:"true"`
 	PostgresPassword         string `split_words:"true" required:"true"`
 	PostgresMigrateOnStartup bool   `split_words:"true" default:"true"`
+	// GitLockTimeout is how long a request waits for other changes to an environment's state before failing with a retryable error
+	GitLockTimeout time.Duration `split_words:"true" default:"10s"`
+	// GitTimeout is how long a single git operation (e.g. clone, fetch, or push) may take
+	GitTimeout time.Duration `split_words:"true" default:"30s"`
+	// GitBackend is either "exec" (the git CLI) or "native" (a pure Go implementation that does not require git or ssh)
+	GitBackend string `split_words:"true" default:"exec"`
+	// GitInMemory keeps the state repo in memory instead of GitDir. Only supported by the native backend.
+	GitInMemory bool `split_words:"true"`
+	// GitSshKeyPath is a private key for SSH auth. Only used by the native backend.
+	GitSshKeyPath string `split_words:"true"`
+	// GitUsername and GitPassword are for HTTPS auth. The password may be an access token. Only used by the native backend.
+	GitUsername string `split_words:"true"`
+	GitPassword string `split_words:"true"`
+	// GitCredentialsDir contains a folder for each credentialsRef used by an environment's state repo. Each folder contains either an
+	// "ssh-privatekey" file or "username" and "password" files (i.e. a mounted kubernetes.io/ssh-auth or kubernetes.io/basic-auth secret).
+	GitCredentialsDir string `split_words:"true" default:"/etc/riser/git-credentials"`
+	// RolloutInterval is how often automated rollouts are checked for status changes and advanced
+	RolloutInterval time.Duration `split_words:"true" default:"10s"`
+	// DriftInterval is how often every environment is checked for drift between the database and the state repo. Zero disables
+	// periodic drift detection.
+	DriftInterval time.Duration `split_words:"true" default:"10m"`
+	// GcInterval is how often files that riser no longer references are removed from the state of every environment. Zero disables
+	// periodic garbage collection.
+	GcInterval time.Duration `split_words:"true" default:"24h"`
+	// OidcIssuerUrl enables authentication with OIDC bearer tokens from this issuer. API key authentication is always enabled.
+	OidcIssuerUrl string `split_words:"true"`
+	OidcAudience  string `split_words:"true"`
+	// OidcJwksUrl is the url of the issuer's signing keys. Either this or OidcJwksFile is required when OIDC is enabled.
+	OidcJwksUrl string `split_words:"true"`
+	// OidcJwksFile is a local JWKS file for environments that cannot reach the issuer
+	OidcJwksFile string `split_words:"true"`
+	// OidcUsernameClaim is the claim used for the username of a user that logs in for the first time. It must be a valid riser
+	// username (e.g. not an email address).
+	OidcUsernameClaim string `split_words:"true" default:"preferred_username"`
+	OidcEmailClaim    string `split_words:"true" default:"email"`
+	// OidcGroupsClaim is the claim containing the user's groups. Role bindings may be granted to a group.
+	OidcGroupsClaim string `split_words:"true" default:"groups"`
 }
@@ -1,13 +1,15 @@
 package core
 
 type SecretMetaRepository interface {
-	// Commit commits a secretmeta at the specified revision. This is an acknowledgement that the secret has been committed to the underlying
-	// resource (e.g. the git state repo)
+	// Commit commits a secretmeta at the specified revision along with its ciphertext. This is an acknowledgement that the secret has been
+	// committed to the underlying resource (e.g. the git state repo)
 	Commit(secretMeta *SecretMeta) error
 	// Save saves the secret meta and returns a new revision. It is up to the caller to modify the secretMeta with the new revision.
 	// Important: You must call r.Commit to validate that the object has been committed. Uncommitted secrets are not applied to deployments
 	Save(secretMeta *SecretMeta) (revision int64, err error)
 	ListByAppInEnvironment(appName *NamespacedName, envName string) ([]SecretMeta, error)
+	// ListByEnvironment returns the committed secrets in an environment including their ciphertext
+	ListByEnvironment(envName string) ([]SecretMeta, error)
 }
 
 type FakeSecretMetaRepository struct {
@@ -16,6 +18,7 @@ type FakeSecretMetaRepository struct {
 	SaveFn                   func(*SecretMeta) (int64, error)
 	SaveCallCount            int
 	ListByAppInEnvironmentFn func(*NamespacedName, string) ([]SecretMeta, error)
+	ListByEnvironmentFn      func(envName string) ([]SecretMeta, error)
 }
 
 func (fake *FakeSecretMetaRepository) Save(secretMeta *SecretMeta) (int64, error) {
@@ -31,3 +34,7 @@ func (fake *FakeSecretMetaRepository) Commit(secretMeta *SecretMeta) error {
 	fake.CommitCallCount++
 	return fake.CommitFn(secretMeta)
 }
+
+func (fake *FakeSecretMetaRepository) ListByEnvironment(envName string) ([]SecretMeta, error) {
+	return fake.ListByEnvironmentFn(envName)
+}
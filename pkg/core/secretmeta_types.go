@@ -5,4 +5,14 @@ type SecretMeta struct {
 	App             *NamespacedName
 	EnvironmentName string
 	Revision        int64
+	// Ciphertext is the sealed secret data. It is only populated by SecretMetaRepository.ListByEnvironment and is nil for secrets
+	// committed before it was recorded.
+	Ciphertext []byte
+}
+
+// SecretReferences are the secret revisions referenced by the live revisions of the deployments in an environment
+type SecretReferences struct {
+	Secrets []SecretMeta
+	// UnknownNamespaces contain live revisions that were deployed before their secret revisions were recorded
+	UnknownNamespaces []string
 }
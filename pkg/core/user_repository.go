@@ -1,18 +1,35 @@
 package core
 
+import "github.com/google/uuid"
+
 type UserRepository interface {
+	// GetByApiKey returns the user for an API key. Disabled users and expired keys are not returned.
 	GetByApiKey(keyHash []byte) (*User, error)
 	GetByUsername(username string) (*User, error)
+	GetByOidcIdentity(identity *OidcIdentity) (*User, error)
 	Create(newUser *NewUser) error
+	UpdateEmail(userId uuid.UUID, email string) error
+	// SetOidcIdentity binds the user to an OIDC identity. Any existing identity is replaced.
+	SetOidcIdentity(userId uuid.UUID, identity *OidcIdentity) error
 	GetActiveCount() (int, error)
+	List() ([]User, error)
+	Disable(userId uuid.UUID) error
 }
 
 type FakeUserRepository struct {
-	GetByApiKeyFn    func(keyHash []byte) (*User, error)
-	GetByUsernameFn  func(username string) (*User, error)
-	CreateFn         func(newUser *NewUser) error
-	CreateCallCount  int
-	GetActiveCountFn func() (int, error)
+	GetByApiKeyFn            func(keyHash []byte) (*User, error)
+	GetByUsernameFn          func(username string) (*User, error)
+	GetByOidcIdentityFn      func(identity *OidcIdentity) (*User, error)
+	CreateFn                 func(newUser *NewUser) error
+	CreateCallCount          int
+	UpdateEmailFn            func(userId uuid.UUID, email string) error
+	UpdateEmailCallCount     int
+	SetOidcIdentityFn        func(userId uuid.UUID, identity *OidcIdentity) error
+	SetOidcIdentityCallCount int
+	GetActiveCountFn         func() (int, error)
+	ListFn                   func() ([]User, error)
+	DisableFn                func(userId uuid.UUID) error
+	DisableCallCount         int
 }
 
 func (r *FakeUserRepository) GetByApiKey(keyHash []byte) (*User, error) {
@@ -23,11 +40,34 @@ func (r *FakeUserRepository) GetByUsername(username string) (*User, error) {
 	return r.GetByUsernameFn(username)
 }
 
+func (r *FakeUserRepository) GetByOidcIdentity(identity *OidcIdentity) (*User, error) {
+	return r.GetByOidcIdentityFn(identity)
+}
+
 func (r *FakeUserRepository) Create(newUser *NewUser) error {
 	r.CreateCallCount++
 	return r.CreateFn(newUser)
 }
 
+func (r *FakeUserRepository) UpdateEmail(userId uuid.UUID, email string) error {
+	r.UpdateEmailCallCount++
+	return r.UpdateEmailFn(userId, email)
+}
+
+func (r *FakeUserRepository) SetOidcIdentity(userId uuid.UUID, identity *OidcIdentity) error {
+	r.SetOidcIdentityCallCount++
+	return r.SetOidcIdentityFn(userId, identity)
+}
+
 func (r *FakeUserRepository) GetActiveCount() (int, error) {
 	return r.GetActiveCountFn()
 }
+
+func (r *FakeUserRepository) List() ([]User, error) {
+	return r.ListFn()
+}
+
+func (r *FakeUserRepository) Disable(userId uuid.UUID) error {
+	r.DisableCallCount++
+	return r.DisableFn(userId)
+}
@@ -9,18 +9,33 @@ import (
 )
 
 type User struct {
-	Id       uuid.UUID
-	Username string
-	Doc      UserDoc
+	Id         uuid.UUID
+	Username   string
+	DisabledAt *time.Time
+	Doc        UserDoc
+	// Groups are the groups from the OIDC token that the user logged in with. Groups are not stored and are empty when the user
+	// logs in with an API key.
+	Groups []string
 }
 
 type UserDoc struct {
 	Created time.Time `json:"created"`
+	// Email is from the user's latest OIDC login
+	Email string `json:"email,omitempty"`
 }
 
 type NewUser struct {
 	Id       uuid.UUID
 	Username string
+	Email    string
+	// OidcIdentity is set for users that are provisioned by an OIDC login
+	OidcIdentity *OidcIdentity
+}
+
+// OidcIdentity identifies a user with the issuer and subject of their OIDC token
+type OidcIdentity struct {
+	Issuer  string
+	Subject string
 }
 
 // Needed for sql.Scanner interface
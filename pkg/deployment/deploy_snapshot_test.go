@@ -24,7 +24,134 @@ import (
 // Pass the UPDATESNAPSHOT=true env var to "go test" to regenerate the snapshot data
 
 func Test_update_snapshot_simple(t *testing.T) {
-	newDeployment := &core.DeploymentConfig{
+	newDeployment := newSnapshotDeploymentConfig()
+	secrets := []core.SecretMeta{{Name: "mysecret", Revision: 1}}
+
+	snapshotPath, err := filepath.Abs("testdata/snapshots/simple")
+	require.NoError(t, err)
+
+	committer, err := snapshot.CreateCommitter(snapshotPath)
+	require.NoError(t, err)
+
+	ctx := &core.DeploymentContext{
+		DeploymentConfig:  newDeployment,
+		EnvironmentConfig: &core.EnvironmentConfig{PublicGatewayHost: "dev.riser.org"},
+		RiserRevision:     3,
+		Secrets:           secrets,
+	}
+
+	err = deploy(ctx, committer)
+	assert.NoError(t, err)
+
+	if !snapshot.ShouldUpdate() {
+		dryRunCommitter := committer.(*state.DryRunCommitter)
+		snapshot.AssertCommitter(t, snapshotPath, dryRunCommitter)
+		assert.Equal(t, "Updating resources for \"myapp.apps\" in environment \"dev\"", dryRunCommitter.Commits[0].Message)
+		assert.Equal(t, &core.CommitMeta{App: "myapp", Namespace: "apps", Deployment: "myapp", Environment: "dev", RiserRevision: 3}, dryRunCommitter.Commits[0].Meta)
+	}
+}
+
+func Test_update_snapshot_kubernetes(t *testing.T) {
+	newDeployment := newSnapshotDeploymentConfig()
+	newDeployment.App.Expose.Scope = model.AppExposeScope_Cluster
+	newDeployment.App.Autoscale = &model.AppConfigAutoscale{
+		Min: util.PtrInt(1),
+		Max: util.PtrInt(3),
+	}
+	newDeployment.Traffic = core.TrafficConfig{
+		core.TrafficConfigRule{
+			RiserRevision: 3,
+			RevisionName:  "myapp-3",
+			Percent:       20,
+		},
+		core.TrafficConfigRule{
+			RiserRevision: 1,
+			RevisionName:  "myapp-1",
+			Percent:       80,
+		},
+	}
+	secrets := []core.SecretMeta{{Name: "mysecret", Revision: 1}}
+
+	snapshotPath, err := filepath.Abs("testdata/snapshots/kubernetes")
+	require.NoError(t, err)
+
+	committer, err := snapshot.CreateCommitter(snapshotPath)
+	require.NoError(t, err)
+
+	ctx := &core.DeploymentContext{
+		DeploymentConfig:  newDeployment,
+		EnvironmentConfig: &core.EnvironmentConfig{Renderer: model.EnvironmentRenderer_Kubernetes},
+		RiserRevision:     3,
+		Secrets:           secrets,
+	}
+
+	err = deploy(ctx, committer)
+	assert.NoError(t, err)
+
+	if !snapshot.ShouldUpdate() {
+		dryRunCommitter := committer.(*state.DryRunCommitter)
+		snapshot.AssertCommitter(t, snapshotPath, dryRunCommitter)
+	}
+}
+
+func Test_update_snapshot_domains(t *testing.T) {
+	newDeployment := newSnapshotDeploymentConfig()
+	newDeployment.App.Domains = []string{"myapp.example.com"}
+
+	snapshotPath, err := filepath.Abs("testdata/snapshots/domains")
+	require.NoError(t, err)
+
+	committer, err := snapshot.CreateCommitter(snapshotPath)
+	require.NoError(t, err)
+
+	ctx := &core.DeploymentContext{
+		DeploymentConfig:  newDeployment,
+		EnvironmentConfig: &core.EnvironmentConfig{PublicGatewayHost: "dev.riser.org", CertificateIssuer: "letsencrypt"},
+		RiserRevision:     3,
+	}
+
+	err = deploy(ctx, committer)
+	assert.NoError(t, err)
+
+	if !snapshot.ShouldUpdate() {
+		dryRunCommitter := committer.(*state.DryRunCommitter)
+		snapshot.AssertCommitter(t, snapshotPath, dryRunCommitter)
+	}
+}
+
+func Test_update_snapshot_access(t *testing.T) {
+	newDeployment := newSnapshotDeploymentConfig()
+	newDeployment.App.Expose.Scope = model.AppExposeScope_Cluster
+	newDeployment.App.Access = &model.AppConfigAccess{
+		From: []model.AppConfigAccessSource{
+			{App: "frontend"},
+			{Namespace: "monitoring", Paths: []string{"/metrics"}, Methods: []string{"GET"}},
+		},
+	}
+
+	snapshotPath, err := filepath.Abs("testdata/snapshots/access")
+	require.NoError(t, err)
+
+	committer, err := snapshot.CreateCommitter(snapshotPath)
+	require.NoError(t, err)
+
+	ctx := &core.DeploymentContext{
+		DeploymentConfig:  newDeployment,
+		EnvironmentConfig: &core.EnvironmentConfig{PublicGatewayHost: "dev.riser.org"},
+		RiserRevision:     3,
+	}
+
+	err = deploy(ctx, committer)
+	assert.NoError(t, err)
+
+	if !snapshot.ShouldUpdate() {
+		dryRunCommitter := committer.(*state.DryRunCommitter)
+		snapshot.AssertCommitter(t, snapshotPath, dryRunCommitter)
+	}
+}
+
+func newSnapshotDeploymentConfig() *core.DeploymentConfig {
+	return &core.DeploymentConfig{
 		Name:            "myapp",
 		Namespace:       "apps",
 		EnvironmentName: "dev",
@@ -61,28 +188,4 @@ func Test_update_snapshot_simple(t *testing.T) {
 			},
 		},
 	}
-
-	secrets := []core.SecretMeta{{Name: "mysecret", Revision: 1}}
-
-	snapshotPath, err := filepath.Abs("testdata/snapshots/simple")
-	require.NoError(t, err)
-
-	committer, err := snapshot.CreateCommitter(snapshotPath)
-	require.NoError(t, err)
-
-	ctx := &core.DeploymentContext{
-		DeploymentConfig:  newDeployment,
-		EnvironmentConfig: &core.EnvironmentConfig{PublicGatewayHost: "dev.riser.org"},
-		RiserRevision:     3,
-		Secrets:           secrets,
-	}
-
-	err = deploy(ctx, committer)
-	assert.NoError(t, err)
-
-	if !snapshot.ShouldUpdate() {
-		dryRunCommitter := committer.(*state.DryRunCommitter)
-		snapshot.AssertCommitter(t, snapshotPath, dryRunCommitter)
-		assert.Equal(t, "Updating resources for \"myapp.apps\" in environment \"dev\"", dryRunCommitter.Commits[0].Message)
-	}
 }
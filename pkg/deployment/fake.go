@@ -6,15 +6,43 @@ import (
 )
 
 type FakeService struct {
-	DeleteFn        func(name *core.NamespacedName, envName string, committer state.Committer) error
-	DeleteCallCount int
+	DeleteFn                      func(name *core.NamespacedName, envName string, deletedBy string, committer state.Committer) error
+	DeleteCallCount               int
+	RollbackFn                    func(name *core.NamespacedName, envName string, riserRevision int64, deployedBy string, committer state.Committer, dryRun bool) (int64, error)
+	RollbackCallCount             int
+	PromoteFn                     func(promotion *core.DeploymentPromotion, committer state.Committer, dryRun bool) (int64, error)
+	PromoteCallCount              int
+	RenderEnvironmentFn           func(envName string) ([]core.ResourceFile, []core.NamespacedName, error)
+	RenderEnvironmentCallCount    int
+	FindSecretReferencesFn        func(envName string) (*core.SecretReferences, error)
+	FindSecretReferencesCallCount int
 }
 
 func (f *FakeService) Update(deployment *core.DeploymentConfig, committer state.Committer, dryRun bool) (int64, error) {
 	panic("NI!")
 }
 
-func (f *FakeService) Delete(name *core.NamespacedName, envName string, committer state.Committer) error {
+func (f *FakeService) Delete(name *core.NamespacedName, envName string, deletedBy string, committer state.Committer) error {
 	f.DeleteCallCount++
-	return f.DeleteFn(name, envName, committer)
+	return f.DeleteFn(name, envName, deletedBy, committer)
+}
+
+func (f *FakeService) Rollback(name *core.NamespacedName, envName string, riserRevision int64, deployedBy string, committer state.Committer, dryRun bool) (int64, error) {
+	f.RollbackCallCount++
+	return f.RollbackFn(name, envName, riserRevision, deployedBy, committer, dryRun)
+}
+
+func (f *FakeService) Promote(promotion *core.DeploymentPromotion, committer state.Committer, dryRun bool) (int64, error) {
+	f.PromoteCallCount++
+	return f.PromoteFn(promotion, committer, dryRun)
+}
+
+func (f *FakeService) RenderEnvironment(envName string) ([]core.ResourceFile, []core.NamespacedName, error) {
+	f.RenderEnvironmentCallCount++
+	return f.RenderEnvironmentFn(envName)
+}
+
+func (f *FakeService) FindSecretReferences(envName string) (*core.SecretReferences, error) {
+	f.FindSecretReferencesCallCount++
+	return f.FindSecretReferencesFn(envName)
 }
@@ -3,12 +3,15 @@ package deployment
 import (
 	"fmt"
 	"regexp"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/riser-platform/riser-server/pkg/deploymentreservation"
+	"github.com/riser-platform/riser-server/pkg/domainclaim"
 	"github.com/riser-platform/riser-server/pkg/namespace"
 
 	validation "github.com/go-ozzo/ozzo-validation/v3"
+	"github.com/riser-platform/riser-server/api/v1/model"
 
 	"github.com/riser-platform/riser-server/pkg/core"
 
@@ -17,11 +20,23 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/riser-platform/riser-server/pkg/state"
+	"github.com/sirupsen/logrus"
 )
 
 type Service interface {
 	Update(deployment *core.DeploymentConfig, committer state.Committer, dryRun bool) (riserRevision int64, err error)
-	Delete(name *core.NamespacedName, envName string, committer state.Committer) error
+	Delete(name *core.NamespacedName, envName string, deletedBy string, committer state.Committer) error
+	// Rollback deploys the config of a previous riser revision as a new riser revision
+	Rollback(name *core.NamespacedName, envName string, riserRevision int64, deployedBy string, committer state.Committer, dryRun bool) (newRiserRevision int64, err error)
+	// Promote deploys the current revision of a deployment in one environment to another environment
+	Promote(promotion *core.DeploymentPromotion, committer state.Committer, dryRun bool) (riserRevision int64, err error)
+	// RenderEnvironment renders the current revision of every active deployment in an environment without committing. Renderers that
+	// render each revision separately also render the previous revisions that are in the traffic config. Deployments with a revision
+	// that was rendered before its config was recorded cannot be rendered and are returned.
+	RenderEnvironment(envName string) (files []core.ResourceFile, skipped []core.NamespacedName, err error)
+	// FindSecretReferences returns the secret revisions referenced by the live revisions of every active deployment in an
+	// environment. A revision is live when it is the current revision, receives traffic, or is reported by the environment.
+	FindSecretReferences(envName string) (*core.SecretReferences, error)
 }
 
 type service struct {
@@ -29,7 +44,11 @@ type service struct {
 	secrets            core.SecretMetaRepository
 	environments       core.EnvironmentRepository
 	deployments        core.DeploymentRepository
+	revisions          core.DeploymentRevisionRepository
+	rollouts           core.RolloutRepository
 	reservationService deploymentreservation.Service
+	domainClaimService domainclaim.Service
+	logger             logrus.FieldLogger
 }
 
 func NewService(
@@ -38,11 +57,15 @@ func NewService(
 	secrets core.SecretMetaRepository,
 	environments core.EnvironmentRepository,
 	deployments core.DeploymentRepository,
-	reservationService deploymentreservation.Service) Service {
-	return &service{namespaceService, secrets, environments, deployments, reservationService}
+	revisions core.DeploymentRevisionRepository,
+	rollouts core.RolloutRepository,
+	reservationService deploymentreservation.Service,
+	domainClaimService domainclaim.Service,
+	logger logrus.FieldLogger) Service {
+	return &service{namespaceService, secrets, environments, deployments, revisions, rollouts, reservationService, domainClaimService, logger}
 }
 
-func (s *service) Delete(name *core.NamespacedName, envName string, committer state.Committer) error {
+func (s *service) Delete(name *core.NamespacedName, envName string, deletedBy string, committer state.Committer) error {
 	// Deleting the deployment is safe to do before we perform the commit since it's a soft delete and therefore idempotent
 	err := s.deployments.Delete(name, envName)
 	if err != nil {
@@ -52,12 +75,22 @@ func (s *service) Delete(name *core.NamespacedName, envName string, committer st
 		return errors.Wrap(err, "error deleting deployment")
 	}
 
+	err = s.domainClaimService.ReleaseClaims(name, envName)
+	if err != nil {
+		return err
+	}
+
 	files := state.RenderDeleteDeployment(name.Name, name.Namespace)
-	return committer.Commit(fmt.Sprintf("Deleting deployment %q", name), files)
+	return committer.Commit(fmt.Sprintf("Deleting deployment %q", name), files, &core.CommitMeta{
+		Username:    deletedBy,
+		Namespace:   name.Namespace,
+		Deployment:  name.Name,
+		Environment: envName,
+	})
 }
 
 func (s *service) Update(deploymentConfig *core.DeploymentConfig, committer state.Committer, dryRun bool) (riserRevision int64, err error) {
-	riserRevision, err = s.prepareForDeployment(deploymentConfig, dryRun)
+	riserRevision, retiredRevisions, err := s.prepareForDeployment(deploymentConfig, dryRun)
 	if err != nil {
 		return 0, err
 	}
@@ -75,63 +108,473 @@ func (s *service) Update(deploymentConfig *core.DeploymentConfig, committer stat
 		EnvironmentConfig: &environment.Doc.Config,
 		RiserRevision:     riserRevision,
 		Secrets:           secrets,
+		RetiredRevisions:  retiredRevisions,
+	}
+	resourceFiles, err := renderForDeployment(ctx)
+	// The revision and rendered config are recorded before committing so that a committed revision is never missing from the
+	// revision history or rendered differently by a reconcile. Saving a revision is an upsert so a failed deployment is safe to retry.
+	if err == nil && !dryRun {
+		err = s.recordDeployment(deploymentConfig, riserRevision, secrets)
+	}
+	if err == nil {
+		err = commitDeployment(ctx, committer, resourceFiles)
 	}
-	err = deploy(ctx, committer)
 	if err != nil {
-		// TODO: Log rollback error but don't return since we want the original deployment error to flow to caller
-		_, _ = s.deployments.RollbackRevision(
-			core.NewNamespacedName(deploymentConfig.Name, deploymentConfig.Namespace), deploymentConfig.EnvironmentName, riserRevision)
+		// A dry run did not increment the revision
+		if !dryRun {
+			// TODO: Log rollback error but don't return since we want the original deployment error to flow to caller
+			_, _ = s.deployments.RollbackRevision(
+				core.NewNamespacedName(deploymentConfig.Name, deploymentConfig.Namespace), deploymentConfig.EnvironmentName, riserRevision)
+		}
 		return 0, err
 	}
 
+	if !dryRun {
+		// The revision has been committed so failures are logged rather than returned. Unused claims are released by the next
+		// deployment.
+		err = s.domainClaimService.ReleaseUnusedClaims(
+			core.NewNamespacedName(deploymentConfig.Name, deploymentConfig.Namespace), deploymentConfig.EnvironmentName, deploymentConfig.App.Domains)
+		if err != nil {
+			s.deploymentLogger(deploymentConfig, riserRevision).WithError(err).Error("Error releasing unused domain claims")
+		}
+
+		err = s.startRollout(deploymentConfig, riserRevision)
+		if err != nil {
+			s.deploymentLogger(deploymentConfig, riserRevision).WithError(err).Error("Error starting rollout")
+		}
+	}
+
 	return riserRevision, nil
 }
 
-func (s *service) prepareForDeployment(deploymentConfig *core.DeploymentConfig, dryRun bool) (riserRevision int64, err error) {
-	if err := validateDeploymentConfig(deploymentConfig); err != nil {
+func (s *service) Rollback(name *core.NamespacedName, envName string, riserRevision int64, deployedBy string, committer state.Committer, dryRun bool) (newRiserRevision int64, err error) {
+	revision, err := s.revisions.GetByRevision(name, envName, riserRevision)
+	if err != nil {
+		if err == core.ErrNotFound {
+			return 0, core.NewValidationErrorMessage(
+				fmt.Sprintf("Revision %d of deployment %q in environment %q does not exist or was deployed before revision history was recorded", riserRevision, name, envName))
+		}
+		return 0, errors.Wrap(err, "Error retrieving deployment revision")
+	}
+
+	// The latest committed secrets are always used. The secret revisions in the revision history are only used to determine which
+	// sealed secrets are still referenced.
+	deploymentConfig := &core.DeploymentConfig{
+		Name:             name.Name,
+		Namespace:        name.Namespace,
+		EnvironmentName:  envName,
+		Docker:           revision.Doc.Docker,
+		App:              revision.Doc.App,
+		AppWithOverrides: revision.Doc.AppWithOverrides,
+		DeployedBy:       deployedBy,
+	}
+
+	return s.Update(deploymentConfig, committer, dryRun)
+}
+
+func (s *service) Promote(promotion *core.DeploymentPromotion, committer state.Committer, dryRun bool) (riserRevision int64, err error) {
+	source, err := s.deployments.GetByName(promotion.Name, promotion.SourceEnvironmentName)
+	if err != nil && err != core.ErrNotFound {
+		return 0, errors.Wrap(err, fmt.Sprintf("Error retrieving deployment %q in environment %q", promotion.Name, promotion.SourceEnvironmentName))
+	}
+	if err == core.ErrNotFound || source.DeletedAt != nil {
+		return 0, core.NewValidationErrorMessage(fmt.Sprintf("There is no deployment by the name %q in environment %q", promotion.Name, promotion.SourceEnvironmentName))
+	}
+
+	err = validatePromotable(source)
+	if err != nil {
 		return 0, err
 	}
 
+	revision, err := s.revisions.GetByRevision(promotion.Name, promotion.SourceEnvironmentName, source.RiserRevision)
+	if err != nil && err != core.ErrNotFound {
+		return 0, errors.Wrap(err, "Error retrieving deployment revision")
+	}
+	if err == core.ErrNotFound || revision.Doc.AppWithOverrides == nil {
+		return 0, core.NewValidationErrorMessage(
+			fmt.Sprintf("Revision %d of deployment %q in environment %q cannot be promoted as it was deployed before revision history was recorded", source.RiserRevision, promotion.Name, promotion.SourceEnvironmentName))
+	}
+
+	app, err := revision.Doc.AppWithOverrides.ApplyOverrides(promotion.TargetEnvironmentName)
+	if err != nil {
+		return 0, errors.Wrap(err, fmt.Sprintf("Error applying overrides for environment %q", promotion.TargetEnvironmentName))
+	}
+
+	deploymentConfig := &core.DeploymentConfig{
+		Name:             promotion.Name.Name,
+		Namespace:        promotion.Name.Namespace,
+		EnvironmentName:  promotion.TargetEnvironmentName,
+		Docker:           revision.Doc.Docker,
+		App:              app,
+		AppWithOverrides: revision.Doc.AppWithOverrides,
+		ManualRollout:    promotion.ManualRollout,
+		DeployedBy:       promotion.DeployedBy,
+	}
+
+	return s.Update(deploymentConfig, committer, dryRun)
+}
+
+func (s *service) RenderEnvironment(envName string) (files []core.ResourceFile, skipped []core.NamespacedName, err error) {
+	environment, err := s.environments.Get(envName)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, fmt.Sprintf("Error retrieving environment %q", envName))
+	}
+
+	deployments, err := s.deployments.FindByEnvironment(envName)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, fmt.Sprintf("Error retrieving deployments in environment %q", envName))
+	}
+
+	renderer, err := resources.NewRenderer(&environment.Doc.Config)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, rendersRevisions := renderer.(resources.RevisionRenderer)
+
+	files = []core.ResourceFile{}
+	skipped = []core.NamespacedName{}
+	for _, deployment := range deployments {
+		name := core.NewNamespacedName(deployment.Name, deployment.Namespace)
+		deploymentConfig, err := s.getRenderedConfig(&deployment)
+		if err != nil {
+			return nil, nil, err
+		}
+		if deploymentConfig == nil {
+			skipped = append(skipped, *name)
+			continue
+		}
+
+		secrets, err := s.secrets.ListByAppInEnvironment(name, envName)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		previousRevisions := []*core.DeploymentContext{}
+		if rendersRevisions {
+			previousRevisions, err = s.getPreviousRevisions(&deployment, &environment.Doc.Config)
+			if err != nil {
+				return nil, nil, err
+			}
+			if previousRevisions == nil {
+				skipped = append(skipped, *name)
+				continue
+			}
+		}
+
+		deploymentFiles, err := renderDeployment(&core.DeploymentContext{
+			DeploymentConfig:  deploymentConfig,
+			EnvironmentConfig: &environment.Doc.Config,
+			RiserRevision:     deployment.RiserRevision,
+			Secrets:           secrets,
+		}, previousRevisions...)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, fmt.Sprintf("Error rendering deployment %q", name))
+		}
+		files = append(files, deploymentFiles...)
+	}
+
+	return files, skipped, nil
+}
+
+func (s *service) FindSecretReferences(envName string) (*core.SecretReferences, error) {
+	deployments, err := s.deployments.FindByEnvironment(envName)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error retrieving deployments in environment %q", envName))
+	}
+
+	references := &core.SecretReferences{Secrets: []core.SecretMeta{}, UnknownNamespaces: []string{}}
+	unknownNamespaces := map[string]bool{}
+	for _, deployment := range deployments {
+		name := core.NewNamespacedName(deployment.Name, deployment.Namespace)
+		for _, riserRevision := range liveRiserRevisions(&deployment) {
+			revision, err := s.revisions.GetByRevision(name, envName, riserRevision)
+			if err != nil && err != core.ErrNotFound {
+				return nil, errors.Wrap(err, fmt.Sprintf("Error retrieving revision %d of deployment %q", riserRevision, name))
+			}
+			if revision == nil || revision.Doc.Secrets == nil || revision.Doc.App == nil {
+				if !unknownNamespaces[deployment.Namespace] {
+					unknownNamespaces[deployment.Namespace] = true
+					references.UnknownNamespaces = append(references.UnknownNamespaces, deployment.Namespace)
+				}
+				continue
+			}
+
+			for _, secret := range revision.Doc.Secrets {
+				references.Secrets = append(references.Secrets, core.SecretMeta{
+					Name:            secret.Name,
+					App:             core.NewNamespacedName(string(revision.Doc.App.Name), deployment.Namespace),
+					EnvironmentName: envName,
+					Revision:        secret.Revision,
+				})
+			}
+		}
+	}
+
+	return references, nil
+}
+
+// liveRiserRevisions returns the riser revisions that may still have a running revision in the environment
+func liveRiserRevisions(deployment *core.Deployment) []int64 {
+	riserRevisions := []int64{}
+	seen := map[int64]bool{}
+	add := func(riserRevision int64) {
+		if riserRevision > 0 && !seen[riserRevision] {
+			seen[riserRevision] = true
+			riserRevisions = append(riserRevisions, riserRevision)
+		}
+	}
+
+	add(deployment.RiserRevision)
+	for _, rule := range deployment.Doc.Traffic {
+		add(rule.RiserRevision)
+	}
+	if deployment.Doc.Status != nil {
+		for _, revision := range deployment.Doc.Status.Revisions {
+			add(revision.RiserRevision)
+		}
+	}
+	return riserRevisions
+}
+
+// getRenderedConfig returns the config that the deployment's current revision was rendered from. Deployments rendered before the
+// config was recorded fall back to the revision history. Returns nil if neither are available.
+func (s *service) getRenderedConfig(deployment *core.Deployment) (*core.DeploymentConfig, error) {
+	deploymentConfig := deployment.Doc.LastRenderedConfig
+	if deploymentConfig == nil {
+		name := core.NewNamespacedName(deployment.Name, deployment.Namespace)
+		revision, err := s.revisions.GetByRevision(name, deployment.EnvironmentName, deployment.RiserRevision)
+		if err == core.ErrNotFound {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error retrieving revision %d of deployment %q", deployment.RiserRevision, name))
+		}
+
+		deploymentConfig = &core.DeploymentConfig{
+			Name:             deployment.Name,
+			Namespace:        deployment.Namespace,
+			EnvironmentName:  deployment.EnvironmentName,
+			Docker:           revision.Doc.Docker,
+			App:              revision.Doc.App,
+			AppWithOverrides: revision.Doc.AppWithOverrides,
+			DeployedBy:       revision.Doc.DeployedBy,
+		}
+	}
+
+	// Traffic is updated independently of the rendered config (e.g. by a rollout)
+	deploymentConfig.Traffic = deployment.Doc.Traffic
+	return deploymentConfig, nil
+}
+
+// getPreviousRevisions returns a context for each revision other than the current revision that is in the deployment's traffic
+// config. Each context uses the config and secrets that the revision was deployed with. Returns nil if any of the revisions were
+// deployed before their config and secrets were recorded.
+func (s *service) getPreviousRevisions(deployment *core.Deployment, environmentConfig *core.EnvironmentConfig) ([]*core.DeploymentContext, error) {
+	name := core.NewNamespacedName(deployment.Name, deployment.Namespace)
+	previousRevisions := []*core.DeploymentContext{}
+	for _, rule := range deployment.Doc.Traffic {
+		if rule.RiserRevision == deployment.RiserRevision {
+			continue
+		}
+
+		revision, err := s.revisions.GetByRevision(name, deployment.EnvironmentName, rule.RiserRevision)
+		if err == core.ErrNotFound {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error retrieving revision %d of deployment %q", rule.RiserRevision, name))
+		}
+		if revision.Doc.App == nil || revision.Doc.Secrets == nil {
+			return nil, nil
+		}
+
+		secrets := []core.SecretMeta{}
+		for _, secret := range revision.Doc.Secrets {
+			secrets = append(secrets, core.SecretMeta{
+				Name:            secret.Name,
+				App:             core.NewNamespacedName(string(revision.Doc.App.Name), deployment.Namespace),
+				EnvironmentName: deployment.EnvironmentName,
+				Revision:        secret.Revision,
+			})
+		}
+
+		previousRevisions = append(previousRevisions, &core.DeploymentContext{
+			DeploymentConfig: &core.DeploymentConfig{
+				Name:            deployment.Name,
+				Namespace:       deployment.Namespace,
+				EnvironmentName: deployment.EnvironmentName,
+				Docker:          revision.Doc.Docker,
+				App:             revision.Doc.App,
+			},
+			EnvironmentConfig: environmentConfig,
+			RiserRevision:     rule.RiserRevision,
+			Secrets:           secrets,
+		})
+	}
+
+	return previousRevisions, nil
+}
+
+// validatePromotable ensures that the latest revision of a deployment has reported that it's ready
+func validatePromotable(deployment *core.Deployment) error {
+	if deployment.Doc.Status != nil {
+		for _, revision := range deployment.Doc.Status.Revisions {
+			if revision.RiserRevision == deployment.RiserRevision && revision.RevisionStatus == model.RevisionStatusReady {
+				return nil
+			}
+		}
+	}
+
+	return core.NewValidationErrorMessage(
+		fmt.Sprintf("Revision %d of deployment %q in environment %q must report a status of %q before it can be promoted",
+			deployment.RiserRevision, deployment.Name, deployment.EnvironmentName, model.RevisionStatusReady))
+}
+
+// saveRevision records the deployed config so that the history of a deployment is available after subsequent deployments
+func (s *service) deploymentLogger(deploymentConfig *core.DeploymentConfig, riserRevision int64) logrus.FieldLogger {
+	return s.logger.WithFields(logrus.Fields{
+		"deployment":    deploymentConfig.Name,
+		"namespace":     deploymentConfig.Namespace,
+		"environment":   deploymentConfig.EnvironmentName,
+		"riserRevision": riserRevision,
+	})
+}
+
+// recordDeployment saves the revision and the config that it is rendered with
+func (s *service) recordDeployment(deploymentConfig *core.DeploymentConfig, riserRevision int64, secrets []core.SecretMeta) error {
+	err := s.saveRevision(deploymentConfig, riserRevision, secrets)
+	if err != nil {
+		return err
+	}
+
+	err = s.deployments.UpdateRenderedConfig(
+		core.NewNamespacedName(deploymentConfig.Name, deploymentConfig.Namespace), deploymentConfig.EnvironmentName, riserRevision, deploymentConfig)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error recording the rendered config for deployment %q in environment %q", deploymentConfig.Name, deploymentConfig.EnvironmentName))
+	}
+	return nil
+}
+
+func (s *service) saveRevision(deploymentConfig *core.DeploymentConfig, riserRevision int64, secrets []core.SecretMeta) error {
+	revisionSecrets := []core.DeploymentRevisionSecret{}
+	for _, secret := range secrets {
+		revisionSecrets = append(revisionSecrets, core.DeploymentRevisionSecret{Name: secret.Name, Revision: secret.Revision})
+	}
+
+	err := s.revisions.Save(
+		core.NewNamespacedName(deploymentConfig.Name, deploymentConfig.Namespace),
+		deploymentConfig.EnvironmentName,
+		&core.DeploymentRevision{
+			Id:            uuid.New(),
+			RiserRevision: riserRevision,
+			Doc: core.DeploymentRevisionDoc{
+				Docker:           deploymentConfig.Docker,
+				App:              deploymentConfig.App,
+				AppWithOverrides: deploymentConfig.AppWithOverrides,
+				Traffic:          deploymentConfig.Traffic,
+				DeployedBy:       deploymentConfig.DeployedBy,
+				Created:          time.Now().UTC(),
+				Secrets:          revisionSecrets,
+			},
+		})
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error saving revision %d for deployment %q in environment %q", riserRevision, deploymentConfig.Name, deploymentConfig.EnvironmentName))
+	}
+	return nil
+}
+
+// startRollout records a rollout so that traffic is progressively shifted to the new revision. Traffic is only shifted when
+// there is existing traffic to shift from.
+func (s *service) startRollout(deploymentConfig *core.DeploymentConfig, riserRevision int64) error {
+	if deploymentConfig.Rollout == nil || len(deploymentConfig.Traffic) < 2 {
+		return nil
+	}
+
+	err := s.rollouts.Save(&core.Rollout{
+		Name:            core.NewNamespacedName(deploymentConfig.Name, deploymentConfig.Namespace),
+		EnvironmentName: deploymentConfig.EnvironmentName,
+		RiserRevision:   riserRevision,
+		Status:          core.RolloutStatusInProgress,
+		NextStepAt:      time.Now().UTC(),
+		Doc: core.RolloutDoc{
+			Strategy: *deploymentConfig.Rollout,
+			// The first rule is always the new revision
+			PreviousTraffic: deploymentConfig.Traffic[1:],
+			CurrentStep:     -1,
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error starting rollout of revision %d for deployment %q in environment %q", riserRevision, deploymentConfig.Name, deploymentConfig.EnvironmentName))
+	}
+	return nil
+}
+
+// prepareForDeployment reserves and records the deployment and computes its traffic. The retired revisions are the revisions that
+// the deployment's traffic no longer routes to.
+func (s *service) prepareForDeployment(deploymentConfig *core.DeploymentConfig, dryRun bool) (riserRevision int64, retiredRevisions []int64, err error) {
+	if err := validateDeploymentConfig(deploymentConfig); err != nil {
+		return 0, nil, err
+	}
+
 	reservation, err := s.reservationService.EnsureReservation(
 		deploymentConfig.App.Id,
 		core.NewNamespacedName(deploymentConfig.Name, deploymentConfig.Namespace))
 	if err != nil {
-		return 0, errors.Wrap(err, "Error ensuring deployment reservation")
+		return 0, nil, errors.Wrap(err, "Error ensuring deployment reservation")
+	}
+
+	// Domains are claimed before they are committed so that two apps can never route the same domain. Like the reservation, a claim is
+	// kept when the deployment fails. It is released by the deployment's next successful deployment if it is no longer used.
+	if len(deploymentConfig.App.Domains) > 0 {
+		claimDomains := s.domainClaimService.Claim
+		if dryRun {
+			claimDomains = s.domainClaimService.ValidateClaims
+		}
+		err = claimDomains(deploymentConfig.App.Id,
+			core.NewNamespacedName(deploymentConfig.Name, deploymentConfig.Namespace), deploymentConfig.EnvironmentName, deploymentConfig.App.Domains)
+		if err != nil {
+			return 0, nil, err
+		}
 	}
 
 	existingDeployment, err := s.deployments.GetByReservation(reservation.Id, deploymentConfig.EnvironmentName)
 	if err != nil && err != core.ErrNotFound {
-		return 0, errors.Wrap(err, fmt.Sprintf("Error retrieving deployment %q in environment %q", deploymentConfig.Name, deploymentConfig.EnvironmentName))
+		return 0, nil, errors.Wrap(err, fmt.Sprintf("Error retrieving deployment %q in environment %q", deploymentConfig.Name, deploymentConfig.EnvironmentName))
 	}
 	if err == core.ErrNotFound {
 		riserRevision = 1
 		deploymentConfig.Traffic = computeTraffic(riserRevision, deploymentConfig, nil)
-		err = s.deployments.Create(&core.DeploymentRecord{
-			Id:              uuid.New(),
-			ReservationId:   reservation.Id,
-			EnvironmentName: deploymentConfig.EnvironmentName,
-			RiserRevision:   riserRevision,
-			Doc: core.DeploymentDoc{
-				Traffic: deploymentConfig.Traffic,
-			},
-		})
-		if err != nil {
-			return 0, errors.Wrap(err, fmt.Sprintf("Error creating deployment %q in environment %q", deploymentConfig.Name, deploymentConfig.EnvironmentName))
+		if !dryRun {
+			err = s.deployments.Create(&core.DeploymentRecord{
+				Id:              uuid.New(),
+				ReservationId:   reservation.Id,
+				EnvironmentName: deploymentConfig.EnvironmentName,
+				RiserRevision:   riserRevision,
+				Doc: core.DeploymentDoc{
+					Traffic: deploymentConfig.Traffic,
+				},
+			})
+			if err != nil {
+				return 0, nil, errors.Wrap(err, fmt.Sprintf("Error creating deployment %q in environment %q", deploymentConfig.Name, deploymentConfig.EnvironmentName))
+			}
 		}
 	} else if existingDeployment.AppId != deploymentConfig.App.Id {
-		return 0, &core.ValidationError{Message: fmt.Sprintf("A deployment with the name %q is owned by app %q", deploymentConfig.Name, existingDeployment.AppId)}
+		return 0, nil, &core.ValidationError{Message: fmt.Sprintf("A deployment with the name %q is owned by app %q", deploymentConfig.Name, existingDeployment.AppId)}
 	} else {
+		// A dry run renders the revision that the deployment would be incremented to
+		riserRevision = existingDeployment.RiserRevision + 1
 		if !dryRun {
 			riserRevision, err = s.deployments.IncrementRevision(
 				core.NewNamespacedName(deploymentConfig.Name, deploymentConfig.Namespace), deploymentConfig.EnvironmentName)
 			if err != nil {
-				return 0, errors.Wrap(err, "Error incrementing deployment revision")
+				return 0, nil, errors.Wrap(err, "Error incrementing deployment revision")
 			}
 		}
 
 		// When a deployment was previously deleted, we don't want to compute traffic with the old traffic rules
 		if existingDeployment.DeletedAt == nil {
 			deploymentConfig.Traffic = computeTraffic(riserRevision, deploymentConfig, &existingDeployment.DeploymentRecord)
+			retiredRevisions = deploymentConfig.Traffic.RetiredRevisions(existingDeployment.Doc.Traffic)
 		} else {
 			deploymentConfig.Traffic = computeTraffic(riserRevision, deploymentConfig, nil)
 		}
@@ -143,12 +586,12 @@ func (s *service) prepareForDeployment(deploymentConfig *core.DeploymentConfig,
 				riserRevision,
 				deploymentConfig.Traffic)
 			if err != nil {
-				return 0, errors.Wrap(err, "Error updating traffic")
+				return 0, nil, errors.Wrap(err, "Error updating traffic")
 			}
 		}
 	}
 
-	return riserRevision, nil
+	return riserRevision, retiredRevisions, nil
 }
 
 func computeTraffic(riserRevision int64, deploymentConfig *core.DeploymentConfig, existingDeployment *core.DeploymentRecord) core.TrafficConfig {
@@ -157,7 +600,8 @@ func computeTraffic(riserRevision int64, deploymentConfig *core.DeploymentConfig
 		RevisionName:  fmt.Sprintf("%s-%d", deploymentConfig.Name, riserRevision),
 	}
 
-	if deploymentConfig.ManualRollout && existingDeployment != nil {
+	// An automated rollout starts at 0% and progressively shifts traffic to the new revision
+	if (deploymentConfig.ManualRollout || deploymentConfig.Rollout != nil) && existingDeployment != nil {
 		newRule.Percent = 0
 		trafficConfig := core.TrafficConfig{newRule}
 		for _, rule := range existingDeployment.Doc.Traffic {
@@ -165,7 +609,10 @@ func computeTraffic(riserRevision int64, deploymentConfig *core.DeploymentConfig
 				trafficConfig = append(trafficConfig, rule)
 			}
 		}
-		return trafficConfig
+		// There is nothing to progressively shift traffic from when no existing revision is receiving traffic
+		if deploymentConfig.ManualRollout || len(trafficConfig) > 1 {
+			return trafficConfig
+		}
 	}
 
 	newRule.Percent = 100
@@ -189,27 +636,78 @@ func validateDeploymentConfig(deployment *core.DeploymentConfig) error {
 }
 
 func deploy(ctx *core.DeploymentContext, committer state.Committer) error {
-	resourceFiles, err := state.RenderDeployment(ctx.DeploymentConfig, createDeployResources(ctx)...)
+	resourceFiles, err := renderForDeployment(ctx)
 	if err != nil {
 		return err
 	}
 
+	return commitDeployment(ctx, committer, resourceFiles)
+}
+
+// renderForDeployment validates that the environment's renderer supports the deployment before rendering it
+func renderForDeployment(ctx *core.DeploymentContext) ([]core.ResourceFile, error) {
+	renderer, err := resources.NewRenderer(ctx.EnvironmentConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	err = renderer.Validate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return renderDeployment(ctx)
+}
+
+func commitDeployment(ctx *core.DeploymentContext, committer state.Committer, resourceFiles []core.ResourceFile) error {
+	return committer.Commit(fmt.Sprintf("Updating resources for \"%s.%s\" in environment %q", ctx.DeploymentConfig.Name, ctx.DeploymentConfig.Namespace, ctx.DeploymentConfig.EnvironmentName), resourceFiles, &core.CommitMeta{
+		Username:      ctx.DeploymentConfig.DeployedBy,
+		App:           string(ctx.DeploymentConfig.App.Name),
+		Namespace:     ctx.DeploymentConfig.Namespace,
+		Deployment:    ctx.DeploymentConfig.Name,
+		Environment:   ctx.DeploymentConfig.EnvironmentName,
+		RiserRevision: ctx.RiserRevision,
+	})
+}
+
+// renderDeployment renders the resources for a deployment. The previous revisions are only rendered by a resources.RevisionRenderer.
+func renderDeployment(ctx *core.DeploymentContext, previousRevisions ...*core.DeploymentContext) ([]core.ResourceFile, error) {
+	renderer, err := resources.NewRenderer(ctx.EnvironmentConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	deployResources := renderer.DeploymentResources(ctx)
+	if revisionRenderer, ok := renderer.(resources.RevisionRenderer); ok {
+		for _, previousRevision := range previousRevisions {
+			deployResources = append(deployResources, revisionRenderer.RevisionResources(previousRevision)...)
+		}
+	}
+
+	resourceFiles, err := state.RenderDeployment(ctx.DeploymentConfig, deployResources...)
+	if err != nil {
+		return nil, err
+	}
+	if revisionRenderer, ok := renderer.(resources.RevisionRenderer); ok {
+		resourceFiles = append(resourceFiles, state.RenderDeleteResources(ctx.DeploymentConfig.Name, ctx.DeploymentConfig.Namespace,
+			revisionRenderer.RetiredRevisionResources(ctx)...)...)
+	}
+
 	// Create the namespace resource whether we need to or not to ensure that it exists and that it's up-to-date
 	clusterResourceFiles, err := state.RenderGeneric(ctx.DeploymentConfig.EnvironmentName,
 		resources.CreateNamespace(ctx.DeploymentConfig.Namespace, ctx.DeploymentConfig.EnvironmentName))
 	if err != nil {
-		return nil
+		return nil, err
 	}
 
 	resourceFiles = append(resourceFiles, clusterResourceFiles...)
 
-	return committer.Commit(fmt.Sprintf("Updating resources for \"%s.%s\" in environment %q", ctx.DeploymentConfig.Name, ctx.DeploymentConfig.Namespace, ctx.DeploymentConfig.EnvironmentName), resourceFiles)
-}
-
-func createDeployResources(ctx *core.DeploymentContext) []state.KubeResource {
-	return []state.KubeResource{
-		resources.CreateHealthcheckDenyPolicy(ctx),
-		resources.CreateKNativeConfiguration(ctx),
-		resources.CreateKNativeRoute(ctx),
+	// Every deployment of an app renders the same service account so that it exists for as long as any of them do
+	serviceAccountFiles, err := state.RenderServiceAccount(
+		resources.CreateServiceAccount(string(ctx.DeploymentConfig.App.Name), ctx.DeploymentConfig.Namespace))
+	if err != nil {
+		return nil, err
 	}
+
+	return append(resourceFiles, serviceAccountFiles...), nil
 }
@@ -1,20 +1,26 @@
 package deployment
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/riser-platform/riser-server/pkg/deploymentreservation"
+	"github.com/riser-platform/riser-server/pkg/domainclaim"
 	"github.com/riser-platform/riser-server/pkg/state"
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
+	logtest "github.com/sirupsen/logrus/hooks/test"
 
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/riser-platform/riser-server/api/v1/model"
 	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/util"
 )
 
 // Note: See snapshot_test for state based testing of deployment artifacts
@@ -29,52 +35,1127 @@ func Test_Delete(t *testing.T) {
 		},
 	}
 
+	domainClaimService := &domainclaim.FakeService{
+		ReleaseClaimsFn: func(nameArg *core.NamespacedName, envName string) error {
+			assert.Equal(t, name, nameArg)
+			assert.Equal(t, "myenv", envName)
+			return nil
+		},
+	}
+
+	committer := state.NewDryRunCommitter()
+
+	service := service{deployments: deploymentRepository, domainClaimService: domainClaimService}
+
+	err := service.Delete(name, "myenv", "myuser", committer)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, deploymentRepository.DeleteCallCount)
+	assert.Equal(t, 1, domainClaimService.ReleaseClaimsCallCount)
+	assert.Len(t, committer.Commits, 1)
+	assert.Equal(t, `Deleting deployment "mydep.apps"`, committer.Commits[0].Message)
+	assert.Equal(t, &core.CommitMeta{Username: "myuser", Namespace: "apps", Deployment: "mydep", Environment: "myenv"}, committer.Commits[0].Meta)
+	assert.Len(t, committer.Commits[0].Files, 2)
+	assert.Equal(t, "state/riser-managed/apps/deployments/mydep", committer.Commits[0].Files[0].Name)
+	assert.True(t, committer.Commits[0].Files[0].Delete)
+	assert.Equal(t, "riser-config/apps/mydep.yaml", committer.Commits[0].Files[1].Name)
+	assert.True(t, committer.Commits[0].Files[1].Delete)
+}
+
+func Test_Delete_SoftDeleteFails(t *testing.T) {
+	deploymentRepository := &core.FakeDeploymentRepository{
+		DeleteFn: func(*core.NamespacedName, string) error {
+			return errors.New("test")
+		},
+	}
+
+	committer := state.NewDryRunCommitter()
+
+	service := service{deployments: deploymentRepository}
+
+	err := service.Delete(core.NewNamespacedName("mydep", "myns"), "myenv", "myuser", committer)
+
+	assert.Equal(t, "error deleting deployment: test", err.Error())
+}
+
+func Test_Delete_ReleaseClaimsFails(t *testing.T) {
+	deploymentRepository := &core.FakeDeploymentRepository{
+		DeleteFn: func(*core.NamespacedName, string) error {
+			return nil
+		},
+	}
+	domainClaimService := &domainclaim.FakeService{
+		ReleaseClaimsFn: func(*core.NamespacedName, string) error {
+			return errors.New("test")
+		},
+	}
+
+	committer := state.NewDryRunCommitter()
+
+	service := service{deployments: deploymentRepository, domainClaimService: domainClaimService}
+
+	err := service.Delete(core.NewNamespacedName("mydep", "myns"), "myenv", "myuser", committer)
+
+	assert.Equal(t, "test", err.Error())
+	assert.Empty(t, committer.Commits)
+}
+
+func Test_Delete_DeploymentNotFound(t *testing.T) {
+	deploymentRepository := &core.FakeDeploymentRepository{
+		DeleteFn: func(*core.NamespacedName, string) error {
+			return core.ErrNotFound
+		},
+	}
+
+	service := service{deployments: deploymentRepository}
+
+	err := service.Delete(core.NewNamespacedName("mydep", "myns"), "myenv", "myuser", nil)
+
+	assert.Equal(t, `There is no deployment by the name "mydep.myns" in environment "myenv"`, err.Error())
+	assert.IsType(t, &core.ValidationError{}, err)
+}
+
+func Test_Rollback(t *testing.T) {
+	appId := uuid.New()
+	name := core.NewNamespacedName("myapp", "myns")
+	revisionRepository := &core.FakeDeploymentRevisionRepository{
+		GetByRevisionFn: func(nameArg *core.NamespacedName, envName string, riserRevision int64) (*core.DeploymentRevision, error) {
+			assert.Equal(t, name, nameArg)
+			assert.Equal(t, "myenv", envName)
+			assert.EqualValues(t, 1, riserRevision)
+			return &core.DeploymentRevision{
+				RiserRevision: 1,
+				Doc: core.DeploymentRevisionDoc{
+					Docker: core.DeploymentDocker{Tag: "v1"},
+					App: &model.AppConfig{
+						Id:        appId,
+						Name:      "myapp",
+						Namespace: "myns",
+						Image:     "myimage",
+						Expose:    &model.AppConfigExpose{ContainerPort: 8080},
+					},
+				},
+			}, nil
+		},
+	}
+
+	reservationService := &deploymentreservation.FakeService{
+		EnsureReservationFn: func(appIdArg uuid.UUID, nameArg *core.NamespacedName) (*core.DeploymentReservation, error) {
+			assert.Equal(t, appId, appIdArg)
+			return &core.DeploymentReservation{Id: uuid.New(), AppId: appId}, nil
+		},
+	}
+
+	deploymentRepository := &core.FakeDeploymentRepository{
+		GetByReservationFn: func(uuid.UUID, string) (*core.Deployment, error) {
+			return &core.Deployment{
+				DeploymentReservation: core.DeploymentReservation{AppId: appId},
+				DeploymentRecord:      core.DeploymentRecord{RiserRevision: 2},
+			}, nil
+		},
+	}
+
+	environmentRepository := &core.FakeEnvironmentRepository{
+		GetFn: func(string) (*core.Environment, error) {
+			return &core.Environment{}, nil
+		},
+	}
+
+	secretRepository := &core.FakeSecretMetaRepository{
+		ListByAppInEnvironmentFn: func(*core.NamespacedName, string) ([]core.SecretMeta, error) {
+			return []core.SecretMeta{}, nil
+		},
+	}
+
+	committer := state.NewDryRunCommitter()
+	service := service{
+		revisions:          revisionRepository,
+		reservationService: reservationService,
+		deployments:        deploymentRepository,
+		environments:       environmentRepository,
+		secrets:            secretRepository,
+	}
+
+	_, err := service.Rollback(name, "myenv", 1, "myuser", committer, true)
+
+	assert.NoError(t, err)
+	require.Len(t, committer.Commits, 1)
+	assert.Equal(t, `Updating resources for "myapp.myns" in environment "myenv"`, committer.Commits[0].Message)
+	configurationFile := committer.Commits[0].Files[0]
+	assert.Equal(t, "state/riser-managed/myns/deployments/myapp/serving.knative.dev.configuration.myapp.yaml", configurationFile.Name)
+	assert.Contains(t, string(configurationFile.Contents), "image: myimage:v1")
+	// Dry runs do not record revision history
+	assert.Equal(t, 0, revisionRepository.SaveCallCount)
+}
+
+func Test_Rollback_RevisionNotFound(t *testing.T) {
+	revisionRepository := &core.FakeDeploymentRevisionRepository{
+		GetByRevisionFn: func(*core.NamespacedName, string, int64) (*core.DeploymentRevision, error) {
+			return nil, core.ErrNotFound
+		},
+	}
+
+	service := service{revisions: revisionRepository}
+
+	_, err := service.Rollback(core.NewNamespacedName("myapp", "myns"), "myenv", 1, "myuser", nil, false)
+
+	assert.IsType(t, &core.ValidationError{}, err)
+	assert.Equal(t, `Revision 1 of deployment "myapp.myns" in environment "myenv" does not exist or was deployed before revision history was recorded`, err.Error())
+}
+
+func Test_Promote(t *testing.T) {
+	appId := uuid.New()
+	name := core.NewNamespacedName("myapp", "myns")
+	deploymentRepository := &core.FakeDeploymentRepository{
+		GetByNameFn: func(nameArg *core.NamespacedName, envName string) (*core.Deployment, error) {
+			assert.Equal(t, name, nameArg)
+			assert.Equal(t, "dev", envName)
+			return &core.Deployment{
+				DeploymentReservation: core.DeploymentReservation{AppId: appId, Name: "myapp"},
+				DeploymentRecord: core.DeploymentRecord{
+					EnvironmentName: "dev",
+					RiserRevision:   3,
+					Doc: core.DeploymentDoc{
+						Status: &core.DeploymentStatus{
+							Revisions: []core.DeploymentRevisionStatus{
+								{RiserRevision: 3, RevisionStatus: model.RevisionStatusReady},
+							},
+						},
+					},
+				},
+			}, nil
+		},
+		GetByReservationFn: func(uuid.UUID, string) (*core.Deployment, error) {
+			return nil, core.ErrNotFound
+		},
+		CreateFn: func(deployment *core.DeploymentRecord) error {
+			assert.Equal(t, "prod", deployment.EnvironmentName)
+			return nil
+		},
+	}
+
+	revisionRepository := &core.FakeDeploymentRevisionRepository{
+		GetByRevisionFn: func(nameArg *core.NamespacedName, envName string, riserRevision int64) (*core.DeploymentRevision, error) {
+			assert.Equal(t, "dev", envName)
+			assert.EqualValues(t, 3, riserRevision)
+			return &core.DeploymentRevision{
+				RiserRevision: 3,
+				Doc: core.DeploymentRevisionDoc{
+					Docker: core.DeploymentDocker{Tag: "v3"},
+					AppWithOverrides: &model.AppConfigWithOverrides{
+						AppConfig: model.AppConfig{
+							Id:        appId,
+							Name:      "myapp",
+							Namespace: "myns",
+							Image:     "myimage",
+							Expose:    &model.AppConfigExpose{ContainerPort: 8080},
+						},
+						Overrides: map[string]model.OverrideableAppConfig{
+							"prod": {
+								Autoscale: &model.AppConfigAutoscale{Min: util.PtrInt(3)},
+							},
+						},
+					},
+				},
+			}, nil
+		},
+	}
+
+	reservationService := &deploymentreservation.FakeService{
+		EnsureReservationFn: func(appIdArg uuid.UUID, nameArg *core.NamespacedName) (*core.DeploymentReservation, error) {
+			return &core.DeploymentReservation{Id: uuid.New(), AppId: appId}, nil
+		},
+	}
+
+	environmentRepository := &core.FakeEnvironmentRepository{
+		GetFn: func(envName string) (*core.Environment, error) {
+			assert.Equal(t, "prod", envName)
+			return &core.Environment{}, nil
+		},
+	}
+
+	secretRepository := &core.FakeSecretMetaRepository{
+		ListByAppInEnvironmentFn: func(*core.NamespacedName, string) ([]core.SecretMeta, error) {
+			return []core.SecretMeta{}, nil
+		},
+	}
+
+	committer := state.NewDryRunCommitter()
+	service := service{
+		revisions:          revisionRepository,
+		reservationService: reservationService,
+		deployments:        deploymentRepository,
+		environments:       environmentRepository,
+		secrets:            secretRepository,
+	}
+
+	promotion := &core.DeploymentPromotion{
+		Name:                  name,
+		SourceEnvironmentName: "dev",
+		TargetEnvironmentName: "prod",
+	}
+
+	riserRevision, err := service.Promote(promotion, committer, true)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, riserRevision)
+	require.Len(t, committer.Commits, 1)
+	assert.Equal(t, `Updating resources for "myapp.myns" in environment "prod"`, committer.Commits[0].Message)
+	configuration := string(committer.Commits[0].Files[0].Contents)
+	assert.Contains(t, configuration, "image: myimage:v3")
+	assert.Contains(t, configuration, `autoscaling.knative.dev/minScale: "3"`)
+}
+
+func Test_Promote_NotReady(t *testing.T) {
+	deploymentRepository := &core.FakeDeploymentRepository{
+		GetByNameFn: func(*core.NamespacedName, string) (*core.Deployment, error) {
+			return &core.Deployment{
+				DeploymentReservation: core.DeploymentReservation{Name: "myapp"},
+				DeploymentRecord: core.DeploymentRecord{
+					EnvironmentName: "dev",
+					RiserRevision:   3,
+					Doc: core.DeploymentDoc{
+						Status: &core.DeploymentStatus{
+							Revisions: []core.DeploymentRevisionStatus{
+								{RiserRevision: 2, RevisionStatus: model.RevisionStatusReady},
+								{RiserRevision: 3, RevisionStatus: model.RevisionStatusWaiting},
+							},
+						},
+					},
+				},
+			}, nil
+		},
+	}
+
+	service := service{deployments: deploymentRepository}
+
+	_, err := service.Promote(&core.DeploymentPromotion{Name: core.NewNamespacedName("myapp", "myns"), SourceEnvironmentName: "dev"}, nil, false)
+
+	assert.IsType(t, &core.ValidationError{}, err)
+	assert.Equal(t, `Revision 3 of deployment "myapp" in environment "dev" must report a status of "Ready" before it can be promoted`, err.Error())
+}
+
+func Test_Promote_DeletedDeployment(t *testing.T) {
+	deletedAt := time.Now()
+	deploymentRepository := &core.FakeDeploymentRepository{
+		GetByNameFn: func(*core.NamespacedName, string) (*core.Deployment, error) {
+			return &core.Deployment{DeploymentRecord: core.DeploymentRecord{DeletedAt: &deletedAt}}, nil
+		},
+	}
+
+	service := service{deployments: deploymentRepository}
+
+	_, err := service.Promote(&core.DeploymentPromotion{Name: core.NewNamespacedName("myapp", "myns"), SourceEnvironmentName: "dev"}, nil, false)
+
+	assert.IsType(t, &core.ValidationError{}, err)
+	assert.Equal(t, `There is no deployment by the name "myapp.myns" in environment "dev"`, err.Error())
+}
+
+func Test_Promote_NoRevisionHistory(t *testing.T) {
+	deploymentRepository := &core.FakeDeploymentRepository{
+		GetByNameFn: func(*core.NamespacedName, string) (*core.Deployment, error) {
+			return &core.Deployment{
+				DeploymentRecord: core.DeploymentRecord{
+					RiserRevision: 1,
+					Doc: core.DeploymentDoc{
+						Status: &core.DeploymentStatus{
+							Revisions: []core.DeploymentRevisionStatus{{RiserRevision: 1, RevisionStatus: model.RevisionStatusReady}},
+						},
+					},
+				},
+			}, nil
+		},
+	}
+	revisionRepository := &core.FakeDeploymentRevisionRepository{
+		GetByRevisionFn: func(*core.NamespacedName, string, int64) (*core.DeploymentRevision, error) {
+			return nil, core.ErrNotFound
+		},
+	}
+
+	service := service{deployments: deploymentRepository, revisions: revisionRepository}
+
+	_, err := service.Promote(&core.DeploymentPromotion{Name: core.NewNamespacedName("myapp", "myns"), SourceEnvironmentName: "dev"}, nil, false)
+
+	assert.IsType(t, &core.ValidationError{}, err)
+	assert.Equal(t, `Revision 1 of deployment "myapp.myns" in environment "dev" cannot be promoted as it was deployed before revision history was recorded`, err.Error())
+}
+
+func newRenderTestRevision(appName string, revision int64) *core.DeploymentRevision {
+	return &core.DeploymentRevision{
+		RiserRevision: revision,
+		Doc: core.DeploymentRevisionDoc{
+			Docker: core.DeploymentDocker{Tag: "v1"},
+			App: &model.AppConfig{
+				Id:        uuid.New(),
+				Name:      model.AppName(appName),
+				Namespace: "myns",
+				Image:     "myimage",
+				Expose:    &model.AppConfigExpose{ContainerPort: 8080},
+			},
+		},
+	}
+}
+
+func Test_RenderEnvironment(t *testing.T) {
+	deploymentRepository := &core.FakeDeploymentRepository{
+		FindByEnvironmentFn: func(envName string) ([]core.Deployment, error) {
+			assert.Equal(t, "myenv", envName)
+			return []core.Deployment{
+				{
+					DeploymentReservation: core.DeploymentReservation{Name: "app1", Namespace: "myns"},
+					DeploymentRecord: core.DeploymentRecord{
+						EnvironmentName: "myenv",
+						RiserRevision:   2,
+						Doc: core.DeploymentDoc{
+							Traffic: core.TrafficConfig{{RiserRevision: 2, RevisionName: "app1-2", Percent: 100}},
+							LastRenderedConfig: &core.DeploymentConfig{
+								Name:            "app1",
+								Namespace:       "myns",
+								EnvironmentName: "myenv",
+								Docker:          core.DeploymentDocker{Tag: "v2"},
+								App:             newRenderTestRevision("app1", 2).Doc.App,
+								// Stale traffic should not be rendered
+								Traffic: core.TrafficConfig{{RiserRevision: 1, RevisionName: "app1-1", Percent: 100}},
+							},
+						},
+					},
+				},
+				{
+					DeploymentReservation: core.DeploymentReservation{Name: "app2", Namespace: "myns"},
+					DeploymentRecord:      core.DeploymentRecord{EnvironmentName: "myenv", RiserRevision: 1},
+				},
+				{
+					DeploymentReservation: core.DeploymentReservation{Name: "old", Namespace: "myns"},
+					DeploymentRecord:      core.DeploymentRecord{EnvironmentName: "myenv", RiserRevision: 1},
+				},
+			}, nil
+		},
+	}
+
+	revisionRepository := &core.FakeDeploymentRevisionRepository{
+		GetByRevisionFn: func(name *core.NamespacedName, envName string, riserRevision int64) (*core.DeploymentRevision, error) {
+			assert.Equal(t, "myenv", envName)
+			if name.Name == "old" {
+				return nil, core.ErrNotFound
+			}
+			return newRenderTestRevision(name.Name, riserRevision), nil
+		},
+	}
+
+	environmentRepository := &core.FakeEnvironmentRepository{
+		GetFn: func(string) (*core.Environment, error) {
+			return &core.Environment{}, nil
+		},
+	}
+
+	secretRepository := &core.FakeSecretMetaRepository{
+		ListByAppInEnvironmentFn: func(*core.NamespacedName, string) ([]core.SecretMeta, error) {
+			return []core.SecretMeta{}, nil
+		},
+	}
+
+	service := service{
+		revisions:    revisionRepository,
+		deployments:  deploymentRepository,
+		environments: environmentRepository,
+		secrets:      secretRepository,
+	}
+
+	files, skipped, err := service.RenderEnvironment("myenv")
+
+	require.NoError(t, err)
+	assert.Equal(t, []core.NamespacedName{*core.NewNamespacedName("old", "myns")}, skipped)
+	// app1 uses its last rendered config instead of the revision history
+	assert.Equal(t, 2, revisionRepository.GetByRevisionCallCount)
+	fileContents := map[string]string{}
+	for _, file := range files {
+		fileContents[file.Name] = string(file.Contents)
+	}
+	assert.Contains(t, fileContents["state/riser-managed/myns/deployments/app1/serving.knative.dev.configuration.app1.yaml"], "image: myimage:v2")
+	assert.Contains(t, fileContents["state/riser-managed/myns/deployments/app1/serving.knative.dev.route.app1.yaml"], "revisionName: app1-2")
+	assert.Contains(t, fileContents, "state/riser-managed/myns/deployments/app2/serving.knative.dev.configuration.app2.yaml")
+	assert.Contains(t, fileContents, "state/riser-managed/namespace.myns.yaml")
+}
+
+func Test_renderDeployment_DeletesRetiredRevisions(t *testing.T) {
+	newContext := func(riserRevision int64) *core.DeploymentContext {
+		app := newRenderTestRevision("myapp", riserRevision).Doc.App
+		app.Autoscale = &model.AppConfigAutoscale{Max: util.PtrInt(2)}
+		return &core.DeploymentContext{
+			DeploymentConfig: &core.DeploymentConfig{
+				Name:            "myapp",
+				Namespace:       "myns",
+				EnvironmentName: "myenv",
+				App:             app,
+				Traffic:         core.TrafficConfig{{RiserRevision: riserRevision, RevisionName: fmt.Sprintf("myapp-%d", riserRevision), Percent: 100}},
+			},
+			EnvironmentConfig: &core.EnvironmentConfig{Renderer: model.EnvironmentRenderer_Kubernetes},
+			RiserRevision:     riserRevision,
+		}
+	}
+	retiredFiles, err := renderDeployment(newContext(1))
+	require.NoError(t, err)
+	ctx := newContext(2)
+	ctx.RetiredRevisions = []int64{1}
+
+	files, err := renderDeployment(ctx)
+
+	require.NoError(t, err)
+	// Every resource that was rendered for the retired revision is deleted
+	expected := []string{}
+	for _, file := range retiredFiles {
+		if strings.HasSuffix(file.Name, ".myapp-1.yaml") {
+			expected = append(expected, file.Name)
+		}
+	}
+	deleted := []string{}
+	for _, file := range files {
+		if file.Delete {
+			deleted = append(deleted, file.Name)
+		}
+	}
+	assert.Len(t, expected, 3)
+	assert.ElementsMatch(t, expected, deleted)
+}
+
+// An app that calls an app with access is identified by its service account even when it does not set access itself
+func Test_renderDeployment_CallerWithoutAccess(t *testing.T) {
+	newContext := func(app *model.AppConfig) *core.DeploymentContext {
+		return &core.DeploymentContext{
+			DeploymentConfig: &core.DeploymentConfig{
+				Name:            string(app.Name),
+				Namespace:       "myns",
+				EnvironmentName: "myenv",
+				App:             app,
+				Traffic:         core.TrafficConfig{{RiserRevision: 1, RevisionName: fmt.Sprintf("%s-1", app.Name), Percent: 100}},
+			},
+			EnvironmentConfig: &core.EnvironmentConfig{},
+			RiserRevision:     1,
+		}
+	}
+	callee := newRenderTestRevision("callee", 1).Doc.App
+	callee.Access = &model.AppConfigAccess{From: []model.AppConfigAccessSource{{App: "caller"}}}
+	caller := newRenderTestRevision("caller", 1).Doc.App
+
+	calleeFiles, err := renderDeployment(newContext(callee))
+	require.NoError(t, err)
+	callerFiles, err := renderDeployment(newContext(caller))
+	require.NoError(t, err)
+
+	contents := func(files []core.ResourceFile, name string) string {
+		for _, file := range files {
+			if file.Name == name {
+				return string(file.Contents)
+			}
+		}
+		require.Failf(t, "file not rendered", name)
+		return ""
+	}
+	assert.Contains(t, contents(calleeFiles, "state/riser-managed/myns/deployments/callee/security.istio.io.authorizationpolicy.callee-access.yaml"), "- '*/ns/myns/sa/caller'")
+	assert.Contains(t, contents(callerFiles, "state/riser-managed/myns/deployments/caller/serving.knative.dev.configuration.caller.yaml"), "serviceAccountName: caller")
+	assert.Contains(t, contents(callerFiles, "state/riser-managed/myns/serviceaccounts/serviceaccount.caller.yaml"), "name: caller")
+}
+
+func Test_Update_RecordsRenderedConfig(t *testing.T) {
+	appId := uuid.New()
+	deploymentConfig := &core.DeploymentConfig{
+		Name:            "myapp",
+		Namespace:       "myns",
+		EnvironmentName: "myenv",
+		Docker:          core.DeploymentDocker{Tag: "v1"},
+		App:             newRenderTestRevision("myapp", 1).Doc.App,
+	}
+	deploymentConfig.App.Id = appId
+	deploymentConfig.App.Domains = []string{"myapp.example.com"}
+
+	domainClaimService := &domainclaim.FakeService{
+		ClaimFn: func(appIdArg uuid.UUID, name *core.NamespacedName, envName string, domains []string) error {
+			assert.Equal(t, appId, appIdArg)
+			assert.Equal(t, core.NewNamespacedName("myapp", "myns"), name)
+			assert.Equal(t, "myenv", envName)
+			assert.Equal(t, []string{"myapp.example.com"}, domains)
+			return nil
+		},
+		ReleaseUnusedClaimsFn: func(name *core.NamespacedName, envName string, domains []string) error {
+			assert.Equal(t, core.NewNamespacedName("myapp", "myns"), name)
+			assert.Equal(t, "myenv", envName)
+			assert.Equal(t, []string{"myapp.example.com"}, domains)
+			return nil
+		},
+	}
+
 	committer := state.NewDryRunCommitter()
+	deploymentRepository := &core.FakeDeploymentRepository{
+		GetByReservationFn: func(uuid.UUID, string) (*core.Deployment, error) {
+			return nil, core.ErrNotFound
+		},
+		CreateFn: func(*core.DeploymentRecord) error {
+			return nil
+		},
+		UpdateRenderedConfigFn: func(name *core.NamespacedName, envName string, riserRevision int64, config *core.DeploymentConfig) error {
+			assert.Equal(t, core.NewNamespacedName("myapp", "myns"), name)
+			assert.Equal(t, "myenv", envName)
+			assert.EqualValues(t, 1, riserRevision)
+			assert.Equal(t, deploymentConfig, config)
+			assert.Empty(t, committer.Commits, "The rendered config must be recorded before committing")
+			return nil
+		},
+	}
+
+	service := service{
+		revisions: &core.FakeDeploymentRevisionRepository{
+			SaveFn: func(*core.NamespacedName, string, *core.DeploymentRevision) error {
+				assert.Empty(t, committer.Commits, "The revision must be saved before committing")
+				return nil
+			},
+		},
+		reservationService: &deploymentreservation.FakeService{
+			EnsureReservationFn: func(uuid.UUID, *core.NamespacedName) (*core.DeploymentReservation, error) {
+				return &core.DeploymentReservation{Id: uuid.New(), AppId: appId}, nil
+			},
+		},
+		deployments: deploymentRepository,
+		environments: &core.FakeEnvironmentRepository{
+			GetFn: func(string) (*core.Environment, error) {
+				return &core.Environment{
+					Doc: core.EnvironmentDoc{Config: core.EnvironmentConfig{CertificateIssuer: "letsencrypt"}},
+				}, nil
+			},
+		},
+		secrets: &core.FakeSecretMetaRepository{
+			ListByAppInEnvironmentFn: func(*core.NamespacedName, string) ([]core.SecretMeta, error) {
+				return []core.SecretMeta{}, nil
+			},
+		},
+		domainClaimService: domainClaimService,
+	}
+
+	_, err := service.Update(deploymentConfig, committer, false)
+
+	assert.NoError(t, err)
+	assert.Len(t, committer.Commits, 1)
+	assert.Equal(t, 1, deploymentRepository.UpdateRenderedConfigCallCount)
+	assert.Equal(t, 1, domainClaimService.ClaimCallCount)
+	assert.Equal(t, 1, domainClaimService.ReleaseUnusedClaimsCallCount)
+}
+
+func Test_Update_RecordErr_RollsBackRevision(t *testing.T) {
+	deploymentConfig := newUpdateTestDeploymentConfig()
+	committer := state.NewDryRunCommitter()
+	deploymentRepository := &core.FakeDeploymentRepository{
+		GetByReservationFn: func(uuid.UUID, string) (*core.Deployment, error) {
+			return nil, core.ErrNotFound
+		},
+		CreateFn: func(*core.DeploymentRecord) error {
+			return nil
+		},
+		UpdateRenderedConfigFn: func(*core.NamespacedName, string, int64, *core.DeploymentConfig) error {
+			return errors.New("broke")
+		},
+		RollbackRevisionFn: func(name *core.NamespacedName, envName string, failedRevision int64) (int64, error) {
+			assert.EqualValues(t, 1, failedRevision)
+			return 0, nil
+		},
+	}
+	service := newUpdateTestService(deploymentConfig.App.Id, deploymentRepository, &domainclaim.FakeService{}, &core.FakeRolloutRepository{})
+
+	_, err := service.Update(deploymentConfig, committer, false)
+
+	assert.Equal(t, `Error recording the rendered config for deployment "myapp" in environment "myenv": broke`, err.Error())
+	assert.Equal(t, 1, deploymentRepository.RollbackRevisionCallCount)
+	assert.Empty(t, committer.Commits)
+}
+
+func Test_Update_PostCommitErrs_ReturnsRevision(t *testing.T) {
+	deploymentConfig := newUpdateTestDeploymentConfig()
+	deploymentConfig.Rollout = &core.RolloutStrategy{Steps: []int{50}}
+	committer := state.NewDryRunCommitter()
+	deploymentRepository := &core.FakeDeploymentRepository{
+		GetByReservationFn: func(uuid.UUID, string) (*core.Deployment, error) {
+			return &core.Deployment{
+				DeploymentReservation: core.DeploymentReservation{AppId: deploymentConfig.App.Id},
+				DeploymentRecord: core.DeploymentRecord{
+					RiserRevision: 1,
+					Doc: core.DeploymentDoc{
+						Traffic: core.TrafficConfig{{RiserRevision: 1, RevisionName: "myapp-1", Percent: 100}},
+					},
+				},
+			}, nil
+		},
+		IncrementRevisionFn: func(*core.NamespacedName, string) (int64, error) {
+			return 2, nil
+		},
+		UpdateTrafficFn: func(*core.NamespacedName, string, int64, core.TrafficConfig) error {
+			return nil
+		},
+		UpdateRenderedConfigFn: func(*core.NamespacedName, string, int64, *core.DeploymentConfig) error {
+			return nil
+		},
+	}
+	domainClaimService := &domainclaim.FakeService{
+		ReleaseUnusedClaimsFn: func(*core.NamespacedName, string, []string) error {
+			return errors.New("claims broke")
+		},
+	}
+	rolloutRepository := &core.FakeRolloutRepository{
+		SaveFn: func(*core.Rollout) error {
+			return errors.New("rollout broke")
+		},
+	}
+	service := newUpdateTestService(deploymentConfig.App.Id, deploymentRepository, domainClaimService, rolloutRepository)
+	logger, hook := logtest.NewNullLogger()
+	service.logger = logger
+
+	riserRevision, err := service.Update(deploymentConfig, committer, false)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, riserRevision)
+	assert.Len(t, committer.Commits, 1)
+	assert.Equal(t, 0, deploymentRepository.RollbackRevisionCallCount)
+	assert.Equal(t, 1, domainClaimService.ReleaseUnusedClaimsCallCount)
+	assert.Equal(t, 1, rolloutRepository.SaveCallCount)
+	require.Len(t, hook.AllEntries(), 2)
+	assert.Equal(t, "Error releasing unused domain claims", hook.AllEntries()[0].Message)
+	assert.Equal(t, "Error starting rollout", hook.AllEntries()[1].Message)
+}
+
+func newUpdateTestDeploymentConfig() *core.DeploymentConfig {
+	deploymentConfig := &core.DeploymentConfig{
+		Name:            "myapp",
+		Namespace:       "myns",
+		EnvironmentName: "myenv",
+		Docker:          core.DeploymentDocker{Tag: "v1"},
+		App:             newRenderTestRevision("myapp", 1).Doc.App,
+	}
+	deploymentConfig.App.Id = uuid.New()
+	return deploymentConfig
+}
+
+func newUpdateTestService(appId uuid.UUID, deployments core.DeploymentRepository, domainClaimService domainclaim.Service, rollouts core.RolloutRepository) *service {
+	return &service{
+		revisions: &core.FakeDeploymentRevisionRepository{
+			SaveFn: func(*core.NamespacedName, string, *core.DeploymentRevision) error {
+				return nil
+			},
+		},
+		reservationService: &deploymentreservation.FakeService{
+			EnsureReservationFn: func(uuid.UUID, *core.NamespacedName) (*core.DeploymentReservation, error) {
+				return &core.DeploymentReservation{Id: uuid.New(), AppId: appId}, nil
+			},
+		},
+		deployments: deployments,
+		environments: &core.FakeEnvironmentRepository{
+			GetFn: func(string) (*core.Environment, error) {
+				return &core.Environment{}, nil
+			},
+		},
+		secrets: &core.FakeSecretMetaRepository{
+			ListByAppInEnvironmentFn: func(*core.NamespacedName, string) ([]core.SecretMeta, error) {
+				return []core.SecretMeta{}, nil
+			},
+		},
+		domainClaimService: domainClaimService,
+		rollouts:           rollouts,
+	}
+}
+
+func Test_Update_UnsupportedByRenderer_RollsBackRevision(t *testing.T) {
+	appId := uuid.New()
+	deploymentConfig := &core.DeploymentConfig{
+		Name:            "myapp",
+		Namespace:       "myns",
+		EnvironmentName: "myenv",
+		Docker:          core.DeploymentDocker{Tag: "v1"},
+		App:             newRenderTestRevision("myapp", 1).Doc.App,
+	}
+	deploymentConfig.App.Id = appId
+	deploymentConfig.App.HealthCheck = &model.AppConfigHealthCheck{
+		Path:    "/health",
+		Startup: &model.AppConfigProbe{Path: "/health"},
+	}
+
+	rolledBack := false
+	deploymentRepository := &core.FakeDeploymentRepository{
+		GetByReservationFn: func(uuid.UUID, string) (*core.Deployment, error) {
+			return nil, core.ErrNotFound
+		},
+		CreateFn: func(*core.DeploymentRecord) error {
+			return nil
+		},
+		RollbackRevisionFn: func(name *core.NamespacedName, envName string, failedRevision int64) (int64, error) {
+			rolledBack = true
+			assert.Equal(t, core.NewNamespacedName("myapp", "myns"), name)
+			assert.Equal(t, "myenv", envName)
+			assert.EqualValues(t, 1, failedRevision)
+			return 0, nil
+		},
+	}
+	committer := state.NewDryRunCommitter()
+
+	service := service{
+		reservationService: &deploymentreservation.FakeService{
+			EnsureReservationFn: func(uuid.UUID, *core.NamespacedName) (*core.DeploymentReservation, error) {
+				return &core.DeploymentReservation{Id: uuid.New(), AppId: appId}, nil
+			},
+		},
+		deployments: deploymentRepository,
+		environments: &core.FakeEnvironmentRepository{
+			GetFn: func(string) (*core.Environment, error) {
+				return &core.Environment{}, nil
+			},
+		},
+		secrets: &core.FakeSecretMetaRepository{
+			ListByAppInEnvironmentFn: func(*core.NamespacedName, string) ([]core.SecretMeta, error) {
+				return []core.SecretMeta{}, nil
+			},
+		},
+	}
+
+	_, err := service.Update(deploymentConfig, committer, false)
+
+	assert.IsType(t, &core.ValidationError{}, err)
+	assert.Equal(t, "The app config is not supported by the environment: healthcheck.startup: is not supported by KNative.", err.Error())
+	assert.True(t, rolledBack)
+	assert.Empty(t, committer.Commits)
+}
 
-	service := service{deployments: deploymentRepository}
+func Test_Update_DryRun_UnsupportedByRenderer_DoesNotRollBackRevision(t *testing.T) {
+	appId := uuid.New()
+	deploymentConfig := &core.DeploymentConfig{
+		Name:            "myapp",
+		Namespace:       "myns",
+		EnvironmentName: "myenv",
+		Docker:          core.DeploymentDocker{Tag: "v1"},
+		App:             newRenderTestRevision("myapp", 1).Doc.App,
+	}
+	deploymentConfig.App.Id = appId
+	deploymentConfig.App.HealthCheck = &model.AppConfigHealthCheck{
+		Path:    "/health",
+		Startup: &model.AppConfigProbe{Path: "/health"},
+	}
+
+	service := service{
+		reservationService: &deploymentreservation.FakeService{
+			EnsureReservationFn: func(uuid.UUID, *core.NamespacedName) (*core.DeploymentReservation, error) {
+				return &core.DeploymentReservation{Id: uuid.New(), AppId: appId}, nil
+			},
+		},
+		deployments: &core.FakeDeploymentRepository{
+			GetByReservationFn: func(uuid.UUID, string) (*core.Deployment, error) {
+				return &core.Deployment{
+					DeploymentReservation: core.DeploymentReservation{AppId: appId},
+					DeploymentRecord:      core.DeploymentRecord{RiserRevision: 2},
+				}, nil
+			},
+		},
+		environments: &core.FakeEnvironmentRepository{
+			GetFn: func(string) (*core.Environment, error) {
+				return &core.Environment{}, nil
+			},
+		},
+		secrets: &core.FakeSecretMetaRepository{
+			ListByAppInEnvironmentFn: func(*core.NamespacedName, string) ([]core.SecretMeta, error) {
+				return []core.SecretMeta{}, nil
+			},
+		},
+	}
 
-	err := service.Delete(name, "myenv", committer)
+	// The fake panics if the revision is rolled back
+	_, err := service.Update(deploymentConfig, state.NewDryRunCommitter(), true)
 
-	assert.NoError(t, err)
-	assert.Equal(t, 1, deploymentRepository.DeleteCallCount)
-	assert.Len(t, committer.Commits, 1)
-	assert.Equal(t, `Deleting deployment "mydep.apps"`, committer.Commits[0].Message)
-	assert.Len(t, committer.Commits[0].Files, 2)
-	assert.Equal(t, "state/riser-managed/apps/deployments/mydep", committer.Commits[0].Files[0].Name)
-	assert.True(t, committer.Commits[0].Files[0].Delete)
-	assert.Equal(t, "riser-config/apps/mydep.yaml", committer.Commits[0].Files[1].Name)
-	assert.True(t, committer.Commits[0].Files[1].Delete)
+	assert.IsType(t, &core.ValidationError{}, err)
 }
 
-func Test_Delete_SoftDeleteFails(t *testing.T) {
+func Test_RenderEnvironment_Kubernetes(t *testing.T) {
 	deploymentRepository := &core.FakeDeploymentRepository{
-		DeleteFn: func(*core.NamespacedName, string) error {
-			return errors.New("test")
+		FindByEnvironmentFn: func(envName string) ([]core.Deployment, error) {
+			return []core.Deployment{
+				{
+					DeploymentReservation: core.DeploymentReservation{Name: "app1", Namespace: "myns"},
+					DeploymentRecord: core.DeploymentRecord{
+						EnvironmentName: "myenv",
+						RiserRevision:   3,
+						Doc: core.DeploymentDoc{
+							Traffic: core.TrafficConfig{
+								{RiserRevision: 3, RevisionName: "app1-3", Percent: 90},
+								{RiserRevision: 2, RevisionName: "app1-2", Percent: 10},
+							},
+						},
+					},
+				},
+				{
+					DeploymentReservation: core.DeploymentReservation{Name: "app2", Namespace: "myns"},
+					DeploymentRecord: core.DeploymentRecord{
+						EnvironmentName: "myenv",
+						RiserRevision:   2,
+						Doc: core.DeploymentDoc{
+							Traffic: core.TrafficConfig{
+								{RiserRevision: 2, RevisionName: "app2-2", Percent: 90},
+								{RiserRevision: 1, RevisionName: "app2-1", Percent: 10},
+							},
+						},
+					},
+				},
+			}, nil
 		},
 	}
 
-	committer := state.NewDryRunCommitter()
+	revisionRepository := &core.FakeDeploymentRevisionRepository{
+		GetByRevisionFn: func(name *core.NamespacedName, envName string, riserRevision int64) (*core.DeploymentRevision, error) {
+			assert.Equal(t, "myenv", envName)
+			revision := newRenderTestRevision(name.Name, riserRevision)
+			revision.Doc.Docker.Tag = fmt.Sprintf("v%d", riserRevision)
+			// app2 revision 1 was deployed before its secrets were recorded
+			if name.Name == "app1" {
+				revision.Doc.Secrets = []core.DeploymentRevisionSecret{{Name: "mysecret", Revision: riserRevision}}
+			}
+			return revision, nil
+		},
+	}
 
-	service := service{deployments: deploymentRepository}
+	environmentRepository := &core.FakeEnvironmentRepository{
+		GetFn: func(string) (*core.Environment, error) {
+			return &core.Environment{
+				Doc: core.EnvironmentDoc{Config: core.EnvironmentConfig{Renderer: model.EnvironmentRenderer_Kubernetes}},
+			}, nil
+		},
+	}
+
+	secretRepository := &core.FakeSecretMetaRepository{
+		ListByAppInEnvironmentFn: func(*core.NamespacedName, string) ([]core.SecretMeta, error) {
+			return []core.SecretMeta{{Name: "mysecret", Revision: 3}}, nil
+		},
+	}
 
-	err := service.Delete(core.NewNamespacedName("mydep", "myns"), "myenv", committer)
+	service := service{
+		revisions:    revisionRepository,
+		deployments:  deploymentRepository,
+		environments: environmentRepository,
+		secrets:      secretRepository,
+	}
 
-	assert.Equal(t, "error deleting deployment: test", err.Error())
+	files, skipped, err := service.RenderEnvironment("myenv")
+
+	require.NoError(t, err)
+	assert.Equal(t, []core.NamespacedName{*core.NewNamespacedName("app2", "myns")}, skipped)
+	fileContents := map[string]string{}
+	for _, file := range files {
+		fileContents[file.Name] = string(file.Contents)
+	}
+	assert.Contains(t, fileContents["state/riser-managed/myns/deployments/app1/apps.deployment.app1-3.yaml"], "image: myimage:v3")
+	assert.Contains(t, fileContents["state/riser-managed/myns/deployments/app1/apps.deployment.app1-3.yaml"], "name: app1-mysecret-3")
+	// The previous revision is rendered with the config and secrets that it was deployed with
+	assert.Contains(t, fileContents["state/riser-managed/myns/deployments/app1/apps.deployment.app1-2.yaml"], "image: myimage:v2")
+	assert.Contains(t, fileContents["state/riser-managed/myns/deployments/app1/apps.deployment.app1-2.yaml"], "name: app1-mysecret-2")
+	assert.Contains(t, fileContents, "state/riser-managed/myns/deployments/app1/service.app1-2.yaml")
+	assert.Contains(t, fileContents, "state/riser-managed/myns/deployments/app1/service.app1.yaml")
+	assert.Contains(t, fileContents["state/riser-managed/myns/deployments/app1/networking.istio.io.virtualservice.app1.yaml"], "host: app1-2")
+	assert.NotContains(t, fileContents, "state/riser-managed/myns/deployments/app1/serving.knative.dev.route.app1.yaml")
+	for name := range fileContents {
+		assert.NotContains(t, name, "/app2/")
+	}
 }
 
-func Test_Delete_DeploymentNotFound(t *testing.T) {
-	deploymentRepository := &core.FakeDeploymentRepository{
-		DeleteFn: func(*core.NamespacedName, string) error {
-			return core.ErrNotFound
+func Test_RenderEnvironment_FindErr(t *testing.T) {
+	service := service{
+		deployments: &core.FakeDeploymentRepository{
+			FindByEnvironmentFn: func(string) ([]core.Deployment, error) {
+				return nil, errors.New("test")
+			},
+		},
+		environments: &core.FakeEnvironmentRepository{
+			GetFn: func(string) (*core.Environment, error) {
+				return &core.Environment{}, nil
+			},
 		},
 	}
 
-	service := service{deployments: deploymentRepository}
+	_, _, err := service.RenderEnvironment("myenv")
+
+	assert.Equal(t, `Error retrieving deployments in environment "myenv": test`, err.Error())
+}
+
+func Test_FindSecretReferences(t *testing.T) {
+	deployments := []core.Deployment{
+		{
+			DeploymentReservation: core.DeploymentReservation{Name: "myapp-mydep", Namespace: "myns"},
+			DeploymentRecord: core.DeploymentRecord{
+				RiserRevision: 3,
+				Doc: core.DeploymentDoc{
+					Traffic: core.TrafficConfig{{RiserRevision: 2, Percent: 50}, {RiserRevision: 3, Percent: 50}},
+					Status: &core.DeploymentStatus{
+						Revisions: []core.DeploymentRevisionStatus{{RiserRevision: 1}, {RiserRevision: 2}},
+					},
+				},
+			},
+		},
+		{
+			DeploymentReservation: core.DeploymentReservation{Name: "legacy", Namespace: "legacyns"},
+			DeploymentRecord:      core.DeploymentRecord{RiserRevision: 1},
+		},
+	}
+	revisions := map[string]*core.DeploymentRevision{
+		"myapp-mydep/1": {Doc: core.DeploymentRevisionDoc{App: &model.AppConfig{Name: "myapp"}, Secrets: []core.DeploymentRevisionSecret{{Name: "s1", Revision: 1}}}},
+		"myapp-mydep/2": {Doc: core.DeploymentRevisionDoc{App: &model.AppConfig{Name: "myapp"}, Secrets: []core.DeploymentRevisionSecret{}}},
+		"myapp-mydep/3": {Doc: core.DeploymentRevisionDoc{App: &model.AppConfig{Name: "myapp"}, Secrets: []core.DeploymentRevisionSecret{{Name: "s1", Revision: 2}}}},
+		// Deployed before secrets were recorded
+		"legacy/1": {Doc: core.DeploymentRevisionDoc{App: &model.AppConfig{Name: "legacy"}}},
+	}
+	service := service{
+		deployments: &core.FakeDeploymentRepository{
+			FindByEnvironmentFn: func(envName string) ([]core.Deployment, error) {
+				assert.Equal(t, "myenv", envName)
+				return deployments, nil
+			},
+		},
+		revisions: &core.FakeDeploymentRevisionRepository{
+			GetByRevisionFn: func(name *core.NamespacedName, envName string, riserRevision int64) (*core.DeploymentRevision, error) {
+				return revisions[fmt.Sprintf("%s/%d", name.Name, riserRevision)], nil
+			},
+		},
+	}
 
-	err := service.Delete(core.NewNamespacedName("mydep", "myns"), "myenv", nil)
+	result, err := service.FindSecretReferences("myenv")
 
-	assert.Equal(t, `There is no deployment by the name "mydep.myns" in environment "myenv"`, err.Error())
-	assert.IsType(t, &core.ValidationError{}, err)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []core.SecretMeta{
+		{Name: "s1", App: core.NewNamespacedName("myapp", "myns"), EnvironmentName: "myenv", Revision: 1},
+		{Name: "s1", App: core.NewNamespacedName("myapp", "myns"), EnvironmentName: "myenv", Revision: 2},
+	}, result.Secrets)
+	assert.Equal(t, []string{"legacyns"}, result.UnknownNamespaces)
+}
+
+func Test_FindSecretReferences_RevisionNotFound(t *testing.T) {
+	service := service{
+		deployments: &core.FakeDeploymentRepository{
+			FindByEnvironmentFn: func(string) ([]core.Deployment, error) {
+				return []core.Deployment{{
+					DeploymentReservation: core.DeploymentReservation{Name: "myapp", Namespace: "myns"},
+					DeploymentRecord:      core.DeploymentRecord{RiserRevision: 1},
+				}}, nil
+			},
+		},
+		revisions: &core.FakeDeploymentRevisionRepository{
+			GetByRevisionFn: func(*core.NamespacedName, string, int64) (*core.DeploymentRevision, error) {
+				return nil, core.ErrNotFound
+			},
+		},
+	}
+
+	result, err := service.FindSecretReferences("myenv")
+
+	require.NoError(t, err)
+	assert.Empty(t, result.Secrets)
+	assert.Equal(t, []string{"myns"}, result.UnknownNamespaces)
+}
+
+func Test_saveRevision(t *testing.T) {
+	deployment := &core.DeploymentConfig{
+		Name:            "myapp-mydep",
+		Namespace:       "myns",
+		EnvironmentName: "myenv",
+		Docker:          core.DeploymentDocker{Tag: "v1"},
+		App: &model.AppConfig{
+			Name: "myapp",
+		},
+		Traffic:    core.TrafficConfig{{RiserRevision: 3, RevisionName: "myapp-mydep-3", Percent: 100}},
+		DeployedBy: "myuser",
+	}
+
+	revisionRepository := &core.FakeDeploymentRevisionRepository{
+		SaveFn: func(name *core.NamespacedName, envName string, revision *core.DeploymentRevision) error {
+			assert.Equal(t, core.NewNamespacedName("myapp-mydep", "myns"), name)
+			assert.Equal(t, "myenv", envName)
+			assert.NotEqual(t, uuid.Nil, revision.Id)
+			assert.EqualValues(t, 3, revision.RiserRevision)
+			assert.Equal(t, deployment.Docker, revision.Doc.Docker)
+			assert.Equal(t, deployment.App, revision.Doc.App)
+			assert.Equal(t, deployment.Traffic, revision.Doc.Traffic)
+			assert.Equal(t, "myuser", revision.Doc.DeployedBy)
+			assert.InDelta(t, time.Now().UTC().Unix(), revision.Doc.Created.Unix(), 3)
+			assert.Equal(t, []core.DeploymentRevisionSecret{{Name: "mysecret", Revision: 2}}, revision.Doc.Secrets)
+			return nil
+		},
+	}
+
+	service := service{revisions: revisionRepository}
+
+	err := service.saveRevision(deployment, 3, []core.SecretMeta{{Name: "mysecret", Revision: 2}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, revisionRepository.SaveCallCount)
+}
+
+func Test_saveRevision_ReturnsErr(t *testing.T) {
+	deployment := &core.DeploymentConfig{
+		Name:            "myapp-mydep",
+		Namespace:       "myns",
+		EnvironmentName: "myenv",
+	}
+
+	revisionRepository := &core.FakeDeploymentRevisionRepository{
+		SaveFn: func(*core.NamespacedName, string, *core.DeploymentRevision) error {
+			return errors.New("test")
+		},
+	}
+
+	service := service{revisions: revisionRepository}
+
+	err := service.saveRevision(deployment, 3, nil)
+
+	assert.Equal(t, `Error saving revision 3 for deployment "myapp-mydep" in environment "myenv": test`, err.Error())
+}
+
+func Test_startRollout(t *testing.T) {
+	deployment := &core.DeploymentConfig{
+		Name:            "myapp-mydep",
+		Namespace:       "myns",
+		EnvironmentName: "myenv",
+		Traffic: core.TrafficConfig{
+			{RiserRevision: 3, RevisionName: "myapp-mydep-3", Percent: 0},
+			{RiserRevision: 2, RevisionName: "myapp-mydep-2", Percent: 100},
+		},
+		Rollout: &core.RolloutStrategy{Steps: []int{10, 100}, Pause: time.Minute},
+	}
+
+	rolloutRepository := &core.FakeRolloutRepository{
+		SaveFn: func(rollout *core.Rollout) error {
+			assert.Equal(t, core.NewNamespacedName("myapp-mydep", "myns"), rollout.Name)
+			assert.Equal(t, "myenv", rollout.EnvironmentName)
+			assert.EqualValues(t, 3, rollout.RiserRevision)
+			assert.Equal(t, core.RolloutStatusInProgress, rollout.Status)
+			assert.InDelta(t, time.Now().UTC().Unix(), rollout.NextStepAt.Unix(), 3)
+			assert.Equal(t, *deployment.Rollout, rollout.Doc.Strategy)
+			assert.Equal(t, deployment.Traffic[1:], rollout.Doc.PreviousTraffic)
+			assert.Equal(t, -1, rollout.Doc.CurrentStep)
+			return nil
+		},
+	}
+
+	service := service{rollouts: rolloutRepository}
+
+	err := service.startRollout(deployment, 3)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, rolloutRepository.SaveCallCount)
+}
+
+// A new deployment receives 100% of traffic so there is nothing to roll out
+func Test_startRollout_NoPreviousTraffic(t *testing.T) {
+	deployment := &core.DeploymentConfig{
+		Name:    "myapp-mydep",
+		Traffic: core.TrafficConfig{{RiserRevision: 1, RevisionName: "myapp-mydep-1", Percent: 100}},
+		Rollout: &core.RolloutStrategy{Steps: []int{10, 100}, Pause: time.Minute},
+	}
+
+	rolloutRepository := &core.FakeRolloutRepository{}
+
+	service := service{rollouts: rolloutRepository}
+
+	err := service.startRollout(deployment, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, rolloutRepository.SaveCallCount)
 }
 
 func Test_prepareForDeployment_whenNewDeploymentCreates(t *testing.T) {
@@ -116,7 +1197,7 @@ func Test_prepareForDeployment_whenNewDeploymentCreates(t *testing.T) {
 	}
 
 	service := service{deployments: deploymentRepository, reservationService: reservationService}
-	result, err := service.prepareForDeployment(deployment, false)
+	result, _, err := service.prepareForDeployment(deployment, false)
 
 	assert.NoError(t, err)
 	assert.Equal(t, "myns", deployment.Namespace)
@@ -157,7 +1238,10 @@ func Test_prepareForDeployment_whenExistingDeployment(t *testing.T) {
 				DeploymentRecord: core.DeploymentRecord{
 					Id:              deploymentId,
 					ReservationId:   reservation.Id,
-					EnvironmentName: "myenv"}}, nil
+					EnvironmentName: "myenv",
+					Doc: core.DeploymentDoc{
+						Traffic: core.TrafficConfig{{RiserRevision: 2, RevisionName: "myapp-mydep-2", Percent: 100}},
+					}}}, nil
 		},
 		IncrementRevisionFn: func(name *core.NamespacedName, envName string) (int64, error) {
 			assert.Equal(t, "myapp-mydep", name.Name)
@@ -178,10 +1262,11 @@ func Test_prepareForDeployment_whenExistingDeployment(t *testing.T) {
 	}
 
 	service := service{deployments: deploymentRepository, reservationService: reservationService}
-	result, err := service.prepareForDeployment(deployment, false)
+	result, retiredRevisions, err := service.prepareForDeployment(deployment, false)
 
 	assert.NoError(t, err)
 	assert.Equal(t, int64(3), result)
+	assert.Equal(t, []int64{2}, retiredRevisions)
 	assert.Equal(t, 1, deploymentRepository.GetByReservationCallCount)
 	assert.Equal(t, 1, deploymentRepository.IncrementRevisionCallCount)
 	assert.Equal(t, 1, deploymentRepository.UpdateTrafficCallCount)
@@ -262,10 +1347,11 @@ func Test_prepareForDeployment_manualRollout_previouslyDeletedDeployment(t *test
 	}
 
 	service := service{deployments: deploymentRepository, reservationService: reservationService}
-	result, err := service.prepareForDeployment(deployment, false)
+	result, retiredRevisions, err := service.prepareForDeployment(deployment, false)
 
 	assert.NoError(t, err)
 	assert.Equal(t, int64(3), result)
+	assert.Empty(t, retiredRevisions)
 	assert.Equal(t, 1, deploymentRepository.GetByReservationCallCount)
 	assert.Equal(t, 1, deploymentRepository.IncrementRevisionCallCount)
 	assert.Equal(t, 1, deploymentRepository.UpdateTrafficCallCount)
@@ -311,7 +1397,7 @@ func Test_prepareForDeployment_whenIncrementRevisionFails(t *testing.T) {
 	}
 
 	service := service{deployments: deploymentRepository, reservationService: reservationService}
-	result, err := service.prepareForDeployment(deployment, false)
+	result, _, err := service.prepareForDeployment(deployment, false)
 
 	assert.Zero(t, result)
 	assert.Equal(t, "Error incrementing deployment revision: test", err.Error())
@@ -348,27 +1434,59 @@ func Test_prepareForDeployment_doesNotUpdateWhenDryRun(t *testing.T) {
 				DeploymentRecord: core.DeploymentRecord{
 					Id:              deploymentId,
 					ReservationId:   reservation.Id,
-					EnvironmentName: "myenv"}}, nil
+					EnvironmentName: "myenv",
+					RiserRevision:   2}}, nil
 		},
 	}
 
 	service := service{deployments: deploymentRepository, reservationService: reservationService}
-	result, err := service.prepareForDeployment(deployment, true)
+	result, _, err := service.prepareForDeployment(deployment, true)
 
 	assert.NoError(t, err)
-	// The RiserRevision is always "0" for a dry-run
-	assert.Equal(t, int64(0), result)
+	// A dry run renders the next revision without incrementing it
+	assert.Equal(t, int64(3), result)
 	assert.Equal(t, 1, deploymentRepository.GetByReservationCallCount)
 	assert.Equal(t, 0, deploymentRepository.IncrementRevisionCallCount)
 	assert.Equal(t, 0, deploymentRepository.UpdateTrafficCallCount)
 	assert.Equal(t, 0, deploymentRepository.CreateCallCount)
 	// Traffic should still be computed in a dry-run, just not persisted
 	assert.Len(t, deployment.Traffic, 1)
-	assert.Equal(t, int64(0), deployment.Traffic[0].RiserRevision)
-	assert.Equal(t, "myapp-mydep-0", deployment.Traffic[0].RevisionName)
+	assert.Equal(t, int64(3), deployment.Traffic[0].RiserRevision)
+	assert.Equal(t, "myapp-mydep-3", deployment.Traffic[0].RevisionName)
 	assert.Equal(t, 100, deployment.Traffic[0].Percent)
 }
 
+func Test_prepareForDeployment_DryRun_NewDeployment(t *testing.T) {
+	deployment := &core.DeploymentConfig{
+		Name:            "myapp-mydep",
+		EnvironmentName: "myenv",
+		App: &model.AppConfig{
+			Id:   uuid.New(),
+			Name: "myapp",
+		},
+	}
+
+	reservationService := &deploymentreservation.FakeService{
+		EnsureReservationFn: func(uuid.UUID, *core.NamespacedName) (*core.DeploymentReservation, error) {
+			return &core.DeploymentReservation{Id: uuid.New(), AppId: deployment.App.Id}, nil
+		},
+	}
+
+	deploymentRepository := &core.FakeDeploymentRepository{
+		GetByReservationFn: func(uuid.UUID, string) (*core.Deployment, error) {
+			return nil, core.ErrNotFound
+		},
+	}
+
+	service := service{deployments: deploymentRepository, reservationService: reservationService}
+	result, _, err := service.prepareForDeployment(deployment, true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), result)
+	assert.Equal(t, 0, deploymentRepository.CreateCallCount)
+	assert.Equal(t, "myapp-mydep-1", deployment.Traffic[0].RevisionName)
+}
+
 func Test_prepareForDeployment_whenUpdateTrafficFails(t *testing.T) {
 	deployment := &core.DeploymentConfig{
 		Name:            "myapp-mydep",
@@ -411,12 +1529,80 @@ func Test_prepareForDeployment_whenUpdateTrafficFails(t *testing.T) {
 	}
 
 	service := service{deployments: deploymentRepository, reservationService: reservationService}
-	result, err := service.prepareForDeployment(deployment, false)
+	result, _, err := service.prepareForDeployment(deployment, false)
 
 	assert.Zero(t, result)
 	assert.Equal(t, "Error updating traffic: broke", err.Error())
 }
 
+func Test_prepareForDeployment_whenDomainAlreadyClaimed(t *testing.T) {
+	deployment := &core.DeploymentConfig{
+		Name:            "myapp",
+		Namespace:       "myns",
+		EnvironmentName: "myenv",
+		App: &model.AppConfig{
+			Id:                    uuid.New(),
+			Name:                  "myapp",
+			OverrideableAppConfig: model.OverrideableAppConfig{Domains: []string{"myapp.example.com"}},
+		},
+	}
+
+	reservationService := &deploymentreservation.FakeService{
+		EnsureReservationFn: func(uuid.UUID, *core.NamespacedName) (*core.DeploymentReservation, error) {
+			return &core.DeploymentReservation{Id: uuid.New()}, nil
+		},
+	}
+
+	claimedErr := core.NewValidationErrorMessage("claimed")
+	domainClaimService := &domainclaim.FakeService{
+		ClaimFn: func(uuid.UUID, *core.NamespacedName, string, []string) error {
+			return claimedErr
+		},
+	}
+
+	deploymentRepository := &core.FakeDeploymentRepository{}
+
+	service := service{deployments: deploymentRepository, reservationService: reservationService, domainClaimService: domainClaimService}
+	result, _, err := service.prepareForDeployment(deployment, false)
+
+	assert.Equal(t, claimedErr, err)
+	assert.Zero(t, result)
+	assert.Equal(t, 0, deploymentRepository.GetByReservationCallCount)
+}
+
+func Test_prepareForDeployment_DryRun_DoesNotClaimDomains(t *testing.T) {
+	deployment := &core.DeploymentConfig{
+		Name:            "myapp",
+		Namespace:       "myns",
+		EnvironmentName: "myenv",
+		App: &model.AppConfig{
+			Id:                    uuid.New(),
+			Name:                  "myapp",
+			OverrideableAppConfig: model.OverrideableAppConfig{Domains: []string{"myapp.example.com"}},
+		},
+	}
+
+	reservationService := &deploymentreservation.FakeService{
+		EnsureReservationFn: func(uuid.UUID, *core.NamespacedName) (*core.DeploymentReservation, error) {
+			return &core.DeploymentReservation{Id: uuid.New()}, nil
+		},
+	}
+
+	claimedErr := core.NewValidationErrorMessage("claimed")
+	domainClaimService := &domainclaim.FakeService{
+		ValidateClaimsFn: func(uuid.UUID, *core.NamespacedName, string, []string) error {
+			return claimedErr
+		},
+	}
+
+	service := service{reservationService: reservationService, domainClaimService: domainClaimService}
+	result, _, err := service.prepareForDeployment(deployment, true)
+
+	assert.Equal(t, claimedErr, err)
+	assert.Zero(t, result)
+	assert.Equal(t, 0, domainClaimService.ClaimCallCount)
+}
+
 func Test_prepareForDeployment_whenEnsureReservationErr(t *testing.T) {
 	deployment := &core.DeploymentConfig{
 		Name:            "myapp-mydep",
@@ -434,7 +1620,7 @@ func Test_prepareForDeployment_whenEnsureReservationErr(t *testing.T) {
 	}
 
 	service := service{reservationService: reservationService}
-	result, err := service.prepareForDeployment(deployment, false)
+	result, _, err := service.prepareForDeployment(deployment, false)
 
 	assert.Zero(t, result)
 	assert.Equal(t, `Error ensuring deployment reservation: test`, err.Error())
@@ -469,7 +1655,7 @@ func Test_prepareForDeployment_whenGetFails(t *testing.T) {
 	}
 
 	service := service{deployments: deploymentRepository, reservationService: reservationService}
-	result, err := service.prepareForDeployment(deployment, false)
+	result, _, err := service.prepareForDeployment(deployment, false)
 
 	assert.Zero(t, result)
 	assert.Equal(t, `Error retrieving deployment "myapp-mydep" in environment "myenv": test`, err.Error())
@@ -507,7 +1693,7 @@ func Test_prepareForDeployment_whenCreateFails(t *testing.T) {
 	}
 
 	service := service{deployments: deploymentRepository, reservationService: reservationService}
-	result, err := service.prepareForDeployment(deployment, false)
+	result, _, err := service.prepareForDeployment(deployment, false)
 
 	assert.Zero(t, result)
 	assert.Equal(t, `Error creating deployment "myapp-mydep" in environment "myenv": test`, err.Error())
@@ -604,6 +1790,58 @@ func Test_computeTraffic_ExistingDeployment_ManualRollout_RemovesExistingZeroPer
 	assert.EqualValues(t, result[1].Percent, 100)
 }
 
+func Test_computeTraffic_ExistingDeployment_Rollout(t *testing.T) {
+	cfg := &core.DeploymentConfig{
+		Name:    "myapp",
+		Rollout: &core.RolloutStrategy{Steps: []int{10, 100}},
+	}
+
+	existingDeployment := &core.DeploymentRecord{
+		Doc: core.DeploymentDoc{
+			Traffic: core.TrafficConfig{
+				core.TrafficConfigRule{
+					RiserRevision: 1,
+					RevisionName:  "myapp-1",
+					Percent:       100,
+				},
+			},
+		},
+	}
+
+	result := computeTraffic(2, cfg, existingDeployment)
+
+	assert.Len(t, result, 2)
+	assert.EqualValues(t, 2, result[0].RiserRevision)
+	assert.EqualValues(t, 0, result[0].Percent)
+	assert.EqualValues(t, 1, result[1].RiserRevision)
+	assert.EqualValues(t, 100, result[1].Percent)
+}
+
+func Test_computeTraffic_ExistingDeployment_Rollout_NoExistingTraffic(t *testing.T) {
+	cfg := &core.DeploymentConfig{
+		Name:    "myapp",
+		Rollout: &core.RolloutStrategy{Steps: []int{10, 100}},
+	}
+
+	existingDeployment := &core.DeploymentRecord{
+		Doc: core.DeploymentDoc{
+			Traffic: core.TrafficConfig{
+				core.TrafficConfigRule{
+					RiserRevision: 1,
+					RevisionName:  "myapp-1",
+					Percent:       0,
+				},
+			},
+		},
+	}
+
+	result := computeTraffic(2, cfg, existingDeployment)
+
+	assert.Len(t, result, 1)
+	assert.EqualValues(t, 2, result[0].RiserRevision)
+	assert.EqualValues(t, 100, result[0].Percent)
+}
+
 func Test_validateDeploymentConfig_ValidatesName(t *testing.T) {
 	tests := []struct {
 		name string
@@ -0,0 +1,35 @@
+package domainclaim
+
+import (
+	"github.com/google/uuid"
+	"github.com/riser-platform/riser-server/pkg/core"
+)
+
+type FakeService struct {
+	ValidateClaimsFn             func(appId uuid.UUID, deploymentName *core.NamespacedName, envName string, domains []string) error
+	ClaimFn                      func(appId uuid.UUID, deploymentName *core.NamespacedName, envName string, domains []string) error
+	ClaimCallCount               int
+	ReleaseUnusedClaimsFn        func(deploymentName *core.NamespacedName, envName string, domains []string) error
+	ReleaseUnusedClaimsCallCount int
+	ReleaseClaimsFn              func(deploymentName *core.NamespacedName, envName string) error
+	ReleaseClaimsCallCount       int
+}
+
+func (f *FakeService) ValidateClaims(appId uuid.UUID, deploymentName *core.NamespacedName, envName string, domains []string) error {
+	return f.ValidateClaimsFn(appId, deploymentName, envName, domains)
+}
+
+func (f *FakeService) Claim(appId uuid.UUID, deploymentName *core.NamespacedName, envName string, domains []string) error {
+	f.ClaimCallCount++
+	return f.ClaimFn(appId, deploymentName, envName, domains)
+}
+
+func (f *FakeService) ReleaseUnusedClaims(deploymentName *core.NamespacedName, envName string, domains []string) error {
+	f.ReleaseUnusedClaimsCallCount++
+	return f.ReleaseUnusedClaimsFn(deploymentName, envName, domains)
+}
+
+func (f *FakeService) ReleaseClaims(deploymentName *core.NamespacedName, envName string) error {
+	f.ReleaseClaimsCallCount++
+	return f.ReleaseClaimsFn(deploymentName, envName)
+}
@@ -0,0 +1,93 @@
+package domainclaim
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/riser-platform/riser-server/pkg/core"
+)
+
+type Service interface {
+	// ValidateClaims returns a validation error if a domain is claimed by another app, or by another deployment in the environment
+	ValidateClaims(appId uuid.UUID, deploymentName *core.NamespacedName, envName string, domains []string) error
+	// Claim claims the domains for the deployment. Returns a validation error if a domain is claimed by another app, or by another
+	// deployment in the environment. Domains must be claimed before they are committed to the state.
+	Claim(appId uuid.UUID, deploymentName *core.NamespacedName, envName string, domains []string) error
+	// ReleaseUnusedClaims releases the deployment's claims for any domains that it no longer uses. Must only be called once the
+	// domains are committed to the state.
+	ReleaseUnusedClaims(deploymentName *core.NamespacedName, envName string, domains []string) error
+	// ReleaseClaims releases all of the deployment's claims in the environment
+	ReleaseClaims(deploymentName *core.NamespacedName, envName string) error
+}
+
+type service struct {
+	claims core.DomainClaimRepository
+}
+
+func NewService(claims core.DomainClaimRepository) Service {
+	return &service{claims}
+}
+
+func (s *service) ValidateClaims(appId uuid.UUID, deploymentName *core.NamespacedName, envName string, domains []string) error {
+	for _, domain := range domains {
+		claims, err := s.claims.FindByDomain(domain)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error retrieving claims for domain %q", domain))
+		}
+
+		for _, claim := range claims {
+			if claim.AppId != appId {
+				return core.NewValidationErrorMessage(fmt.Sprintf("The domain %q is already claimed by another app", domain))
+			}
+			if claim.EnvironmentName == envName && (claim.DeploymentName != deploymentName.Name || claim.Namespace != deploymentName.Namespace) {
+				return core.NewValidationErrorMessage(
+					fmt.Sprintf("The domain %q is already claimed by deployment %q in environment %q",
+						domain, core.NewNamespacedName(claim.DeploymentName, claim.Namespace), envName))
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *service) Claim(appId uuid.UUID, deploymentName *core.NamespacedName, envName string, domains []string) error {
+	for _, domain := range domains {
+		err := s.claims.Create(&core.DomainClaim{
+			Domain:          domain,
+			AppId:           appId,
+			EnvironmentName: envName,
+			DeploymentName:  deploymentName.Name,
+			Namespace:       deploymentName.Namespace,
+		})
+		if err == core.ErrDomainClaimed {
+			// Describe the existing claim when it can still be found
+			validationErr := s.ValidateClaims(appId, deploymentName, envName, []string{domain})
+			if _, ok := validationErr.(*core.ValidationError); ok {
+				return validationErr
+			}
+			return core.NewValidationErrorMessage(fmt.Sprintf("The domain %q is already claimed", domain))
+		}
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error claiming domain %q", domain))
+		}
+	}
+
+	return nil
+}
+
+func (s *service) ReleaseUnusedClaims(deploymentName *core.NamespacedName, envName string, domains []string) error {
+	return s.release(deploymentName, envName, domains)
+}
+
+func (s *service) ReleaseClaims(deploymentName *core.NamespacedName, envName string) error {
+	return s.release(deploymentName, envName, []string{})
+}
+
+func (s *service) release(deploymentName *core.NamespacedName, envName string, keepDomains []string) error {
+	err := s.claims.ReleaseByDeployment(deploymentName, envName, keepDomains)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error releasing domains for deployment %q in environment %q", deploymentName, envName))
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package domainclaim
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ValidateClaims(t *testing.T) {
+	appId := uuid.New()
+	name := core.NewNamespacedName("mydep", "myns")
+	claims := &core.FakeDomainClaimRepository{
+		FindByDomainFn: func(domain string) ([]core.DomainClaim, error) {
+			if domain == "unclaimed.example.com" {
+				return []core.DomainClaim{}, nil
+			}
+			assert.Equal(t, "myapp.example.com", domain)
+			return []core.DomainClaim{
+				// Claimed by the same deployment
+				{Domain: domain, AppId: appId, EnvironmentName: "myenv", DeploymentName: "mydep", Namespace: "myns"},
+				// Claimed by another deployment of the same app in another environment
+				{Domain: domain, AppId: appId, EnvironmentName: "otherenv", DeploymentName: "otherdep", Namespace: "myns"},
+			}, nil
+		},
+	}
+
+	svc := service{claims}
+
+	err := svc.ValidateClaims(appId, name, "myenv", []string{"myapp.example.com", "unclaimed.example.com"})
+
+	assert.NoError(t, err)
+}
+
+func Test_ValidateClaims_ClaimedByAnotherApp(t *testing.T) {
+	claims := &core.FakeDomainClaimRepository{
+		FindByDomainFn: func(domain string) ([]core.DomainClaim, error) {
+			return []core.DomainClaim{
+				{Domain: domain, AppId: uuid.New(), EnvironmentName: "otherenv", DeploymentName: "mydep", Namespace: "myns"},
+			}, nil
+		},
+	}
+
+	svc := service{claims}
+
+	err := svc.ValidateClaims(uuid.New(), core.NewNamespacedName("mydep", "myns"), "myenv", []string{"myapp.example.com"})
+
+	assert.IsType(t, &core.ValidationError{}, err)
+	assert.Equal(t, `The domain "myapp.example.com" is already claimed by another app`, err.Error())
+}
+
+func Test_ValidateClaims_ClaimedByAnotherDeployment(t *testing.T) {
+	appId := uuid.New()
+	claims := &core.FakeDomainClaimRepository{
+		FindByDomainFn: func(domain string) ([]core.DomainClaim, error) {
+			return []core.DomainClaim{
+				{Domain: domain, AppId: appId, EnvironmentName: "myenv", DeploymentName: "otherdep", Namespace: "myns"},
+			}, nil
+		},
+	}
+
+	svc := service{claims}
+
+	err := svc.ValidateClaims(appId, core.NewNamespacedName("mydep", "myns"), "myenv", []string{"myapp.example.com"})
+
+	assert.IsType(t, &core.ValidationError{}, err)
+	assert.Equal(t, `The domain "myapp.example.com" is already claimed by deployment "otherdep.myns" in environment "myenv"`, err.Error())
+}
+
+func Test_ValidateClaims_FindErr(t *testing.T) {
+	claims := &core.FakeDomainClaimRepository{
+		FindByDomainFn: func(string) ([]core.DomainClaim, error) {
+			return nil, errors.New("test")
+		},
+	}
+
+	svc := service{claims}
+
+	err := svc.ValidateClaims(uuid.New(), core.NewNamespacedName("mydep", "myns"), "myenv", []string{"myapp.example.com"})
+
+	assert.Equal(t, `Error retrieving claims for domain "myapp.example.com": test`, err.Error())
+}
+
+func Test_Claim(t *testing.T) {
+	appId := uuid.New()
+	claimed := []string{}
+	claims := &core.FakeDomainClaimRepository{
+		CreateFn: func(claim *core.DomainClaim) error {
+			assert.Equal(t, appId, claim.AppId)
+			assert.Equal(t, "myenv", claim.EnvironmentName)
+			assert.Equal(t, "mydep", claim.DeploymentName)
+			assert.Equal(t, "myns", claim.Namespace)
+			claimed = append(claimed, claim.Domain)
+			return nil
+		},
+	}
+
+	svc := service{claims}
+
+	err := svc.Claim(appId, core.NewNamespacedName("mydep", "myns"), "myenv", []string{"a.example.com", "b.example.com"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.example.com", "b.example.com"}, claimed)
+	assert.Equal(t, 0, claims.ReleaseByDeploymentCallCount)
+}
+
+func Test_Claim_AlreadyClaimed(t *testing.T) {
+	claims := &core.FakeDomainClaimRepository{
+		CreateFn: func(*core.DomainClaim) error {
+			return core.ErrDomainClaimed
+		},
+		FindByDomainFn: func(string) ([]core.DomainClaim, error) {
+			return []core.DomainClaim{{Domain: "myapp.example.com", AppId: uuid.New(), EnvironmentName: "otherenv"}}, nil
+		},
+	}
+
+	svc := service{claims}
+
+	err := svc.Claim(uuid.New(), core.NewNamespacedName("mydep", "myns"), "myenv", []string{"myapp.example.com"})
+
+	assert.IsType(t, &core.ValidationError{}, err)
+	assert.Equal(t, `The domain "myapp.example.com" is already claimed by another app`, err.Error())
+}
+
+func Test_Claim_AlreadyClaimed_ClaimReleased(t *testing.T) {
+	claims := &core.FakeDomainClaimRepository{
+		CreateFn: func(*core.DomainClaim) error {
+			return core.ErrDomainClaimed
+		},
+		FindByDomainFn: func(string) ([]core.DomainClaim, error) {
+			return []core.DomainClaim{}, nil
+		},
+	}
+
+	svc := service{claims}
+
+	err := svc.Claim(uuid.New(), core.NewNamespacedName("mydep", "myns"), "myenv", []string{"myapp.example.com"})
+
+	assert.IsType(t, &core.ValidationError{}, err)
+	assert.Equal(t, `The domain "myapp.example.com" is already claimed`, err.Error())
+}
+
+func Test_Claim_CreateErr(t *testing.T) {
+	claims := &core.FakeDomainClaimRepository{
+		CreateFn: func(*core.DomainClaim) error {
+			return errors.New("test")
+		},
+	}
+
+	svc := service{claims}
+
+	err := svc.Claim(uuid.New(), core.NewNamespacedName("mydep", "myns"), "myenv", []string{"myapp.example.com"})
+
+	assert.Equal(t, `Error claiming domain "myapp.example.com": test`, err.Error())
+}
+
+func Test_ReleaseUnusedClaims(t *testing.T) {
+	name := core.NewNamespacedName("mydep", "myns")
+	claims := &core.FakeDomainClaimRepository{
+		ReleaseByDeploymentFn: func(nameArg *core.NamespacedName, envName string, keepDomains []string) error {
+			assert.Equal(t, name, nameArg)
+			assert.Equal(t, "myenv", envName)
+			assert.Equal(t, []string{"a.example.com"}, keepDomains)
+			return nil
+		},
+	}
+
+	svc := service{claims}
+
+	err := svc.ReleaseUnusedClaims(name, "myenv", []string{"a.example.com"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, claims.ReleaseByDeploymentCallCount)
+}
+
+func Test_ReleaseClaims(t *testing.T) {
+	name := core.NewNamespacedName("mydep", "myns")
+	claims := &core.FakeDomainClaimRepository{
+		ReleaseByDeploymentFn: func(nameArg *core.NamespacedName, envName string, keepDomains []string) error {
+			assert.Equal(t, name, nameArg)
+			assert.Equal(t, "myenv", envName)
+			assert.Empty(t, keepDomains)
+			return nil
+		},
+	}
+
+	svc := service{claims}
+
+	err := svc.ReleaseClaims(name, "myenv")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, claims.ReleaseByDeploymentCallCount)
+}
+
+func Test_ReleaseClaims_ReleaseErr(t *testing.T) {
+	claims := &core.FakeDomainClaimRepository{
+		ReleaseByDeploymentFn: func(*core.NamespacedName, string, []string) error {
+			return errors.New("test")
+		},
+	}
+
+	svc := service{claims}
+
+	err := svc.ReleaseClaims(core.NewNamespacedName("mydep", "myns"), "myenv")
+
+	assert.Equal(t, `Error releasing domains for deployment "mydep.myns" in environment "myenv": test`, err.Error())
+}
@@ -1,7 +1,10 @@
 package environment
 
 import (
+	"context"
 	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/git"
+	"github.com/riser-platform/riser-server/pkg/state"
 )
 
 type FakeService struct {
@@ -9,6 +12,10 @@ type FakeService struct {
 	PingCallCount        int
 	GetStatusFn          func(envName string) (*core.EnvironmentStatus, error)
 	GetStatusCallCount   int
+	GetConfigFn          func(envName string) (*core.EnvironmentConfig, error)
+	GetConfigCallCount   int
+	SetConfigFn          func(envName string, environment *core.EnvironmentConfig) error
+	SetConfigCallCount   int
 	ValidateDeployableFn func(envName string) error
 }
 
@@ -22,14 +29,45 @@ func (fake *FakeService) GetStatus(envName string) (*core.EnvironmentStatus, err
 	return fake.GetStatusFn(envName)
 }
 
-func (fake *FakeService) GetConfig(string) (*core.EnvironmentConfig, error) {
-	panic("NI")
+func (fake *FakeService) GetConfig(envName string) (*core.EnvironmentConfig, error) {
+	fake.GetConfigCallCount++
+	return fake.GetConfigFn(envName)
 }
 
-func (fake *FakeService) SetConfig(string, *core.EnvironmentConfig) error {
-	panic("NI")
+func (fake *FakeService) SetConfig(envName string, environment *core.EnvironmentConfig) error {
+	fake.SetConfigCallCount++
+	return fake.SetConfigFn(envName, environment)
 }
 
 func (fake *FakeService) ValidateDeployable(envName string) error {
 	return fake.ValidateDeployableFn(envName)
 }
+
+type FakeStateBackend struct {
+	NewCommitterFn        func(ctx context.Context, envName string) (state.Committer, error)
+	NewCommitterCallCount int
+	NewReaderFn           func(ctx context.Context, envName string) (state.Reader, error)
+	NewReaderCallCount    int
+}
+
+// NewFakeStateBackend returns a backend whose committers and readers use a git.FakeRepo
+func NewFakeStateBackend() *FakeStateBackend {
+	return &FakeStateBackend{
+		NewCommitterFn: func(ctx context.Context, _ string) (state.Committer, error) {
+			return state.NewGitCommitter(ctx, &git.FakeRepo{}), nil
+		},
+		NewReaderFn: func(ctx context.Context, _ string) (state.Reader, error) {
+			return state.NewGitCommitter(ctx, &git.FakeRepo{}), nil
+		},
+	}
+}
+
+func (fake *FakeStateBackend) NewCommitter(ctx context.Context, envName string) (state.Committer, error) {
+	fake.NewCommitterCallCount++
+	return fake.NewCommitterFn(ctx, envName)
+}
+
+func (fake *FakeStateBackend) NewReader(ctx context.Context, envName string) (state.Reader, error) {
+	fake.NewReaderCallCount++
+	return fake.NewReaderFn(ctx, envName)
+}
@@ -1,26 +1,48 @@
 package environment
 
 import (
-	"errors"
+	"fmt"
+	"os"
 	"path/filepath"
 	"sync"
 
+	"github.com/pkg/errors"
+	"github.com/riser-platform/riser-server/pkg/core"
 	"github.com/riser-platform/riser-server/pkg/git"
 )
 
+const (
+	// These match the keys of the kubernetes.io/ssh-auth and kubernetes.io/basic-auth secret types so that a secret can be mounted as-is
+	credentialsSSHKeyFile   = "ssh-privatekey"
+	credentialsUsernameFile = "username"
+	credentialsPasswordFile = "password"
+)
+
 type RepoCache struct {
 	settings RepoSettings
-	newFunc  func(git.RepoSettings) (git.Repo, error)
-	cache    map[string]git.Repo
-	sync     sync.Mutex
+	// environments is used to resolve an environment's own state repo. A nil value always uses the settings.
+	environments core.EnvironmentRepository
+	newFunc      func(git.RepoSettings) (git.Repo, error)
+	cache        map[string]*cachedRepo
+	sync         sync.Mutex
+}
+
+type cachedRepo struct {
+	settings git.RepoSettings
+	repo     git.Repo
 }
 
-func NewBranchPerEnvRepoCache(settings RepoSettings) *RepoCache {
+func NewBranchPerEnvRepoCache(settings RepoSettings) (*RepoCache, error) {
+	newFunc, err := git.InitFuncForBackend(settings.Backend)
+	if err != nil {
+		return nil, err
+	}
+
 	return &RepoCache{
 		settings: settings,
-		newFunc:  git.InitRepoWorkspace,
-		cache:    map[string]git.Repo{},
-	}
+		newFunc:  newFunc,
+		cache:    map[string]*cachedRepo{},
+	}, nil
 }
 
 func NewFakeRepoCache() *RepoCache {
@@ -28,24 +50,77 @@ func NewFakeRepoCache() *RepoCache {
 		newFunc: func(git.RepoSettings) (git.Repo, error) {
 			return &git.FakeRepo{}, nil
 		},
-		cache: map[string]git.Repo{},
+		cache: map[string]*cachedRepo{},
 	}
 }
 
+// GetRepo returns the repo for the environment's branch. The environment's own state repo is used when specified.
 func (cache *RepoCache) GetRepo(envName string) (git.Repo, error) {
+	repo, err := cache.getStateRepo(envName)
+	if err != nil || repo != nil {
+		return repo, err
+	}
 	return cache.getRepo(envName, newGitSettingsForEnv(envName, cache.settings))
 }
 
-func (cache *RepoCache) getRepo(envName string, settings git.RepoSettings) (git.Repo, error) {
+// getStateRepo returns nil if the environment does not specify its own state repo
+func (cache *RepoCache) getStateRepo(envName string) (git.Repo, error) {
 	if envName == "" {
 		return nil, errors.New("Environment name cannot be empty")
 	}
 
+	settings, err := cache.resolveStateRepoSettings(envName)
+	if err != nil || settings == nil {
+		return nil, err
+	}
+	return cache.getRepo(envName, *settings)
+}
+
+func (cache *RepoCache) resolveStateRepoSettings(envName string) (*git.RepoSettings, error) {
+	if cache.environments == nil {
+		return nil, nil
+	}
+
+	environment, err := cache.environments.Get(envName)
+	if err == core.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error retrieving environment %q", envName))
+	}
+
+	stateRepo := environment.Doc.Config.StateRepo
+	if stateRepo == nil {
+		return nil, nil
+	}
+
+	settings := newGitSettingsForEnv(envName, cache.settings)
+	settings.URL = stateRepo.URL
+	if stateRepo.Branch != "" {
+		settings.Branch = stateRepo.Branch
+	}
+	settings.Auth = git.Auth{}
+	if stateRepo.CredentialsRef != "" {
+		settings.Auth, err = readCredentials(cache.settings.CredentialsDir, stateRepo.CredentialsRef)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error reading git credentials for environment %q", envName))
+		}
+	}
+	return &settings, nil
+}
+
+// getRepo returns the cached repo for the key. The repo is initialized again when the settings change (e.g. an environment's state
+// repo was changed).
+func (cache *RepoCache) getRepo(key string, settings git.RepoSettings) (git.Repo, error) {
+	if key == "" {
+		return nil, errors.New("Environment name cannot be empty")
+	}
+
 	cache.sync.Lock()
 	defer cache.sync.Unlock()
 
-	if repo, ok := cache.cache[envName]; ok {
-		return repo, nil
+	if cached, ok := cache.cache[key]; ok && cached.settings == settings {
+		return cached.repo, nil
 	}
 
 	repo, err := cache.newFunc(settings)
@@ -53,14 +128,53 @@ func (cache *RepoCache) getRepo(envName string, settings git.RepoSettings) (git.
 		return nil, err
 	}
 
-	cache.cache[envName] = repo
+	cache.cache[key] = &cachedRepo{settings: settings, repo: repo}
 	return repo, nil
 }
 
+// readCredentials reads the credentials from a folder named credentialsRef. The folder contains either an ssh-privatekey file or
+// username and password files.
+func readCredentials(credentialsDir, credentialsRef string) (git.Auth, error) {
+	if credentialsDir == "" {
+		return git.Auth{}, errors.New("A git credentials directory must be configured to use credentials")
+	}
+	refDir := filepath.Join(credentialsDir, credentialsRef)
+
+	sshKeyPath := filepath.Join(refDir, credentialsSSHKeyFile)
+	if _, err := os.Stat(sshKeyPath); err == nil {
+		return git.Auth{SSHKeyPath: sshKeyPath}, nil
+	}
+
+	password, err := os.ReadFile(filepath.Join(refDir, credentialsPasswordFile))
+	if err != nil {
+		return git.Auth{}, errors.Wrap(err, fmt.Sprintf("credentials %q must contain either %q or %q", credentialsRef, credentialsSSHKeyFile, credentialsPasswordFile))
+	}
+
+	// The username is optional (e.g. for an access token)
+	username, err := os.ReadFile(filepath.Join(refDir, credentialsUsernameFile))
+	if err != nil && !os.IsNotExist(err) {
+		return git.Auth{}, errors.Wrap(err, "error reading username")
+	}
+
+	return git.Auth{Username: string(username), Password: string(password)}, nil
+}
+
 func newGitSettingsForEnv(envName string, settings RepoSettings) git.RepoSettings {
+	return newGitSettings(envName, filepath.Join(settings.BaseGitDir, "/env/", envName), settings)
+}
+
+func newGitSettingsForBranch(branch string, settings RepoSettings) git.RepoSettings {
+	return newGitSettings(branch, filepath.Join(settings.BaseGitDir, "/branch/", branch), settings)
+}
+
+func newGitSettings(branch string, workspaceDir string, settings RepoSettings) git.RepoSettings {
 	return git.RepoSettings{
 		URL:              settings.URL,
-		BaseWorkspaceDir: filepath.Join(settings.BaseGitDir, "/env/", envName),
-		Branch:           envName,
+		BaseWorkspaceDir: workspaceDir,
+		Branch:           branch,
+		LockTimeout:      settings.LockTimeout,
+		Timeout:          settings.Timeout,
+		Auth:             settings.Auth,
+		InMemory:         settings.InMemory,
 	}
 }
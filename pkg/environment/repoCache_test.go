@@ -1,9 +1,13 @@
 package environment
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
+	"github.com/riser-platform/riser-server/pkg/core"
 	"github.com/riser-platform/riser-server/pkg/git"
 	"github.com/stretchr/testify/assert"
 
@@ -27,6 +31,155 @@ func Test_getRepo(t *testing.T) {
 	assert.NotSame(t, repo1, repo3)
 }
 
+func Test_getRepo_SettingsChanged(t *testing.T) {
+	cache := NewFakeRepoCache()
+
+	repo1, err := cache.getRepo("env1", git.RepoSettings{URL: "git@my.org/state1"})
+	require.NoError(t, err)
+	repo2, err := cache.getRepo("env1", git.RepoSettings{URL: "git@my.org/state2"})
+	require.NoError(t, err)
+
+	assert.NotSame(t, repo1, repo2)
+}
+
+func newStateRepoTestCache(t *testing.T, stateRepo *core.EnvironmentStateRepo) (*RepoCache, *[]git.RepoSettings) {
+	initSettings := &[]git.RepoSettings{}
+	cache := NewFakeRepoCache()
+	cache.settings = RepoSettings{
+		URL:            "git@my.org/state",
+		BaseGitDir:     "/tmp/riserstate",
+		Auth:           git.Auth{SSHKeyPath: "/etc/riser/ssh/identity"},
+		CredentialsDir: t.TempDir(),
+	}
+	cache.newFunc = func(settings git.RepoSettings) (git.Repo, error) {
+		*initSettings = append(*initSettings, settings)
+		return &git.FakeRepo{}, nil
+	}
+	cache.environments = &core.FakeEnvironmentRepository{
+		GetFn: func(envName string) (*core.Environment, error) {
+			assert.Equal(t, "env1", envName)
+			return &core.Environment{Doc: core.EnvironmentDoc{Config: core.EnvironmentConfig{StateRepo: stateRepo}}}, nil
+		},
+	}
+	return cache, initSettings
+}
+
+func Test_GetRepo_Default(t *testing.T) {
+	cache, initSettings := newStateRepoTestCache(t, nil)
+
+	_, err := cache.GetRepo("env1")
+
+	require.NoError(t, err)
+	require.Len(t, *initSettings, 1)
+	assert.Equal(t, newGitSettingsForEnv("env1", cache.settings), (*initSettings)[0])
+}
+
+func Test_GetRepo_EnvironmentNotFound(t *testing.T) {
+	cache, initSettings := newStateRepoTestCache(t, nil)
+	cache.environments = &core.FakeEnvironmentRepository{
+		GetFn: func(string) (*core.Environment, error) {
+			return nil, core.ErrNotFound
+		},
+	}
+
+	_, err := cache.GetRepo("env1")
+
+	require.NoError(t, err)
+	assert.Equal(t, "git@my.org/state", (*initSettings)[0].URL)
+}
+
+func Test_GetRepo_EnvironmentErr(t *testing.T) {
+	cache, _ := newStateRepoTestCache(t, nil)
+	cache.environments = &core.FakeEnvironmentRepository{
+		GetFn: func(string) (*core.Environment, error) {
+			return nil, errors.New("test")
+		},
+	}
+
+	repo, err := cache.GetRepo("env1")
+
+	assert.Nil(t, repo)
+	assert.Equal(t, `Error retrieving environment "env1": test`, err.Error())
+}
+
+func Test_GetRepo_StateRepo(t *testing.T) {
+	cache, initSettings := newStateRepoTestCache(t, &core.EnvironmentStateRepo{URL: "git@my.org/env1"})
+
+	_, err := cache.GetRepo("env1")
+
+	require.NoError(t, err)
+	require.Len(t, *initSettings, 1)
+	assert.Equal(t, "git@my.org/env1", (*initSettings)[0].URL)
+	assert.Equal(t, "env1", (*initSettings)[0].Branch)
+	assert.Equal(t, "/tmp/riserstate/env/env1", (*initSettings)[0].BaseWorkspaceDir)
+	// The server's credentials are never used for an environment's state repo
+	assert.Equal(t, git.Auth{}, (*initSettings)[0].Auth)
+}
+
+func Test_GetRepo_StateRepo_BranchAndCredentials(t *testing.T) {
+	cache, initSettings := newStateRepoTestCache(t, &core.EnvironmentStateRepo{URL: "git@my.org/env1", Branch: "state", CredentialsRef: "env1creds"})
+	credsDir := filepath.Join(cache.settings.CredentialsDir, "env1creds")
+	require.NoError(t, os.MkdirAll(credsDir, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(credsDir, "ssh-privatekey"), []byte("key"), 0600))
+
+	_, err := cache.GetRepo("env1")
+
+	require.NoError(t, err)
+	assert.Equal(t, "state", (*initSettings)[0].Branch)
+	assert.Equal(t, git.Auth{SSHKeyPath: filepath.Join(credsDir, "ssh-privatekey")}, (*initSettings)[0].Auth)
+}
+
+func Test_GetRepo_StateRepo_Changed(t *testing.T) {
+	stateRepo := &core.EnvironmentStateRepo{URL: "git@my.org/env1"}
+	cache, initSettings := newStateRepoTestCache(t, stateRepo)
+
+	_, err := cache.GetRepo("env1")
+	require.NoError(t, err)
+	_, err = cache.GetRepo("env1")
+	require.NoError(t, err)
+	stateRepo.URL = "git@my.org/env1-new"
+	_, err = cache.GetRepo("env1")
+	require.NoError(t, err)
+
+	require.Len(t, *initSettings, 2)
+	assert.Equal(t, "git@my.org/env1-new", (*initSettings)[1].URL)
+}
+
+func Test_readCredentials_BasicAuth(t *testing.T) {
+	credsDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(credsDir, "mycreds"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(credsDir, "mycreds", "username"), []byte("myuser"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(credsDir, "mycreds", "password"), []byte("mypassword"), 0600))
+
+	result, err := readCredentials(credsDir, "mycreds")
+
+	require.NoError(t, err)
+	assert.Equal(t, git.Auth{Username: "myuser", Password: "mypassword"}, result)
+}
+
+func Test_readCredentials_TokenOnly(t *testing.T) {
+	credsDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(credsDir, "mycreds"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(credsDir, "mycreds", "password"), []byte("mytoken"), 0600))
+
+	result, err := readCredentials(credsDir, "mycreds")
+
+	require.NoError(t, err)
+	assert.Equal(t, git.Auth{Password: "mytoken"}, result)
+}
+
+func Test_readCredentials_Missing(t *testing.T) {
+	_, err := readCredentials(t.TempDir(), "mycreds")
+
+	assert.Contains(t, err.Error(), `credentials "mycreds" must contain either "ssh-privatekey" or "password"`)
+}
+
+func Test_readCredentials_NoDir(t *testing.T) {
+	_, err := readCredentials("", "mycreds")
+
+	assert.Equal(t, "A git credentials directory must be configured to use credentials", err.Error())
+}
+
 func Test_getRepo_emptyName(t *testing.T) {
 	cache := NewFakeRepoCache()
 
@@ -42,7 +195,7 @@ func Test_getRepo_NewErr(t *testing.T) {
 		newFunc: func(git.RepoSettings) (git.Repo, error) {
 			return nil, expectedErr
 		},
-		cache: map[string]git.Repo{},
+		cache: map[string]*cachedRepo{},
 	}
 
 	repo, err := cache.getRepo("env", git.RepoSettings{})
@@ -55,10 +208,23 @@ func Test_newGitSettingsForEnv(t *testing.T) {
 	settings := RepoSettings{
 		URL:        "git@my.org/state",
 		BaseGitDir: "/tmp/riserstate",
+		Timeout:    time.Minute,
+		Auth:       git.Auth{SSHKeyPath: "/etc/riser/ssh/identity"},
+		InMemory:   true,
 	}
 	result := newGitSettingsForEnv("env1", settings)
 
 	assert.Equal(t, settings.URL, result.URL)
 	assert.Equal(t, "env1", result.Branch)
 	assert.Equal(t, "/tmp/riserstate/env/env1", result.BaseWorkspaceDir)
+	assert.Equal(t, time.Minute, result.Timeout)
+	assert.Equal(t, settings.Auth, result.Auth)
+	assert.True(t, result.InMemory)
+}
+
+func Test_NewBranchPerEnvRepoCache_UnknownBackend(t *testing.T) {
+	cache, err := NewBranchPerEnvRepoCache(RepoSettings{Backend: "unknown"})
+
+	assert.Nil(t, cache)
+	assert.Equal(t, `unknown git backend "unknown"`, err.Error())
 }
@@ -10,14 +10,26 @@ import (
 	"github.com/dustin/go-humanize"
 	"github.com/pkg/errors"
 	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/git"
 )
 
 // UnhealthyAfter indicates the duration that is used to calculate if the environment is unhealthy due to not receiving any type of communication from the environment
 var UnhealthyAfter = time.Duration(30) * time.Second
 
 type RepoSettings struct {
-	URL        string
+	URL string
+	// Branch is only used by the dir-per-env state backend. The branch-per-env backend uses the environment name.
+	Branch     string
 	BaseGitDir string
+	// LockTimeout is how long to wait for another change to the environment's state to complete
+	LockTimeout time.Duration
+	// Backend is either git.BackendExec or git.BackendNative
+	Backend  string
+	Timeout  time.Duration
+	Auth     git.Auth
+	InMemory bool
+	// CredentialsDir contains the credentials referenced by an environment's state repo (see core.EnvironmentStateRepo)
+	CredentialsDir string
 }
 
 type Service interface {
@@ -53,7 +65,8 @@ func (s *service) GetConfig(envName string) (*core.EnvironmentConfig, error) {
 	return &env.Doc.Config, nil
 }
 
-// SetConfig merges any non zero value with the existing environment configuration
+// SetConfig merges any non zero value with the existing environment configuration. Settings that are replaced as a whole
+// (see replaceConfig) are not merged so that they can be removed.
 // DeleteConfig should be added if we ever need to clear a environment config value
 func (s *service) SetConfig(envName string, environmentConfig *core.EnvironmentConfig) error {
 	environment, err := s.environments.Get(envName)
@@ -61,7 +74,10 @@ func (s *service) SetConfig(envName string, environmentConfig *core.EnvironmentC
 		return errors.Wrap(err, fmt.Sprintf("Error retrieving environment %q", envName))
 	}
 
-	err = mergo.MergeWithOverwrite(&environment.Doc.Config, environmentConfig)
+	merged := *environmentConfig
+	replaceConfig(&environment.Doc.Config, &merged)
+
+	err = mergo.MergeWithOverwrite(&environment.Doc.Config, merged)
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("Error merging environment configuration for environment %q", envName))
 	}
@@ -74,6 +90,38 @@ func (s *service) SetConfig(envName string, environmentConfig *core.EnvironmentC
 	return nil
 }
 
+// replaceConfig replaces the settings of the existing config that must not be merged and clears them from the update
+func replaceConfig(existing *core.EnvironmentConfig, update *core.EnvironmentConfig) {
+	// An empty state repo removes the state repo so that the environment uses the server's state backend again
+	if update.StateRepo != nil {
+		existing.StateRepo = update.StateRepo
+		if update.StateRepo.URL == "" {
+			existing.StateRepo = nil
+		}
+		update.StateRepo = nil
+	}
+
+	// An empty renderer is not a change. Use model.EnvironmentRenderer_KNative to return to the default renderer.
+	if update.Renderer != "" {
+		existing.Renderer = update.Renderer
+		update.Renderer = ""
+	}
+
+	if update.Kustomize != nil {
+		existing.Kustomize = update.Kustomize
+		update.Kustomize = nil
+	}
+
+	// Resources are replaced as a whole so that a default or maximum can be removed. Empty resources remove all of them.
+	if update.Resources != nil {
+		existing.Resources = update.Resources
+		if *update.Resources == (core.EnvironmentResources{}) {
+			existing.Resources = nil
+		}
+		update.Resources = nil
+	}
+}
+
 func (s *service) GetStatus(envName string) (*core.EnvironmentStatus, error) {
 	environment, err := s.environments.Get(envName)
 	if err != nil {
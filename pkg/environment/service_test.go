@@ -6,6 +6,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/util"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -211,6 +212,140 @@ func Test_SetConfig_IgnoresEmptyValues(t *testing.T) {
 	assert.Equal(t, 1, environmentRepository.SaveCallCount)
 }
 
+func Test_SetConfig_ReplacesStateRepo(t *testing.T) {
+	tests := []struct {
+		name     string
+		update   *core.EnvironmentStateRepo
+		expected *core.EnvironmentStateRepo
+	}{
+		{"unchanged", nil, &core.EnvironmentStateRepo{URL: "git@my.org/state", Branch: "mybranch"}},
+		{"replaced", &core.EnvironmentStateRepo{URL: "git@my.org/other"}, &core.EnvironmentStateRepo{URL: "git@my.org/other"}},
+		{"removed", &core.EnvironmentStateRepo{}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			environmentRepository := &core.FakeEnvironmentRepository{
+				GetFn: func(envName string) (*core.Environment, error) {
+					return &core.Environment{
+						Name: "myenv",
+						Doc: core.EnvironmentDoc{
+							Config: core.EnvironmentConfig{
+								StateRepo: &core.EnvironmentStateRepo{URL: "git@my.org/state", Branch: "mybranch"},
+							},
+						},
+					}, nil
+				},
+				SaveFn: func(environment *core.Environment) error {
+					assert.Equal(t, tt.expected, environment.Doc.Config.StateRepo)
+					return nil
+				},
+			}
+
+			service := service{environmentRepository}
+
+			err := service.SetConfig("myenv", &core.EnvironmentConfig{StateRepo: tt.update})
+
+			assert.NoError(t, err)
+			assert.Equal(t, 1, environmentRepository.SaveCallCount)
+		})
+	}
+}
+
+func Test_SetConfig_ReplacesRenderer(t *testing.T) {
+	environmentRepository := &core.FakeEnvironmentRepository{
+		GetFn: func(envName string) (*core.Environment, error) {
+			return &core.Environment{
+				Name: "myenv",
+				Doc:  core.EnvironmentDoc{Config: core.EnvironmentConfig{Renderer: "kubernetes"}},
+			}, nil
+		},
+		SaveFn: func(environment *core.Environment) error {
+			assert.Equal(t, "knative", environment.Doc.Config.Renderer)
+			return nil
+		},
+	}
+
+	service := service{environmentRepository}
+
+	err := service.SetConfig("myenv", &core.EnvironmentConfig{Renderer: "knative"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, environmentRepository.SaveCallCount)
+}
+
+func Test_SetConfig_ReplacesKustomize(t *testing.T) {
+	environmentRepository := &core.FakeEnvironmentRepository{
+		GetFn: func(envName string) (*core.Environment, error) {
+			return &core.Environment{
+				Name: "myenv",
+				Doc:  core.EnvironmentDoc{Config: core.EnvironmentConfig{Kustomize: util.PtrBool(true)}},
+			}, nil
+		},
+		SaveFn: func(environment *core.Environment) error {
+			assert.False(t, environment.Doc.Config.KustomizeEnabled())
+			return nil
+		},
+	}
+
+	service := service{environmentRepository}
+
+	err := service.SetConfig("myenv", &core.EnvironmentConfig{Kustomize: util.PtrBool(false)})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, environmentRepository.SaveCallCount)
+}
+
+func Test_SetConfig_ReplacesResources(t *testing.T) {
+	tests := []struct {
+		name     string
+		update   *core.EnvironmentResources
+		expected *core.EnvironmentResources
+	}{
+		{"unchanged", nil, &core.EnvironmentResources{
+			DefaultLimits: &core.EnvironmentResourceQuantities{MemoryMB: util.PtrInt32(256)},
+			MaxLimits:     &core.EnvironmentResourceQuantities{MemoryMB: util.PtrInt32(1024)},
+		}},
+		{"replaced", &core.EnvironmentResources{
+			DefaultLimits: &core.EnvironmentResourceQuantities{CpuCores: util.PtrFloat32(1)},
+		}, &core.EnvironmentResources{
+			DefaultLimits: &core.EnvironmentResourceQuantities{CpuCores: util.PtrFloat32(1)},
+		}},
+		{"removed", &core.EnvironmentResources{}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			environmentRepository := &core.FakeEnvironmentRepository{
+				GetFn: func(envName string) (*core.Environment, error) {
+					return &core.Environment{
+						Name: "myenv",
+						Doc: core.EnvironmentDoc{
+							Config: core.EnvironmentConfig{
+								Resources: &core.EnvironmentResources{
+									DefaultLimits: &core.EnvironmentResourceQuantities{MemoryMB: util.PtrInt32(256)},
+									MaxLimits:     &core.EnvironmentResourceQuantities{MemoryMB: util.PtrInt32(1024)},
+								},
+							},
+						},
+					}, nil
+				},
+				SaveFn: func(environment *core.Environment) error {
+					assert.Equal(t, tt.expected, environment.Doc.Config.Resources)
+					return nil
+				},
+			}
+
+			service := service{environmentRepository}
+
+			err := service.SetConfig("myenv", &core.EnvironmentConfig{Resources: tt.update})
+
+			assert.NoError(t, err)
+			assert.Equal(t, 1, environmentRepository.SaveCallCount)
+		})
+	}
+}
+
 func Test_ValidateDeployable(t *testing.T) {
 	environmentRepository := &core.FakeEnvironmentRepository{
 		ListFn: func() ([]core.Environment, error) {
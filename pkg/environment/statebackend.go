@@ -0,0 +1,204 @@
+package environment
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/state"
+)
+
+const (
+	// StateBackendBranchPerEnv stores the state for each environment in a git branch named after the environment
+	StateBackendBranchPerEnv = "branch-per-env"
+	// StateBackendDirPerEnv stores the state for each environment in a directory named after the environment on a single git branch
+	StateBackendDirPerEnv = "dir-per-env"
+	// StateBackendFilesystem writes the state for each environment to a local directory. Syncing the state to the cluster is left to
+	// other means (e.g. for air-gapped clusters).
+	StateBackendFilesystem = "filesystem"
+)
+
+// StateBackend stores the state of each environment
+type StateBackend interface {
+	// NewCommitter returns a committer for the environment's state. Remote operations are canceled when the context is done.
+	NewCommitter(ctx context.Context, envName string) (state.Committer, error)
+	// NewReader returns a reader for the environment's state. Remote operations are canceled when the context is done.
+	NewReader(ctx context.Context, envName string) (state.Reader, error)
+}
+
+// stateStore is implemented by every committer that a StateBackend returns
+type stateStore interface {
+	state.Committer
+	state.Reader
+}
+
+// newCommitter maintains the environment's kustomization files when enabled (see core.EnvironmentConfig.Kustomize)
+func newCommitter(environments core.EnvironmentRepository, envName string, store stateStore) (state.Committer, error) {
+	if environments == nil {
+		return store, nil
+	}
+
+	environment, err := environments.Get(envName)
+	if err == core.ErrNotFound {
+		return store, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error retrieving environment %q", envName))
+	}
+
+	if environment.Doc.Config.KustomizeEnabled() {
+		return state.NewKustomizeCommitter(envName, store, store), nil
+	}
+	return store, nil
+}
+
+type StateBackendSettings struct {
+	// Type is one of the StateBackend constants. Defaults to StateBackendBranchPerEnv.
+	Type string
+	// Repo is used by the git backends
+	Repo RepoSettings
+	// FileDir is the root folder for the filesystem backend
+	FileDir string
+	// Environments is used to resolve an environment's own state repo, which takes precedence over the backend. Nil disables
+	// per-environment state repos.
+	Environments core.EnvironmentRepository
+}
+
+// NewStateBackend returns the StateBackend for the StateBackendSettings.Type
+func NewStateBackend(settings StateBackendSettings) (StateBackend, error) {
+	switch settings.Type {
+	case "", StateBackendBranchPerEnv:
+		repos, err := newGitRepoCache(settings)
+		if err != nil {
+			return nil, err
+		}
+		return &branchPerEnvStateBackend{repos}, nil
+	case StateBackendDirPerEnv:
+		if settings.Repo.Branch == "" {
+			return nil, errors.New("A branch is required for the dir-per-env state backend")
+		}
+		repos, err := newGitRepoCache(settings)
+		if err != nil {
+			return nil, err
+		}
+		return &dirPerEnvStateBackend{repos}, nil
+	case StateBackendFilesystem:
+		if settings.FileDir == "" {
+			return nil, errors.New("A directory is required for the filesystem state backend")
+		}
+		// The git url is optional since it's only needed for environments with their own state repo
+		repos, err := NewBranchPerEnvRepoCache(settings.Repo)
+		if err != nil {
+			return nil, err
+		}
+		repos.environments = settings.Environments
+		return &filesystemStateBackend{settings.FileDir, repos}, nil
+	}
+	return nil, fmt.Errorf("unknown state backend %q", settings.Type)
+}
+
+func newGitRepoCache(settings StateBackendSettings) (*RepoCache, error) {
+	if settings.Repo.URL == "" {
+		return nil, errors.New("A git url is required for git state backends")
+	}
+	repos, err := NewBranchPerEnvRepoCache(settings.Repo)
+	if err != nil {
+		return nil, err
+	}
+	repos.environments = settings.Environments
+	return repos, nil
+}
+
+type branchPerEnvStateBackend struct {
+	repos *RepoCache
+}
+
+func (backend *branchPerEnvStateBackend) NewCommitter(ctx context.Context, envName string) (state.Committer, error) {
+	store, err := backend.newStateStore(ctx, envName)
+	if err != nil {
+		return nil, err
+	}
+	return newCommitter(backend.repos.environments, envName, store)
+}
+
+func (backend *branchPerEnvStateBackend) NewReader(ctx context.Context, envName string) (state.Reader, error) {
+	return backend.newStateStore(ctx, envName)
+}
+
+func (backend *branchPerEnvStateBackend) newStateStore(ctx context.Context, envName string) (stateStore, error) {
+	repo, err := backend.repos.GetRepo(envName)
+	if err != nil {
+		return nil, err
+	}
+	return state.NewGitCommitter(ctx, repo), nil
+}
+
+type dirPerEnvStateBackend struct {
+	repos *RepoCache
+}
+
+func (backend *dirPerEnvStateBackend) NewCommitter(ctx context.Context, envName string) (state.Committer, error) {
+	store, err := backend.newStateStore(ctx, envName)
+	if err != nil {
+		return nil, err
+	}
+	return newCommitter(backend.repos.environments, envName, store)
+}
+
+func (backend *dirPerEnvStateBackend) NewReader(ctx context.Context, envName string) (state.Reader, error) {
+	return backend.newStateStore(ctx, envName)
+}
+
+func (backend *dirPerEnvStateBackend) newStateStore(ctx context.Context, envName string) (stateStore, error) {
+	// An environment with its own state repo owns the whole branch
+	repo, err := backend.repos.getStateRepo(envName)
+	if err != nil {
+		return nil, err
+	}
+	if repo != nil {
+		return state.NewGitCommitter(ctx, repo), nil
+	}
+
+	// All environments share the same repo and therefore the same lock
+	branch := backend.repos.settings.Branch
+	repo, err = backend.repos.getRepo(sharedBranchKey(branch), newGitSettingsForBranch(branch, backend.repos.settings))
+	if err != nil {
+		return nil, err
+	}
+	return state.NewGitCommitterForDir(ctx, repo, envName), nil
+}
+
+// sharedBranchKey prevents a shared branch from colliding with an environment of the same name in the RepoCache
+func sharedBranchKey(branch string) string {
+	return "branch/" + branch
+}
+
+type filesystemStateBackend struct {
+	baseDir string
+	repos   *RepoCache
+}
+
+func (backend *filesystemStateBackend) NewCommitter(ctx context.Context, envName string) (state.Committer, error) {
+	store, err := backend.newStateStore(ctx, envName)
+	if err != nil {
+		return nil, err
+	}
+	return newCommitter(backend.repos.environments, envName, store)
+}
+
+func (backend *filesystemStateBackend) NewReader(ctx context.Context, envName string) (state.Reader, error) {
+	return backend.newStateStore(ctx, envName)
+}
+
+func (backend *filesystemStateBackend) newStateStore(ctx context.Context, envName string) (stateStore, error) {
+	repo, err := backend.repos.getStateRepo(envName)
+	if err != nil {
+		return nil, err
+	}
+	if repo != nil {
+		return state.NewGitCommitter(ctx, repo), nil
+	}
+	return state.NewFileCommitter(filepath.Join(backend.baseDir, envName)), nil
+}
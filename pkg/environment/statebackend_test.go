@@ -0,0 +1,215 @@
+package environment
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/git"
+	"github.com/riser-platform/riser-server/pkg/state"
+	"github.com/riser-platform/riser-server/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRepoSettings(t *testing.T) RepoSettings {
+	remoteDir := t.TempDir()
+	_, err := gogit.PlainInit(remoteDir, true)
+	require.NoError(t, err)
+	return RepoSettings{
+		URL:      remoteDir,
+		Branch:   "main",
+		Backend:  git.BackendNative,
+		InMemory: true,
+	}
+}
+
+// readRemoteFile reads a file from the tip of a branch in a bare repo
+func readRemoteFile(t *testing.T, remoteDir, branch, fileName string) string {
+	remote, err := gogit.PlainOpen(remoteDir)
+	require.NoError(t, err)
+	ref, err := remote.Reference(plumbing.NewBranchReferenceName(branch), true)
+	require.NoError(t, err)
+	commit, err := remote.CommitObject(ref.Hash())
+	require.NoError(t, err)
+	file, err := commit.File(fileName)
+	require.NoError(t, err)
+	contents, err := file.Contents()
+	require.NoError(t, err)
+	return contents
+}
+
+func commitTestFile(t *testing.T, backend StateBackend, envName string) state.Committer {
+	committer, err := backend.NewCommitter(context.Background(), envName)
+	require.NoError(t, err)
+	err = committer.Commit("test", []core.ResourceFile{{Name: "state/test.yaml", Contents: []byte(envName)}}, nil)
+	require.NoError(t, err)
+	return committer
+}
+
+func Test_NewStateBackend_BranchPerEnv(t *testing.T) {
+	settings := newTestRepoSettings(t)
+	backend, err := NewStateBackend(StateBackendSettings{Type: StateBackendBranchPerEnv, Repo: settings})
+	require.NoError(t, err)
+
+	committer := commitTestFile(t, backend, "dev")
+	commitTestFile(t, backend, "prod")
+
+	assert.IsType(t, &state.GitCommitter{}, committer)
+	assert.Equal(t, "dev", readRemoteFile(t, settings.URL, "dev", "state/test.yaml"))
+	assert.Equal(t, "prod", readRemoteFile(t, settings.URL, "prod", "state/test.yaml"))
+}
+
+func Test_NewStateBackend_DefaultsToBranchPerEnv(t *testing.T) {
+	backend, err := NewStateBackend(StateBackendSettings{Repo: RepoSettings{URL: "git@my.org/state"}})
+
+	require.NoError(t, err)
+	assert.IsType(t, &branchPerEnvStateBackend{}, backend)
+}
+
+func Test_NewStateBackend_DirPerEnv(t *testing.T) {
+	settings := newTestRepoSettings(t)
+	backend, err := NewStateBackend(StateBackendSettings{Type: StateBackendDirPerEnv, Repo: settings})
+	require.NoError(t, err)
+
+	committer := commitTestFile(t, backend, "dev")
+	commitTestFile(t, backend, "prod")
+
+	assert.IsType(t, &state.GitCommitter{}, committer)
+	assert.Equal(t, "dev", readRemoteFile(t, settings.URL, "main", "dev/state/test.yaml"))
+	assert.Equal(t, "prod", readRemoteFile(t, settings.URL, "main", "prod/state/test.yaml"))
+
+	reader, err := backend.NewReader(context.Background(), "dev")
+	require.NoError(t, err)
+	files, err := reader.ReadFiles("state")
+	require.NoError(t, err)
+	assert.Equal(t, []core.ResourceFile{{Name: "state/test.yaml", Contents: []byte("dev")}}, files)
+}
+
+func Test_NewStateBackend_DirPerEnv_RequiresBranch(t *testing.T) {
+	backend, err := NewStateBackend(StateBackendSettings{Type: StateBackendDirPerEnv, Repo: RepoSettings{URL: "git@my.org/state"}})
+
+	assert.Nil(t, backend)
+	assert.Equal(t, "A branch is required for the dir-per-env state backend", err.Error())
+}
+
+func Test_NewStateBackend_Filesystem(t *testing.T) {
+	dir := t.TempDir()
+	backend, err := NewStateBackend(StateBackendSettings{Type: StateBackendFilesystem, FileDir: dir})
+	require.NoError(t, err)
+
+	commitTestFile(t, backend, "dev")
+
+	contents, err := os.ReadFile(filepath.Join(dir, "dev/state/test.yaml"))
+	require.NoError(t, err)
+	assert.EqualValues(t, "dev", contents)
+}
+
+func Test_NewStateBackend_Filesystem_StateRepo(t *testing.T) {
+	settings := newTestRepoSettings(t)
+	backend, err := NewStateBackend(StateBackendSettings{
+		Type:    StateBackendFilesystem,
+		FileDir: t.TempDir(),
+		Repo:    RepoSettings{Backend: settings.Backend, InMemory: true},
+		Environments: &core.FakeEnvironmentRepository{
+			GetFn: func(string) (*core.Environment, error) {
+				return &core.Environment{Doc: core.EnvironmentDoc{Config: core.EnvironmentConfig{
+					StateRepo: &core.EnvironmentStateRepo{URL: settings.URL, Branch: "mybranch"},
+				}}}, nil
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	commitTestFile(t, backend, "dev")
+
+	assert.Equal(t, "dev", readRemoteFile(t, settings.URL, "mybranch", "state/test.yaml"))
+}
+
+func Test_NewStateBackend_Kustomize(t *testing.T) {
+	dir := t.TempDir()
+	backend, err := NewStateBackend(StateBackendSettings{
+		Type:    StateBackendFilesystem,
+		FileDir: dir,
+		Environments: &core.FakeEnvironmentRepository{
+			GetFn: func(string) (*core.Environment, error) {
+				return &core.Environment{Doc: core.EnvironmentDoc{Config: core.EnvironmentConfig{Kustomize: util.PtrBool(true)}}}, nil
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	committer, err := backend.NewCommitter(context.Background(), "dev")
+	require.NoError(t, err)
+	err = committer.Commit("test", []core.ResourceFile{{Name: "state/riser-managed/namespace.myns.yaml", Contents: []byte("ns")}}, nil)
+	require.NoError(t, err)
+
+	assert.IsType(t, &state.KustomizeCommitter{}, committer)
+	assert.FileExists(t, filepath.Join(dir, "dev/state/riser-managed/kustomization.yaml"))
+}
+
+func Test_NewStateBackend_DirPerEnv_StateRepo(t *testing.T) {
+	settings := newTestRepoSettings(t)
+	envSettings := newTestRepoSettings(t)
+	backend, err := NewStateBackend(StateBackendSettings{
+		Type: StateBackendDirPerEnv,
+		Repo: settings,
+		Environments: &core.FakeEnvironmentRepository{
+			GetFn: func(envName string) (*core.Environment, error) {
+				if envName == "prod" {
+					return &core.Environment{Doc: core.EnvironmentDoc{Config: core.EnvironmentConfig{
+						StateRepo: &core.EnvironmentStateRepo{URL: envSettings.URL},
+					}}}, nil
+				}
+				return nil, core.ErrNotFound
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	commitTestFile(t, backend, "dev")
+	commitTestFile(t, backend, "prod")
+
+	assert.Equal(t, "dev", readRemoteFile(t, settings.URL, "main", "dev/state/test.yaml"))
+	// An environment with its own state repo owns the whole branch
+	assert.Equal(t, "prod", readRemoteFile(t, envSettings.URL, "prod", "state/test.yaml"))
+}
+
+func Test_NewStateBackend_Filesystem_RequiresDir(t *testing.T) {
+	backend, err := NewStateBackend(StateBackendSettings{Type: StateBackendFilesystem})
+
+	assert.Nil(t, backend)
+	assert.Equal(t, "A directory is required for the filesystem state backend", err.Error())
+}
+
+func Test_NewStateBackend_GitRequiresURL(t *testing.T) {
+	backend, err := NewStateBackend(StateBackendSettings{Type: StateBackendBranchPerEnv})
+
+	assert.Nil(t, backend)
+	assert.Equal(t, "A git url is required for git state backends", err.Error())
+}
+
+func Test_NewStateBackend_Unknown(t *testing.T) {
+	backend, err := NewStateBackend(StateBackendSettings{Type: "unknown"})
+
+	assert.Nil(t, backend)
+	assert.Equal(t, `unknown state backend "unknown"`, err.Error())
+}
+
+func Test_StateBackend_EmptyEnvName(t *testing.T) {
+	backends := []StateBackend{
+		&branchPerEnvStateBackend{NewFakeRepoCache()},
+		&dirPerEnvStateBackend{NewFakeRepoCache()},
+		&filesystemStateBackend{t.TempDir(), NewFakeRepoCache()},
+	}
+
+	for _, backend := range backends {
+		committer, err := backend.NewCommitter(context.Background(), "")
+		assert.Nil(t, committer)
+		assert.Equal(t, "Environment name cannot be empty", err.Error())
+	}
+}
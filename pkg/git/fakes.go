@@ -1,32 +1,53 @@
 package git
 
 import (
-	"sync"
+	"context"
+	"time"
 
 	"github.com/riser-platform/riser-server/pkg/core"
 )
 
 type FakeRepo struct {
-	CommitFn                 func(message string, files []core.ResourceFile) error
+	CommitFn                 func(message string, files []core.ResourceFile, meta *core.CommitMeta) error
 	CommitCallCount          int
-	PushFn                   func() error
+	PushFn                   func(ctx context.Context) error
 	PushCallCount            int
-	ResetHardRemoteFn        func() error
+	ResetHardRemoteFn        func(ctx context.Context) error
 	ResetHardRemoteCallCount int
-	sync.Mutex
+	HeadShaFn                func() (string, error)
+	ReadFilesFn              func(dir string) ([]core.ResourceFile, error)
+	// LockTimeout is how long Lock waits. Zero waits indefinitely.
+	LockTimeout time.Duration
+	lock        repoLock
 }
 
-func (fake *FakeRepo) Commit(message string, files []core.ResourceFile) error {
+func (fake *FakeRepo) Commit(message string, files []core.ResourceFile, meta *core.CommitMeta) error {
 	fake.CommitCallCount++
-	return fake.CommitFn(message, files)
+	return fake.CommitFn(message, files, meta)
 }
 
-func (fake *FakeRepo) Push() error {
+func (fake *FakeRepo) Push(ctx context.Context) error {
 	fake.PushCallCount++
-	return fake.PushFn()
+	return fake.PushFn(ctx)
 }
 
-func (fake *FakeRepo) ResetHardRemote() error {
+func (fake *FakeRepo) ResetHardRemote(ctx context.Context) error {
 	fake.ResetHardRemoteCallCount++
-	return fake.ResetHardRemoteFn()
+	return fake.ResetHardRemoteFn(ctx)
+}
+
+func (fake *FakeRepo) HeadSha() (string, error) {
+	return fake.HeadShaFn()
+}
+
+func (fake *FakeRepo) ReadFiles(dir string) ([]core.ResourceFile, error) {
+	return fake.ReadFilesFn(dir)
+}
+
+func (fake *FakeRepo) Lock() error {
+	return fake.lock.lock(fake.LockTimeout)
+}
+
+func (fake *FakeRepo) Unlock() {
+	fake.lock.unlock()
 }
@@ -0,0 +1,45 @@
+package git
+
+import (
+	"sync"
+	"time"
+)
+
+// repoLock is a mutex that supports waiting with a timeout. The zero value is unlocked.
+type repoLock struct {
+	once sync.Once
+	sem  chan struct{}
+}
+
+func (l *repoLock) init() {
+	l.once.Do(func() {
+		l.sem = make(chan struct{}, 1)
+	})
+}
+
+// lock returns ErrLockTimeout if the lock is not acquired within the timeout. A zero timeout waits indefinitely.
+func (l *repoLock) lock(timeout time.Duration) error {
+	l.init()
+	if timeout == 0 {
+		l.sem <- struct{}{}
+		return nil
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case l.sem <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return ErrLockTimeout
+	}
+}
+
+func (l *repoLock) unlock() {
+	l.init()
+	select {
+	case <-l.sem:
+	default:
+		panic("unlock of unlocked repo")
+	}
+}
@@ -0,0 +1,36 @@
+package git
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_repoLock(t *testing.T) {
+	lock := repoLock{}
+
+	assert.NoError(t, lock.lock(0))
+	assert.Equal(t, ErrLockTimeout, lock.lock(time.Millisecond))
+
+	lock.unlock()
+	assert.NoError(t, lock.lock(time.Millisecond))
+}
+
+func Test_repoLock_WaitsForUnlock(t *testing.T) {
+	lock := repoLock{}
+	assert.NoError(t, lock.lock(0))
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		lock.unlock()
+	}()
+
+	assert.NoError(t, lock.lock(time.Second))
+}
+
+func Test_repoLock_UnlockUnlocked_Panics(t *testing.T) {
+	lock := repoLock{}
+
+	assert.Panics(t, lock.unlock)
+}
@@ -0,0 +1,355 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/osfs"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/cache"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v5/storage"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/pkg/errors"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/util"
+)
+
+const (
+	defaultSSHUser   = "git"
+	defaultHTTPSUser = "riser"
+)
+
+// nativeRepo implements Repo using go-git instead of the git CLI
+type nativeRepo struct {
+	settings *RepoSettings
+	auth     transport.AuthMethod
+	repo     *gogit.Repository
+	lock     repoLock
+}
+
+// InitNativeRepoWorkspace is the same as InitRepoWorkspace except that it uses a pure Go git implementation. Neither git nor ssh need
+// to be installed. The worktree is kept in memory when RepoSettings.InMemory is set.
+func InitNativeRepoWorkspace(repoSettings RepoSettings) (Repo, error) {
+	auth, err := newAuthMethod(repoSettings)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := &nativeRepo{
+		settings: &repoSettings,
+		auth:     auth,
+	}
+
+	err = repo.init()
+	if err != nil {
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+func (repo *nativeRepo) Commit(message string, files []core.ResourceFile, meta *core.CommitMeta) error {
+	worktree, err := repo.repo.Worktree()
+	if err != nil {
+		return errors.Wrap(err, "error opening worktree")
+	}
+
+	err = processFilesFs(worktree.Filesystem, files)
+	if err != nil {
+		return err
+	}
+
+	err = worktree.AddWithOptions(&gogit.AddOptions{All: true})
+	if err != nil {
+		return errors.Wrap(err, "error adding files")
+	}
+
+	status, err := worktree.Status()
+	if err != nil {
+		return errors.Wrap(err, "error getting worktree status")
+	}
+	if status.IsClean() {
+		return ErrNoChanges
+	}
+
+	authorName, authorEmail := commitAuthorNameAndEmail(meta)
+	now := time.Now()
+	_, err = worktree.Commit(formatCommitMessage(message, meta), &gogit.CommitOptions{
+		Author:    &object.Signature{Name: authorName, Email: authorEmail, When: now},
+		Committer: newCommitterSignature(now),
+		// We've already checked for changes. This allows a commit that deletes every file.
+		AllowEmptyCommits: true,
+	})
+	return err
+}
+
+func (repo *nativeRepo) Push(ctx context.Context) error {
+	ctx, cancel := repo.newContext(ctx)
+	defer cancel()
+
+	err := repo.repo.PushContext(ctx, &gogit.PushOptions{
+		RemoteName: remoteName,
+		RefSpecs:   []config.RefSpec{repo.pushRefSpec()},
+		Auth:       repo.auth,
+	})
+	if err == nil || err == gogit.NoErrAlreadyUpToDate {
+		return nil
+	}
+	// go-git checks for a fast-forward before pushing. With a shallow clone the check fails on the missing history instead.
+	if isPushRejectedErr(err) || errors.Is(err, plumbing.ErrObjectNotFound) {
+		return errors.Wrap(ErrPushRejected, err.Error())
+	}
+	return wrapNativeErr(ctx, err, "push")
+}
+
+func (repo *nativeRepo) Lock() error {
+	return repo.lock.lock(repo.settings.LockTimeout)
+}
+
+func (repo *nativeRepo) Unlock() {
+	repo.lock.unlock()
+}
+
+// ResetHardRemote ensures that the remote is up-to-date. Pending commits will be lost.
+func (repo *nativeRepo) ResetHardRemote(ctx context.Context) error {
+	err := repo.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	remoteRef, err := repo.repo.Reference(plumbing.NewRemoteReferenceName(remoteName, repo.settings.Branch), true)
+	if err != nil {
+		return errors.Wrap(err, "error resolving remote branch")
+	}
+
+	err = repo.repo.Storer.SetReference(plumbing.NewHashReference(repo.branchRef(), remoteRef.Hash()))
+	if err != nil {
+		return errors.Wrap(err, "error updating branch")
+	}
+
+	worktree, err := repo.repo.Worktree()
+	if err != nil {
+		return errors.Wrap(err, "error opening worktree")
+	}
+
+	err = worktree.Reset(&gogit.ResetOptions{Commit: remoteRef.Hash(), Mode: gogit.HardReset})
+	if err != nil {
+		return errors.Wrap(err, "error resetting worktree")
+	}
+	return nil
+}
+
+func (repo *nativeRepo) HeadSha() (string, error) {
+	head, err := repo.repo.Head()
+	if err != nil {
+		return "", err
+	}
+	return head.Hash().String(), nil
+}
+
+func (repo *nativeRepo) ReadFiles(dir string) ([]core.ResourceFile, error) {
+	worktree, err := repo.repo.Worktree()
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening worktree")
+	}
+	return readFilesFs(worktree.Filesystem, dir)
+}
+
+func (repo *nativeRepo) init() error {
+	store, fs, err := repo.newStorage()
+	if err != nil {
+		return err
+	}
+
+	r, err := gogit.Init(store, fs)
+	if err != nil {
+		return errors.Wrap(err, "error initializing repo")
+	}
+
+	_, err = r.CreateRemote(&config.RemoteConfig{
+		Name:  remoteName,
+		URLs:  []string{repo.settings.URL},
+		Fetch: []config.RefSpec{repo.fetchRefSpec()},
+	})
+	if err != nil {
+		return errors.Wrap(err, "error creating remote")
+	}
+
+	err = r.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, repo.branchRef()))
+	if err != nil {
+		return errors.Wrap(err, "error setting HEAD")
+	}
+	repo.repo = r
+
+	// The repo is initialized on demand for the first caller but is shared by every caller thereafter
+	ctx := context.Background()
+
+	// Create the branch on demand if needed.
+	branchExists, err := repo.branchExists(ctx)
+	if err != nil {
+		return err
+	}
+
+	if branchExists {
+		return repo.ResetHardRemote(ctx)
+	}
+
+	err = repo.createEmptyBranch()
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("error creating branch %q", repo.settings.Branch))
+	}
+	return repo.Push(ctx)
+}
+
+func (repo *nativeRepo) newStorage() (storage.Storer, billy.Filesystem, error) {
+	if repo.settings.InMemory {
+		return memory.NewStorage(), memfs.New(), nil
+	}
+
+	err := util.EnsureDir(util.EnsureTrailingSlash(repo.settings.BaseWorkspaceDir), workspaceFilePerm)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, fmt.Sprintf("error ensuring git dir: %s", repo.settings.BaseWorkspaceDir))
+	}
+
+	workspaceDir, err := os.MkdirTemp(repo.settings.BaseWorkspaceDir, "repo-*")
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Error creating workspace dir")
+	}
+
+	fs := osfs.New(workspaceDir)
+	dotGit, err := fs.Chroot(gogit.GitDirName)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error creating git dir")
+	}
+
+	return filesystem.NewStorage(dotGit, cache.NewObjectLRUDefault()), fs, nil
+}
+
+// createEmptyBranch creates an empty branch with no history
+func (repo *nativeRepo) createEmptyBranch() error {
+	worktree, err := repo.repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	signature := newCommitterSignature(time.Now())
+	_, err = worktree.Commit("Initial commit for Riser state branch", &gogit.CommitOptions{
+		Author:            signature,
+		Committer:         signature,
+		AllowEmptyCommits: true,
+	})
+	return err
+}
+
+// branchExists determines if the branch exists on the remote. Returns an error if the remote is invalid
+func (repo *nativeRepo) branchExists(ctx context.Context) (bool, error) {
+	ctx, cancel := repo.newContext(ctx)
+	defer cancel()
+
+	remote, err := repo.repo.Remote(remoteName)
+	if err != nil {
+		return false, err
+	}
+
+	refs, err := remote.ListContext(ctx, &gogit.ListOptions{Auth: repo.auth})
+	if err != nil {
+		if err == transport.ErrEmptyRemoteRepository {
+			return false, nil
+		}
+		return false, wrapNativeErr(ctx, err, "ls-remote")
+	}
+
+	for _, ref := range refs {
+		if ref.Name() == repo.branchRef() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (repo *nativeRepo) fetch(ctx context.Context) error {
+	ctx, cancel := repo.newContext(ctx)
+	defer cancel()
+
+	err := repo.repo.FetchContext(ctx, &gogit.FetchOptions{
+		RemoteName: remoteName,
+		RefSpecs:   []config.RefSpec{repo.fetchRefSpec()},
+		Depth:      1,
+		Auth:       repo.auth,
+		Force:      true,
+	})
+	if err != nil && err != gogit.NoErrAlreadyUpToDate {
+		return wrapNativeErr(ctx, err, "fetch")
+	}
+	return nil
+}
+
+// newContext limits a remote operation to the repo's timeout. The operation is also canceled with the caller's context.
+func (repo *nativeRepo) newContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, timeoutOrDefault(repo.settings.Timeout))
+}
+
+func (repo *nativeRepo) branchRef() plumbing.ReferenceName {
+	return plumbing.NewBranchReferenceName(repo.settings.Branch)
+}
+
+func (repo *nativeRepo) fetchRefSpec() config.RefSpec {
+	return config.RefSpec(fmt.Sprintf("+%s:%s", repo.branchRef(), plumbing.NewRemoteReferenceName(remoteName, repo.settings.Branch)))
+}
+
+func (repo *nativeRepo) pushRefSpec() config.RefSpec {
+	return config.RefSpec(fmt.Sprintf("%s:%s", repo.branchRef(), repo.branchRef()))
+}
+
+func newCommitterSignature(when time.Time) *object.Signature {
+	name, email := commitAuthorNameAndEmail(nil)
+	return &object.Signature{Name: name, Email: email, When: when}
+}
+
+// newAuthMethod returns nil when no credentials are configured so that go-git uses its defaults (e.g. the ssh agent)
+func newAuthMethod(settings RepoSettings) (transport.AuthMethod, error) {
+	if settings.Auth.SSHKeyPath != "" {
+		endpoint, err := transport.NewEndpoint(settings.URL)
+		if err != nil {
+			return nil, errors.Wrap(err, "error parsing git url")
+		}
+		user := endpoint.User
+		if user == "" {
+			user = defaultSSHUser
+		}
+		// Unlike the ssh CLI, go-git does not care about the key file's permissions. This avoids the need for the KubeSSHMountPath hack.
+		keys, err := gitssh.NewPublicKeysFromFile(user, settings.Auth.SSHKeyPath, "")
+		if err != nil {
+			return nil, errors.Wrap(err, "error loading SSH key")
+		}
+		return keys, nil
+	}
+
+	if settings.Auth.Password != "" {
+		username := settings.Auth.Username
+		if username == "" {
+			username = defaultHTTPSUser
+		}
+		return &githttp.BasicAuth{Username: username, Password: settings.Auth.Password}, nil
+	}
+
+	return nil, nil
+}
+
+func wrapNativeErr(ctx context.Context, err error, operation string) error {
+	if ctx.Err() == context.DeadlineExceeded || ctx.Err() == context.Canceled {
+		return errors.Wrap(ctx.Err(), fmt.Sprintf("git %s aborted", operation))
+	}
+	return errors.Wrap(err, fmt.Sprintf("git %s", operation))
+}
@@ -0,0 +1,234 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-billy/v5/util"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/pkg/errors"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBareRemote creates an empty bare repo. Note that go-git uses the git CLI for local file transport.
+func newBareRemote(t *testing.T) string {
+	dir := t.TempDir()
+	_, err := gogit.PlainInit(dir, true)
+	require.NoError(t, err)
+	return dir
+}
+
+func newNativeTestRepo(t *testing.T, remoteDir string, inMemory bool) *nativeRepo {
+	repo, err := InitNativeRepoWorkspace(RepoSettings{
+		URL:              remoteDir,
+		Branch:           "dev",
+		BaseWorkspaceDir: t.TempDir(),
+		InMemory:         inMemory,
+	})
+	require.NoError(t, err)
+	return repo.(*nativeRepo)
+}
+
+func remoteCommit(t *testing.T, remoteDir string) *plumbing.Reference {
+	remote, err := gogit.PlainOpen(remoteDir)
+	require.NoError(t, err)
+	ref, err := remote.Reference(plumbing.NewBranchReferenceName("dev"), true)
+	require.NoError(t, err)
+	return ref
+}
+
+func Test_nativeRepo_CommitAndPush(t *testing.T) {
+	remoteDir := newBareRemote(t)
+	repo := newNativeTestRepo(t, remoteDir, true)
+
+	err := repo.Commit("test message", []core.ResourceFile{{Name: "nested/test.yaml", Contents: []byte("contents")}},
+		&core.CommitMeta{Username: "myuser", App: "myapp"})
+	require.NoError(t, err)
+	require.NoError(t, repo.Push(context.Background()))
+
+	sha, err := repo.HeadSha()
+	require.NoError(t, err)
+	assert.Equal(t, sha, remoteCommit(t, remoteDir).Hash().String())
+
+	commit, err := repo.repo.CommitObject(plumbing.NewHash(sha))
+	require.NoError(t, err)
+	assert.Equal(t, "test message\n\nRiser-User: myuser\nRiser-App: myapp", commit.Message)
+	assert.Equal(t, "myuser", commit.Author.Name)
+	assert.Equal(t, "myuser@tempuri.org", commit.Author.Email)
+	assert.Equal(t, "riser-server", commit.Committer.Name)
+
+	// A new workspace on disk should see the pushed changes
+	other := newNativeTestRepo(t, remoteDir, false)
+	worktree, err := other.repo.Worktree()
+	require.NoError(t, err)
+	contents, err := util.ReadFile(worktree.Filesystem, "nested/test.yaml")
+	require.NoError(t, err)
+	assert.EqualValues(t, "contents", contents)
+}
+
+func Test_nativeRepo_ReadFiles(t *testing.T) {
+	remoteDir := newBareRemote(t)
+	repo := newNativeTestRepo(t, remoteDir, true)
+	require.NoError(t, repo.Commit("add", []core.ResourceFile{
+		{Name: "nested/a.yaml", Contents: []byte("a")},
+		{Name: "nested/deeper/b.yaml", Contents: []byte("b")},
+		{Name: "other/c.yaml", Contents: []byte("c")},
+	}, nil))
+
+	result, err := repo.ReadFiles("nested")
+
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []core.ResourceFile{
+		{Name: "nested/a.yaml", Contents: []byte("a")},
+		{Name: "nested/deeper/b.yaml", Contents: []byte("b")},
+	}, result)
+}
+
+func Test_nativeRepo_ReadFiles_MissingDir(t *testing.T) {
+	repo := newNativeTestRepo(t, newBareRemote(t), true)
+
+	result, err := repo.ReadFiles("missing")
+
+	require.NoError(t, err)
+	assert.Empty(t, result)
+}
+
+func Test_nativeRepo_Commit_Deletes(t *testing.T) {
+	remoteDir := newBareRemote(t)
+	repo := newNativeTestRepo(t, remoteDir, true)
+	require.NoError(t, repo.Commit("add", []core.ResourceFile{{Name: "nested/test.yaml", Contents: []byte("contents")}}, nil))
+
+	err := repo.Commit("delete", []core.ResourceFile{{Name: "nested", Delete: true}}, nil)
+
+	require.NoError(t, err)
+	head, err := repo.repo.Head()
+	require.NoError(t, err)
+	commit, err := repo.repo.CommitObject(head.Hash())
+	require.NoError(t, err)
+	tree, err := commit.Tree()
+	require.NoError(t, err)
+	assert.Empty(t, tree.Entries)
+}
+
+func Test_nativeRepo_Commit_NoChanges(t *testing.T) {
+	remoteDir := newBareRemote(t)
+	repo := newNativeTestRepo(t, remoteDir, true)
+	files := []core.ResourceFile{{Name: "test.yaml", Contents: []byte("contents")}}
+	require.NoError(t, repo.Commit("test message", files, nil))
+
+	err := repo.Commit("test message", files, nil)
+
+	assert.Equal(t, ErrNoChanges, err)
+}
+
+func Test_nativeRepo_Push_Rejected(t *testing.T) {
+	remoteDir := newBareRemote(t)
+	repo1 := newNativeTestRepo(t, remoteDir, true)
+	repo2 := newNativeTestRepo(t, remoteDir, false)
+
+	require.NoError(t, repo1.Commit("repo1", []core.ResourceFile{{Name: "repo1.yaml", Contents: []byte("1")}}, nil))
+	require.NoError(t, repo1.Push(context.Background()))
+	require.NoError(t, repo2.Commit("repo2", []core.ResourceFile{{Name: "repo2.yaml", Contents: []byte("2")}}, nil))
+
+	err := repo2.Push(context.Background())
+
+	assert.True(t, errors.Is(err, ErrPushRejected), err)
+
+	// Reapplying on top of the remote should succeed
+	require.NoError(t, repo2.ResetHardRemote(context.Background()))
+	require.NoError(t, repo2.Commit("repo2", []core.ResourceFile{{Name: "repo2.yaml", Contents: []byte("2")}}, nil))
+	require.NoError(t, repo2.Push(context.Background()))
+	worktree, err := repo2.repo.Worktree()
+	require.NoError(t, err)
+	_, err = worktree.Filesystem.Stat("repo1.yaml")
+	assert.NoError(t, err)
+}
+
+func Test_nativeRepo_CanceledContext(t *testing.T) {
+	remoteDir := newBareRemote(t)
+	repo := newNativeTestRepo(t, remoteDir, true)
+	require.NoError(t, repo.Commit("test message", []core.ResourceFile{{Name: "test.yaml", Contents: []byte("contents")}}, nil))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.Error(t, repo.Push(ctx))
+	assert.Error(t, repo.ResetHardRemote(ctx))
+	assert.NoError(t, repo.Push(context.Background()))
+}
+
+func Test_nativeRepo_OnDisk(t *testing.T) {
+	remoteDir := newBareRemote(t)
+	baseDir := t.TempDir()
+
+	_, err := InitNativeRepoWorkspace(RepoSettings{URL: remoteDir, Branch: "dev", BaseWorkspaceDir: baseDir})
+
+	require.NoError(t, err)
+	workspaces, err := filepath.Glob(filepath.Join(baseDir, "repo-*", ".git"))
+	require.NoError(t, err)
+	assert.Len(t, workspaces, 1)
+}
+
+func Test_InitNativeRepoWorkspace_InvalidRemote(t *testing.T) {
+	_, err := InitNativeRepoWorkspace(RepoSettings{URL: filepath.Join(os.TempDir(), "riser-does-not-exist"), Branch: "dev", InMemory: true})
+
+	assert.Error(t, err)
+}
+
+func Test_wrapNativeErr(t *testing.T) {
+	result := wrapNativeErr(context.Background(), errors.New("test"), "fetch")
+
+	assert.Equal(t, "git fetch: test", result.Error())
+}
+
+func Test_wrapNativeErr_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result := wrapNativeErr(ctx, errors.New("test"), "fetch")
+
+	assert.Equal(t, "git fetch aborted: context canceled", result.Error())
+}
+
+func Test_newAuthMethod_None(t *testing.T) {
+	result, err := newAuthMethod(RepoSettings{URL: "https://git.tempuri.org/state"})
+
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func Test_newAuthMethod_Token(t *testing.T) {
+	result, err := newAuthMethod(RepoSettings{URL: "https://git.tempuri.org/state", Auth: Auth{Password: "mytoken"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, &githttp.BasicAuth{Username: "riser", Password: "mytoken"}, result)
+}
+
+func Test_newAuthMethod_TokenWithUsername(t *testing.T) {
+	result, err := newAuthMethod(RepoSettings{URL: "https://git.tempuri.org/state", Auth: Auth{Username: "myuser", Password: "mytoken"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, &githttp.BasicAuth{Username: "myuser", Password: "mytoken"}, result)
+}
+
+func Test_newAuthMethod_SSHKeyNotFound(t *testing.T) {
+	_, err := newAuthMethod(RepoSettings{URL: "git@git.tempuri.org:org/state", Auth: Auth{SSHKeyPath: "/does/not/exist"}})
+
+	assert.Contains(t, err.Error(), "error loading SSH key")
+}
+
+func Test_InitFuncForBackend(t *testing.T) {
+	for _, backend := range []string{"", BackendExec, BackendNative} {
+		result, err := InitFuncForBackend(backend)
+		assert.NoError(t, err)
+		assert.NotNil(t, result)
+	}
+
+	_, err := InitFuncForBackend("unknown")
+	assert.Equal(t, `unknown git backend "unknown"`, err.Error())
+}
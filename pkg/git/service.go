@@ -7,9 +7,9 @@ import (
 	"os"
 	"os/exec"
 	"strings"
-	"sync"
 	"time"
 
+	"github.com/go-git/go-billy/v5/osfs"
 	"github.com/pkg/errors"
 	"github.com/riser-platform/riser-server/pkg/core"
 	"github.com/riser-platform/riser-server/pkg/util"
@@ -17,20 +17,28 @@ import (
 
 var (
 	// ErrNoChanges indicates that there were no changes to commit
-	ErrNoChanges       = errors.New("no changes to commit")
+	ErrNoChanges = errors.New("no changes to commit")
+	// ErrLockTimeout indicates that the repo lock could not be acquired within the RepoSettings.LockTimeout
+	ErrLockTimeout = errors.New("timed out waiting for the repo lock")
+	// ErrPushRejected indicates that the remote rejected the push because it has changes that we do not have locally
+	ErrPushRejected    = errors.New("push rejected by the remote")
 	KubeSSHMountPath   = "/etc/riser/kube/ssh/identity"
 	KubeSSHTargetPath  = "/etc/riser/ssh/identity"
 	KubeSSHKeyFileMode = os.FileMode(0400)
 )
 
 const (
-	// TODO: After adding auth consider making this dynamic e.g. "riser-server (initiated by johndoe@acme.org)"
-	commitName  = "riser-server"
-	commitEmail = "riser-server@tempuri.org"
-	remoteName  = "origin"
+	commitName        = "riser-server"
+	commitEmailDomain = "tempuri.org"
+	remoteName        = "origin"
 
-	// TODO: Consider making configurable - the main scenario is large repos that take a long time for the initial clone
-	gitExecTimeoutSeconds = 30 * time.Second
+	// defaultTimeout is used when RepoSettings.Timeout is not set
+	defaultTimeout = 30 * time.Second
+
+	// BackendExec executes the git CLI
+	BackendExec = "exec"
+	// BackendNative uses a pure Go git implementation and does not require git or ssh to be installed
+	BackendNative = "native"
 )
 
 type RepoSettings struct {
@@ -38,14 +46,39 @@ type RepoSettings struct {
 	Branch string
 	// BaseWorkspaceDir is the root folder for this repo's workspace. A random folder will be created here.
 	BaseWorkspaceDir string
+	// LockTimeout is how long to wait for the repo lock. Zero waits indefinitely.
+	LockTimeout time.Duration
+	// Timeout is how long a single git operation (e.g. clone, fetch, or push) may take. Zero uses a default of 30s.
+	Timeout time.Duration
+	// Auth is only used by the native backend. The exec backend uses the git CLI's configuration (e.g. ~/.ssh or credential helpers).
+	Auth Auth
+	// InMemory keeps the worktree in memory instead of the BaseWorkspaceDir. Only supported by the native backend.
+	InMemory bool
+}
+
+type Auth struct {
+	// SSHKeyPath is the path to a PEM encoded private key. Takes precedence over Password.
+	SSHKeyPath string
+	// Username is the HTTPS basic auth username. Most git providers accept any value when Password is a token.
+	Username string
+	// Password is the HTTPS basic auth password or access token
+	Password string
 }
 
 type Repo interface {
-	Commit(message string, files []core.ResourceFile) error
-	Push() error
-	ResetHardRemote() error
-	// Lock locks the repo. Be sure to call Unlock when your work is completed.
-	Lock()
+	// Commit commits all changes. The user in the CommitMeta is the commit author. Other CommitMeta fields are added as trailers.
+	Commit(message string, files []core.ResourceFile, meta *core.CommitMeta) error
+	// Push pushes the committed changes. The push is canceled when the context is done.
+	Push(ctx context.Context) error
+	// ResetHardRemote resets the worktree to the remote branch. The fetch is canceled when the context is done.
+	ResetHardRemote(ctx context.Context) error
+	// HeadSha returns the SHA of the current commit
+	HeadSha() (string, error)
+	// ReadFiles returns every file in a folder of the worktree. Call ResetHardRemote first to read the latest state.
+	ReadFiles(dir string) ([]core.ResourceFile, error)
+	// Lock locks the repo. Returns ErrLockTimeout if the lock is not acquired within the LockTimeout. Be sure to call Unlock when
+	// your work is completed.
+	Lock() error
 	// Unlock unlocks the repo.
 	Unlock()
 }
@@ -53,7 +86,18 @@ type Repo interface {
 type repo struct {
 	settings     *RepoSettings
 	workspaceDir string
-	sync.Mutex
+	lock         repoLock
+}
+
+// InitFuncForBackend returns the function used to initialize repos for the specified backend. An empty backend uses the git CLI.
+func InitFuncForBackend(backend string) (func(RepoSettings) (Repo, error), error) {
+	switch backend {
+	case "", BackendExec:
+		return InitRepoWorkspace, nil
+	case BackendNative:
+		return InitNativeRepoWorkspace, nil
+	}
+	return nil, fmt.Errorf("unknown git backend %q", backend)
 }
 
 // InitRepoWorkspace clones a repo reference into the specified folder and returns a new reference to the repo.
@@ -62,7 +106,6 @@ type repo struct {
 func InitRepoWorkspace(repoSettings RepoSettings) (Repo, error) {
 	repo := &repo{
 		settings: &repoSettings,
-		Mutex:    sync.Mutex{},
 	}
 
 	// Terrible hack due to https://github.com/kubernetes/kubernetes/issues/57923
@@ -88,7 +131,7 @@ func InitRepoWorkspace(repoSettings RepoSettings) (Repo, error) {
 	return repo, nil
 }
 
-func (repo *repo) Commit(message string, files []core.ResourceFile) error {
+func (repo *repo) Commit(message string, files []core.ResourceFile, meta *core.CommitMeta) error {
 	err := processFiles(repo.workspaceDir, files)
 	if err != nil {
 		return err
@@ -97,30 +140,53 @@ func (repo *repo) Commit(message string, files []core.ResourceFile) error {
 	if err != nil {
 		return err
 	}
-	_, err = repo.execGitCmd("commit", "-m", message, "--author", fmt.Sprintf("%s <%s>", commitName, commitEmail))
+	_, err = repo.execGitCmd("commit", "-m", formatCommitMessage(message, meta), "--author", commitAuthor(meta))
 	if err != nil && isNoChangesErr(err) {
 		return ErrNoChanges
 	}
 	return err
 }
 
-func (repo *repo) Push() error {
-	_, err := repo.execGitCmd("push")
+func (repo *repo) Push(ctx context.Context) error {
+	_, err := repo.execGitCmdContext(ctx, "push")
+	if err != nil && isPushRejectedErr(err) {
+		return errors.Wrap(ErrPushRejected, err.Error())
+	}
 	return err
 }
 
+func (repo *repo) Lock() error {
+	return repo.lock.lock(repo.settings.LockTimeout)
+}
+
+func (repo *repo) Unlock() {
+	repo.lock.unlock()
+}
+
 // ResetHardRemote ensures that the remote is up-to-date. Pending commits will be lost.
-func (repo *repo) ResetHardRemote() error {
+func (repo *repo) ResetHardRemote(ctx context.Context) error {
 	// Always fetch before resetting to the remote to ensure that we're up-to-date
-	err := repo.fetch()
+	err := repo.fetch(ctx)
 	if err != nil {
 		return err
 	}
 
-	_, err = repo.execGitCmd("reset", "--hard", fmt.Sprintf("%s/%s", remoteName, repo.settings.Branch))
+	_, err = repo.execGitCmdContext(ctx, "reset", "--hard", fmt.Sprintf("%s/%s", remoteName, repo.settings.Branch))
 	return err
 }
 
+func (repo *repo) HeadSha() (string, error) {
+	buffer, err := repo.execGitCmd("rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buffer.String()), nil
+}
+
+func (repo *repo) ReadFiles(dir string) ([]core.ResourceFile, error) {
+	return readFilesFs(osfs.New(repo.workspaceDir), dir)
+}
+
 func (repo *repo) addAll() error {
 
 	_, err := repo.execGitCmd("add", "--all")
@@ -202,13 +268,18 @@ func (repo *repo) branchExists() (exists bool, err error) {
 	return exists, nil
 }
 
-func (repo *repo) fetch() error {
-	_, err := repo.execGitCmd("fetch", "-f", remoteName, repo.settings.Branch)
+func (repo *repo) fetch(ctx context.Context) error {
+	_, err := repo.execGitCmdContext(ctx, "fetch", "-f", remoteName, repo.settings.Branch)
 	return err
 }
 
 func (repo *repo) execGitCmd(args ...string) (stdOutAndStdErr *bytes.Buffer, err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), gitExecTimeoutSeconds)
+	return repo.execGitCmdContext(context.Background(), args...)
+}
+
+// execGitCmdContext limits the command to the repo's timeout. The command is also canceled with the caller's context.
+func (repo *repo) execGitCmdContext(ctx context.Context, args ...string) (stdOutAndStdErr *bytes.Buffer, err error) {
+	ctx, cancel := context.WithTimeout(ctx, timeoutOrDefault(repo.settings.Timeout))
 	defer cancel()
 	cmd := repo.buildGitCmd(ctx, args...)
 	stdOutAndStdErr, err = execWithContext(ctx, cmd)
@@ -224,3 +295,10 @@ func (repo *repo) buildGitCmd(ctx context.Context, args ...string) *exec.Cmd {
 	cmd.Dir = repo.workspaceDir
 	return cmd
 }
+
+func timeoutOrDefault(timeout time.Duration) time.Duration {
+	if timeout == 0 {
+		return defaultTimeout
+	}
+	return timeout
+}
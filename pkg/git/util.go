@@ -1,12 +1,15 @@
 package git
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/osfs"
+	billyutil "github.com/go-git/go-billy/v5/util"
 	"github.com/pkg/errors"
-	"github.com/riser-platform/riser-server/pkg/util"
 
 	"github.com/riser-platform/riser-server/pkg/core"
 )
@@ -16,20 +19,51 @@ const (
 )
 
 func processFiles(baseDir string, files []core.ResourceFile) error {
+	return processFilesFs(osfs.New(baseDir), files)
+}
+
+// readFilesFs reads every file in a folder. Returns no files if the folder does not exist.
+func readFilesFs(fs billy.Filesystem, dir string) ([]core.ResourceFile, error) {
+	files := []core.ResourceFile{}
+	err := billyutil.Walk(fs, dir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && filePath == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		contents, err := billyutil.ReadFile(fs, filePath)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("error reading file %q", filePath))
+		}
+		files = append(files, core.ResourceFile{Name: filepath.ToSlash(filePath), Contents: contents})
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("error reading directory %q", dir))
+	}
+	return files, nil
+}
+
+// processFilesFs is the same as processFiles for any filesystem (e.g. an in-memory worktree)
+func processFilesFs(fs billy.Filesystem, files []core.ResourceFile) error {
 	for _, file := range files {
-		fullFileName := filepath.Join(baseDir, file.Name)
 		if file.Delete {
-			err := os.RemoveAll(fullFileName)
+			err := billyutil.RemoveAll(fs, file.Name)
 			if err != nil {
 				return errors.Wrap(err, "error deleting file or directory")
 			}
 		} else {
-			err := util.EnsureDir(fullFileName, workspaceFilePerm)
+			err := fs.MkdirAll(filepath.Dir(file.Name), workspaceFilePerm)
 			if err != nil {
-				return err
+				return errors.Wrap(err, "error creating directory")
 			}
 
-			err = os.WriteFile(fullFileName, file.Contents, workspaceFilePerm)
+			err = billyutil.WriteFile(fs, file.Name, file.Contents, workspaceFilePerm)
 			if err != nil {
 				return errors.Wrap(err, "error writing file")
 			}
@@ -42,3 +76,54 @@ func processFiles(baseDir string, files []core.ResourceFile) error {
 func isNoChangesErr(err error) bool {
 	return strings.Contains(err.Error(), "working tree clean")
 }
+
+// isPushRejectedErr determines if the remote rejected a push because it contains commits that we do not have (e.g. a concurrent push)
+func isPushRejectedErr(err error) bool {
+	message := err.Error()
+	return strings.Contains(message, "[rejected]") || strings.Contains(message, "non-fast-forward") || strings.Contains(message, "fetch first")
+}
+
+// commitAuthor returns the requesting user as the author, or riser-server when the change was not requested by a user
+func commitAuthor(meta *core.CommitMeta) string {
+	name, email := commitAuthorNameAndEmail(meta)
+	return fmt.Sprintf("%s <%s>", name, email)
+}
+
+func commitAuthorNameAndEmail(meta *core.CommitMeta) (name string, email string) {
+	if meta == nil || meta.Username == "" {
+		return commitName, fmt.Sprintf("%s@%s", commitName, commitEmailDomain)
+	}
+
+	email = meta.Username
+	if !strings.Contains(email, "@") {
+		email = fmt.Sprintf("%s@%s", meta.Username, commitEmailDomain)
+	}
+	return meta.Username, email
+}
+
+// formatCommitMessage appends trailers so that commits may be found with "git log --grep" (e.g. --grep "Riser-Deployment: myapp")
+func formatCommitMessage(message string, meta *core.CommitMeta) string {
+	if meta == nil {
+		return message
+	}
+
+	trailers := []string{}
+	addTrailer := func(key, value string) {
+		if value != "" {
+			trailers = append(trailers, fmt.Sprintf("%s: %s", key, value))
+		}
+	}
+	addTrailer("Riser-User", meta.Username)
+	addTrailer("Riser-App", meta.App)
+	addTrailer("Riser-Namespace", meta.Namespace)
+	addTrailer("Riser-Deployment", meta.Deployment)
+	addTrailer("Riser-Environment", meta.Environment)
+	if meta.RiserRevision > 0 {
+		addTrailer("Riser-Revision", fmt.Sprintf("%d", meta.RiserRevision))
+	}
+
+	if len(trailers) == 0 {
+		return message
+	}
+	return fmt.Sprintf("%s\n\n%s", message, strings.Join(trailers, "\n"))
+}
@@ -67,3 +67,44 @@ func Test_isNoChangesErr_false(t *testing.T) {
 
 	assert.False(t, result)
 }
+
+func Test_commitAuthor(t *testing.T) {
+	assert.Equal(t, "riser-server <riser-server@tempuri.org>", commitAuthor(nil))
+	assert.Equal(t, "riser-server <riser-server@tempuri.org>", commitAuthor(&core.CommitMeta{}))
+	assert.Equal(t, "myuser <myuser@tempuri.org>", commitAuthor(&core.CommitMeta{Username: "myuser"}))
+	assert.Equal(t, "me@example.com <me@example.com>", commitAuthor(&core.CommitMeta{Username: "me@example.com"}))
+}
+
+func Test_formatCommitMessage(t *testing.T) {
+	meta := &core.CommitMeta{
+		Username:      "myuser",
+		App:           "myapp",
+		Namespace:     "myns",
+		Deployment:    "myapp-dep",
+		Environment:   "prod",
+		RiserRevision: 3,
+	}
+
+	result := formatCommitMessage("Updating resources", meta)
+
+	assert.Equal(t, `Updating resources
+
+Riser-User: myuser
+Riser-App: myapp
+Riser-Namespace: myns
+Riser-Deployment: myapp-dep
+Riser-Environment: prod
+Riser-Revision: 3`, result)
+}
+
+func Test_formatCommitMessage_OmitsEmpty(t *testing.T) {
+	assert.Equal(t, "msg", formatCommitMessage("msg", nil))
+	assert.Equal(t, "msg", formatCommitMessage("msg", &core.CommitMeta{}))
+	assert.Equal(t, "msg\n\nRiser-Environment: prod", formatCommitMessage("msg", &core.CommitMeta{Environment: "prod"}))
+}
+
+func Test_isPushRejectedErr(t *testing.T) {
+	assert.True(t, isPushRejectedErr(errors.New(" ! [rejected]        main -> main (fetch first)")))
+	assert.True(t, isPushRejectedErr(errors.New("Updates were rejected because the tip of your current branch is behind (non-fast-forward)")))
+	assert.False(t, isPushRejectedErr(errors.New("Permission denied (publickey)")))
+}
@@ -1,19 +1,30 @@
 package login
 
 import (
+	"crypto/rand"
 	"crypto/sha256"
+	"encoding/base64"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/pkg/errors"
 
+	"github.com/riser-platform/riser-server/api/v1/model"
 	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/oidc"
 )
 
 const RootUsername = "root"
 const ApiKeyMinCharacterLength = 32
 
+// RootApiKeyName is the name of the API key created when bootstrapping the root user
+const RootApiKeyName = "bootstrap"
+
+// apiKeyByteLength is the number of random bytes in a generated API key
+const apiKeyByteLength = 30
+
 // ErrRootUserExists is returned when the root user exists with an active API key
 var ErrRootUserExists = errors.New("The root user already exists")
 
@@ -25,16 +36,21 @@ var ErrNoBootstrapNoUsers = errors.New("You must specify RISER_BOOTSTRAP_APIKEY
 
 type Service interface {
 	LoginWithApiKey(apiKeyPlainText string) (*core.User, error)
+	// LoginWithToken verifies an OIDC bearer token. Users are provisioned on their first login. The user's email is updated and the
+	// groups are mapped from the token on each login.
+	LoginWithToken(token string) (*core.User, error)
 	BootstrapRootUser(apiKeyPlainText string) error
 }
 
 type service struct {
 	users   core.UserRepository
 	apikeys core.ApiKeyRepository
+	// verifier is nil when OIDC is not configured
+	verifier oidc.Verifier
 }
 
-func NewService(users core.UserRepository, apikeys core.ApiKeyRepository) Service {
-	return &service{users, apikeys}
+func NewService(users core.UserRepository, apikeys core.ApiKeyRepository, verifier oidc.Verifier) Service {
+	return &service{users, apikeys, verifier}
 }
 
 func (s *service) LoginWithApiKey(apiKeyPlainText string) (*core.User, error) {
@@ -47,9 +63,87 @@ func (s *service) LoginWithApiKey(apiKeyPlainText string) (*core.User, error) {
 		return nil, err
 	}
 
+	err = s.apikeys.UpdateLastUsed(hash)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error updating API key usage")
+	}
+
 	return user, nil
 }
 
+func (s *service) LoginWithToken(token string) (*core.User, error) {
+	if s.verifier == nil {
+		return nil, ErrInvalidLogin
+	}
+
+	claims, err := s.verifier.Verify(strings.TrimSpace(token))
+	if err != nil {
+		if errors.Is(err, oidc.ErrInvalidToken) {
+			return nil, ErrInvalidLogin
+		}
+		return nil, err
+	}
+
+	user, err := s.getOrProvisionUser(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.DisabledAt != nil {
+		return nil, ErrInvalidLogin
+	}
+
+	if claims.Email != "" && claims.Email != user.Doc.Email {
+		err = s.users.UpdateEmail(user.Id, claims.Email)
+		if err != nil {
+			return nil, errors.Wrap(err, "Unable to update user")
+		}
+		user.Doc.Email = claims.Email
+	}
+	user.Groups = claims.Groups
+
+	return user, nil
+}
+
+// getOrProvisionUser returns the user bound to an OIDC identity. A user is provisioned with the username from the token the first time
+// that the identity logs in. An identity is never bound to an existing user by username since the identity provider may allow a user to
+// choose their username. An administrator must link an existing user to their identity instead.
+func (s *service) getOrProvisionUser(claims *oidc.Claims) (*core.User, error) {
+	identity := &core.OidcIdentity{Issuer: claims.Issuer, Subject: claims.Subject}
+	username := claims.Username
+	user, err := s.users.GetByOidcIdentity(identity)
+	if err == nil {
+		return user, nil
+	}
+	if err != core.ErrNotFound {
+		return nil, errors.Wrap(err, "Unable to retrieve user")
+	}
+
+	if username == RootUsername || (model.NewUser{Username: username}).Validate() != nil {
+		return nil, ErrInvalidLogin
+	}
+
+	_, err = s.users.GetByUsername(username)
+	if err == nil {
+		return nil, ErrInvalidLogin
+	}
+	if err != core.ErrNotFound {
+		return nil, errors.Wrap(err, "Unable to retrieve user")
+	}
+
+	err = s.users.Create(&core.NewUser{Id: uuid.New(), Username: username, Email: claims.Email, OidcIdentity: identity})
+	if err != nil {
+		// The user may have been provisioned by a concurrent login
+		user, getErr := s.users.GetByOidcIdentity(identity)
+		if getErr == nil {
+			return user, nil
+		}
+		return nil, errors.Wrap(err, "Unable to provision user")
+	}
+
+	return s.users.GetByOidcIdentity(identity)
+}
+
 // BootstrapRootUser is an idempotent function that will create the root user with the specified API key if needed.
 // Passing an empty value for the API key results in a NOOP unless no logins are specified, in which case an operator friendly error is returned.
 // If the root user exists with an API key, the request is ignored and ErrRootUserExists is returned
@@ -96,7 +190,13 @@ func (s *service) BootstrapRootUser(apiKeyPlainText string) error {
 		}
 	}
 
-	err = s.apikeys.Create(rootUserId, hashApiKey([]byte(apiKeyPlainText)))
+	err = s.apikeys.Create(&core.ApiKey{
+		Id:      uuid.New(),
+		UserId:  rootUserId,
+		Name:    RootApiKeyName,
+		KeyHash: hashApiKey([]byte(apiKeyPlainText)),
+		Created: time.Now().UTC(),
+	})
 	if err != nil {
 		return errors.Wrap(err, "Error creating root API key")
 	}
@@ -104,6 +204,18 @@ func (s *service) BootstrapRootUser(apiKeyPlainText string) error {
 	return nil
 }
 
+// GenerateApiKey returns a new random API key and its hash. The plain text key must only be returned to the user once and never stored.
+func GenerateApiKey() (apiKeyPlainText string, keyHash []byte, err error) {
+	keyBytes := make([]byte, apiKeyByteLength)
+	_, err = rand.Read(keyBytes)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "Error generating API key")
+	}
+
+	apiKeyPlainText = base64.RawURLEncoding.EncodeToString(keyBytes)
+	return apiKeyPlainText, hashApiKey([]byte(apiKeyPlainText)), nil
+}
+
 /*
 Important! Changing this algorithm could be a breaking change:
   - Update the DB to store the algorithm used for existing keys and/or provide a way to rehash the keys on next login
@@ -2,11 +2,13 @@ package login
 
 import (
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 
 	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/oidc"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -23,10 +25,17 @@ func Test_LoginWithApiKey(t *testing.T) {
 			return user, nil
 		},
 	}
-	service := service{users: userRepository}
+	apikeyRepository := &core.FakeApiKeyRepository{
+		UpdateLastUsedFn: func(hashArg []byte) error {
+			assert.Equal(t, hash, hashArg)
+			return nil
+		},
+	}
+	service := service{users: userRepository, apikeys: apikeyRepository}
 
 	result, err := service.LoginWithApiKey(plainText)
 
+	assert.Equal(t, 1, apikeyRepository.UpdateLastUsedCallCount)
 	assert.Equal(t, user, result)
 	assert.NoError(t, err)
 	assert.Equal(t, hashApiKey([]byte(plainText)), hash)
@@ -42,10 +51,17 @@ func Test_LoginWithApiKey_Trims(t *testing.T) {
 			return user, nil
 		},
 	}
-	service := service{users: userRepository}
+	apikeyRepository := &core.FakeApiKeyRepository{
+		UpdateLastUsedFn: func(hashArg []byte) error {
+			assert.Equal(t, hash, hashArg)
+			return nil
+		},
+	}
+	service := service{users: userRepository, apikeys: apikeyRepository}
 
 	result, err := service.LoginWithApiKey(plainText)
 
+	assert.Equal(t, 1, apikeyRepository.UpdateLastUsedCallCount)
 	assert.Equal(t, user, result)
 	assert.NoError(t, err)
 	assert.Equal(t, hashApiKey([]byte("aabbccdd")), hash)
@@ -94,14 +110,17 @@ func Test_BootstrapRootUser(t *testing.T) {
 		},
 	}
 	apikeyRepository := &core.FakeApiKeyRepository{
-		CreateFn: func(userId uuid.UUID, keyHash []byte) error {
-			assert.Equal(t, rootUserId, userId)
-			assert.Equal(t, hashApiKey([]byte(testValidKey)), keyHash)
+		CreateFn: func(apiKey *core.ApiKey) error {
+			assert.NotEqual(t, uuid.Nil, apiKey.Id)
+			assert.Equal(t, rootUserId, apiKey.UserId)
+			assert.Equal(t, RootApiKeyName, apiKey.Name)
+			assert.Equal(t, hashApiKey([]byte(testValidKey)), apiKey.KeyHash)
+			assert.Nil(t, apiKey.Expires)
 			return nil
 		},
 	}
 
-	service := service{userRepository, apikeyRepository}
+	service := service{users: userRepository, apikeys: apikeyRepository}
 
 	err := service.BootstrapRootUser(testValidKey)
 
@@ -120,12 +139,12 @@ func Test_BootstrapRootUser_UnableToCreateApiKey_ReturnsError(t *testing.T) {
 		},
 	}
 	apikeyRepository := &core.FakeApiKeyRepository{
-		CreateFn: func(uuid.UUID, []byte) error {
+		CreateFn: func(*core.ApiKey) error {
 			return errors.New("test")
 		},
 	}
 
-	service := service{userRepository, apikeyRepository}
+	service := service{users: userRepository, apikeys: apikeyRepository}
 
 	err := service.BootstrapRootUser(testValidKey)
 
@@ -144,7 +163,7 @@ func Test_BootstrapRootUser_UserWithKeyExists_ReturnsError(t *testing.T) {
 		},
 	}
 
-	service := service{userRepository, apikeyRepository}
+	service := service{users: userRepository, apikeys: apikeyRepository}
 
 	err := service.BootstrapRootUser(testValidKey)
 
@@ -162,7 +181,7 @@ func Test_BootstrapRootUser_UnableToCreateUser_ReturnsError(t *testing.T) {
 	}
 	apikeyRepository := &core.FakeApiKeyRepository{}
 
-	service := service{userRepository, apikeyRepository}
+	service := service{users: userRepository, apikeys: apikeyRepository}
 
 	err := service.BootstrapRootUser(testValidKey)
 
@@ -181,7 +200,7 @@ func Test_BootstrapRootUser_UnableToQueryKeys_ReturnsError(t *testing.T) {
 		},
 	}
 
-	service := service{userRepository, apikeyRepository}
+	service := service{users: userRepository, apikeys: apikeyRepository}
 
 	err := service.BootstrapRootUser(testValidKey)
 
@@ -235,3 +254,236 @@ func Test_BootstrapRootUser_EmptyKeyNoUsers_NOOP(t *testing.T) {
 
 	assert.Equal(t, "You must specify RISER_BOOTSTRAP_APIKEY is required when there are no users. Use \"riser ops generate-apikey\" to generate the key.", err.Error())
 }
+
+func Test_LoginWithApiKey_UpdateLastUsedError_ReturnsError(t *testing.T) {
+	userRepository := &core.FakeUserRepository{
+		GetByApiKeyFn: func([]byte) (*core.User, error) {
+			return &core.User{}, nil
+		},
+	}
+	apikeyRepository := &core.FakeApiKeyRepository{
+		UpdateLastUsedFn: func([]byte) error {
+			return errors.New("test")
+		},
+	}
+	service := service{users: userRepository, apikeys: apikeyRepository}
+
+	result, err := service.LoginWithApiKey("aabbccdd")
+
+	assert.Nil(t, result)
+	assert.Equal(t, "Error updating API key usage: test", err.Error())
+}
+
+func Test_GenerateApiKey(t *testing.T) {
+	plainText, hash, err := GenerateApiKey()
+
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, len(plainText), ApiKeyMinCharacterLength)
+	assert.Equal(t, hashApiKey([]byte(plainText)), hash)
+
+	other, _, err := GenerateApiKey()
+	assert.NoError(t, err)
+	assert.NotEqual(t, plainText, other)
+}
+
+func Test_LoginWithToken(t *testing.T) {
+	user := &core.User{Id: uuid.New(), Username: "myuser", Doc: core.UserDoc{Email: "user@example.com"}}
+	verifier := &oidc.FakeVerifier{
+		VerifyFn: func(token string) (*oidc.Claims, error) {
+			assert.Equal(t, "mytoken", token)
+			return &oidc.Claims{Issuer: "https://issuer", Subject: "1234", Username: "renamed", Email: "user@example.com", Groups: []string{"dev"}}, nil
+		},
+	}
+	userRepository := &core.FakeUserRepository{
+		GetByOidcIdentityFn: func(identity *core.OidcIdentity) (*core.User, error) {
+			assert.Equal(t, &core.OidcIdentity{Issuer: "https://issuer", Subject: "1234"}, identity)
+			return user, nil
+		},
+	}
+	service := service{users: userRepository, verifier: verifier}
+
+	result, err := service.LoginWithToken(" mytoken ")
+
+	assert.NoError(t, err)
+	assert.Equal(t, user, result)
+	assert.Equal(t, []string{"dev"}, result.Groups)
+	assert.Equal(t, 0, userRepository.CreateCallCount)
+	assert.Equal(t, 0, userRepository.UpdateEmailCallCount)
+}
+
+func Test_LoginWithToken_UpdatesEmail(t *testing.T) {
+	userId := uuid.New()
+	verifier := &oidc.FakeVerifier{
+		VerifyFn: func(token string) (*oidc.Claims, error) {
+			return &oidc.Claims{Issuer: "https://issuer", Subject: "1234", Username: "myuser", Email: "new@example.com"}, nil
+		},
+	}
+	userRepository := &core.FakeUserRepository{
+		GetByOidcIdentityFn: func(identity *core.OidcIdentity) (*core.User, error) {
+			return &core.User{Id: userId, Username: "myuser", Doc: core.UserDoc{Email: "old@example.com"}}, nil
+		},
+		UpdateEmailFn: func(userIdArg uuid.UUID, email string) error {
+			assert.Equal(t, userId, userIdArg)
+			assert.Equal(t, "new@example.com", email)
+			return nil
+		},
+	}
+	service := service{users: userRepository, verifier: verifier}
+
+	result, err := service.LoginWithToken("mytoken")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "new@example.com", result.Doc.Email)
+	assert.Equal(t, 1, userRepository.UpdateEmailCallCount)
+}
+
+func Test_LoginWithToken_ProvisionsUser(t *testing.T) {
+	var created *core.NewUser
+	verifier := &oidc.FakeVerifier{
+		VerifyFn: func(token string) (*oidc.Claims, error) {
+			return &oidc.Claims{Issuer: "https://issuer", Subject: "1234", Username: "myuser", Email: "user@example.com"}, nil
+		},
+	}
+	userRepository := &core.FakeUserRepository{
+		GetByOidcIdentityFn: func(identity *core.OidcIdentity) (*core.User, error) {
+			if created == nil {
+				return nil, core.ErrNotFound
+			}
+			return &core.User{Id: created.Id, Username: created.Username, Doc: core.UserDoc{Email: created.Email}}, nil
+		},
+		GetByUsernameFn: func(username string) (*core.User, error) {
+			assert.Equal(t, "myuser", username)
+			return nil, core.ErrNotFound
+		},
+		CreateFn: func(newUser *core.NewUser) error {
+			created = newUser
+			return nil
+		},
+	}
+	service := service{users: userRepository, verifier: verifier}
+
+	result, err := service.LoginWithToken("mytoken")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, userRepository.CreateCallCount)
+	assert.NotEqual(t, uuid.Nil, created.Id)
+	assert.Equal(t, "myuser", created.Username)
+	assert.Equal(t, "user@example.com", created.Email)
+	assert.Equal(t, &core.OidcIdentity{Issuer: "https://issuer", Subject: "1234"}, created.OidcIdentity)
+	assert.Equal(t, created.Id, result.Id)
+}
+
+func Test_LoginWithToken_InvalidUsername_ReturnsError(t *testing.T) {
+	tt := []struct {
+		name     string
+		username string
+	}{
+		{"root", RootUsername},
+		{"email", "user@example.com"},
+		{"uppercase", "MyUser"},
+		{"too long", "myuser-abcdefghijklmnopqrstuvwxyz"},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			verifier := &oidc.FakeVerifier{
+				VerifyFn: func(token string) (*oidc.Claims, error) {
+					return &oidc.Claims{Issuer: "https://issuer", Subject: "1234", Username: test.username}, nil
+				},
+			}
+			userRepository := &core.FakeUserRepository{
+				GetByOidcIdentityFn: func(*core.OidcIdentity) (*core.User, error) {
+					return nil, core.ErrNotFound
+				},
+			}
+			service := service{users: userRepository, verifier: verifier}
+
+			result, err := service.LoginWithToken("mytoken")
+
+			assert.Nil(t, result)
+			assert.Equal(t, ErrInvalidLogin, err)
+			assert.Equal(t, 0, userRepository.CreateCallCount)
+		})
+	}
+}
+
+// An identity must never be bound to an existing user (e.g. an API key user) by username. See user.Service.LinkOidcIdentity.
+func Test_LoginWithToken_UsernameExists_ReturnsError(t *testing.T) {
+	verifier := &oidc.FakeVerifier{
+		VerifyFn: func(token string) (*oidc.Claims, error) {
+			return &oidc.Claims{Issuer: "https://issuer", Subject: "1234", Username: "myuser"}, nil
+		},
+	}
+	userRepository := &core.FakeUserRepository{
+		GetByOidcIdentityFn: func(*core.OidcIdentity) (*core.User, error) {
+			return nil, core.ErrNotFound
+		},
+		GetByUsernameFn: func(username string) (*core.User, error) {
+			return &core.User{Username: username}, nil
+		},
+	}
+	service := service{users: userRepository, verifier: verifier}
+
+	result, err := service.LoginWithToken("mytoken")
+
+	assert.Nil(t, result)
+	assert.Equal(t, ErrInvalidLogin, err)
+	assert.Equal(t, 0, userRepository.CreateCallCount)
+}
+
+func Test_LoginWithToken_DisabledUser_ReturnsError(t *testing.T) {
+	disabledAt := time.Now()
+	verifier := &oidc.FakeVerifier{
+		VerifyFn: func(token string) (*oidc.Claims, error) {
+			return &oidc.Claims{Issuer: "https://issuer", Subject: "1234", Username: "myuser"}, nil
+		},
+	}
+	userRepository := &core.FakeUserRepository{
+		GetByOidcIdentityFn: func(*core.OidcIdentity) (*core.User, error) {
+			return &core.User{Username: "myuser", DisabledAt: &disabledAt}, nil
+		},
+	}
+	service := service{users: userRepository, verifier: verifier}
+
+	result, err := service.LoginWithToken("mytoken")
+
+	assert.Nil(t, result)
+	assert.Equal(t, ErrInvalidLogin, err)
+}
+
+func Test_LoginWithToken_InvalidToken_ReturnsError(t *testing.T) {
+	verifier := &oidc.FakeVerifier{
+		VerifyFn: func(token string) (*oidc.Claims, error) {
+			return nil, errors.Wrap(oidc.ErrInvalidToken, "missing claim")
+		},
+	}
+	service := service{verifier: verifier}
+
+	result, err := service.LoginWithToken("mytoken")
+
+	assert.Nil(t, result)
+	assert.Equal(t, ErrInvalidLogin, err)
+}
+
+func Test_LoginWithToken_VerifyError_ReturnsError(t *testing.T) {
+	verifier := &oidc.FakeVerifier{
+		VerifyFn: func(token string) (*oidc.Claims, error) {
+			return nil, errors.New("test")
+		},
+	}
+	service := service{verifier: verifier}
+
+	result, err := service.LoginWithToken("mytoken")
+
+	assert.Nil(t, result)
+	assert.Equal(t, "test", err.Error())
+}
+
+func Test_LoginWithToken_NotConfigured_ReturnsError(t *testing.T) {
+	service := service{}
+
+	result, err := service.LoginWithToken("mytoken")
+
+	assert.Nil(t, result)
+	assert.Equal(t, ErrInvalidLogin, err)
+}
@@ -0,0 +1,11 @@
+package oidc
+
+type FakeVerifier struct {
+	VerifyFn        func(token string) (*Claims, error)
+	VerifyCallCount int
+}
+
+func (fake *FakeVerifier) Verify(token string) (*Claims, error) {
+	fake.VerifyCallCount++
+	return fake.VerifyFn(token)
+}
@@ -0,0 +1,202 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// minRefreshInterval prevents tokens with unknown key ids from causing excessive requests to the JWKS endpoint
+const minRefreshInterval = time.Minute
+
+// ErrKeyNotFound is returned when the key set does not contain a key with the requested id
+var ErrKeyNotFound = errors.New("Signing key not found")
+
+// KeySet provides the public keys used to verify token signatures
+type KeySet interface {
+	// GetKey returns an *rsa.PublicKey or *ecdsa.PublicKey for the key id
+	GetKey(keyId string) (interface{}, error)
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type staticKeySet struct {
+	keys map[string]interface{}
+}
+
+// NewFileKeySet loads a JWKS document from the local filesystem. This is useful for offline environments and testing.
+func NewFileKeySet(path string) (KeySet, error) {
+	jwksBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error reading JWKS file")
+	}
+
+	keys, err := parseJwks(jwksBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &staticKeySet{keys}, nil
+}
+
+func (s *staticKeySet) GetKey(keyId string) (interface{}, error) {
+	if key, ok := s.keys[keyId]; ok {
+		return key, nil
+	}
+	return nil, ErrKeyNotFound
+}
+
+type remoteKeySet struct {
+	url         string
+	httpClient  *http.Client
+	mutex       sync.Mutex
+	keys        map[string]interface{}
+	lastFetched time.Time
+}
+
+// NewRemoteKeySet fetches keys from a JWKS url. Keys are fetched on first use and refetched when a token is signed with an unknown key id
+// (e.g. after the issuer rotates its keys).
+func NewRemoteKeySet(url string, httpClient *http.Client) KeySet {
+	return &remoteKeySet{url: url, httpClient: httpClient}
+}
+
+func (s *remoteKeySet) GetKey(keyId string) (interface{}, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if key, ok := s.keys[keyId]; ok {
+		return key, nil
+	}
+
+	if time.Since(s.lastFetched) < minRefreshInterval {
+		return nil, ErrKeyNotFound
+	}
+
+	keys, err := s.fetch()
+	if err != nil {
+		return nil, err
+	}
+	s.keys = keys
+	s.lastFetched = time.Now()
+
+	if key, ok := s.keys[keyId]; ok {
+		return key, nil
+	}
+	return nil, ErrKeyNotFound
+}
+
+func (s *remoteKeySet) fetch() (map[string]interface{}, error) {
+	response, err := s.httpClient.Get(s.url)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error fetching JWKS")
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Error fetching JWKS: unexpected status code %d", response.StatusCode)
+	}
+
+	jwks := &jsonWebKeySet{}
+	err = json.NewDecoder(response.Body).Decode(jwks)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error decoding JWKS")
+	}
+
+	return jwks.publicKeys()
+}
+
+func parseJwks(jwksBytes []byte) (map[string]interface{}, error) {
+	jwks := &jsonWebKeySet{}
+	err := json.Unmarshal(jwksBytes, jwks)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error decoding JWKS")
+	}
+	return jwks.publicKeys()
+}
+
+// publicKeys returns the signing keys by key id. Keys for other uses (e.g. encryption) are ignored.
+func (jwks *jsonWebKeySet) publicKeys() (map[string]interface{}, error) {
+	keys := map[string]interface{}{}
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error parsing key %q", jwk.Kid)
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
+
+func (jwk *jsonWebKey) publicKey() (interface{}, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", jwk.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(decoded), nil
+}
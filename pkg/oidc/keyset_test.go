@@ -0,0 +1,107 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rsaJwks(keyId string, key *rsa.PublicKey) string {
+	return fmt.Sprintf(`{"keys": [{"kid": %q, "kty": "RSA", "use": "sig", "n": %q, "e": %q}, {"kid": "enc", "kty": "RSA", "use": "enc"}]}`,
+		keyId,
+		base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()))
+}
+
+func Test_parseJwks(t *testing.T) {
+	key := newTestKey(t)
+
+	result, err := parseJwks([]byte(rsaJwks("key1", &key.PublicKey)))
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+	assert.Equal(t, &key.PublicKey, result["key1"])
+}
+
+func Test_parseJwks_EC(t *testing.T) {
+	jwks := `{"keys": [{"kid": "ec1", "kty": "EC", "crv": "P-256",
+		"x": "MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4", "y": "4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM"}]}`
+
+	result, err := parseJwks([]byte(jwks))
+
+	assert.NoError(t, err)
+	assert.IsType(t, &ecdsa.PublicKey{}, result["ec1"])
+}
+
+func Test_parseJwks_UnsupportedKeyType(t *testing.T) {
+	result, err := parseJwks([]byte(`{"keys": [{"kid": "key1", "kty": "oct"}]}`))
+
+	assert.Nil(t, result)
+	assert.Equal(t, `Error parsing key "key1": unsupported key type "oct"`, err.Error())
+}
+
+func Test_NewFileKeySet(t *testing.T) {
+	key := newTestKey(t)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, []byte(rsaJwks("key1", &key.PublicKey)), 0600))
+
+	keySet, err := NewFileKeySet(path)
+	require.NoError(t, err)
+
+	result, err := keySet.GetKey("key1")
+	assert.NoError(t, err)
+	assert.Equal(t, &key.PublicKey, result)
+
+	result, err = keySet.GetKey("key2")
+	assert.Nil(t, result)
+	assert.Equal(t, ErrKeyNotFound, err)
+}
+
+func Test_RemoteKeySet(t *testing.T) {
+	key := newTestKey(t)
+	requestCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		fmt.Fprint(w, rsaJwks("key1", &key.PublicKey))
+	}))
+	defer server.Close()
+
+	keySet := NewRemoteKeySet(server.URL, server.Client())
+
+	result, err := keySet.GetKey("key1")
+	assert.NoError(t, err)
+	assert.Equal(t, &key.PublicKey, result)
+
+	// Cached
+	_, err = keySet.GetKey("key1")
+	assert.NoError(t, err)
+
+	// Unknown keys do not refetch within the minimum refresh interval
+	_, err = keySet.GetKey("key2")
+	assert.Equal(t, ErrKeyNotFound, err)
+	assert.Equal(t, 1, requestCount)
+}
+
+func Test_RemoteKeySet_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	keySet := NewRemoteKeySet(server.URL, server.Client())
+
+	result, err := keySet.GetKey("key1")
+
+	assert.Nil(t, result)
+	assert.Equal(t, "Error fetching JWKS: unexpected status code 500", err.Error())
+}
@@ -0,0 +1,131 @@
+package oidc
+
+import (
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidToken is returned when a token is malformed, expired, or not issued for this server
+var ErrInvalidToken = errors.New("Invalid token")
+
+// Only asymmetric algorithms are supported. Symmetric algorithms would allow anyone with the key set to issue tokens.
+var validSigningMethods = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}
+
+type VerifierConfig struct {
+	// Issuer must match the "iss" claim
+	Issuer string
+	// Audience must be contained in the "aud" claim
+	Audience string
+	// UsernameClaim is the claim used for the riser username of a user that logs in for the first time (e.g. "preferred_username")
+	UsernameClaim string
+	// EmailClaim is the claim containing the user's email address. Optional.
+	EmailClaim string
+	// GroupsClaim is the claim containing the user's groups, which may be granted roles. Optional.
+	GroupsClaim string
+}
+
+// Claims are the claims from a verified token that riser uses to identify a user. The issuer and subject identify the user.
+type Claims struct {
+	Issuer   string
+	Subject  string
+	Username string
+	Email    string
+	Groups   []string
+}
+
+type Verifier interface {
+	// Verify validates the token signature and standard claims and returns the mapped claims.
+	Verify(token string) (*Claims, error)
+}
+
+type verifier struct {
+	config VerifierConfig
+	keys   KeySet
+	parser *jwt.Parser
+	now    func() time.Time
+}
+
+func NewVerifier(config VerifierConfig, keys KeySet) Verifier {
+	return &verifier{
+		config: config,
+		keys:   keys,
+		parser: &jwt.Parser{ValidMethods: validSigningMethods},
+		now:    time.Now,
+	}
+}
+
+func (v *verifier) Verify(token string) (*Claims, error) {
+	mapClaims := jwt.MapClaims{}
+	_, err := v.parser.ParseWithClaims(token, mapClaims, v.keyFunc)
+	if err != nil {
+		if keySetErr := keySetError(err); keySetErr != nil {
+			// Surface errors from the key set (e.g. the JWKS endpoint is unavailable) rather than treating them as an invalid token
+			return nil, errors.Wrap(keySetErr, "Error retrieving signing key")
+		}
+		return nil, ErrInvalidToken
+	}
+
+	now := v.now().Unix()
+	if !mapClaims.VerifyExpiresAt(now, true) ||
+		!mapClaims.VerifyIssuer(v.config.Issuer, true) ||
+		!mapClaims.VerifyAudience(v.config.Audience, true) {
+		return nil, ErrInvalidToken
+	}
+
+	subject, _ := mapClaims["sub"].(string)
+	if subject == "" {
+		return nil, errors.Wrap(ErrInvalidToken, "missing \"sub\" claim")
+	}
+	username, _ := mapClaims[v.config.UsernameClaim].(string)
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return nil, errors.Wrapf(ErrInvalidToken, "missing %q claim", v.config.UsernameClaim)
+	}
+
+	return &Claims{
+		Issuer:   v.config.Issuer,
+		Subject:  subject,
+		Username: username,
+		Email:    stringClaim(mapClaims[v.config.EmailClaim]),
+		Groups:   stringSliceClaim(mapClaims[v.config.GroupsClaim]),
+	}, nil
+}
+
+func (v *verifier) keyFunc(token *jwt.Token) (interface{}, error) {
+	keyId, _ := token.Header["kid"].(string)
+	return v.keys.GetKey(keyId)
+}
+
+// keySetError returns the error from the KeySet, if any. A key that is not found is the token's fault and is not returned.
+func keySetError(err error) error {
+	validationErr, ok := err.(*jwt.ValidationError)
+	if !ok || validationErr.Errors&jwt.ValidationErrorUnverifiable == 0 || validationErr.Inner == nil || validationErr.Inner == ErrKeyNotFound {
+		return nil
+	}
+	return validationErr.Inner
+}
+
+func stringClaim(claim interface{}) string {
+	value, _ := claim.(string)
+	return strings.TrimSpace(value)
+}
+
+func stringSliceClaim(claim interface{}) []string {
+	switch value := claim.(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		values := []string{}
+		for _, item := range value {
+			if str, ok := item.(string); ok {
+				values = append(values, str)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
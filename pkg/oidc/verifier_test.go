@@ -0,0 +1,165 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testConfig = VerifierConfig{
+	Issuer:        "https://issuer.example.com",
+	Audience:      "riser",
+	UsernameClaim: "preferred_username",
+	EmailClaim:    "email",
+	GroupsClaim:   "groups",
+}
+
+func newTestKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+func signTestToken(t *testing.T, key *rsa.PrivateKey, keyId string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyId
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":                testConfig.Issuer,
+		"aud":                []interface{}{"other", testConfig.Audience},
+		"sub":                "1234",
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"preferred_username": "myuser",
+		"email":              "user@example.com",
+		"groups":             []interface{}{"dev", "ops"},
+	}
+}
+
+func Test_Verify(t *testing.T) {
+	key := newTestKey(t)
+	verifier := NewVerifier(testConfig, &staticKeySet{map[string]interface{}{"key1": &key.PublicKey}})
+
+	result, err := verifier.Verify(signTestToken(t, key, "key1", validClaims()))
+
+	assert.NoError(t, err)
+	assert.Equal(t, testConfig.Issuer, result.Issuer)
+	assert.Equal(t, "1234", result.Subject)
+	assert.Equal(t, "myuser", result.Username)
+	assert.Equal(t, "user@example.com", result.Email)
+	assert.Equal(t, []string{"dev", "ops"}, result.Groups)
+}
+
+func Test_Verify_InvalidClaims(t *testing.T) {
+	key := newTestKey(t)
+	verifier := NewVerifier(testConfig, &staticKeySet{map[string]interface{}{"key1": &key.PublicKey}})
+
+	tt := []struct {
+		name   string
+		mutate func(jwt.MapClaims)
+	}{
+		{"expired", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }},
+		{"missing exp", func(c jwt.MapClaims) { delete(c, "exp") }},
+		{"wrong issuer", func(c jwt.MapClaims) { c["iss"] = "https://other.example.com" }},
+		{"wrong audience", func(c jwt.MapClaims) { c["aud"] = "other" }},
+		{"missing subject", func(c jwt.MapClaims) { delete(c, "sub") }},
+		{"missing username", func(c jwt.MapClaims) { delete(c, "preferred_username") }},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			claims := validClaims()
+			test.mutate(claims)
+
+			result, err := verifier.Verify(signTestToken(t, key, "key1", claims))
+
+			assert.Nil(t, result)
+			assert.True(t, errors.Is(err, ErrInvalidToken), err)
+		})
+	}
+}
+
+func Test_Verify_WrongKey(t *testing.T) {
+	key := newTestKey(t)
+	otherKey := newTestKey(t)
+	verifier := NewVerifier(testConfig, &staticKeySet{map[string]interface{}{"key1": &key.PublicKey}})
+
+	result, err := verifier.Verify(signTestToken(t, otherKey, "key1", validClaims()))
+
+	assert.Nil(t, result)
+	assert.Equal(t, ErrInvalidToken, err)
+}
+
+func Test_Verify_UnknownKeyId(t *testing.T) {
+	key := newTestKey(t)
+	verifier := NewVerifier(testConfig, &staticKeySet{map[string]interface{}{"key1": &key.PublicKey}})
+
+	result, err := verifier.Verify(signTestToken(t, key, "key2", validClaims()))
+
+	assert.Nil(t, result)
+	assert.Equal(t, ErrInvalidToken, err)
+}
+
+func Test_Verify_RejectsSymmetricAlgorithm(t *testing.T) {
+	key := newTestKey(t)
+	verifier := NewVerifier(testConfig, &staticKeySet{map[string]interface{}{"key1": &key.PublicKey}})
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims())
+	token.Header["kid"] = "key1"
+	signed, err := token.SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	result, err := verifier.Verify(signed)
+
+	assert.Nil(t, result)
+	assert.Equal(t, ErrInvalidToken, err)
+}
+
+func Test_Verify_KeySetError(t *testing.T) {
+	key := newTestKey(t)
+	keySet := &fakeKeySet{err: errors.New("broken")}
+	verifier := NewVerifier(testConfig, keySet)
+
+	result, err := verifier.Verify(signTestToken(t, key, "key1", validClaims()))
+
+	assert.Nil(t, result)
+	assert.Equal(t, "Error retrieving signing key: broken", err.Error())
+}
+
+func Test_Verify_OptionalClaims(t *testing.T) {
+	key := newTestKey(t)
+	claims := validClaims()
+	delete(claims, "email")
+	delete(claims, "groups")
+
+	verifier := NewVerifier(testConfig, &staticKeySet{map[string]interface{}{"key1": &key.PublicKey}})
+
+	result, err := verifier.Verify(signTestToken(t, key, "key1", claims))
+
+	assert.NoError(t, err)
+	assert.Empty(t, result.Email)
+	assert.Nil(t, result.Groups)
+}
+
+func Test_stringSliceClaim(t *testing.T) {
+	assert.Equal(t, []string{"a"}, stringSliceClaim("a"))
+	assert.Equal(t, []string{"a", "b"}, stringSliceClaim([]interface{}{"a", 1, "b"}))
+	assert.Nil(t, stringSliceClaim(nil))
+}
+
+type fakeKeySet struct {
+	err error
+}
+
+func (f *fakeKeySet) GetKey(string) (interface{}, error) {
+	return nil, f.err
+}
@@ -18,9 +18,10 @@ func NewApiKeyRepository(db *sql.DB) core.ApiKeyRepository {
 func (r *apiKeyRepository) GetByUserId(userId uuid.UUID) ([]core.ApiKey, error) {
 	apiKeys := []core.ApiKey{}
 	rows, err := r.db.Query(`
-	SELECT riser_user_id, key_hash
+	SELECT id, riser_user_id, name, key_hash, created_at, last_used_at, expires_at
 	FROM apikey
 	WHERE riser_user_id = $1
+	ORDER BY name
 	`, userId)
 
 	if err != nil {
@@ -30,7 +31,7 @@ func (r *apiKeyRepository) GetByUserId(userId uuid.UUID) ([]core.ApiKey, error)
 	defer rows.Close()
 	for rows.Next() {
 		apiKey := core.ApiKey{}
-		err := rows.Scan(&apiKey.UserId, &apiKey.KeyHash)
+		err := rows.Scan(&apiKey.Id, &apiKey.UserId, &apiKey.Name, &apiKey.KeyHash, &apiKey.Created, &apiKey.LastUsed, &apiKey.Expires)
 		if err != nil {
 			return nil, err
 		}
@@ -40,7 +41,32 @@ func (r *apiKeyRepository) GetByUserId(userId uuid.UUID) ([]core.ApiKey, error)
 	return apiKeys, nil
 }
 
-func (r *apiKeyRepository) Create(userId uuid.UUID, keyHash []byte) error {
-	_, err := r.db.Exec("INSERT INTO apikey (riser_user_id, key_hash) VALUES ($1, $2)", userId, keyHash)
+func (r *apiKeyRepository) Create(apiKey *core.ApiKey) error {
+	_, err := r.db.Exec(`
+	INSERT INTO apikey (id, riser_user_id, name, key_hash, created_at, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	`, apiKey.Id, apiKey.UserId, apiKey.Name, apiKey.KeyHash, apiKey.Created, apiKey.Expires)
+	return err
+}
+
+func (r *apiKeyRepository) Delete(userId uuid.UUID, id uuid.UUID) error {
+	result, err := r.db.Exec("DELETE FROM apikey WHERE riser_user_id = $1 AND id = $2", userId, id)
+	if err != nil {
+		return err
+	}
+
+	if !resultHasRows(result) {
+		return core.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *apiKeyRepository) UpdateLastUsed(keyHash []byte) error {
+	// Only update periodically to avoid a write on every request
+	_, err := r.db.Exec(`
+	UPDATE apikey SET last_used_at = now()
+	WHERE key_hash = $1 AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute')
+	`, keyHash)
 	return err
 }
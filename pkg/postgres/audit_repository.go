@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/riser-platform/riser-server/pkg/core"
+)
+
+type auditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) core.AuditRepository {
+	return &auditRepository{db}
+}
+
+func (r *auditRepository) Create(entry *core.AuditEntry) error {
+	_, err := r.db.Exec(`
+	INSERT INTO audit_log (id, created_at, username, action, namespace, environment_name, resource_name, app_name, status_code, doc)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, entry.Id, entry.Created, entry.Username, entry.Action, entry.Namespace, entry.Environment, entry.ResourceName, entry.AppName, entry.StatusCode, &entry.Doc)
+	return err
+}
+
+func (r *auditRepository) Find(filter *core.AuditFilter) ([]core.AuditEntry, error) {
+	conditions := []string{}
+	args := []interface{}{}
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Username != "" {
+		addCondition("username = $%d", filter.Username)
+	}
+	if filter.Namespace != "" {
+		addCondition("namespace = $%d", filter.Namespace)
+	}
+	if filter.Environment != "" {
+		addCondition("environment_name = $%d", filter.Environment)
+	}
+	if filter.AppName != "" {
+		addCondition("app_name = $%d", filter.AppName)
+	}
+	if filter.From != nil {
+		addCondition("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("created_at < $%d", *filter.To)
+	}
+
+	query := `SELECT id, created_at, username, action, namespace, environment_name, resource_name, app_name, status_code, doc FROM audit_log`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d", len(args))
+
+	entries := []core.AuditEntry{}
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		entry := core.AuditEntry{}
+		err := rows.Scan(&entry.Id, &entry.Created, &entry.Username, &entry.Action, &entry.Namespace, &entry.Environment, &entry.ResourceName, &entry.AppName, &entry.StatusCode, &entry.Doc)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"database/sql"
+	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
@@ -106,8 +107,17 @@ func (r *deploymentRepository) GetByReservation(reservationId uuid.UUID, envName
 
 // FindByApp returns all active deployments in all environments by a given app ID
 func (r *deploymentRepository) FindByApp(appId uuid.UUID) ([]core.Deployment, error) {
+	return r.findActive("deployment_reservation.app_id = $1", appId)
+}
+
+// FindByEnvironment returns all active deployments in an environment
+func (r *deploymentRepository) FindByEnvironment(envName string) ([]core.Deployment, error) {
+	return r.findActive("deployment.environment_name = $1", envName)
+}
+
+func (r *deploymentRepository) findActive(where string, args ...interface{}) ([]core.Deployment, error) {
 	deployments := []core.Deployment{}
-	rows, err := r.db.Query(`
+	rows, err := r.db.Query(fmt.Sprintf(`
 	SELECT
 		deployment_reservation.id,
 		deployment_reservation.app_id,
@@ -121,9 +131,9 @@ func (r *deploymentRepository) FindByApp(appId uuid.UUID) ([]core.Deployment, er
 		deployment.doc
 	FROM deployment
 	INNER JOIN deployment_reservation ON deployment.deployment_reservation_id = deployment_reservation.id
-	WHERE deployment_reservation.app_id = $1 AND deployment.deleted_at IS NULL
+	WHERE %s AND deployment.deleted_at IS NULL
 	ORDER BY deployment.environment_name, deployment_reservation.name
-	`, appId)
+	`, where), args...)
 
 	if err != nil {
 		return nil, err
@@ -259,3 +269,29 @@ func (r *deploymentRepository) UpdateTraffic(name *core.NamespacedName, envName
 
 	return nil
 }
+
+func (r *deploymentRepository) UpdateRenderedConfig(name *core.NamespacedName, envName string, riserRevision int64, config *core.DeploymentConfig) error {
+	result, err := r.db.Exec(`
+		UPDATE deployment
+		SET doc = jsonb_set(doc, '{lastRenderedConfig}', $5)
+		FROM deployment_reservation
+		WHERE
+		deployment.deployment_reservation_id = deployment_reservation.id
+		AND deployment_reservation.name = $1
+		AND deployment_reservation.namespace = $2
+		AND deployment.environment_name = $3
+		AND riser_revision = $4
+		AND deleted_at IS NULL
+	`, name.Name, name.Namespace, envName, riserRevision, config)
+
+	if err != nil {
+		return err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return errors.New("Deployment not found or has been updated by another process")
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package postgres
+
+import (
+	"database/sql"
+
+	"github.com/riser-platform/riser-server/pkg/core"
+)
+
+type deploymentRevisionRepository struct {
+	db *sql.DB
+}
+
+func NewDeploymentRevisionRepository(db *sql.DB) core.DeploymentRevisionRepository {
+	return &deploymentRevisionRepository{db: db}
+}
+
+func (r *deploymentRevisionRepository) Save(name *core.NamespacedName, envName string, revision *core.DeploymentRevision) error {
+	result, err := r.db.Exec(`
+	INSERT INTO deployment_revision (id, deployment_id, riser_revision, doc)
+	SELECT $1, deployment.id, $2, $3
+	FROM deployment
+	INNER JOIN deployment_reservation ON deployment.deployment_reservation_id = deployment_reservation.id
+	WHERE
+		deployment_reservation.name = $4
+		AND deployment_reservation.namespace = $5
+		AND deployment.environment_name = $6
+	ON CONFLICT(deployment_id, riser_revision) DO
+	UPDATE SET
+		doc = $3
+	`, revision.Id, revision.RiserRevision, &revision.Doc, name.Name, name.Namespace, envName)
+
+	if err != nil {
+		return err
+	}
+
+	if !resultHasRows(result) {
+		return core.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *deploymentRevisionRepository) GetByRevision(name *core.NamespacedName, envName string, riserRevision int64) (*core.DeploymentRevision, error) {
+	revision := &core.DeploymentRevision{}
+	err := r.db.QueryRow(`
+	SELECT
+		deployment_revision.id,
+		deployment_revision.riser_revision,
+		deployment_revision.doc
+	FROM deployment_revision
+	INNER JOIN deployment ON deployment_revision.deployment_id = deployment.id
+	INNER JOIN deployment_reservation ON deployment.deployment_reservation_id = deployment_reservation.id
+	WHERE
+		deployment_reservation.name = $1
+		AND deployment_reservation.namespace = $2
+		AND deployment.environment_name = $3
+		AND deployment_revision.riser_revision = $4
+	`, name.Name, name.Namespace, envName, riserRevision).Scan(&revision.Id, &revision.RiserRevision, &revision.Doc)
+
+	return revision, noRowsErrorHandler(err)
+}
+
+// ListByDeployment returns all recorded revisions for a deployment with the most recent revision first
+func (r *deploymentRevisionRepository) ListByDeployment(name *core.NamespacedName, envName string) ([]core.DeploymentRevision, error) {
+	revisions := []core.DeploymentRevision{}
+	rows, err := r.db.Query(`
+	SELECT
+		deployment_revision.id,
+		deployment_revision.riser_revision,
+		deployment_revision.doc
+	FROM deployment_revision
+	INNER JOIN deployment ON deployment_revision.deployment_id = deployment.id
+	INNER JOIN deployment_reservation ON deployment.deployment_reservation_id = deployment_reservation.id
+	WHERE
+		deployment_reservation.name = $1
+		AND deployment_reservation.namespace = $2
+		AND deployment.environment_name = $3
+	ORDER BY deployment_revision.riser_revision DESC
+	`, name.Name, name.Namespace, envName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		revision := core.DeploymentRevision{}
+		err := rows.Scan(&revision.Id, &revision.RiserRevision, &revision.Doc)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, nil
+}
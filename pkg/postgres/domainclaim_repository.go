@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/riser-platform/riser-server/pkg/core"
+)
+
+type domainClaimRepository struct {
+	db *sql.DB
+}
+
+func NewDomainClaimRepository(db *sql.DB) core.DomainClaimRepository {
+	return &domainClaimRepository{db: db}
+}
+
+func (r *domainClaimRepository) FindByDomain(domain string) ([]core.DomainClaim, error) {
+	claims := []core.DomainClaim{}
+	rows, err := r.db.Query(`
+	SELECT domain, app_id, environment_name, deployment_name, namespace
+	FROM domain_claim
+	WHERE domain = $1
+	ORDER BY environment_name
+	`, domain)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		claim := core.DomainClaim{}
+		err := rows.Scan(&claim.Domain, &claim.AppId, &claim.EnvironmentName, &claim.DeploymentName, &claim.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		claims = append(claims, claim)
+	}
+
+	return claims, nil
+}
+
+func (r *domainClaimRepository) Create(claim *core.DomainClaim) error {
+	// The owner is locked by the upsert so that concurrent claims for the domain are serialized. No rows are inserted when the domain
+	// is owned by another app or when the claim in the environment belongs to another deployment.
+	result, err := r.db.Exec(`
+		WITH owner AS (
+			INSERT INTO domain_owner (domain, app_id) VALUES ($1,$3)
+			ON CONFLICT (domain) DO UPDATE SET app_id = domain_owner.app_id
+			RETURNING app_id
+		)
+		INSERT INTO domain_claim (domain, environment_name, app_id, deployment_name, namespace)
+		SELECT $1,$2,$3,$4,$5 FROM owner WHERE owner.app_id = $3
+		ON CONFLICT (domain, environment_name) DO UPDATE SET deployment_name = domain_claim.deployment_name
+		WHERE domain_claim.deployment_name = EXCLUDED.deployment_name AND domain_claim.namespace = EXCLUDED.namespace
+		`, claim.Domain, claim.EnvironmentName, claim.AppId, claim.DeploymentName, claim.Namespace)
+	if err != nil {
+		return err
+	}
+	if !resultHasRows(result) {
+		return core.ErrDomainClaimed
+	}
+	return nil
+}
+
+func (r *domainClaimRepository) ReleaseByDeployment(deploymentName *core.NamespacedName, envName string, keepDomains []string) error {
+	// A nil array is NULL which would never match any domain
+	if keepDomains == nil {
+		keepDomains = []string{}
+	}
+	// The statements share a snapshot so the released claims are excluded explicitly when checking for the remaining claims
+	_, err := r.db.Exec(`
+		WITH released AS (
+			DELETE FROM domain_claim
+			WHERE
+				deployment_name = $1
+				AND namespace = $2
+				AND environment_name = $3
+				AND NOT (domain = ANY($4))
+			RETURNING domain
+		)
+		DELETE FROM domain_owner
+		WHERE
+			domain IN (SELECT domain FROM released)
+			AND NOT EXISTS (
+				SELECT 1 FROM domain_claim
+				WHERE
+					domain_claim.domain = domain_owner.domain
+					AND NOT (deployment_name = $1 AND namespace = $2 AND environment_name = $3)
+			)
+		`, deploymentName.Name, deploymentName.Namespace, envName, pq.Array(keepDomains))
+	return err
+}
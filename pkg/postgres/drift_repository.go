@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"database/sql"
+
+	"github.com/riser-platform/riser-server/pkg/core"
+)
+
+type driftReportRepository struct {
+	db *sql.DB
+}
+
+func NewDriftReportRepository(db *sql.DB) core.DriftReportRepository {
+	return &driftReportRepository{db: db}
+}
+
+func (r *driftReportRepository) Save(report *core.DriftReport) error {
+	_, err := r.db.Exec(`
+	INSERT INTO drift_report (environment_name, created_at, doc)
+	VALUES ($1, $2, $3)
+	ON CONFLICT(environment_name) DO
+	UPDATE SET
+		created_at = $2,
+		doc = $3
+	`, report.EnvironmentName, report.Created, &report.Doc)
+	return err
+}
+
+func (r *driftReportRepository) Get(envName string) (*core.DriftReport, error) {
+	report := &core.DriftReport{}
+	err := r.db.QueryRow(`
+	SELECT environment_name, created_at, doc
+	FROM drift_report
+	WHERE environment_name = $1
+	`, envName).Scan(&report.EnvironmentName, &report.Created, &report.Doc)
+	if err == sql.ErrNoRows {
+		return nil, core.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
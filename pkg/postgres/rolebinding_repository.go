@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/riser-platform/riser-server/pkg/core"
+)
+
+type roleBindingRepository struct {
+	db *sql.DB
+}
+
+func NewRoleBindingRepository(db *sql.DB) core.RoleBindingRepository {
+	return &roleBindingRepository{db: db}
+}
+
+func (r *roleBindingRepository) Create(binding *core.RoleBinding) error {
+	var userId *uuid.UUID
+	var group *string
+	if binding.Group == "" {
+		userId = &binding.UserId
+	} else {
+		group = &binding.Group
+	}
+	_, err := r.db.Exec(`
+	INSERT INTO role_binding (id, riser_user_id, group_name, role, namespace, environment_name)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT DO NOTHING
+	`, binding.Id, userId, group, binding.Role, binding.Namespace, binding.Environment)
+	return err
+}
+
+func (r *roleBindingRepository) Delete(id uuid.UUID) error {
+	result, err := r.db.Exec(`DELETE FROM role_binding WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	if !resultHasRows(result) {
+		return core.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *roleBindingRepository) FindByUser(userId uuid.UUID, groups []string) ([]core.RoleBinding, error) {
+	return r.query(`
+	SELECT role_binding.id, role_binding.riser_user_id, riser_user.username, role_binding.group_name, role_binding.role, role_binding.namespace, role_binding.environment_name
+	FROM role_binding
+	LEFT JOIN riser_user ON role_binding.riser_user_id = riser_user.id
+	WHERE role_binding.riser_user_id = $1 OR role_binding.group_name = ANY($2)
+	`, userId, pq.Array(groups))
+}
+
+func (r *roleBindingRepository) List() ([]core.RoleBinding, error) {
+	return r.query(`
+	SELECT role_binding.id, role_binding.riser_user_id, riser_user.username, role_binding.group_name, role_binding.role, role_binding.namespace, role_binding.environment_name
+	FROM role_binding
+	LEFT JOIN riser_user ON role_binding.riser_user_id = riser_user.id
+	ORDER BY riser_user.username, role_binding.group_name, role_binding.role, role_binding.namespace, role_binding.environment_name
+	`)
+}
+
+func (r *roleBindingRepository) query(query string, args ...interface{}) ([]core.RoleBinding, error) {
+	bindings := []core.RoleBinding{}
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		binding := core.RoleBinding{}
+		var userId *uuid.UUID
+		var username, group sql.NullString
+		err := rows.Scan(&binding.Id, &userId, &username, &group, &binding.Role, &binding.Namespace, &binding.Environment)
+		if err != nil {
+			return nil, err
+		}
+		if userId != nil {
+			binding.UserId = *userId
+		}
+		binding.Username = username.String
+		binding.Group = group.String
+		bindings = append(bindings, binding)
+	}
+
+	return bindings, nil
+}
@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/riser-platform/riser-server/pkg/core"
+)
+
+type rolloutRepository struct {
+	db *sql.DB
+}
+
+func NewRolloutRepository(db *sql.DB) core.RolloutRepository {
+	return &rolloutRepository{db: db}
+}
+
+func (r *rolloutRepository) Save(rollout *core.Rollout) error {
+	result, err := r.db.Exec(`
+	INSERT INTO rollout (deployment_id, riser_revision, status, next_step_at, doc)
+	SELECT deployment.id, $1, $2, $3, $4
+	FROM deployment
+	INNER JOIN deployment_reservation ON deployment.deployment_reservation_id = deployment_reservation.id
+	WHERE
+		deployment_reservation.name = $5
+		AND deployment_reservation.namespace = $6
+		AND deployment.environment_name = $7
+	ON CONFLICT(deployment_id) DO
+	UPDATE SET
+		riser_revision = $1,
+		status = $2,
+		next_step_at = $3,
+		doc = $4
+	`, rollout.RiserRevision, rollout.Status, rollout.NextStepAt, &rollout.Doc,
+		rollout.Name.Name, rollout.Name.Namespace, rollout.EnvironmentName)
+
+	if err != nil {
+		return err
+	}
+
+	if !resultHasRows(result) {
+		return core.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *rolloutRepository) UpdateProgress(rollout *core.Rollout) error {
+	result, err := r.db.Exec(`
+	UPDATE rollout
+	SET
+		status = $1,
+		next_step_at = $2,
+		doc = $3
+	FROM deployment
+	INNER JOIN deployment_reservation ON deployment.deployment_reservation_id = deployment_reservation.id
+	WHERE
+		rollout.deployment_id = deployment.id
+		AND deployment_reservation.name = $4
+		AND deployment_reservation.namespace = $5
+		AND deployment.environment_name = $6
+		AND rollout.riser_revision = $7
+	`, rollout.Status, rollout.NextStepAt, &rollout.Doc,
+		rollout.Name.Name, rollout.Name.Namespace, rollout.EnvironmentName, rollout.RiserRevision)
+
+	if err != nil {
+		return err
+	}
+
+	if !resultHasRows(result) {
+		return core.ErrConflictNewerVersion
+	}
+
+	return nil
+}
+
+func (r *rolloutRepository) LeaseInProgress(holder string, duration time.Duration) ([]core.Rollout, error) {
+	rollouts := []core.Rollout{}
+	rows, err := r.db.Query(`
+	WITH leased AS (
+		UPDATE rollout
+		SET
+			lease_holder = $2,
+			lease_expires_at = now() + $3 * interval '1 second'
+		WHERE deployment_id IN (
+			SELECT deployment_id
+			FROM rollout
+			WHERE
+				status = $1
+				AND (lease_holder IS NULL OR lease_holder = $2 OR lease_expires_at < now())
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING rollout.*
+	)
+	SELECT
+		deployment_reservation.name,
+		deployment_reservation.namespace,
+		deployment.environment_name,
+		leased.riser_revision,
+		leased.status,
+		leased.next_step_at,
+		leased.doc
+	FROM leased
+	INNER JOIN deployment ON leased.deployment_id = deployment.id
+	INNER JOIN deployment_reservation ON deployment.deployment_reservation_id = deployment_reservation.id
+	ORDER BY leased.next_step_at
+	`, core.RolloutStatusInProgress, holder, duration.Seconds())
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		rollout := core.Rollout{Name: &core.NamespacedName{}}
+		err := rows.Scan(&rollout.Name.Name, &rollout.Name.Namespace, &rollout.EnvironmentName,
+			&rollout.RiserRevision, &rollout.Status, &rollout.NextStepAt, &rollout.Doc)
+		if err != nil {
+			return nil, err
+		}
+		rollouts = append(rollouts, rollout)
+	}
+
+	return rollouts, nil
+}
@@ -36,7 +36,7 @@ func (r *secretMetaRepository) Save(secretMeta *core.SecretMeta) (int64, error)
 func (r *secretMetaRepository) Commit(secretMeta *core.SecretMeta) error {
 	result, err := r.db.Exec(`
 		UPDATE secret_meta
-		SET committed_revision = revision
+		SET committed_revision = revision, committed_ciphertext = $6
 		FROM app
 		WHERE
 			secret_meta.app_id = app.id
@@ -45,7 +45,7 @@ func (r *secretMetaRepository) Commit(secretMeta *core.SecretMeta) error {
 			AND secret_meta.environment_name = $3
 			AND secret_meta.name = $4
 			AND secret_meta.revision = $5
-	`, secretMeta.App.Name, secretMeta.App.Namespace, secretMeta.EnvironmentName, secretMeta.Name, secretMeta.Revision)
+	`, secretMeta.App.Name, secretMeta.App.Namespace, secretMeta.EnvironmentName, secretMeta.Name, secretMeta.Revision, secretMeta.Ciphertext)
 
 	if err != nil && !resultHasRows(result) {
 		return core.ErrConflictNewerVersion
@@ -88,3 +88,38 @@ func (r *secretMetaRepository) ListByAppInEnvironment(appName *core.NamespacedNa
 
 	return secretMetas, nil
 }
+
+func (r *secretMetaRepository) ListByEnvironment(envName string) ([]core.SecretMeta, error) {
+	secretMetas := []core.SecretMeta{}
+	rows, err := r.db.Query(`
+	SELECT
+		app.name,
+		app.namespace,
+		secret_meta.environment_name,
+		secret_meta.name,
+		secret_meta.committed_revision,
+		secret_meta.committed_ciphertext
+	FROM secret_meta
+	INNER JOIN app ON app.id = secret_meta.app_id
+	WHERE
+		secret_meta.environment_name = $1
+		AND secret_meta.committed_revision > 0
+	ORDER BY app.namespace, app.name, secret_meta.name
+	`, envName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		secretMeta := core.SecretMeta{App: &core.NamespacedName{}}
+		err := rows.Scan(&secretMeta.App.Name, &secretMeta.App.Namespace, &secretMeta.EnvironmentName, &secretMeta.Name, &secretMeta.Revision, &secretMeta.Ciphertext)
+		if err != nil {
+			return nil, err
+		}
+		secretMetas = append(secretMetas, secretMeta)
+	}
+
+	return secretMetas, nil
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/riser-platform/riser-server/pkg/core"
 )
 
@@ -17,10 +18,12 @@ func NewUserRepository(db *sql.DB) core.UserRepository {
 
 func (r *userRepository) GetByApiKey(keyHash []byte) (*core.User, error) {
 	user := &core.User{}
-	err := r.db.QueryRow(`SELECT riser_user.id, username, doc
+	err := r.db.QueryRow(`SELECT riser_user.id, username, disabled_at, doc
 	FROM riser_user
 	INNER JOIN apikey ON (riser_user.id = apikey.riser_user_id)
-	WHERE apikey.key_hash = $1`, keyHash).Scan(&user.Id, &user.Username, &user.Doc)
+	WHERE apikey.key_hash = $1
+	AND riser_user.disabled_at IS NULL
+	AND (apikey.expires_at IS NULL OR apikey.expires_at > now())`, keyHash).Scan(&user.Id, &user.Username, &user.DisabledAt, &user.Doc)
 	if err == sql.ErrNoRows {
 		return nil, core.ErrNotFound
 	}
@@ -34,9 +37,24 @@ func (r *userRepository) GetByApiKey(keyHash []byte) (*core.User, error) {
 // TODO: Refactor common projection, scanning, and error handling
 func (r *userRepository) GetByUsername(username string) (*core.User, error) {
 	user := &core.User{}
-	err := r.db.QueryRow(`SELECT id, username, doc
+	err := r.db.QueryRow(`SELECT id, username, disabled_at, doc
 	FROM riser_user
-	WHERE username = $1`, username).Scan(&user.Id, &user.Username, &user.Doc)
+	WHERE username = $1`, username).Scan(&user.Id, &user.Username, &user.DisabledAt, &user.Doc)
+	if err == sql.ErrNoRows {
+		return nil, core.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r *userRepository) GetByOidcIdentity(identity *core.OidcIdentity) (*core.User, error) {
+	user := &core.User{}
+	err := r.db.QueryRow(`SELECT id, username, disabled_at, doc
+	FROM riser_user
+	WHERE oidc_issuer = $1 AND oidc_subject = $2`, identity.Issuer, identity.Subject).Scan(&user.Id, &user.Username, &user.DisabledAt, &user.Doc)
 	if err == sql.ErrNoRows {
 		return nil, core.ErrNotFound
 	}
@@ -48,12 +66,78 @@ func (r *userRepository) GetByUsername(username string) (*core.User, error) {
 }
 
 func (r *userRepository) Create(newUser *core.NewUser) error {
-	doc := &core.UserDoc{Created: time.Now().UTC()}
-	_, err := r.db.Exec("INSERT INTO riser_user (id, username, doc) VALUES ($1, $2, $3) RETURNING id", newUser.Id, newUser.Username, doc)
+	doc := &core.UserDoc{Created: time.Now().UTC(), Email: newUser.Email}
+	var oidcIssuer, oidcSubject *string
+	if newUser.OidcIdentity != nil {
+		oidcIssuer, oidcSubject = &newUser.OidcIdentity.Issuer, &newUser.OidcIdentity.Subject
+	}
+	_, err := r.db.Exec("INSERT INTO riser_user (id, username, doc, oidc_issuer, oidc_subject) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		newUser.Id, newUser.Username, doc, oidcIssuer, oidcSubject)
 	return err
 }
 
+func (r *userRepository) UpdateEmail(userId uuid.UUID, email string) error {
+	result, err := r.db.Exec("UPDATE riser_user SET doc = jsonb_set(doc, '{email}', to_jsonb($2::text)) WHERE id = $1", userId, email)
+	if err != nil {
+		return err
+	}
+
+	if !resultHasRows(result) {
+		return core.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *userRepository) SetOidcIdentity(userId uuid.UUID, identity *core.OidcIdentity) error {
+	result, err := r.db.Exec("UPDATE riser_user SET oidc_issuer = $2, oidc_subject = $3 WHERE id = $1", userId, identity.Issuer, identity.Subject)
+	if err != nil {
+		return err
+	}
+
+	if !resultHasRows(result) {
+		return core.ErrNotFound
+	}
+
+	return nil
+}
+
 func (r *userRepository) GetActiveCount() (activeUserCount int, err error) {
-	err = r.db.QueryRow("SELECT COUNT(1) FROM riser_user INNER JOIN apikey ON riser_user.id = apikey.riser_user_id").Scan(&activeUserCount)
+	err = r.db.QueryRow(`SELECT COUNT(DISTINCT riser_user.id) FROM riser_user
+	INNER JOIN apikey ON riser_user.id = apikey.riser_user_id
+	WHERE riser_user.disabled_at IS NULL`).Scan(&activeUserCount)
 	return activeUserCount, err
 }
+
+func (r *userRepository) List() ([]core.User, error) {
+	users := []core.User{}
+	rows, err := r.db.Query("SELECT id, username, disabled_at, doc FROM riser_user ORDER BY username")
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		user := core.User{}
+		err := rows.Scan(&user.Id, &user.Username, &user.DisabledAt, &user.Doc)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+func (r *userRepository) Disable(userId uuid.UUID) error {
+	result, err := r.db.Exec("UPDATE riser_user SET disabled_at = COALESCE(disabled_at, now()) WHERE id = $1", userId)
+	if err != nil {
+		return err
+	}
+
+	if !resultHasRows(result) {
+		return core.ErrNotFound
+	}
+
+	return nil
+}
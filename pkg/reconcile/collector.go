@@ -0,0 +1,80 @@
+package reconcile
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/state"
+)
+
+// CommitterFunc returns the committer for the state of an environment
+type CommitterFunc func(ctx context.Context, envName string) (state.Committer, error)
+
+// GarbageCollector periodically removes the files in every environment that riser no longer references
+type GarbageCollector struct {
+	environments     core.EnvironmentRepository
+	reconcileService Service
+	getReader        ReaderFunc
+	getCommitter     CommitterFunc
+	logger           logrus.FieldLogger
+}
+
+func NewGarbageCollector(environments core.EnvironmentRepository, reconcileService Service, getReader ReaderFunc, getCommitter CommitterFunc, logger logrus.FieldLogger) *GarbageCollector {
+	return &GarbageCollector{environments, reconcileService, getReader, getCommitter, logger}
+}
+
+// Run collects garbage at the specified interval until the context is done
+func (g *GarbageCollector) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := g.CollectAll(ctx)
+			if err != nil {
+				g.logger.WithError(err).Error("Error collecting garbage")
+			}
+		}
+	}
+}
+
+// CollectAll collects garbage in every environment. Collection stops when the context is done.
+func (g *GarbageCollector) CollectAll(ctx context.Context) error {
+	environments, err := g.environments.List()
+	if err != nil {
+		return errors.Wrap(err, "error listing environments")
+	}
+
+	for _, environment := range environments {
+		logger := g.logger.WithField("environment", environment.Name)
+		// An error with one environment should not prevent garbage collection in other environments
+		result, err := g.collect(ctx, environment.Name)
+		if err != nil {
+			logger.WithError(err).Error("Error collecting garbage")
+			continue
+		}
+		if !result.NoChanges {
+			logger.WithField("deleted", result.Deleted).Info("Removed files that are no longer referenced")
+		}
+	}
+
+	return nil
+}
+
+func (g *GarbageCollector) collect(ctx context.Context, envName string) (*core.GarbageCollectionResult, error) {
+	reader, err := g.getReader(ctx, envName)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting state reader")
+	}
+	committer, err := g.getCommitter(ctx, envName)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting state committer")
+	}
+	return g.reconcileService.CollectGarbage(envName, "", reader, committer)
+}
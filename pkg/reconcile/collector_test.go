@@ -0,0 +1,64 @@
+package reconcile
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/state"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CollectAll(t *testing.T) {
+	environments := &core.FakeEnvironmentRepository{
+		ListFn: func() ([]core.Environment, error) {
+			return []core.Environment{{Name: "dev"}, {Name: "prod"}}, nil
+		},
+	}
+	collected := []string{}
+	reconcileService := &FakeService{
+		CollectGarbageFn: func(envName string, collectedBy string, reader state.Reader, committer state.Committer) (*core.GarbageCollectionResult, error) {
+			collected = append(collected, envName)
+			assert.Empty(t, collectedBy)
+			if envName == "dev" {
+				return nil, errors.New("test")
+			}
+			return &core.GarbageCollectionResult{Deleted: []string{"test.yaml"}}, nil
+		},
+	}
+	getReader := func(context.Context, string) (state.Reader, error) {
+		return &fakeReader{}, nil
+	}
+	getCommitter := func(context.Context, string) (state.Committer, error) {
+		return state.NewDryRunCommitter(), nil
+	}
+
+	err := NewGarbageCollector(environments, reconcileService, getReader, getCommitter, logrus.New()).CollectAll(context.Background())
+
+	require.NoError(t, err)
+	// An error in one environment does not prevent other environments from being collected
+	assert.Equal(t, []string{"dev", "prod"}, collected)
+}
+
+func Test_CollectAll_CommitterErr(t *testing.T) {
+	environments := &core.FakeEnvironmentRepository{
+		ListFn: func() ([]core.Environment, error) {
+			return []core.Environment{{Name: "dev"}}, nil
+		},
+	}
+	reconcileService := &FakeService{}
+	getReader := func(context.Context, string) (state.Reader, error) {
+		return &fakeReader{}, nil
+	}
+	getCommitter := func(context.Context, string) (state.Committer, error) {
+		return nil, errors.New("test")
+	}
+
+	err := NewGarbageCollector(environments, reconcileService, getReader, getCommitter, logrus.New()).CollectAll(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, reconcileService.CollectGarbageCallCount)
+}
@@ -0,0 +1,79 @@
+package reconcile
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/state"
+)
+
+// ReaderFunc returns the reader for the state of an environment
+type ReaderFunc func(ctx context.Context, envName string) (state.Reader, error)
+
+// DriftDetector periodically detects drift in every environment so that changes made directly to the state repo are noticed
+type DriftDetector struct {
+	environments     core.EnvironmentRepository
+	reconcileService Service
+	getReader        ReaderFunc
+	logger           logrus.FieldLogger
+}
+
+func NewDriftDetector(environments core.EnvironmentRepository, reconcileService Service, getReader ReaderFunc, logger logrus.FieldLogger) *DriftDetector {
+	return &DriftDetector{environments, reconcileService, getReader, logger}
+}
+
+// Run detects drift at the specified interval until the context is done
+func (d *DriftDetector) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := d.DetectAll(ctx)
+			if err != nil {
+				d.logger.WithError(err).Error("Error detecting drift")
+			}
+		}
+	}
+}
+
+// DetectAll detects drift in every environment. Detection stops when the context is done.
+func (d *DriftDetector) DetectAll(ctx context.Context) error {
+	environments, err := d.environments.List()
+	if err != nil {
+		return errors.Wrap(err, "error listing environments")
+	}
+
+	for _, environment := range environments {
+		logger := d.logger.WithField("environment", environment.Name)
+		// An error with one environment should not prevent drift detection in other environments
+		report, err := d.detect(ctx, environment.Name)
+		if err != nil {
+			logger.WithError(err).Error("Error detecting drift")
+			continue
+		}
+		if report.Doc.HasDrift() {
+			logger.WithFields(logrus.Fields{
+				"missing":    len(report.Doc.Missing),
+				"unexpected": len(report.Doc.Unexpected),
+				"changed":    len(report.Doc.Changed),
+			}).Warn("The state repo has drifted from the expected state")
+		}
+	}
+
+	return nil
+}
+
+func (d *DriftDetector) detect(ctx context.Context, envName string) (*core.DriftReport, error) {
+	reader, err := d.getReader(ctx, envName)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting state reader")
+	}
+	return d.reconcileService.DetectDrift(envName, reader)
+}
@@ -0,0 +1,52 @@
+package reconcile
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/state"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DetectAll(t *testing.T) {
+	environments := &core.FakeEnvironmentRepository{
+		ListFn: func() ([]core.Environment, error) {
+			return []core.Environment{{Name: "dev"}, {Name: "prod"}}, nil
+		},
+	}
+	detected := []string{}
+	reconcileService := &FakeService{
+		DetectDriftFn: func(envName string, reader state.Reader) (*core.DriftReport, error) {
+			detected = append(detected, envName)
+			if envName == "dev" {
+				return nil, errors.New("test")
+			}
+			return &core.DriftReport{EnvironmentName: envName, Doc: core.DriftReportDoc{Missing: []string{"test.yaml"}}}, nil
+		},
+	}
+	getReader := func(_ context.Context, envName string) (state.Reader, error) {
+		return &fakeReader{}, nil
+	}
+
+	err := NewDriftDetector(environments, reconcileService, getReader, logrus.New()).DetectAll(context.Background())
+
+	require.NoError(t, err)
+	// An error in one environment does not prevent other environments from being checked
+	assert.Equal(t, []string{"dev", "prod"}, detected)
+}
+
+func Test_DetectAll_ListErr(t *testing.T) {
+	environments := &core.FakeEnvironmentRepository{
+		ListFn: func() ([]core.Environment, error) {
+			return nil, errors.New("test")
+		},
+	}
+
+	err := NewDriftDetector(environments, &FakeService{}, nil, logrus.New()).DetectAll(context.Background())
+
+	assert.Equal(t, "error listing environments: test", err.Error())
+}
@@ -0,0 +1,194 @@
+package reconcile
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/state"
+	"github.com/riser-platform/riser-server/pkg/state/resources"
+	"github.com/riser-platform/riser-server/pkg/util"
+)
+
+func (s *service) DetectDrift(envName string, reader state.Reader) (*core.DriftReport, error) {
+	doc, result, err := s.compareState(envName, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	doc.SkippedDeployments = result.SkippedDeployments
+	doc.SkippedSecrets = []core.DriftSkippedSecret{}
+	for _, secretMeta := range result.SkippedSecrets {
+		doc.SkippedSecrets = append(doc.SkippedSecrets, core.DriftSkippedSecret{App: *secretMeta.App, Name: secretMeta.Name})
+	}
+
+	report := &core.DriftReport{
+		EnvironmentName: envName,
+		Created:         s.now(),
+		Doc:             *doc,
+	}
+
+	err = s.driftReports.Save(report)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error saving the drift report for environment %q", envName))
+	}
+
+	return report, nil
+}
+
+func (s *service) GetDriftReport(envName string) (*core.DriftReport, error) {
+	return s.driftReports.Get(envName)
+}
+
+// compareState compares the state that riser expects in an environment with the riser managed folders of the state repo. Resources
+// that could not be rendered are not compared.
+func (s *service) compareState(envName string, reader state.Reader) (*core.DriftReportDoc, *core.ReconcileResult, error) {
+	expectedFiles, result, err := s.renderEnvironment(envName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	actualFiles := []core.ResourceFile{}
+	for _, dir := range state.ManagedDirs {
+		files, err := reader.ReadFiles(dir)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, fmt.Sprintf("Error reading the state of environment %q", envName))
+		}
+		actualFiles = append(actualFiles, files...)
+	}
+
+	ignored, err := skippedPaths(envName, result)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kustomizations, err := s.renderKustomizations(envName, expectedFiles, actualFiles)
+	if err != nil {
+		return nil, nil, err
+	}
+	expectedFiles = append(expectedFiles, kustomizations...)
+
+	doc, err := compareFiles(expectedFiles, actualFiles, ignored)
+	if err != nil {
+		return nil, nil, err
+	}
+	return doc, result, nil
+}
+
+// renderKustomizations returns the kustomization files that are expected when the environment maintains them. Kustomization files
+// list the files that are in the state repo, so unexpected files are reported once rather than also as a change to a kustomization.
+func (s *service) renderKustomizations(envName string, expectedFiles, actualFiles []core.ResourceFile) ([]core.ResourceFile, error) {
+	environment, err := s.environments.Get(envName)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error retrieving environment %q", envName))
+	}
+	if !environment.Doc.Config.KustomizeEnabled() {
+		return []core.ResourceFile{}, nil
+	}
+
+	fileNames := map[string]bool{}
+	for _, file := range append(expectedFiles, actualFiles...) {
+		fileNames[file.Name] = true
+	}
+	names := []string{}
+	for name := range fileNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return state.RenderKustomizations(envName, names)
+}
+
+// compareFiles compares the expected state with the actual state. Actual files that are in an ignored path are not reported.
+func compareFiles(expectedFiles, actualFiles []core.ResourceFile, ignored []string) (*core.DriftReportDoc, error) {
+	doc := &core.DriftReportDoc{
+		Missing:    []string{},
+		Unexpected: []string{},
+		Changed:    []core.DriftedFile{},
+	}
+
+	expected := map[string][]byte{}
+	for _, file := range expectedFiles {
+		if !file.Delete {
+			expected[file.Name] = file.Contents
+		}
+	}
+
+	actual := map[string][]byte{}
+	for _, file := range actualFiles {
+		if !isPathIgnored(file.Name, ignored) {
+			actual[file.Name] = file.Contents
+		}
+	}
+
+	for name, expectedContents := range expected {
+		actualContents, ok := actual[name]
+		if !ok {
+			doc.Missing = append(doc.Missing, name)
+			continue
+		}
+		if !bytes.Equal(expectedContents, actualContents) {
+			diff, err := util.UnifiedDiff(name, actualContents, expectedContents)
+			if err != nil {
+				return nil, errors.Wrap(err, fmt.Sprintf("Error comparing file %q", name))
+			}
+			doc.Changed = append(doc.Changed, core.DriftedFile{Name: name, Diff: diff})
+		}
+	}
+
+	for name := range actual {
+		if _, ok := expected[name]; !ok {
+			doc.Unexpected = append(doc.Unexpected, name)
+		}
+	}
+
+	sort.Strings(doc.Missing)
+	sort.Strings(doc.Unexpected)
+	sort.Slice(doc.Changed, func(i, j int) bool { return doc.Changed[i].Name < doc.Changed[j].Name })
+	return doc, nil
+}
+
+// skippedPaths returns the paths of resources that could not be rendered. Their state is unknown so they are not reported.
+func skippedPaths(envName string, result *core.ReconcileResult) ([]string, error) {
+	paths := []string{}
+	for _, name := range result.SkippedDeployments {
+		for _, file := range state.RenderDeleteDeployment(name.Name, name.Namespace) {
+			paths = append(paths, file.Name)
+		}
+		// The namespace is not rendered when all of its deployments are skipped
+		namespaceFiles, err := state.RenderGeneric(envName, resources.CreateNamespace(name.Namespace, envName))
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error rendering namespace %q in environment %q", name.Namespace, envName))
+		}
+		for _, file := range namespaceFiles {
+			paths = append(paths, file.Name)
+		}
+		// The app of a skipped deployment is not known so none of the service accounts in its namespace are reported
+		paths = append(paths, state.ServiceAccountsDir(name.Namespace))
+	}
+
+	for idx := range result.SkippedSecrets {
+		secretMeta := &result.SkippedSecrets[idx]
+		files, err := state.RenderSealedSecret(secretMeta.App.Name, envName, resources.CreateSealedSecretFromCiphertext(secretMeta, nil))
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error rendering sealed secret resource %q in environment %q", secretMeta.Name, envName))
+		}
+		for _, file := range files {
+			paths = append(paths, file.Name)
+		}
+	}
+
+	return paths, nil
+}
+
+func isPathIgnored(name string, ignored []string) bool {
+	for _, ignoredPath := range ignored {
+		if name == ignoredPath || strings.HasPrefix(name, ignoredPath+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,179 @@
+package reconcile
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/deployment"
+	"github.com/riser-platform/riser-server/pkg/secret"
+	"github.com/riser-platform/riser-server/pkg/state"
+	"github.com/riser-platform/riser-server/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeReader struct {
+	files map[string][]core.ResourceFile
+	err   error
+}
+
+func (r *fakeReader) ReadFiles(dir string) ([]core.ResourceFile, error) {
+	return r.files[dir], r.err
+}
+
+func newTestEnvironments(config core.EnvironmentConfig) *core.FakeEnvironmentRepository {
+	return &core.FakeEnvironmentRepository{
+		GetFn: func(string) (*core.Environment, error) {
+			return &core.Environment{Doc: core.EnvironmentDoc{Config: config}}, nil
+		},
+	}
+}
+
+func Test_DetectDrift(t *testing.T) {
+	deploymentService := &deployment.FakeService{
+		RenderEnvironmentFn: func(envName string) ([]core.ResourceFile, []core.NamespacedName, error) {
+			return []core.ResourceFile{
+					{Name: "state/riser-managed/myns/deployments/app1/deployment.yaml", Contents: []byte("a: 1\n")},
+					{Name: "state/riser-managed/myns/deployments/app1/service.yaml", Contents: []byte("b: 1\n")},
+					{Name: "riser-config/myns/app1.yaml", Contents: []byte("c: 1\n")},
+				},
+				[]core.NamespacedName{*core.NewNamespacedName("old", "myns")}, nil
+		},
+	}
+	secretService := &secret.FakeService{
+		RenderEnvironmentFn: func(string) ([]core.ResourceFile, []core.SecretMeta, error) {
+			return []core.ResourceFile{}, []core.SecretMeta{{Name: "oldsecret", App: core.NewNamespacedName("app1", "myns"), Revision: 1}}, nil
+		},
+	}
+	reader := &fakeReader{
+		files: map[string][]core.ResourceFile{
+			"state/riser-managed": {
+				{Name: "state/riser-managed/myns/deployments/app1/deployment.yaml", Contents: []byte("a: 2\n")},
+				{Name: "state/riser-managed/myns/deployments/orphan/deployment.yaml", Contents: []byte("d: 1\n")},
+				// Skipped resources are not reported
+				{Name: "state/riser-managed/myns/deployments/old/deployment.yaml", Contents: []byte("e: 1\n")},
+				{Name: "state/riser-managed/namespace.myns.yaml", Contents: []byte("h: 1\n")},
+				{Name: "state/riser-managed/myns/serviceaccounts/serviceaccount.old.yaml", Contents: []byte("i: 1\n")},
+				{Name: "state/riser-managed/myns/secrets/app1/bitnami.com.sealedsecret.app1-oldsecret-1.yaml", Contents: []byte("f: 1\n")},
+			},
+			"riser-config": {
+				{Name: "riser-config/myns/app1.yaml", Contents: []byte("c: 1\n")},
+				{Name: "riser-config/myns/old.yaml", Contents: []byte("g: 1\n")},
+			},
+		},
+	}
+	now := time.Now()
+	driftReports := &core.FakeDriftReportRepository{
+		SaveFn: func(report *core.DriftReport) error {
+			return nil
+		},
+	}
+	svc := &service{deploymentService, secretService, driftReports, newTestEnvironments(core.EnvironmentConfig{}), func() time.Time { return now }}
+
+	result, err := svc.DetectDrift("myenv", reader)
+
+	require.NoError(t, err)
+	assert.Equal(t, "myenv", result.EnvironmentName)
+	assert.Equal(t, now, result.Created)
+	assert.Equal(t, []string{"state/riser-managed/myns/deployments/app1/service.yaml"}, result.Doc.Missing)
+	assert.Equal(t, []string{"state/riser-managed/myns/deployments/orphan/deployment.yaml"}, result.Doc.Unexpected)
+	require.Len(t, result.Doc.Changed, 1)
+	assert.Equal(t, "state/riser-managed/myns/deployments/app1/deployment.yaml", result.Doc.Changed[0].Name)
+	assert.Contains(t, result.Doc.Changed[0].Diff, "-a: 2\n+a: 1\n")
+	assert.Equal(t, []core.NamespacedName{*core.NewNamespacedName("old", "myns")}, result.Doc.SkippedDeployments)
+	assert.Equal(t, []core.DriftSkippedSecret{{App: *core.NewNamespacedName("app1", "myns"), Name: "oldsecret"}}, result.Doc.SkippedSecrets)
+	assert.True(t, result.Doc.HasDrift())
+	assert.Equal(t, 1, driftReports.SaveCallCount)
+}
+
+func Test_DetectDrift_NoDrift(t *testing.T) {
+	deploymentService := &deployment.FakeService{
+		RenderEnvironmentFn: func(string) ([]core.ResourceFile, []core.NamespacedName, error) {
+			return []core.ResourceFile{{Name: "riser-config/myns/app1.yaml", Contents: []byte("c: 1\n")}}, []core.NamespacedName{}, nil
+		},
+	}
+	secretService := &secret.FakeService{
+		RenderEnvironmentFn: func(string) ([]core.ResourceFile, []core.SecretMeta, error) {
+			return []core.ResourceFile{}, []core.SecretMeta{}, nil
+		},
+	}
+	reader := &fakeReader{
+		files: map[string][]core.ResourceFile{
+			"riser-config": {{Name: "riser-config/myns/app1.yaml", Contents: []byte("c: 1\n")}},
+		},
+	}
+	driftReports := &core.FakeDriftReportRepository{
+		SaveFn: func(report *core.DriftReport) error {
+			return nil
+		},
+	}
+
+	result, err := NewService(deploymentService, secretService, driftReports, newTestEnvironments(core.EnvironmentConfig{})).DetectDrift("myenv", reader)
+
+	require.NoError(t, err)
+	assert.False(t, result.Doc.HasDrift())
+	assert.Empty(t, result.Doc.Missing)
+	assert.Empty(t, result.Doc.Unexpected)
+	assert.Empty(t, result.Doc.Changed)
+}
+
+func Test_DetectDrift_Kustomize(t *testing.T) {
+	deploymentService := &deployment.FakeService{
+		RenderEnvironmentFn: func(string) ([]core.ResourceFile, []core.NamespacedName, error) {
+			return []core.ResourceFile{{Name: "state/riser-managed/myns/deployments/app1/deployment.yaml", Contents: []byte("a: 1\n")}},
+				[]core.NamespacedName{}, nil
+		},
+	}
+	secretService := &secret.FakeService{
+		RenderEnvironmentFn: func(string) ([]core.ResourceFile, []core.SecretMeta, error) {
+			return []core.ResourceFile{}, []core.SecretMeta{}, nil
+		},
+	}
+	actualFiles := []core.ResourceFile{
+		{Name: "state/riser-managed/myns/deployments/app1/deployment.yaml", Contents: []byte("a: 1\n")},
+		{Name: "state/riser-managed/myns/deployments/orphan/deployment.yaml", Contents: []byte("b: 1\n")},
+	}
+	kustomizations, err := state.RenderKustomizations("myenv", []string{actualFiles[0].Name, actualFiles[1].Name})
+	require.NoError(t, err)
+	for _, file := range kustomizations {
+		if file.Name != "state/riser-managed/kustomization.yaml" {
+			actualFiles = append(actualFiles, file)
+		}
+	}
+	driftReports := &core.FakeDriftReportRepository{
+		SaveFn: func(report *core.DriftReport) error {
+			return nil
+		},
+	}
+	svc := NewService(deploymentService, secretService, driftReports, newTestEnvironments(core.EnvironmentConfig{Kustomize: util.PtrBool(true)}))
+
+	result, err := svc.DetectDrift("myenv", &fakeReader{files: map[string][]core.ResourceFile{"state/riser-managed": actualFiles}})
+
+	require.NoError(t, err)
+	// The kustomizations list the orphan since it's in the state repo
+	assert.Equal(t, []string{"state/riser-managed/myns/deployments/orphan/deployment.yaml"}, result.Doc.Unexpected)
+	assert.Equal(t, []string{"state/riser-managed/kustomization.yaml"}, result.Doc.Missing)
+	assert.Empty(t, result.Doc.Changed)
+}
+
+func Test_DetectDrift_ReadErr(t *testing.T) {
+	deploymentService := &deployment.FakeService{
+		RenderEnvironmentFn: func(string) ([]core.ResourceFile, []core.NamespacedName, error) {
+			return []core.ResourceFile{}, []core.NamespacedName{}, nil
+		},
+	}
+	secretService := &secret.FakeService{
+		RenderEnvironmentFn: func(string) ([]core.ResourceFile, []core.SecretMeta, error) {
+			return []core.ResourceFile{}, []core.SecretMeta{}, nil
+		},
+	}
+	driftReports := &core.FakeDriftReportRepository{}
+
+	result, err := NewService(deploymentService, secretService, driftReports, newTestEnvironments(core.EnvironmentConfig{})).DetectDrift("myenv", &fakeReader{err: errors.New("test")})
+
+	assert.Nil(t, result)
+	assert.Equal(t, `Error reading the state of environment "myenv": test`, err.Error())
+	assert.Equal(t, 0, driftReports.SaveCallCount)
+}
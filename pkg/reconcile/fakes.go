@@ -0,0 +1,37 @@
+package reconcile
+
+import (
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/state"
+)
+
+type FakeService struct {
+	ReconcileFn             func(envName string, reconciledBy string, committer state.Committer) (*core.ReconcileResult, error)
+	ReconcileCallCount      int
+	DetectDriftFn           func(envName string, reader state.Reader) (*core.DriftReport, error)
+	DetectDriftCallCount    int
+	GetDriftReportFn        func(envName string) (*core.DriftReport, error)
+	GetDriftReportCallCount int
+	CollectGarbageFn        func(envName string, collectedBy string, reader state.Reader, committer state.Committer) (*core.GarbageCollectionResult, error)
+	CollectGarbageCallCount int
+}
+
+func (f *FakeService) Reconcile(envName string, reconciledBy string, committer state.Committer) (*core.ReconcileResult, error) {
+	f.ReconcileCallCount++
+	return f.ReconcileFn(envName, reconciledBy, committer)
+}
+
+func (f *FakeService) DetectDrift(envName string, reader state.Reader) (*core.DriftReport, error) {
+	f.DetectDriftCallCount++
+	return f.DetectDriftFn(envName, reader)
+}
+
+func (f *FakeService) GetDriftReport(envName string) (*core.DriftReport, error) {
+	f.GetDriftReportCallCount++
+	return f.GetDriftReportFn(envName)
+}
+
+func (f *FakeService) CollectGarbage(envName string, collectedBy string, reader state.Reader, committer state.Committer) (*core.GarbageCollectionResult, error) {
+	f.CollectGarbageCallCount++
+	return f.CollectGarbageFn(envName, collectedBy, reader, committer)
+}
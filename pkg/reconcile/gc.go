@@ -0,0 +1,91 @@
+package reconcile
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/git"
+	"github.com/riser-platform/riser-server/pkg/state"
+	"github.com/riser-platform/riser-server/pkg/state/resources"
+)
+
+func (s *service) CollectGarbage(envName string, collectedBy string, reader state.Reader, committer state.Committer) (*core.GarbageCollectionResult, error) {
+	var result *core.GarbageCollectionResult
+	var collectErr error
+	// The garbage is collected from the state as it is when committing. Otherwise a file that is committed (e.g. by a deployment)
+	// after riser's state was rendered would be removed.
+	prepare := func(current state.Reader) ([]core.ResourceFile, error) {
+		var files []core.ResourceFile
+		var err error
+		result, files, err = s.collectGarbage(envName, current)
+		if err != nil {
+			collectErr = err
+			return nil, err
+		}
+		if len(files) == 0 {
+			return nil, git.ErrNoChanges
+		}
+		return files, nil
+	}
+
+	err := state.CommitPrepared(committer, reader, fmt.Sprintf("Collecting garbage in environment %q", envName), prepare, &core.CommitMeta{
+		Username:    collectedBy,
+		Environment: envName,
+	})
+	if collectErr != nil {
+		return nil, collectErr
+	}
+	if err == git.ErrNoChanges {
+		result.NoChanges = true
+		return result, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error committing the state of environment %q", envName))
+	}
+
+	return result, nil
+}
+
+// collectGarbage returns the files to delete from the current state
+func (s *service) collectGarbage(envName string, reader state.Reader) (*core.GarbageCollectionResult, []core.ResourceFile, error) {
+	doc, _, err := s.compareState(envName, reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	references, err := s.deploymentService.FindSecretReferences(envName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Older sealed secret revisions are unexpected but must be kept while a live revision references them
+	kept := []string{}
+	for idx := range references.Secrets {
+		secretMeta := &references.Secrets[idx]
+		files, err := state.RenderSealedSecret(secretMeta.App.Name, envName, resources.CreateSealedSecretFromCiphertext(secretMeta, nil))
+		if err != nil {
+			return nil, nil, errors.Wrap(err, fmt.Sprintf("Error rendering sealed secret resource %q in environment %q", secretMeta.Name, envName))
+		}
+		for _, file := range files {
+			kept = append(kept, file.Name)
+		}
+	}
+	for _, namespace := range references.UnknownNamespaces {
+		kept = append(kept, state.SecretsDir(namespace))
+	}
+
+	result := &core.GarbageCollectionResult{
+		Deleted:           []string{},
+		SkippedNamespaces: references.UnknownNamespaces,
+	}
+	files := []core.ResourceFile{}
+	for _, name := range doc.Unexpected {
+		if !isPathIgnored(name, kept) {
+			result.Deleted = append(result.Deleted, name)
+			files = append(files, core.ResourceFile{Name: name, Delete: true})
+		}
+	}
+
+	return result, files, nil
+}
@@ -0,0 +1,189 @@
+package reconcile
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/deployment"
+	"github.com/riser-platform/riser-server/pkg/secret"
+	"github.com/riser-platform/riser-server/pkg/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newGCTestServices(references *core.SecretReferences) (*deployment.FakeService, *secret.FakeService) {
+	deploymentService := &deployment.FakeService{
+		RenderEnvironmentFn: func(string) ([]core.ResourceFile, []core.NamespacedName, error) {
+			return []core.ResourceFile{
+				{Name: "state/riser-managed/namespace.myns.yaml"},
+				{Name: "state/riser-managed/myns/deployments/myapp/deployment.yaml"},
+				{Name: "riser-config/myns/myapp.yaml"},
+			}, []core.NamespacedName{}, nil
+		},
+		FindSecretReferencesFn: func(envName string) (*core.SecretReferences, error) {
+			return references, nil
+		},
+	}
+	secretService := &secret.FakeService{
+		RenderEnvironmentFn: func(string) ([]core.ResourceFile, []core.SecretMeta, error) {
+			return []core.ResourceFile{{Name: "state/riser-managed/myns/secrets/myapp/bitnami.com.sealedsecret.myapp-s1-3.yaml"}}, []core.SecretMeta{}, nil
+		},
+	}
+	return deploymentService, secretService
+}
+
+func newGCTestReader() *fakeReader {
+	return &fakeReader{
+		files: map[string][]core.ResourceFile{
+			"state/riser-managed": {
+				{Name: "state/riser-managed/namespace.myns.yaml"},
+				{Name: "state/riser-managed/namespace.oldns.yaml"},
+				{Name: "state/riser-managed/myns/deployments/myapp/deployment.yaml"},
+				{Name: "state/riser-managed/myns/secrets/myapp/bitnami.com.sealedsecret.myapp-s1-1.yaml"},
+				{Name: "state/riser-managed/myns/secrets/myapp/bitnami.com.sealedsecret.myapp-s1-2.yaml"},
+				{Name: "state/riser-managed/myns/secrets/myapp/bitnami.com.sealedsecret.myapp-s1-3.yaml"},
+				{Name: "state/riser-managed/legacyns/secrets/legacy/bitnami.com.sealedsecret.legacy-s1-1.yaml"},
+			},
+			"riser-config": {
+				{Name: "riser-config/myns/myapp.yaml"},
+				{Name: "riser-config/oldns/oldapp.yaml"},
+			},
+		},
+	}
+}
+
+func Test_CollectGarbage(t *testing.T) {
+	deploymentService, secretService := newGCTestServices(&core.SecretReferences{
+		Secrets: []core.SecretMeta{
+			{Name: "s1", App: core.NewNamespacedName("myapp", "myns"), Revision: 2},
+		},
+		UnknownNamespaces: []string{"legacyns"},
+	})
+	committer := state.NewDryRunCommitter()
+
+	result, err := NewService(deploymentService, secretService, nil, newTestEnvironments(core.EnvironmentConfig{})).CollectGarbage("myenv", "myuser", newGCTestReader(), committer)
+
+	require.NoError(t, err)
+	expected := []string{
+		"riser-config/oldns/oldapp.yaml",
+		"state/riser-managed/myns/secrets/myapp/bitnami.com.sealedsecret.myapp-s1-1.yaml",
+		"state/riser-managed/namespace.oldns.yaml",
+	}
+	assert.Equal(t, expected, result.Deleted)
+	assert.Equal(t, []string{"legacyns"}, result.SkippedNamespaces)
+	assert.False(t, result.NoChanges)
+	require.Len(t, committer.Commits, 1)
+	assert.Equal(t, `Collecting garbage in environment "myenv"`, committer.Commits[0].Message)
+	assert.Equal(t, &core.CommitMeta{Username: "myuser", Environment: "myenv"}, committer.Commits[0].Meta)
+	require.Len(t, committer.Commits[0].Files, 3)
+	for idx, file := range committer.Commits[0].Files {
+		assert.Equal(t, expected[idx], file.Name)
+		assert.True(t, file.Delete)
+	}
+}
+
+// fakePreparingCommitter prepares the files from the reader after simulating a concurrent change
+type fakePreparingCommitter struct {
+	reader          state.Reader
+	concurrentFn    func()
+	CommittedFiles  []core.ResourceFile
+	CommitCallCount int
+}
+
+func (c *fakePreparingCommitter) Commit(message string, files []core.ResourceFile, meta *core.CommitMeta) error {
+	c.CommitCallCount++
+	c.CommittedFiles = files
+	return nil
+}
+
+func (c *fakePreparingCommitter) CommitPrepared(message string, prepare state.PrepareFunc, meta *core.CommitMeta) error {
+	c.concurrentFn()
+	files, err := prepare(c.reader)
+	if err != nil {
+		return err
+	}
+	return c.Commit(message, files, meta)
+}
+
+func Test_CollectGarbage_CollectsFromCommittedState(t *testing.T) {
+	deploymentService, secretService := newGCTestServices(&core.SecretReferences{
+		Secrets: []core.SecretMeta{
+			{Name: "s1", App: core.NewNamespacedName("myapp", "myns"), Revision: 2},
+		},
+		UnknownNamespaces: []string{"legacyns"},
+	})
+	reader := newGCTestReader()
+	committer := &fakePreparingCommitter{
+		reader: reader,
+		// A new deployment and a new secret are committed after the collection started
+		concurrentFn: func() {
+			renderDeployments := deploymentService.RenderEnvironmentFn
+			deploymentService.RenderEnvironmentFn = func(envName string) ([]core.ResourceFile, []core.NamespacedName, error) {
+				files, skipped, err := renderDeployments(envName)
+				files = append(files,
+					core.ResourceFile{Name: "state/riser-managed/namespace.newns.yaml"},
+					core.ResourceFile{Name: "state/riser-managed/newns/deployments/newapp/deployment.yaml"})
+				return files, skipped, err
+			}
+			secretService.RenderEnvironmentFn = func(string) ([]core.ResourceFile, []core.SecretMeta, error) {
+				return []core.ResourceFile{
+					{Name: "state/riser-managed/myns/secrets/myapp/bitnami.com.sealedsecret.myapp-s1-3.yaml"},
+					{Name: "state/riser-managed/myns/secrets/myapp/bitnami.com.sealedsecret.myapp-s2-1.yaml"},
+				}, []core.SecretMeta{}, nil
+			}
+			reader.files["state/riser-managed"] = append(reader.files["state/riser-managed"],
+				core.ResourceFile{Name: "state/riser-managed/namespace.newns.yaml"},
+				core.ResourceFile{Name: "state/riser-managed/newns/deployments/newapp/deployment.yaml"},
+				core.ResourceFile{Name: "state/riser-managed/myns/secrets/myapp/bitnami.com.sealedsecret.myapp-s2-1.yaml"})
+		},
+	}
+
+	// The state is read from the committer
+	result, err := NewService(deploymentService, secretService, nil, newTestEnvironments(core.EnvironmentConfig{})).CollectGarbage("myenv", "myuser", &fakeReader{err: errors.New("test")}, committer)
+
+	require.NoError(t, err)
+	expected := []string{
+		"riser-config/oldns/oldapp.yaml",
+		"state/riser-managed/myns/secrets/myapp/bitnami.com.sealedsecret.myapp-s1-1.yaml",
+		"state/riser-managed/namespace.oldns.yaml",
+	}
+	assert.Equal(t, expected, result.Deleted)
+	assert.Equal(t, 1, committer.CommitCallCount)
+	require.Len(t, committer.CommittedFiles, 3)
+	for idx, file := range committer.CommittedFiles {
+		assert.Equal(t, expected[idx], file.Name)
+		assert.True(t, file.Delete)
+	}
+}
+
+func Test_CollectGarbage_NoGarbage(t *testing.T) {
+	deploymentService, secretService := newGCTestServices(&core.SecretReferences{})
+	reader := &fakeReader{
+		files: map[string][]core.ResourceFile{
+			"riser-config": {{Name: "riser-config/myns/myapp.yaml"}},
+		},
+	}
+	committer := state.NewDryRunCommitter()
+
+	result, err := NewService(deploymentService, secretService, nil, newTestEnvironments(core.EnvironmentConfig{})).CollectGarbage("myenv", "myuser", reader, committer)
+
+	require.NoError(t, err)
+	assert.True(t, result.NoChanges)
+	assert.Empty(t, result.Deleted)
+	assert.Empty(t, committer.Commits)
+}
+
+func Test_CollectGarbage_FindSecretReferencesErr(t *testing.T) {
+	deploymentService, secretService := newGCTestServices(nil)
+	deploymentService.FindSecretReferencesFn = func(string) (*core.SecretReferences, error) {
+		return nil, errors.New("test")
+	}
+	committer := state.NewDryRunCommitter()
+
+	result, err := NewService(deploymentService, secretService, nil, newTestEnvironments(core.EnvironmentConfig{})).CollectGarbage("myenv", "myuser", newGCTestReader(), committer)
+
+	assert.Nil(t, result)
+	assert.Equal(t, "test", err.Error())
+	assert.Empty(t, committer.Commits)
+}
@@ -0,0 +1,112 @@
+package reconcile
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/deployment"
+	"github.com/riser-platform/riser-server/pkg/git"
+	"github.com/riser-platform/riser-server/pkg/secret"
+	"github.com/riser-platform/riser-server/pkg/state"
+)
+
+type Service interface {
+	// Reconcile regenerates every riser managed resource in an environment (deployments, routes, namespaces, and sealed secrets) from
+	// the database in a single commit. Files that are no longer managed by riser are not removed. The environment is rendered while
+	// the state is guarded against concurrent changes when the committer is a state.PreparingCommitter.
+	Reconcile(envName string, reconciledBy string, committer state.Committer) (*core.ReconcileResult, error)
+	// DetectDrift compares the state that riser expects in an environment with the riser managed folders of the state repo and
+	// saves the report
+	DetectDrift(envName string, reader state.Reader) (*core.DriftReport, error)
+	// GetDriftReport returns the last saved report for an environment
+	GetDriftReport(envName string) (*core.DriftReport, error)
+	// CollectGarbage removes the files in the riser managed folders of an environment that riser no longer references (e.g. the
+	// namespace of deleted deployments and sealed secret revisions that are not referenced by a live revision) in a single commit.
+	// Use a DryRunCommitter to report what would be removed. The state is read from the committer when it is a
+	// state.PreparingCommitter so that files committed concurrently are not removed. Otherwise it is read from the reader.
+	CollectGarbage(envName string, collectedBy string, reader state.Reader, committer state.Committer) (*core.GarbageCollectionResult, error)
+}
+
+type service struct {
+	deploymentService deployment.Service
+	secretService     secret.Service
+	driftReports      core.DriftReportRepository
+	environments      core.EnvironmentRepository
+	now               func() time.Time
+}
+
+func NewService(deploymentService deployment.Service, secretService secret.Service, driftReports core.DriftReportRepository, environments core.EnvironmentRepository) Service {
+	return &service{deploymentService, secretService, driftReports, environments, time.Now}
+}
+
+func (s *service) Reconcile(envName string, reconciledBy string, committer state.Committer) (*core.ReconcileResult, error) {
+	var result *core.ReconcileResult
+	var renderErr error
+	// The environment is rendered when committing so that a concurrent change (e.g. a deployment or a rollout step) is not reverted
+	// to the state that was rendered before it was committed
+	prepare := func(state.Reader) ([]core.ResourceFile, error) {
+		var files []core.ResourceFile
+		var err error
+		files, result, err = s.renderEnvironment(envName)
+		if err != nil {
+			renderErr = err
+			return nil, err
+		}
+		if len(files) == 0 {
+			return nil, git.ErrNoChanges
+		}
+		return files, nil
+	}
+
+	err := state.CommitPrepared(committer, nil, fmt.Sprintf("Reconciling environment %q", envName), prepare, &core.CommitMeta{
+		Username:    reconciledBy,
+		Environment: envName,
+	})
+	if renderErr != nil {
+		return nil, renderErr
+	}
+	if err == git.ErrNoChanges {
+		result.NoChanges = true
+		return result, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error committing the state of environment %q", envName))
+	}
+
+	return result, nil
+}
+
+// renderEnvironment renders every riser managed resource in an environment
+func (s *service) renderEnvironment(envName string) ([]core.ResourceFile, *core.ReconcileResult, error) {
+	deploymentFiles, skippedDeployments, err := s.deploymentService.RenderEnvironment(envName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	secretFiles, skippedSecrets, err := s.secretService.RenderEnvironment(envName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := &core.ReconcileResult{
+		SkippedDeployments: skippedDeployments,
+		SkippedSecrets:     skippedSecrets,
+	}
+
+	return uniqueFiles(append(deploymentFiles, secretFiles...)), result, nil
+}
+
+// uniqueFiles removes duplicate files (e.g. the namespace resource is rendered for every deployment in the namespace)
+func uniqueFiles(files []core.ResourceFile) []core.ResourceFile {
+	fileNames := map[string]bool{}
+	unique := []core.ResourceFile{}
+	for _, file := range files {
+		if !fileNames[file.Name] {
+			fileNames[file.Name] = true
+			unique = append(unique, file)
+		}
+	}
+	return unique
+}
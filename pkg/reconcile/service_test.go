@@ -0,0 +1,146 @@
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/deployment"
+	"github.com/riser-platform/riser-server/pkg/git"
+	"github.com/riser-platform/riser-server/pkg/secret"
+	"github.com/riser-platform/riser-server/pkg/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Reconcile(t *testing.T) {
+	deploymentService := &deployment.FakeService{
+		RenderEnvironmentFn: func(envName string) ([]core.ResourceFile, []core.NamespacedName, error) {
+			assert.Equal(t, "myenv", envName)
+			return []core.ResourceFile{
+					{Name: "app1.yaml"},
+					{Name: "namespace.yaml"},
+					{Name: "app2.yaml"},
+					{Name: "namespace.yaml"},
+				},
+				[]core.NamespacedName{*core.NewNamespacedName("old", "myns")}, nil
+		},
+	}
+	secretService := &secret.FakeService{
+		RenderEnvironmentFn: func(envName string) ([]core.ResourceFile, []core.SecretMeta, error) {
+			assert.Equal(t, "myenv", envName)
+			return []core.ResourceFile{{Name: "secret.yaml"}}, []core.SecretMeta{{Name: "oldsecret"}}, nil
+		},
+	}
+	committer := state.NewDryRunCommitter()
+
+	result, err := NewService(deploymentService, secretService, nil, nil).Reconcile("myenv", "myuser", committer)
+
+	require.NoError(t, err)
+	assert.Equal(t, []core.NamespacedName{*core.NewNamespacedName("old", "myns")}, result.SkippedDeployments)
+	assert.Equal(t, []core.SecretMeta{{Name: "oldsecret"}}, result.SkippedSecrets)
+	assert.False(t, result.NoChanges)
+	require.Len(t, committer.Commits, 1)
+	assert.Equal(t, `Reconciling environment "myenv"`, committer.Commits[0].Message)
+	assert.Equal(t, &core.CommitMeta{Username: "myuser", Environment: "myenv"}, committer.Commits[0].Meta)
+	assert.Equal(t, []core.ResourceFile{{Name: "app1.yaml"}, {Name: "namespace.yaml"}, {Name: "app2.yaml"}, {Name: "secret.yaml"}}, committer.Commits[0].Files)
+}
+
+func Test_Reconcile_NoFiles(t *testing.T) {
+	deploymentService := &deployment.FakeService{
+		RenderEnvironmentFn: func(string) ([]core.ResourceFile, []core.NamespacedName, error) {
+			return []core.ResourceFile{}, []core.NamespacedName{}, nil
+		},
+	}
+	secretService := &secret.FakeService{
+		RenderEnvironmentFn: func(string) ([]core.ResourceFile, []core.SecretMeta, error) {
+			return []core.ResourceFile{}, []core.SecretMeta{}, nil
+		},
+	}
+	committer := state.NewDryRunCommitter()
+
+	result, err := NewService(deploymentService, secretService, nil, nil).Reconcile("myenv", "myuser", committer)
+
+	require.NoError(t, err)
+	assert.True(t, result.NoChanges)
+	assert.Empty(t, committer.Commits)
+}
+
+func Test_Reconcile_NoChanges(t *testing.T) {
+	deploymentService := &deployment.FakeService{
+		RenderEnvironmentFn: func(string) ([]core.ResourceFile, []core.NamespacedName, error) {
+			return []core.ResourceFile{{Name: "app1.yaml"}}, []core.NamespacedName{}, nil
+		},
+	}
+	secretService := &secret.FakeService{
+		RenderEnvironmentFn: func(string) ([]core.ResourceFile, []core.SecretMeta, error) {
+			return []core.ResourceFile{}, []core.SecretMeta{}, nil
+		},
+	}
+	committer := state.NewGitCommitter(context.Background(), &git.FakeRepo{
+		ResetHardRemoteFn: func(context.Context) error {
+			return nil
+		},
+		CommitFn: func(string, []core.ResourceFile, *core.CommitMeta) error {
+			return git.ErrNoChanges
+		},
+	})
+
+	result, err := NewService(deploymentService, secretService, nil, nil).Reconcile("myenv", "myuser", committer)
+
+	require.NoError(t, err)
+	assert.True(t, result.NoChanges)
+}
+
+func Test_Reconcile_RenderErr(t *testing.T) {
+	deploymentService := &deployment.FakeService{
+		RenderEnvironmentFn: func(string) ([]core.ResourceFile, []core.NamespacedName, error) {
+			return nil, nil, errors.New("test")
+		},
+	}
+
+	result, err := NewService(deploymentService, &secret.FakeService{}, nil, nil).Reconcile("myenv", "myuser", state.NewDryRunCommitter())
+
+	assert.Nil(t, result)
+	assert.Equal(t, "test", err.Error())
+}
+
+func Test_Reconcile_RendersWhenCommitting(t *testing.T) {
+	revision := 1
+	deploymentService := &deployment.FakeService{
+		RenderEnvironmentFn: func(string) ([]core.ResourceFile, []core.NamespacedName, error) {
+			return []core.ResourceFile{{Name: "app1.yaml", Contents: []byte(fmt.Sprintf("revision: %d", revision))}}, []core.NamespacedName{}, nil
+		},
+	}
+	secretService := &secret.FakeService{
+		RenderEnvironmentFn: func(string) ([]core.ResourceFile, []core.SecretMeta, error) {
+			return []core.ResourceFile{}, []core.SecretMeta{}, nil
+		},
+	}
+	var committed []core.ResourceFile
+	repo := &git.FakeRepo{
+		// A deployment is committed by another instance while reconciling
+		ResetHardRemoteFn: func(context.Context) error {
+			revision++
+			return nil
+		},
+		CommitFn: func(message string, files []core.ResourceFile, meta *core.CommitMeta) error {
+			committed = files
+			return nil
+		},
+		PushFn: func(context.Context) error {
+			return nil
+		},
+		HeadShaFn: func() (string, error) {
+			return "sha", nil
+		},
+	}
+
+	result, err := NewService(deploymentService, secretService, nil, nil).Reconcile("myenv", "myuser", state.NewGitCommitter(context.Background(), repo))
+
+	require.NoError(t, err)
+	assert.False(t, result.NoChanges)
+	assert.Equal(t, []core.ResourceFile{{Name: "app1.yaml", Contents: []byte("revision: 2")}}, committed)
+}
@@ -0,0 +1,249 @@
+package rollout
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/git"
+	"github.com/riser-platform/riser-server/pkg/state"
+)
+
+// rolloutLease is how long a rollout is leased to an engine. The lease is renewed every time the engine processes rollouts,
+// so it only needs to outlast the interval between runs and the time taken to process them.
+const rolloutLease = 5 * time.Minute
+
+// CommitterFunc returns the committer for the state of an environment
+type CommitterFunc func(ctx context.Context, envName string) (state.Committer, error)
+
+// Engine progressively shifts traffic for automated rollouts. Progress is saved after every step so that rollouts resume
+// where they left off after a server restart. Each engine leases the rollouts that it processes so that multiple servers
+// do not advance the same rollout.
+type Engine struct {
+	id             string
+	rollouts       core.RolloutRepository
+	deployments    core.DeploymentRepository
+	rolloutService Service
+	getCommitter   CommitterFunc
+	logger         logrus.FieldLogger
+	now            func() time.Time
+}
+
+func NewEngine(rollouts core.RolloutRepository, deployments core.DeploymentRepository, rolloutService Service, getCommitter CommitterFunc, logger logrus.FieldLogger) *Engine {
+	return &Engine{uuid.New().String(), rollouts, deployments, rolloutService, getCommitter, logger, time.Now}
+}
+
+// Run processes rollouts at the specified interval until the context is done
+func (e *Engine) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := e.ProcessRollouts(ctx)
+			if err != nil {
+				e.logger.WithError(err).Error("Error processing rollouts")
+			}
+		}
+	}
+}
+
+// ProcessRollouts advances or aborts every rollout that is in progress and not leased by another engine
+func (e *Engine) ProcessRollouts(ctx context.Context) error {
+	rollouts, err := e.rollouts.LeaseInProgress(e.id, rolloutLease)
+	if err != nil {
+		return errors.Wrap(err, "error leasing rollouts in progress")
+	}
+
+	for idx := range rollouts {
+		rollout := &rollouts[idx]
+		// An error with one rollout should not prevent other rollouts from progressing
+		err = e.processRollout(ctx, rollout)
+		if err != nil {
+			e.logger.WithError(err).WithFields(logrus.Fields{
+				"deployment":    rollout.Name.String(),
+				"environment":   rollout.EnvironmentName,
+				"riserRevision": rollout.RiserRevision,
+			}).Error("Error processing rollout")
+		}
+	}
+
+	return nil
+}
+
+func (e *Engine) processRollout(ctx context.Context, rollout *core.Rollout) error {
+	deployment, err := e.deployments.GetByName(rollout.Name, rollout.EnvironmentName)
+	if err != nil && err != core.ErrNotFound {
+		return errors.Wrap(err, "error getting deployment")
+	}
+	if err == core.ErrNotFound || deployment.DeletedAt != nil {
+		return e.finish(rollout, core.RolloutStatusAborted, "The deployment was deleted")
+	}
+	if deployment.RiserRevision != rollout.RiserRevision {
+		return e.finish(rollout, core.RolloutStatusAborted, fmt.Sprintf("Superseded by revision %d", deployment.RiserRevision))
+	}
+	if !trafficEqual(deployment.Doc.Traffic, currentTraffic(rollout)) {
+		// The traffic for the next step was routed but the step was not saved (e.g. the server stopped in between)
+		nextStep := rollout.Doc.CurrentStep + 1
+		if nextStep < len(rollout.Doc.Strategy.Steps) && trafficEqual(deployment.Doc.Traffic, stepTraffic(rollout, rollout.Doc.Strategy.Steps[nextStep])) {
+			return e.saveStep(rollout, nextStep)
+		}
+		return e.finish(rollout, core.RolloutStatusAborted, "Traffic was changed outside of the rollout")
+	}
+
+	status := revisionStatus(deployment, rollout.RiserRevision)
+	// Wait for the revision to report its status
+	if status == nil {
+		return nil
+	}
+
+	switch status.RevisionStatus {
+	case model.RevisionStatusUnhealthy:
+		return e.abort(ctx, rollout, status)
+	case model.RevisionStatusReady:
+		if e.now().Before(rollout.NextStepAt) {
+			return nil
+		}
+		return e.advance(ctx, rollout)
+	}
+
+	return nil
+}
+
+// abort restores the traffic from before the rollout started
+func (e *Engine) abort(ctx context.Context, rollout *core.Rollout, status *core.DeploymentRevisionStatus) error {
+	err := e.updateTraffic(ctx, rollout, rollout.Doc.PreviousTraffic)
+	if err != nil {
+		return errors.Wrap(err, "error restoring traffic")
+	}
+
+	return e.finish(rollout, core.RolloutStatusAborted,
+		fmt.Sprintf("Revision %d reported a status of %q: %s", rollout.RiserRevision, status.RevisionStatus, status.RevisionStatusReason))
+}
+
+func (e *Engine) advance(ctx context.Context, rollout *core.Rollout) error {
+	step := rollout.Doc.CurrentStep + 1
+	if step >= len(rollout.Doc.Strategy.Steps) {
+		return e.finish(rollout, core.RolloutStatusCompleted, "")
+	}
+
+	percent := rollout.Doc.Strategy.Steps[step]
+	err := e.updateTraffic(ctx, rollout, stepTraffic(rollout, percent))
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("error routing %d%% of traffic to revision %d", percent, rollout.RiserRevision))
+	}
+
+	return e.saveStep(rollout, step)
+}
+
+// saveStep saves the progress of a step once its traffic has been routed
+func (e *Engine) saveStep(rollout *core.Rollout, step int) error {
+	rollout.Doc.CurrentStep = step
+	if step == len(rollout.Doc.Strategy.Steps)-1 {
+		rollout.Status = core.RolloutStatusCompleted
+	} else {
+		rollout.NextStepAt = e.now().UTC().Add(rollout.Doc.Strategy.Pause)
+	}
+
+	return e.rollouts.UpdateProgress(rollout)
+}
+
+func (e *Engine) finish(rollout *core.Rollout, status string, reason string) error {
+	rollout.Status = status
+	rollout.Doc.Reason = reason
+	return e.rollouts.UpdateProgress(rollout)
+}
+
+func (e *Engine) updateTraffic(ctx context.Context, rollout *core.Rollout, traffic core.TrafficConfig) error {
+	committer, err := e.getCommitter(ctx, rollout.EnvironmentName)
+	if err != nil {
+		return err
+	}
+
+	err = e.rolloutService.UpdateTraffic(rollout.Name, rollout.EnvironmentName, traffic, "", committer)
+	if err == git.ErrNoChanges {
+		return nil
+	}
+	return err
+}
+
+// currentTraffic returns the traffic that the rollout expects based on the last step applied
+func currentTraffic(rollout *core.Rollout) core.TrafficConfig {
+	if rollout.Doc.CurrentStep < 0 {
+		return rollout.Doc.PreviousTraffic
+	}
+	return stepTraffic(rollout, rollout.Doc.Strategy.Steps[rollout.Doc.CurrentStep])
+}
+
+// stepTraffic routes a percentage of traffic to the new revision and scales the previous traffic to the remaining percentage
+func stepTraffic(rollout *core.Rollout, percent int) core.TrafficConfig {
+	traffic := core.TrafficConfig{
+		core.TrafficConfigRule{
+			RiserRevision: rollout.RiserRevision,
+			RevisionName:  fmt.Sprintf("%s-%d", rollout.Name.Name, rollout.RiserRevision),
+			Percent:       percent,
+		},
+	}
+
+	previousTotal := 0
+	for _, rule := range rollout.Doc.PreviousTraffic {
+		previousTotal += rule.Percent
+	}
+	if previousTotal == 0 {
+		return traffic
+	}
+
+	remaining := 100 - percent
+	allocated := 0
+	for _, rule := range rollout.Doc.PreviousTraffic {
+		rule.Percent = rule.Percent * remaining / previousTotal
+		allocated += rule.Percent
+		traffic = append(traffic, rule)
+	}
+	// Any percentage lost to rounding is routed to the first of the previous revisions
+	traffic[1].Percent += remaining - allocated
+
+	withoutEmptyRules := core.TrafficConfig{}
+	for _, rule := range traffic {
+		if rule.Percent > 0 {
+			withoutEmptyRules = append(withoutEmptyRules, rule)
+		}
+	}
+	return withoutEmptyRules
+}
+
+// trafficEqual compares the percentage of traffic routed to each revision
+func trafficEqual(a, b core.TrafficConfig) bool {
+	return reflect.DeepEqual(trafficPercentages(a), trafficPercentages(b))
+}
+
+func trafficPercentages(traffic core.TrafficConfig) map[int64]int {
+	percentages := map[int64]int{}
+	for _, rule := range traffic {
+		if rule.Percent > 0 {
+			percentages[rule.RiserRevision] += rule.Percent
+		}
+	}
+	return percentages
+}
+
+func revisionStatus(deployment *core.Deployment, riserRevision int64) *core.DeploymentRevisionStatus {
+	if deployment.Doc.Status == nil {
+		return nil
+	}
+	for idx := range deployment.Doc.Status.Revisions {
+		if deployment.Doc.Status.Revisions[idx].RiserRevision == riserRevision {
+			return &deployment.Doc.Status.Revisions[idx]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,331 @@
+package rollout
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/git"
+	"github.com/riser-platform/riser-server/pkg/state"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+func Test_ProcessRollouts_AdvancesFirstStep(t *testing.T) {
+	rollout := createTestRollout()
+	deployments := createTestDeployments(model.RevisionStatusReady, core.TrafficConfig{
+		{RiserRevision: 3, RevisionName: "myapp-3", Percent: 0},
+		{RiserRevision: 2, RevisionName: "myapp-2", Percent: 100},
+	})
+	rollouts := createTestRolloutRepository(t, rollout)
+	rolloutService := &FakeService{
+		UpdateTrafficFn: func(name *core.NamespacedName, envName string, traffic core.TrafficConfig, updatedBy string, committer state.Committer) error {
+			assert.Equal(t, rollout.Name, name)
+			assert.Equal(t, "dev", envName)
+			assert.Equal(t, core.TrafficConfig{
+				{RiserRevision: 3, RevisionName: "myapp-3", Percent: 10},
+				{RiserRevision: 2, RevisionName: "myapp-2", Percent: 90},
+			}, traffic)
+			assert.NotNil(t, committer)
+			return nil
+		},
+	}
+
+	err := createTestEngine(rollouts, deployments, rolloutService).ProcessRollouts(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, rolloutService.UpdateTrafficCallCount)
+	require.Equal(t, 1, rollouts.UpdateProgressCallCount)
+	assert.Equal(t, core.RolloutStatusInProgress, rollout.Status)
+	assert.Equal(t, 0, rollout.Doc.CurrentStep)
+	assert.Equal(t, testNow.Add(time.Minute), rollout.NextStepAt)
+}
+
+func Test_ProcessRollouts_CompletesFinalStep(t *testing.T) {
+	rollout := createTestRollout()
+	rollout.Doc.CurrentStep = 1
+	deployments := createTestDeployments(model.RevisionStatusReady, core.TrafficConfig{
+		{RiserRevision: 3, RevisionName: "myapp-3", Percent: 50},
+		{RiserRevision: 2, RevisionName: "myapp-2", Percent: 50},
+	})
+	rollouts := createTestRolloutRepository(t, rollout)
+	rolloutService := &FakeService{
+		UpdateTrafficFn: func(name *core.NamespacedName, envName string, traffic core.TrafficConfig, updatedBy string, committer state.Committer) error {
+			assert.Equal(t, core.TrafficConfig{{RiserRevision: 3, RevisionName: "myapp-3", Percent: 100}}, traffic)
+			return git.ErrNoChanges
+		},
+	}
+
+	err := createTestEngine(rollouts, deployments, rolloutService).ProcessRollouts(context.Background())
+
+	assert.NoError(t, err)
+	require.Equal(t, 1, rollouts.UpdateProgressCallCount)
+	assert.Equal(t, core.RolloutStatusCompleted, rollout.Status)
+	assert.Equal(t, 2, rollout.Doc.CurrentStep)
+}
+
+func Test_ProcessRollouts_WaitsForNextStep(t *testing.T) {
+	rollout := createTestRollout()
+	rollout.NextStepAt = testNow.Add(time.Second)
+	deployments := createTestDeployments(model.RevisionStatusReady, core.TrafficConfig{
+		{RiserRevision: 2, RevisionName: "myapp-2", Percent: 100},
+	})
+	rollouts := createTestRolloutRepository(t, rollout)
+	rolloutService := &FakeService{}
+
+	err := createTestEngine(rollouts, deployments, rolloutService).ProcessRollouts(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, rolloutService.UpdateTrafficCallCount)
+	assert.Equal(t, 0, rollouts.UpdateProgressCallCount)
+}
+
+func Test_ProcessRollouts_WaitsForReady(t *testing.T) {
+	rollout := createTestRollout()
+	deployments := createTestDeployments(model.RevisionStatusWaiting, core.TrafficConfig{
+		{RiserRevision: 2, RevisionName: "myapp-2", Percent: 100},
+	})
+	rollouts := createTestRolloutRepository(t, rollout)
+	rolloutService := &FakeService{}
+
+	err := createTestEngine(rollouts, deployments, rolloutService).ProcessRollouts(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, rolloutService.UpdateTrafficCallCount)
+	assert.Equal(t, 0, rollouts.UpdateProgressCallCount)
+}
+
+func Test_ProcessRollouts_AbortsWhenUnhealthy(t *testing.T) {
+	rollout := createTestRollout()
+	rollout.Doc.CurrentStep = 0
+	deployments := createTestDeployments(model.RevisionStatusUnhealthy, core.TrafficConfig{
+		{RiserRevision: 3, RevisionName: "myapp-3", Percent: 10},
+		{RiserRevision: 2, RevisionName: "myapp-2", Percent: 90},
+	})
+	rollouts := createTestRolloutRepository(t, rollout)
+	rolloutService := &FakeService{
+		UpdateTrafficFn: func(name *core.NamespacedName, envName string, traffic core.TrafficConfig, updatedBy string, committer state.Committer) error {
+			assert.Equal(t, rollout.Doc.PreviousTraffic, traffic)
+			return nil
+		},
+	}
+
+	err := createTestEngine(rollouts, deployments, rolloutService).ProcessRollouts(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, rolloutService.UpdateTrafficCallCount)
+	require.Equal(t, 1, rollouts.UpdateProgressCallCount)
+	assert.Equal(t, core.RolloutStatusAborted, rollout.Status)
+	assert.Equal(t, `Revision 3 reported a status of "Unhealthy": crashing`, rollout.Doc.Reason)
+}
+
+func Test_ProcessRollouts_AbortsWhenSuperseded(t *testing.T) {
+	rollout := createTestRollout()
+	deployments := createTestDeployments(model.RevisionStatusReady, nil)
+	deployments.GetByNameFn = func(*core.NamespacedName, string) (*core.Deployment, error) {
+		return &core.Deployment{DeploymentRecord: core.DeploymentRecord{RiserRevision: 4}}, nil
+	}
+	rollouts := createTestRolloutRepository(t, rollout)
+	rolloutService := &FakeService{}
+
+	err := createTestEngine(rollouts, deployments, rolloutService).ProcessRollouts(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, rolloutService.UpdateTrafficCallCount)
+	require.Equal(t, 1, rollouts.UpdateProgressCallCount)
+	assert.Equal(t, core.RolloutStatusAborted, rollout.Status)
+	assert.Equal(t, "Superseded by revision 4", rollout.Doc.Reason)
+}
+
+func Test_ProcessRollouts_AbortsWhenTrafficChanged(t *testing.T) {
+	rollout := createTestRollout()
+	deployments := createTestDeployments(model.RevisionStatusReady, core.TrafficConfig{
+		{RiserRevision: 3, RevisionName: "myapp-3", Percent: 100},
+	})
+	rollouts := createTestRolloutRepository(t, rollout)
+	rolloutService := &FakeService{}
+
+	err := createTestEngine(rollouts, deployments, rolloutService).ProcessRollouts(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, rolloutService.UpdateTrafficCallCount)
+	require.Equal(t, 1, rollouts.UpdateProgressCallCount)
+	assert.Equal(t, core.RolloutStatusAborted, rollout.Status)
+	assert.Equal(t, "Traffic was changed outside of the rollout", rollout.Doc.Reason)
+}
+
+func Test_ProcessRollouts_SavesStepWhenTrafficAlreadyRouted(t *testing.T) {
+	rollout := createTestRollout()
+	deployments := createTestDeployments(model.RevisionStatusReady, core.TrafficConfig{
+		{RiserRevision: 3, RevisionName: "myapp-3", Percent: 10},
+		{RiserRevision: 2, RevisionName: "myapp-2", Percent: 90},
+	})
+	rollouts := createTestRolloutRepository(t, rollout)
+	rolloutService := &FakeService{}
+
+	err := createTestEngine(rollouts, deployments, rolloutService).ProcessRollouts(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, rolloutService.UpdateTrafficCallCount)
+	require.Equal(t, 1, rollouts.UpdateProgressCallCount)
+	assert.Equal(t, core.RolloutStatusInProgress, rollout.Status)
+	assert.Equal(t, 0, rollout.Doc.CurrentStep)
+	assert.Equal(t, testNow.Add(time.Minute), rollout.NextStepAt)
+}
+
+func Test_ProcessRollouts_ContinuesAfterError(t *testing.T) {
+	rollouts := &core.FakeRolloutRepository{
+		LeaseInProgressFn: func(string, time.Duration) ([]core.Rollout, error) {
+			return []core.Rollout{*createTestRollout(), *createTestRollout()}, nil
+		},
+	}
+	deployments := &core.FakeDeploymentRepository{
+		GetByNameFn: func(*core.NamespacedName, string) (*core.Deployment, error) {
+			return nil, errors.New("test")
+		},
+	}
+
+	err := createTestEngine(rollouts, deployments, &FakeService{}).ProcessRollouts(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, deployments.GetByNameCallCount)
+}
+
+func Test_ProcessRollouts_RenewsLease(t *testing.T) {
+	holders := []string{}
+	rollouts := &core.FakeRolloutRepository{
+		LeaseInProgressFn: func(holder string, duration time.Duration) ([]core.Rollout, error) {
+			assert.Equal(t, rolloutLease, duration)
+			holders = append(holders, holder)
+			return []core.Rollout{}, nil
+		},
+	}
+	engine := createTestEngine(rollouts, &core.FakeDeploymentRepository{}, &FakeService{})
+
+	require.NoError(t, engine.ProcessRollouts(context.Background()))
+	require.NoError(t, engine.ProcessRollouts(context.Background()))
+
+	require.Len(t, holders, 2)
+	assert.NotEmpty(t, holders[0])
+	assert.Equal(t, holders[0], holders[1])
+	assert.NotEqual(t, holders[0], createTestEngine(rollouts, &core.FakeDeploymentRepository{}, &FakeService{}).id)
+}
+
+func Test_ProcessRollouts_ReturnsLeaseErr(t *testing.T) {
+	rollouts := &core.FakeRolloutRepository{
+		LeaseInProgressFn: func(string, time.Duration) ([]core.Rollout, error) {
+			return nil, errors.New("test")
+		},
+	}
+
+	err := createTestEngine(rollouts, &core.FakeDeploymentRepository{}, &FakeService{}).ProcessRollouts(context.Background())
+
+	assert.Equal(t, "error leasing rollouts in progress: test", err.Error())
+}
+
+func Test_stepTraffic(t *testing.T) {
+	rollout := &core.Rollout{
+		Name:          core.NewNamespacedName("myapp", "myns"),
+		RiserRevision: 4,
+		Doc: core.RolloutDoc{
+			PreviousTraffic: core.TrafficConfig{
+				{RiserRevision: 2, RevisionName: "myapp-2", Percent: 50},
+				{RiserRevision: 3, RevisionName: "myapp-3", Percent: 50},
+			},
+		},
+	}
+
+	tt := []struct {
+		percent  int
+		expected core.TrafficConfig
+	}{
+		{10, core.TrafficConfig{
+			{RiserRevision: 4, RevisionName: "myapp-4", Percent: 10},
+			{RiserRevision: 2, RevisionName: "myapp-2", Percent: 45},
+			{RiserRevision: 3, RevisionName: "myapp-3", Percent: 45},
+		}},
+		{25, core.TrafficConfig{
+			{RiserRevision: 4, RevisionName: "myapp-4", Percent: 25},
+			{RiserRevision: 2, RevisionName: "myapp-2", Percent: 38},
+			{RiserRevision: 3, RevisionName: "myapp-3", Percent: 37},
+		}},
+		{99, core.TrafficConfig{
+			{RiserRevision: 4, RevisionName: "myapp-4", Percent: 99},
+			{RiserRevision: 2, RevisionName: "myapp-2", Percent: 1},
+		}},
+		{100, core.TrafficConfig{
+			{RiserRevision: 4, RevisionName: "myapp-4", Percent: 100},
+		}},
+	}
+
+	for _, test := range tt {
+		assert.Equal(t, test.expected, stepTraffic(rollout, test.percent), "percent: %d", test.percent)
+	}
+}
+
+func createTestEngine(rollouts core.RolloutRepository, deployments core.DeploymentRepository, rolloutService Service) *Engine {
+	engine := NewEngine(rollouts, deployments, rolloutService, func(_ context.Context, envName string) (state.Committer, error) {
+		return state.NewDryRunCommitter(), nil
+	}, logrus.New())
+	engine.now = func() time.Time { return testNow }
+	return engine
+}
+
+func createTestRollout() *core.Rollout {
+	return &core.Rollout{
+		Name:            core.NewNamespacedName("myapp", "myns"),
+		EnvironmentName: "dev",
+		RiserRevision:   3,
+		Status:          core.RolloutStatusInProgress,
+		NextStepAt:      testNow,
+		Doc: core.RolloutDoc{
+			Strategy: core.RolloutStrategy{
+				Steps: []int{10, 50, 100},
+				Pause: time.Minute,
+			},
+			PreviousTraffic: core.TrafficConfig{{RiserRevision: 2, RevisionName: "myapp-2", Percent: 100}},
+			CurrentStep:     -1,
+		},
+	}
+}
+
+func createTestRolloutRepository(t *testing.T, rollout *core.Rollout) *core.FakeRolloutRepository {
+	return &core.FakeRolloutRepository{
+		LeaseInProgressFn: func(string, time.Duration) ([]core.Rollout, error) {
+			return []core.Rollout{*rollout}, nil
+		},
+		UpdateProgressFn: func(updated *core.Rollout) error {
+			assert.Equal(t, rollout.Name, updated.Name)
+			assert.Equal(t, rollout.RiserRevision, updated.RiserRevision)
+			*rollout = *updated
+			return nil
+		},
+	}
+}
+
+func createTestDeployments(revisionStatus string, traffic core.TrafficConfig) *core.FakeDeploymentRepository {
+	return &core.FakeDeploymentRepository{
+		GetByNameFn: func(*core.NamespacedName, string) (*core.Deployment, error) {
+			return &core.Deployment{
+				DeploymentRecord: core.DeploymentRecord{
+					RiserRevision: 3,
+					Doc: core.DeploymentDoc{
+						Traffic: traffic,
+						Status: &core.DeploymentStatus{
+							Revisions: []core.DeploymentRevisionStatus{
+								{RiserRevision: 2, RevisionStatus: model.RevisionStatusReady},
+								{RiserRevision: 3, RevisionStatus: revisionStatus, RevisionStatusReason: "crashing"},
+							},
+						},
+					},
+				},
+			}, nil
+		},
+	}
+}
@@ -0,0 +1,16 @@
+package rollout
+
+import (
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/state"
+)
+
+type FakeService struct {
+	UpdateTrafficFn        func(name *core.NamespacedName, envName string, traffic core.TrafficConfig, updatedBy string, committer state.Committer) error
+	UpdateTrafficCallCount int
+}
+
+func (fake *FakeService) UpdateTraffic(name *core.NamespacedName, envName string, traffic core.TrafficConfig, updatedBy string, committer state.Committer) error {
+	fake.UpdateTrafficCallCount++
+	return fake.UpdateTrafficFn(name, envName, traffic, updatedBy, committer)
+}
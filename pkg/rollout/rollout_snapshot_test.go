@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/google/uuid"
+	"github.com/riser-platform/riser-server/api/v1/model"
 	"github.com/riser-platform/riser-server/pkg/core"
 	"github.com/riser-platform/riser-server/pkg/snapshot"
 	"github.com/riser-platform/riser-server/pkg/state"
@@ -17,8 +18,40 @@ import (
 // Pass the UPDATESNAPSHOT=true env var to "go test" to regenerate the snapshot data
 
 func Test_update_snapshot_rollout(t *testing.T) {
+	traffic := core.TrafficConfig{
+		core.TrafficConfigRule{
+			RiserRevision: 1,
+			Percent:       100,
+		},
+	}
+
+	assertUpdateTrafficSnapshot(t, "rollout", &core.EnvironmentConfig{}, traffic)
+}
+
+func Test_update_snapshot_rollout_kubernetes(t *testing.T) {
+	traffic := core.TrafficConfig{
+		core.TrafficConfigRule{
+			RiserRevision: 1,
+			RevisionName:  "myapp-1",
+			Percent:       90,
+		},
+		core.TrafficConfigRule{
+			RiserRevision: 2,
+			RevisionName:  "myapp-2",
+			Percent:       10,
+		},
+	}
+
+	assertUpdateTrafficSnapshot(t, "rollout_kubernetes", &core.EnvironmentConfig{Renderer: model.EnvironmentRenderer_Kubernetes}, traffic)
+}
+
+func assertUpdateTrafficSnapshot(t *testing.T, snapshotName string, environmentConfig *core.EnvironmentConfig, traffic core.TrafficConfig) {
 	appId := uuid.New()
 	name := core.NewNamespacedName("myapp", "myns")
+	revisionStatuses := []core.DeploymentRevisionStatus{}
+	for _, rule := range traffic {
+		revisionStatuses = append(revisionStatuses, core.DeploymentRevisionStatus{RiserRevision: rule.RiserRevision})
+	}
 
 	deployments := &core.FakeDeploymentRepository{
 		GetByNameFn: func(nameArg *core.NamespacedName, envName string) (*core.Deployment, error) {
@@ -31,16 +64,19 @@ func Test_update_snapshot_rollout(t *testing.T) {
 				DeploymentRecord: core.DeploymentRecord{
 					Doc: core.DeploymentDoc{
 						Status: &core.DeploymentStatus{
-							Revisions: []core.DeploymentRevisionStatus{
-								{
-									RiserRevision: 1,
-								},
-							},
+							Revisions: revisionStatuses,
 						},
 					},
 				},
 			}, nil
 		},
+		UpdateTrafficFn: func(nameArg *core.NamespacedName, envName string, riserRevision int64, trafficArg core.TrafficConfig) error {
+			assert.Equal(t, name, nameArg)
+			assert.Equal(t, "dev", envName)
+			assert.EqualValues(t, 0, riserRevision)
+			assert.Equal(t, traffic, trafficArg)
+			return nil
+		},
 	}
 
 	apps := &core.FakeAppRepository{
@@ -53,27 +89,29 @@ func Test_update_snapshot_rollout(t *testing.T) {
 		},
 	}
 
-	traffic := core.TrafficConfig{
-		core.TrafficConfigRule{
-			RiserRevision: 1,
-			Percent:       100,
+	environments := &core.FakeEnvironmentRepository{
+		GetFn: func(envName string) (*core.Environment, error) {
+			assert.Equal(t, "dev", envName)
+			return &core.Environment{Doc: core.EnvironmentDoc{Config: *environmentConfig}}, nil
 		},
 	}
 
-	svc := service{apps, deployments}
+	svc := service{apps, deployments, environments}
 
-	snapshotPath, err := filepath.Abs("testdata/snapshots/rollout")
+	snapshotPath, err := filepath.Abs(filepath.Join("testdata/snapshots", snapshotName))
 	require.NoError(t, err)
 
 	committer, err := snapshot.CreateCommitter(snapshotPath)
 	require.NoError(t, err)
 
-	err = svc.UpdateTraffic(name, "dev", traffic, committer)
+	err = svc.UpdateTraffic(name, "dev", traffic, "myuser", committer)
 
 	assert.NoError(t, err)
+	assert.Equal(t, 1, deployments.UpdateTrafficCallCount)
 	if !snapshot.ShouldUpdate() {
 		dryRunCommitter := committer.(*state.DryRunCommitter)
 		snapshot.AssertCommitter(t, snapshotPath, dryRunCommitter)
 		assert.Equal(t, `Updating resources for "myapp.myns" in environment "dev"`, dryRunCommitter.Commits[0].Message)
+		assert.Equal(t, &core.CommitMeta{Username: "myuser", App: "myapp", Namespace: "myns", Deployment: "myapp", Environment: "dev"}, dryRunCommitter.Commits[0].Meta)
 	}
 }
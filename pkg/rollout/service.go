@@ -7,24 +7,27 @@ import (
 
 	"github.com/riser-platform/riser-server/api/v1/model"
 	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/git"
 	"github.com/riser-platform/riser-server/pkg/state"
 	"github.com/riser-platform/riser-server/pkg/state/resources"
 )
 
 type Service interface {
-	UpdateTraffic(name *core.NamespacedName, envName string, rollout core.TrafficConfig, committer state.Committer) error
+	// UpdateTraffic commits the traffic for a deployment. updatedBy is empty when the change is not requested by a user.
+	UpdateTraffic(name *core.NamespacedName, envName string, rollout core.TrafficConfig, updatedBy string, committer state.Committer) error
 }
 
 type service struct {
-	apps        core.AppRepository
-	deployments core.DeploymentRepository
+	apps         core.AppRepository
+	deployments  core.DeploymentRepository
+	environments core.EnvironmentRepository
 }
 
-func NewService(apps core.AppRepository, deployments core.DeploymentRepository) Service {
-	return &service{apps, deployments}
+func NewService(apps core.AppRepository, deployments core.DeploymentRepository, environments core.EnvironmentRepository) Service {
+	return &service{apps, deployments, environments}
 }
 
-func (s *service) UpdateTraffic(name *core.NamespacedName, envName string, traffic core.TrafficConfig, committer state.Committer) error {
+func (s *service) UpdateTraffic(name *core.NamespacedName, envName string, traffic core.TrafficConfig, updatedBy string, committer state.Committer) error {
 	deployment, err := s.deployments.GetByName(name, envName)
 	if err != nil {
 		if err == core.ErrNotFound {
@@ -43,6 +46,16 @@ func (s *service) UpdateTraffic(name *core.NamespacedName, envName string, traff
 		return err
 	}
 
+	environment, err := s.environments.Get(envName)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("error getting environment %q", envName))
+	}
+
+	renderer, err := resources.NewRenderer(&environment.Doc.Config)
+	if err != nil {
+		return err
+	}
+
 	// TODO: Refactor underlying code to not require the entire deployment context. Currently this is hydrated only with fields that we know are needed
 	ctx := &core.DeploymentContext{
 		DeploymentConfig: &core.DeploymentConfig{
@@ -54,15 +67,40 @@ func (s *service) UpdateTraffic(name *core.NamespacedName, envName string, traff
 				Name: model.AppName(app.Name),
 			},
 		},
-		RiserRevision: deployment.RiserRevision,
+		EnvironmentConfig: &environment.Doc.Config,
+		RiserRevision:     deployment.RiserRevision,
+		RetiredRevisions:  traffic.RetiredRevisions(deployment.Doc.Traffic),
 	}
 
-	resourceFiles, err := state.RenderRoute(name.Name, name.Namespace, envName, resources.CreateKNativeRoute(ctx))
+	resourceFiles, err := state.RenderRoute(name.Name, name.Namespace, envName, renderer.TrafficResources(ctx)...)
 	if err != nil {
 		return err
 	}
+	// e.g. the previous revisions once a rollout finishes
+	if revisionRenderer, ok := renderer.(resources.RevisionRenderer); ok {
+		resourceFiles = append(resourceFiles, state.RenderDeleteResources(name.Name, name.Namespace,
+			revisionRenderer.RetiredRevisionResources(ctx)...)...)
+	}
+
+	err = committer.Commit(fmt.Sprintf("Updating resources for %q in environment %q", name, ctx.DeploymentConfig.EnvironmentName), resourceFiles, &core.CommitMeta{
+		Username:      updatedBy,
+		App:           app.Name,
+		Namespace:     name.Namespace,
+		Deployment:    name.Name,
+		Environment:   envName,
+		RiserRevision: deployment.RiserRevision,
+	})
+	if err != nil && err != git.ErrNoChanges {
+		return err
+	}
+
+	// Traffic is saved so that subsequent deployments and rollouts compute traffic from the current routes
+	updateErr := s.deployments.UpdateTraffic(name, envName, deployment.RiserRevision, traffic)
+	if updateErr != nil {
+		return errors.Wrap(updateErr, "error updating traffic")
+	}
 
-	return committer.Commit(fmt.Sprintf("Updating resources for %q in environment %q", name, ctx.DeploymentConfig.EnvironmentName), resourceFiles)
+	return err
 }
 
 func validateTrafficRules(traffic core.TrafficConfig, deployment *core.Deployment) error {
@@ -1,13 +1,18 @@
 package rollout
 
 import (
+	"context"
 	"errors"
 	"testing"
 
 	"github.com/google/uuid"
 
+	"github.com/riser-platform/riser-server/api/v1/model"
 	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/git"
+	"github.com/riser-platform/riser-server/pkg/state"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // See snapshot test for happy path
@@ -21,7 +26,7 @@ func Test_UpdateTraffic_ReturnsGetDeploymentError(t *testing.T) {
 
 	svc := service{deployments: deployments}
 
-	result := svc.UpdateTraffic(core.NewNamespacedName("myapp", "myns"), "dev", core.TrafficConfig{}, nil)
+	result := svc.UpdateTraffic(core.NewNamespacedName("myapp", "myns"), "dev", core.TrafficConfig{}, "myuser", nil)
 
 	assert.Equal(t, "error getting deployment: test", result.Error())
 }
@@ -35,7 +40,7 @@ func Test_UpdateTraffic_WhenDeploymentDoesNotExist(t *testing.T) {
 
 	svc := service{deployments: deployments}
 
-	result := svc.UpdateTraffic(core.NewNamespacedName("myapp", "myns"), "dev", core.TrafficConfig{}, nil)
+	result := svc.UpdateTraffic(core.NewNamespacedName("myapp", "myns"), "dev", core.TrafficConfig{}, "myuser", nil)
 
 	assert.IsType(t, &core.ValidationError{}, result)
 	vErr := result.(*core.ValidationError)
@@ -81,9 +86,9 @@ func Test_UpdateTraffic_ValidatesRevisionStatus(t *testing.T) {
 		},
 	}
 
-	svc := service{apps, deployments}
+	svc := service{apps: apps, deployments: deployments}
 
-	result := svc.UpdateTraffic(core.NewNamespacedName("myapp", "myns"), "dev", traffic, nil)
+	result := svc.UpdateTraffic(core.NewNamespacedName("myapp", "myns"), "dev", traffic, "myuser", nil)
 
 	assert.Equal(t, `revision "2" either does not exist or has not reported its status yet`, result.Error())
 }
@@ -112,9 +117,118 @@ func Test_UpdateTraffic_ValidatesRevisionStatus_NoStatus(t *testing.T) {
 		},
 	}
 
-	svc := service{apps, deployments}
+	svc := service{apps: apps, deployments: deployments}
 
-	result := svc.UpdateTraffic(core.NewNamespacedName("myapp", "myns"), "dev", traffic, nil)
+	result := svc.UpdateTraffic(core.NewNamespacedName("myapp", "myns"), "dev", traffic, "myuser", nil)
 
 	assert.Equal(t, `revision "1" either does not exist or has not reported its status yet`, result.Error())
 }
+
+func Test_UpdateTraffic_SavesTrafficWhenNoChanges(t *testing.T) {
+	deployments := &core.FakeDeploymentRepository{
+		GetByNameFn: func(*core.NamespacedName, string) (*core.Deployment, error) {
+			return &core.Deployment{
+				DeploymentRecord: core.DeploymentRecord{
+					RiserRevision: 2,
+					Doc: core.DeploymentDoc{
+						Status: &core.DeploymentStatus{
+							Revisions: []core.DeploymentRevisionStatus{{RiserRevision: 1}},
+						},
+					},
+				},
+			}, nil
+		},
+		UpdateTrafficFn: func(name *core.NamespacedName, envName string, riserRevision int64, traffic core.TrafficConfig) error {
+			assert.EqualValues(t, 2, riserRevision)
+			assert.Len(t, traffic, 1)
+			return nil
+		},
+	}
+
+	apps := &core.FakeAppRepository{
+		GetFn: func(id uuid.UUID) (*core.App, error) {
+			return &core.App{Id: id, Name: "myapp"}, nil
+		},
+	}
+
+	repo := &git.FakeRepo{
+		ResetHardRemoteFn: func(context.Context) error {
+			return nil
+		},
+		CommitFn: func(string, []core.ResourceFile, *core.CommitMeta) error {
+			return git.ErrNoChanges
+		},
+	}
+
+	environments := &core.FakeEnvironmentRepository{
+		GetFn: func(string) (*core.Environment, error) {
+			return &core.Environment{}, nil
+		},
+	}
+
+	svc := service{apps, deployments, environments}
+
+	result := svc.UpdateTraffic(core.NewNamespacedName("myapp", "myns"), "dev",
+		core.TrafficConfig{{RiserRevision: 1, Percent: 100}}, "myuser", state.NewGitCommitter(context.Background(), repo))
+
+	assert.Equal(t, git.ErrNoChanges, result)
+	assert.Equal(t, 1, deployments.UpdateTrafficCallCount)
+}
+
+func Test_UpdateTraffic_DeletesRetiredRevisions(t *testing.T) {
+	deployments := &core.FakeDeploymentRepository{
+		GetByNameFn: func(*core.NamespacedName, string) (*core.Deployment, error) {
+			return &core.Deployment{
+				DeploymentRecord: core.DeploymentRecord{
+					RiserRevision: 2,
+					Doc: core.DeploymentDoc{
+						Status: &core.DeploymentStatus{
+							Revisions: []core.DeploymentRevisionStatus{{RiserRevision: 1}, {RiserRevision: 2}},
+						},
+						Traffic: core.TrafficConfig{
+							{RiserRevision: 2, RevisionName: "myapp-2", Percent: 90},
+							{RiserRevision: 1, RevisionName: "myapp-1", Percent: 10},
+						},
+					},
+				},
+			}, nil
+		},
+		UpdateTrafficFn: func(*core.NamespacedName, string, int64, core.TrafficConfig) error {
+			return nil
+		},
+	}
+
+	apps := &core.FakeAppRepository{
+		GetFn: func(id uuid.UUID) (*core.App, error) {
+			return &core.App{Id: id, Name: "myapp"}, nil
+		},
+	}
+
+	environments := &core.FakeEnvironmentRepository{
+		GetFn: func(string) (*core.Environment, error) {
+			return &core.Environment{
+				Doc: core.EnvironmentDoc{Config: core.EnvironmentConfig{Renderer: model.EnvironmentRenderer_Kubernetes}},
+			}, nil
+		},
+	}
+
+	committer := state.NewDryRunCommitter()
+	svc := service{apps, deployments, environments}
+
+	err := svc.UpdateTraffic(core.NewNamespacedName("myapp", "myns"), "dev",
+		core.TrafficConfig{{RiserRevision: 2, RevisionName: "myapp-2", Percent: 100}}, "myuser", committer)
+
+	require.NoError(t, err)
+	require.Len(t, committer.Commits, 1)
+	deleted := []string{}
+	for _, file := range committer.Commits[0].Files {
+		if file.Delete {
+			deleted = append(deleted, file.Name)
+		}
+	}
+	assert.ElementsMatch(t, []string{
+		"state/riser-managed/myns/deployments/myapp/apps.deployment.myapp-1.yaml",
+		"state/riser-managed/myns/deployments/myapp/service.myapp-1.yaml",
+		"state/riser-managed/myns/deployments/myapp/autoscaling.horizontalpodautoscaler.myapp-1.yaml",
+	}, deleted)
+}
@@ -0,0 +1,55 @@
+package sdk
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/riser-platform/riser-server/api/v1/model"
+)
+
+type ApiKeysClient interface {
+	List(username string) ([]model.ApiKey, error)
+	// Create returns the new API key including the plain text key. The plain text key cannot be retrieved again.
+	Create(username string, newApiKey *model.NewApiKey) (*model.NewApiKeyResponse, error)
+	Revoke(username string, id uuid.UUID) error
+}
+
+type apiKeysClient struct {
+	client *Client
+}
+
+func (c *apiKeysClient) List(username string) ([]model.ApiKey, error) {
+	apiKeys := []model.ApiKey{}
+	request, err := c.client.NewGetRequest(fmt.Sprintf("/api/v1/users/%s/apikeys", username))
+	if err != nil {
+		return nil, err
+	}
+	_, err = c.client.Do(request, &apiKeys)
+	if err != nil {
+		return nil, err
+	}
+	return apiKeys, nil
+}
+
+func (c *apiKeysClient) Create(username string, newApiKey *model.NewApiKey) (*model.NewApiKeyResponse, error) {
+	request, err := c.client.NewRequest(http.MethodPost, fmt.Sprintf("/api/v1/users/%s/apikeys", username), newApiKey)
+	if err != nil {
+		return nil, err
+	}
+	response := &model.NewApiKeyResponse{}
+	_, err = c.client.Do(request, response)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (c *apiKeysClient) Revoke(username string, id uuid.UUID) error {
+	request, err := c.client.NewRequest(http.MethodDelete, fmt.Sprintf("/api/v1/users/%s/apikeys/%s", username, id), nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.client.Do(request, nil)
+	return err
+}
@@ -0,0 +1,62 @@
+package sdk
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ApiKeys_List(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/users/myuser/apikeys", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		fmt.Fprint(w, `[{"name": "ci", "lastUsed": "2020-10-01T12:00:00Z"}]`)
+	})
+
+	apiKeys, err := client.ApiKeys.List("myuser")
+
+	assert.NoError(t, err)
+	assert.Len(t, apiKeys, 1)
+	assert.Equal(t, "ci", apiKeys[0].Name)
+	assert.NotNil(t, apiKeys[0].LastUsed)
+}
+
+func Test_ApiKeys_Create(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/users/myuser/apikeys", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		actualModel := &model.NewApiKey{}
+		mustUnmarshalR(r.Body, actualModel)
+		assert.Equal(t, "ci", actualModel.Name)
+		fmt.Fprint(w, `{"name": "ci", "plainTextKey": "mykey"}`)
+	})
+
+	response, err := client.ApiKeys.Create("myuser", &model.NewApiKey{Name: "ci"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "ci", response.Name)
+	assert.Equal(t, "mykey", response.PlainTextKey)
+}
+
+func Test_ApiKeys_Revoke(t *testing.T) {
+	setup()
+	defer teardown()
+
+	id := uuid.New()
+	mux.HandleFunc(fmt.Sprintf("/api/v1/users/myuser/apikeys/%s", id), func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		fmt.Fprint(w, "")
+	})
+
+	err := client.ApiKeys.Revoke("myuser", id)
+
+	assert.NoError(t, err)
+}
@@ -0,0 +1,51 @@
+package sdk
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/riser-platform/riser-server/api/v1/model"
+)
+
+type AuditClient interface {
+	List(filter *model.AuditFilter) ([]model.AuditEntry, error)
+}
+
+type auditClient struct {
+	client *Client
+}
+
+func (c *auditClient) List(filter *model.AuditFilter) ([]model.AuditEntry, error) {
+	entries := []model.AuditEntry{}
+	request, err := c.client.NewGetRequest("/api/v1/audit")
+	if err != nil {
+		return nil, err
+	}
+
+	q := request.URL.Query()
+	addParam := func(key, value string) {
+		if value != "" {
+			q.Add(key, value)
+		}
+	}
+	addParam("username", filter.Username)
+	addParam("namespace", filter.Namespace)
+	addParam("environment", filter.Environment)
+	addParam("app", filter.App)
+	if filter.From != nil {
+		q.Add("from", filter.From.Format(time.RFC3339))
+	}
+	if filter.To != nil {
+		q.Add("to", filter.To.Format(time.RFC3339))
+	}
+	if filter.Limit > 0 {
+		q.Add("limit", strconv.Itoa(filter.Limit))
+	}
+	request.URL.RawQuery = q.Encode()
+
+	_, err = c.client.Do(request, &entries)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
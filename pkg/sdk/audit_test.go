@@ -0,0 +1,35 @@
+package sdk
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Audit_List(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/audit", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "myuser", r.URL.Query().Get("username"))
+		assert.Equal(t, "prod", r.URL.Query().Get("environment"))
+		assert.Equal(t, "myapp", r.URL.Query().Get("app"))
+		assert.Equal(t, "2020-10-01T00:00:00Z", r.URL.Query().Get("from"))
+		assert.Equal(t, "", r.URL.Query().Get("to"))
+		assert.Equal(t, "10", r.URL.Query().Get("limit"))
+		fmt.Fprint(w, `[{"username": "myuser", "action": "deployment.save", "commitShas": ["abc123"]}]`)
+	})
+
+	from := time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+	entries, err := client.Audit.List(&model.AuditFilter{Username: "myuser", Environment: "prod", App: "myapp", From: &from, Limit: 10})
+
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "deployment.save", entries[0].Action)
+	assert.Equal(t, []string{"abc123"}, entries[0].CommitShas)
+}
@@ -26,12 +26,16 @@ type Client struct {
 	client  *http.Client
 
 	// Model clients
+	ApiKeys      ApiKeysClient
 	Apps         AppsClient
+	Audit        AuditClient
 	Deployments  DeploymentsClient
 	Namespaces   NamespacesClient
+	RoleBindings RoleBindingsClient
 	Rollouts     RolloutsClient
 	Secrets      SecretsClient
 	Environments EnvironmentsClient
+	Users        UsersClient
 	Validate     ValidateClient
 }
 
@@ -44,12 +48,16 @@ func NewClient(baseURI string, apikey string) (*Client, error) {
 	client := &Client{BaseURL: baseURIParsed, apikey: apikey}
 	client.client = &http.Client{}
 
+	client.ApiKeys = &apiKeysClient{client}
 	client.Apps = &appsClient{client}
+	client.Audit = &auditClient{client}
 	client.Deployments = &deploymentsClient{client}
 	client.Namespaces = &namespacesClient{client}
+	client.RoleBindings = &roleBindingsClient{client}
 	client.Rollouts = &rolloutsClient{client}
 	client.Secrets = &secretsClient{client}
 	client.Environments = &environmentsClient{client}
+	client.Users = &usersClient{client}
 	client.Validate = &validateClient{client}
 
 	return client, nil
@@ -9,6 +9,10 @@ import (
 
 type DeploymentsClient interface {
 	Delete(deploymentName, namespace, envName string) (*model.SaveDeploymentResponse, error)
+	Diff(deployment *model.SaveDeploymentRequest) (*model.SaveDeploymentResponse, error)
+	GetRevisions(deploymentName, namespace, envName string) ([]model.DeploymentRevision, error)
+	Promote(promotion *model.PromoteDeploymentRequest, dryRun bool) (*model.SaveDeploymentResponse, error)
+	Rollback(deploymentName, namespace, envName string, riserRevision int64, dryRun bool) (*model.SaveDeploymentResponse, error)
 	Save(deployment *model.SaveDeploymentRequest, dryRun bool) (*model.SaveDeploymentResponse, error)
 	SaveStatus(deploymentName, namespace, envName string, status *model.DeploymentStatusMutable) (statusCode int, err error)
 }
@@ -32,6 +36,85 @@ func (c *deploymentsClient) Delete(deploymentName, namespace, envName string) (*
 	return responseModel, nil
 }
 
+// Diff performs a dry run of a deployment and returns a unified diff of the changes against the current state of the environment
+func (c *deploymentsClient) Diff(deployment *model.SaveDeploymentRequest) (*model.SaveDeploymentResponse, error) {
+	request, err := c.client.NewRequest(http.MethodPut, "/api/v1/deployments", deployment)
+	if err != nil {
+		return nil, err
+	}
+
+	q := request.URL.Query()
+	q.Add("dryRun", "true")
+	q.Add("diff", "true")
+	request.URL.RawQuery = q.Encode()
+
+	responseModel := &model.SaveDeploymentResponse{}
+	_, err = c.client.Do(request, responseModel)
+	if err != nil {
+		return nil, err
+	}
+
+	return responseModel, nil
+}
+
+func (c *deploymentsClient) GetRevisions(deploymentName, namespace, envName string) ([]model.DeploymentRevision, error) {
+	request, err := c.client.NewGetRequest(fmt.Sprintf("/api/v1/deployments/%s/%s/%s/revisions", envName, namespace, deploymentName))
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := []model.DeploymentRevision{}
+	_, err = c.client.Do(request, &revisions)
+	if err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+func (c *deploymentsClient) Promote(promotion *model.PromoteDeploymentRequest, dryRun bool) (*model.SaveDeploymentResponse, error) {
+	request, err := c.client.NewRequest(http.MethodPost, "/api/v1/deployments/promote", promotion)
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		q := request.URL.Query()
+		q.Add("dryRun", "true")
+		request.URL.RawQuery = q.Encode()
+	}
+
+	responseModel := &model.SaveDeploymentResponse{}
+	_, err = c.client.Do(request, responseModel)
+	if err != nil {
+		return nil, err
+	}
+
+	return responseModel, nil
+}
+
+func (c *deploymentsClient) Rollback(deploymentName, namespace, envName string, riserRevision int64, dryRun bool) (*model.SaveDeploymentResponse, error) {
+	rollbackRequest := &model.RollbackRequest{RiserRevision: riserRevision}
+	request, err := c.client.NewRequest(http.MethodPost, fmt.Sprintf("/api/v1/deployments/%s/%s/%s/rollback", envName, namespace, deploymentName), rollbackRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		q := request.URL.Query()
+		q.Add("dryRun", "true")
+		request.URL.RawQuery = q.Encode()
+	}
+
+	responseModel := &model.SaveDeploymentResponse{}
+	_, err = c.client.Do(request, responseModel)
+	if err != nil {
+		return nil, err
+	}
+
+	return responseModel, nil
+}
+
 func (c *deploymentsClient) Save(deployment *model.SaveDeploymentRequest, dryRun bool) (*model.SaveDeploymentResponse, error) {
 	request, err := c.client.NewRequest(http.MethodPut, "/api/v1/deployments", deployment)
 	if err != nil {
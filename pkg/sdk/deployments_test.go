@@ -24,6 +24,87 @@ func Test_Deployments_Delete(t *testing.T) {
 	assert.Equal(t, "deleted", result.Message)
 }
 
+func Test_Deployments_GetRevisions(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/deployments/myenv/myns/mydep/revisions", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		fmt.Fprint(w, `[{"riserRevision": 2, "docker": {"tag": "v2"}, "deployedBy": "myuser"}, {"riserRevision": 1, "docker": {"tag": "v1"}}]`)
+	})
+
+	result, err := client.Deployments.GetRevisions("mydep", "myns", "myenv")
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.EqualValues(t, 2, result[0].RiserRevision)
+	assert.Equal(t, "v2", result[0].Docker.Tag)
+	assert.Equal(t, "myuser", result[0].DeployedBy)
+	assert.EqualValues(t, 1, result[1].RiserRevision)
+}
+
+func Test_Deployments_Promote(t *testing.T) {
+	setup()
+	defer teardown()
+
+	requestModel := &model.PromoteDeploymentRequest{
+		Name:              "mydep",
+		Namespace:         "myns",
+		SourceEnvironment: "dev",
+		TargetEnvironment: "prod",
+	}
+
+	mux.HandleFunc("/api/v1/deployments/promote", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "true", r.URL.Query().Get("dryRun"))
+		actualModel := &model.PromoteDeploymentRequest{}
+		mustUnmarshalR(r.Body, actualModel)
+		assert.Equal(t, requestModel, actualModel)
+		fmt.Fprint(w, `{"message": "promoted"}`)
+	})
+
+	result, err := client.Deployments.Promote(requestModel, true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "promoted", result.Message)
+}
+
+func Test_Deployments_Rollback(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/deployments/myenv/myns/mydep/rollback", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Empty(t, r.URL.Query().Get("dryRun"))
+		actualModel := &model.RollbackRequest{}
+		mustUnmarshalR(r.Body, actualModel)
+		assert.EqualValues(t, 2, actualModel.RiserRevision)
+		fmt.Fprint(w, `{"message": "rolled back", "riserRevision": 4}`)
+	})
+
+	result, err := client.Deployments.Rollback("mydep", "myns", "myenv", 2, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "rolled back", result.Message)
+	assert.EqualValues(t, 4, result.RiserRevision)
+}
+
+func Test_Deployments_Rollback_DryRun(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/deployments/myenv/myns/mydep/rollback", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "true", r.URL.Query().Get("dryRun"))
+		fmt.Fprint(w, `{"message": "dryRun", "dryRunCommits": [{ "message": "test"}]}`)
+	})
+
+	result, err := client.Deployments.Rollback("mydep", "myns", "myenv", 2, true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "dryRun", result.Message)
+	assert.Equal(t, "test", result.DryRunCommits[0].Message)
+}
+
 func Test_Deployments_Save(t *testing.T) {
 	setup()
 	defer teardown()
@@ -48,6 +129,30 @@ func Test_Deployments_Save(t *testing.T) {
 	assert.Equal(t, "saved", result.Message)
 }
 
+func Test_Deployments_Diff(t *testing.T) {
+	setup()
+	defer teardown()
+
+	requestModel := &model.SaveDeploymentRequest{
+		DeploymentMeta: model.DeploymentMeta{
+			Name: "mydeployment",
+		},
+	}
+
+	mux.HandleFunc("/api/v1/deployments", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "true", r.URL.Query().Get("dryRun"))
+		assert.Equal(t, "true", r.URL.Query().Get("diff"))
+		fmt.Fprint(w, `{"message": "dryRun", "hasChanges": true, "dryRunCommits": [{"message": "test", "diff": "mydiff"}]}`)
+	})
+
+	result, err := client.Deployments.Diff(requestModel)
+
+	assert.NoError(t, err)
+	assert.True(t, *result.HasChanges)
+	assert.Equal(t, "mydiff", result.DryRunCommits[0].Diff)
+}
+
 func Test_Deployments_Save_DryRun(t *testing.T) {
 	setup()
 	defer teardown()
@@ -12,6 +12,9 @@ type EnvironmentsClient interface {
 	List() ([]model.EnvironmentMeta, error)
 	GetConfig(envName string) (*model.EnvironmentConfig, error)
 	SetConfig(envName string, config *model.EnvironmentConfig) error
+	Reconcile(envName string, dryRun bool) (*model.ReconcileResponse, error)
+	GetDrift(envName string, refresh bool) (*model.DriftReport, error)
+	CollectGarbage(envName string, dryRun bool) (*model.GarbageCollectionResponse, error)
 }
 
 type environmentsClient struct {
@@ -59,7 +62,8 @@ func (c *environmentsClient) GetConfig(envName string) (*model.EnvironmentConfig
 	return responseModel, nil
 }
 
-// SetConfig sets configuration for a environment. Empty values are ignored and merged with existing config values.
+// SetConfig sets configuration for a environment. Empty values are ignored and merged with existing config values. See
+// model.EnvironmentConfig for the settings that are replaced instead.
 func (c *environmentsClient) SetConfig(envName string, config *model.EnvironmentConfig) error {
 	request, err := c.client.NewRequest(http.MethodPut, fmt.Sprintf("/api/v1/environments/%s/config", envName), config)
 	if err != nil {
@@ -69,3 +73,69 @@ func (c *environmentsClient) SetConfig(envName string, config *model.Environment
 	_, err = c.client.Do(request, nil)
 	return err
 }
+
+// Reconcile regenerates the state of an environment from the server's database
+func (c *environmentsClient) Reconcile(envName string, dryRun bool) (*model.ReconcileResponse, error) {
+	request, err := c.client.NewRequest(http.MethodPost, fmt.Sprintf("/api/v1/environments/%s/reconcile", envName), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		q := request.URL.Query()
+		q.Add("dryRun", "true")
+		request.URL.RawQuery = q.Encode()
+	}
+
+	responseModel := &model.ReconcileResponse{}
+	_, err = c.client.Do(request, responseModel)
+	if err != nil {
+		return nil, err
+	}
+
+	return responseModel, nil
+}
+
+// GetDrift gets the last drift report for an environment. Set refresh to detect drift immediately.
+func (c *environmentsClient) GetDrift(envName string, refresh bool) (*model.DriftReport, error) {
+	request, err := c.client.NewGetRequest(fmt.Sprintf("/api/v1/environments/%s/drift", envName))
+	if err != nil {
+		return nil, err
+	}
+
+	if refresh {
+		q := request.URL.Query()
+		q.Add("refresh", "true")
+		request.URL.RawQuery = q.Encode()
+	}
+
+	responseModel := &model.DriftReport{}
+	_, err = c.client.Do(request, responseModel)
+	if err != nil {
+		return nil, err
+	}
+
+	return responseModel, nil
+}
+
+// CollectGarbage removes the files that riser no longer references from the state of an environment
+func (c *environmentsClient) CollectGarbage(envName string, dryRun bool) (*model.GarbageCollectionResponse, error) {
+	request, err := c.client.NewRequest(http.MethodPost, fmt.Sprintf("/api/v1/environments/%s/gc", envName), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		q := request.URL.Query()
+		q.Add("dryRun", "true")
+		request.URL.RawQuery = q.Encode()
+	}
+
+	responseModel := &model.GarbageCollectionResponse{}
+	_, err = c.client.Do(request, responseModel)
+	if err != nil {
+		return nil, err
+	}
+
+	return responseModel, nil
+}
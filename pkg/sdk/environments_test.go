@@ -75,3 +75,69 @@ func Test_Environments_GetConfig(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "tempuri.org", result.PublicGatewayHost)
 }
+
+func Test_Environments_Reconcile(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/environments/dev/reconcile", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Empty(t, r.URL.Query().Get("dryRun"))
+		fmt.Fprint(w, `{"message": "reconciled", "skippedDeployments": ["myapp.myns"], "skippedSecrets": []}`)
+	})
+
+	result, err := client.Environments.Reconcile("dev", false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "reconciled", result.Message)
+	assert.Equal(t, []string{"myapp.myns"}, result.SkippedDeployments)
+}
+
+func Test_Environments_Reconcile_DryRun(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/environments/dev/reconcile", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "true", r.URL.Query().Get("dryRun"))
+		fmt.Fprint(w, `{"message": "dryRun", "dryRunCommits": [{ "message": "test"}]}`)
+	})
+
+	result, err := client.Environments.Reconcile("dev", true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "test", result.DryRunCommits[0].Message)
+}
+
+func Test_Environments_GetDrift(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/environments/dev/drift", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "true", r.URL.Query().Get("refresh"))
+		fmt.Fprint(w, `{"environmentName": "dev", "hasDrift": true, "missing": ["missing.yaml"], "changed": [{"name": "changed.yaml", "diff": "diff"}]}`)
+	})
+
+	result, err := client.Environments.GetDrift("dev", true)
+
+	assert.NoError(t, err)
+	assert.True(t, result.HasDrift)
+	assert.Equal(t, []string{"missing.yaml"}, result.Missing)
+	assert.Equal(t, []model.DriftedFile{{Name: "changed.yaml", Diff: "diff"}}, result.Changed)
+}
+
+func Test_Environments_CollectGarbage(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/environments/dev/gc", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "true", r.URL.Query().Get("dryRun"))
+		fmt.Fprint(w, `{"message": "dryRun", "deleted": ["a.yaml"], "skippedNamespaces": []}`)
+	})
+
+	result, err := client.Environments.CollectGarbage("dev", true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.yaml"}, result.Deleted)
+}
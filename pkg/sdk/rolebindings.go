@@ -0,0 +1,54 @@
+package sdk
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/riser-platform/riser-server/api/v1/model"
+)
+
+type RoleBindingsClient interface {
+	List() ([]model.RoleBinding, error)
+	Grant(roleBinding *model.NewRoleBinding) (*model.RoleBinding, error)
+	Revoke(id uuid.UUID) error
+}
+
+type roleBindingsClient struct {
+	client *Client
+}
+
+func (c *roleBindingsClient) List() ([]model.RoleBinding, error) {
+	roleBindings := []model.RoleBinding{}
+	request, err := c.client.NewGetRequest("/api/v1/rolebindings")
+	if err != nil {
+		return nil, err
+	}
+	_, err = c.client.Do(request, &roleBindings)
+	if err != nil {
+		return nil, err
+	}
+	return roleBindings, nil
+}
+
+func (c *roleBindingsClient) Grant(newRoleBinding *model.NewRoleBinding) (*model.RoleBinding, error) {
+	request, err := c.client.NewRequest(http.MethodPost, "/api/v1/rolebindings", newRoleBinding)
+	if err != nil {
+		return nil, err
+	}
+	roleBinding := &model.RoleBinding{}
+	_, err = c.client.Do(request, roleBinding)
+	if err != nil {
+		return nil, err
+	}
+	return roleBinding, nil
+}
+
+func (c *roleBindingsClient) Revoke(id uuid.UUID) error {
+	request, err := c.client.NewRequest(http.MethodDelete, fmt.Sprintf("/api/v1/rolebindings/%s", id), nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.client.Do(request, nil)
+	return err
+}
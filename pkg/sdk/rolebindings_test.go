@@ -0,0 +1,69 @@
+package sdk
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RoleBindings_List(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/rolebindings", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		response := `
+		[
+			{"username": "myuser", "role": "deployer", "namespace": "myns"},
+			{"username": "root", "role": "server-admin"}
+		]`
+
+		fmt.Fprint(w, response)
+	})
+
+	roleBindings, err := client.RoleBindings.List()
+
+	assert.NoError(t, err)
+	assert.Len(t, roleBindings, 2)
+	assert.Equal(t, "myuser", roleBindings[0].Username)
+	assert.Equal(t, "myns", roleBindings[0].Namespace)
+	assert.Equal(t, model.RoleServerAdmin, roleBindings[1].Role)
+}
+
+func Test_RoleBindings_Grant(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/rolebindings", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		actualModel := &model.NewRoleBinding{}
+		mustUnmarshalR(r.Body, actualModel)
+		assert.Equal(t, "myuser", actualModel.Username)
+		assert.Equal(t, model.RoleViewer, actualModel.Role)
+		fmt.Fprint(w, `{"id": "6ba7b810-9dad-11d1-80b4-00c04fd430c8", "username": "myuser", "role": "viewer"}`)
+	})
+
+	roleBinding, err := client.RoleBindings.Grant(&model.NewRoleBinding{Username: "myuser", Role: model.RoleViewer})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "6ba7b810-9dad-11d1-80b4-00c04fd430c8", roleBinding.Id.String())
+}
+
+func Test_RoleBindings_Revoke(t *testing.T) {
+	setup()
+	defer teardown()
+
+	id := uuid.New()
+	mux.HandleFunc(fmt.Sprintf("/api/v1/rolebindings/%s", id), func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		fmt.Fprint(w, "")
+	})
+
+	err := client.RoleBindings.Revoke(id)
+
+	assert.NoError(t, err)
+}
@@ -0,0 +1,64 @@
+package sdk
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/riser-platform/riser-server/api/v1/model"
+)
+
+type UsersClient interface {
+	List() ([]model.User, error)
+	Create(username string) (*model.User, error)
+	Disable(username string) error
+	// LinkOidcIdentity links an existing user to an OIDC identity so that the user may log in with an OIDC token
+	LinkOidcIdentity(username string, identity *model.OidcIdentity) error
+}
+
+type usersClient struct {
+	client *Client
+}
+
+func (c *usersClient) List() ([]model.User, error) {
+	users := []model.User{}
+	request, err := c.client.NewGetRequest("/api/v1/users")
+	if err != nil {
+		return nil, err
+	}
+	_, err = c.client.Do(request, &users)
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (c *usersClient) Create(username string) (*model.User, error) {
+	request, err := c.client.NewRequest(http.MethodPost, "/api/v1/users", &model.NewUser{Username: username})
+	if err != nil {
+		return nil, err
+	}
+	user := &model.User{}
+	_, err = c.client.Do(request, user)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (c *usersClient) Disable(username string) error {
+	request, err := c.client.NewRequest(http.MethodPost, fmt.Sprintf("/api/v1/users/%s/disable", username), nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.client.Do(request, nil)
+	return err
+}
+
+func (c *usersClient) LinkOidcIdentity(username string, identity *model.OidcIdentity) error {
+	request, err := c.client.NewRequest(http.MethodPut, fmt.Sprintf("/api/v1/users/%s/oidc", username), identity)
+	if err != nil {
+		return err
+	}
+	_, err = c.client.Do(request, nil)
+	return err
+}
@@ -0,0 +1,77 @@
+package sdk
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Users_List(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/users", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		fmt.Fprint(w, `[{"username": "root"}, {"username": "myuser", "disabled": "2020-10-01T12:00:00Z"}]`)
+	})
+
+	users, err := client.Users.List()
+
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+	assert.Equal(t, "root", users[0].Username)
+	assert.Nil(t, users[0].Disabled)
+	assert.NotNil(t, users[1].Disabled)
+}
+
+func Test_Users_Create(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/users", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		actualModel := &model.NewUser{}
+		mustUnmarshalR(r.Body, actualModel)
+		assert.Equal(t, "myuser", actualModel.Username)
+		fmt.Fprint(w, `{"username": "myuser"}`)
+	})
+
+	user, err := client.Users.Create("myuser")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "myuser", user.Username)
+}
+
+func Test_Users_Disable(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/users/myuser/disable", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		fmt.Fprint(w, "")
+	})
+
+	err := client.Users.Disable("myuser")
+
+	assert.NoError(t, err)
+}
+
+func Test_Users_LinkOidcIdentity(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/users/myuser/oidc", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		actualModel := &model.OidcIdentity{}
+		mustUnmarshalR(r.Body, actualModel)
+		assert.Equal(t, &model.OidcIdentity{Issuer: "https://issuer", Subject: "1234"}, actualModel)
+		fmt.Fprint(w, "")
+	})
+
+	err := client.Users.LinkOidcIdentity("myuser", &model.OidcIdentity{Issuer: "https://issuer", Subject: "1234"})
+
+	assert.NoError(t, err)
+}
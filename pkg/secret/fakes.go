@@ -6,11 +6,18 @@ import (
 )
 
 type FakeService struct {
-	SealAndSaveFn        func(plaintextSecret string, secretMeta *core.SecretMeta, committer state.Committer) error
-	SealAndSaveCallCount int
+	SealAndSaveFn              func(plaintextSecret string, secretMeta *core.SecretMeta, savedBy string, committer state.Committer) error
+	SealAndSaveCallCount       int
+	RenderEnvironmentFn        func(envName string) ([]core.ResourceFile, []core.SecretMeta, error)
+	RenderEnvironmentCallCount int
 }
 
-func (f *FakeService) SealAndSave(plaintextSecret string, secretMeta *core.SecretMeta, committer state.Committer) error {
+func (f *FakeService) SealAndSave(plaintextSecret string, secretMeta *core.SecretMeta, savedBy string, committer state.Committer) error {
 	f.SealAndSaveCallCount++
-	return f.SealAndSaveFn(plaintextSecret, secretMeta, committer)
+	return f.SealAndSaveFn(plaintextSecret, secretMeta, savedBy, committer)
+}
+
+func (f *FakeService) RenderEnvironment(envName string) ([]core.ResourceFile, []core.SecretMeta, error) {
+	f.RenderEnvironmentCallCount++
+	return f.RenderEnvironmentFn(envName)
 }
@@ -55,7 +55,7 @@ func Test_update_snapshot_sealedsecret(t *testing.T) {
 
 	secretService := service{secretMetaRepository, environmentRepository, staticReader{}}
 
-	err = secretService.SealAndSave("mysecretval", secretMeta, committer)
+	err = secretService.SealAndSave("mysecretval", secretMeta, "myuser", committer)
 
 	assert.NoError(t, err)
 	if !snapshot.ShouldUpdate() {
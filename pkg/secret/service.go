@@ -13,7 +13,10 @@ import (
 )
 
 type Service interface {
-	SealAndSave(plaintextSecret string, secretMeta *core.SecretMeta, committer state.Committer) error
+	SealAndSave(plaintextSecret string, secretMeta *core.SecretMeta, savedBy string, committer state.Committer) error
+	// RenderEnvironment renders the committed revision of every sealed secret in an environment without committing. Secrets that
+	// were committed before their ciphertext was recorded cannot be rendered and are returned.
+	RenderEnvironment(envName string) (files []core.ResourceFile, skipped []core.SecretMeta, err error)
 }
 
 type service struct {
@@ -26,16 +29,16 @@ func NewService(secretMetas core.SecretMetaRepository, environments core.Environ
 	return &service{secretMetas, environments, rand.Reader}
 }
 
-func (s *service) SealAndSave(plaintextSecret string, secretMeta *core.SecretMeta, committer state.Committer) error {
+func (s *service) SealAndSave(plaintextSecret string, secretMeta *core.SecretMeta, savedBy string, committer state.Committer) error {
 	sealedSecretCert, err := s.getSealedSecretCert(plaintextSecret, secretMeta.EnvironmentName)
 	if err != nil {
 		return err
 	}
 
-	return s.sealAndSave(plaintextSecret, sealedSecretCert, secretMeta, committer)
+	return s.sealAndSave(plaintextSecret, sealedSecretCert, secretMeta, savedBy, committer)
 }
 
-func (s *service) sealAndSave(plaintextSecret string, sealedSecretCert []byte, secretMeta *core.SecretMeta, committer state.Committer) error {
+func (s *service) sealAndSave(plaintextSecret string, sealedSecretCert []byte, secretMeta *core.SecretMeta, savedBy string, committer state.Committer) error {
 	revision, err := s.secretMetas.Save(secretMeta)
 	if err != nil {
 		return errors.Wrap(err, "Error saving secret metadata")
@@ -53,11 +56,17 @@ func (s *service) sealAndSave(plaintextSecret string, sealedSecretCert []byte, s
 		return errors.Wrap(err, fmt.Sprintf("Error rendering sealed secret resource %q in environment %q", secretMeta.Name, secretMeta.EnvironmentName))
 	}
 
-	err = committer.Commit(fmt.Sprintf("Updating secret %q in environment %q", sealedSecret.Name, secretMeta.EnvironmentName), resourceFiles)
+	err = committer.Commit(fmt.Sprintf("Updating secret %q in environment %q", sealedSecret.Name, secretMeta.EnvironmentName), resourceFiles, &core.CommitMeta{
+		Username:    savedBy,
+		App:         secretMeta.App.Name,
+		Namespace:   secretMeta.App.Namespace,
+		Environment: secretMeta.EnvironmentName,
+	})
 	if err != nil {
 		return errors.Wrap(err, "Error committing sealed secret resources")
 	}
 
+	secretMeta.Ciphertext = sealedSecret.Ciphertext()
 	err = s.secretMetas.Commit(secretMeta)
 	if err != nil {
 		// Let the client handle this error specifically
@@ -69,6 +78,32 @@ func (s *service) sealAndSave(plaintextSecret string, sealedSecretCert []byte, s
 	return nil
 }
 
+func (s *service) RenderEnvironment(envName string) (files []core.ResourceFile, skipped []core.SecretMeta, err error) {
+	secretMetas, err := s.secretMetas.ListByEnvironment(envName)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, fmt.Sprintf("Error retrieving secrets in environment %q", envName))
+	}
+
+	files = []core.ResourceFile{}
+	skipped = []core.SecretMeta{}
+	for idx := range secretMetas {
+		secretMeta := &secretMetas[idx]
+		if len(secretMeta.Ciphertext) == 0 {
+			skipped = append(skipped, *secretMeta)
+			continue
+		}
+
+		sealedSecret := resources.CreateSealedSecretFromCiphertext(secretMeta, secretMeta.Ciphertext)
+		secretFiles, err := state.RenderSealedSecret(secretMeta.App.Name, envName, sealedSecret)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, fmt.Sprintf("Error rendering sealed secret resource %q in environment %q", secretMeta.Name, envName))
+		}
+		files = append(files, secretFiles...)
+	}
+
+	return files, skipped, nil
+}
+
 func (s *service) getSealedSecretCert(plaintextSecret, envName string) ([]byte, error) {
 	environment, err := s.environments.Get(envName)
 	if err != nil {
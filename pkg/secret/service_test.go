@@ -80,6 +80,7 @@ func Test_sealAndSave(t *testing.T) {
 	secretMetaRepository := &core.FakeSecretMetaRepository{
 		CommitFn: func(secretMeta *core.SecretMeta) error {
 			assert.EqualValues(t, 1, secretMeta.Revision)
+			assert.NotEmpty(t, secretMeta.Ciphertext)
 			return nil
 		},
 		SaveFn: func(secretMeta *core.SecretMeta) (int64, error) {
@@ -102,7 +103,7 @@ func Test_sealAndSave(t *testing.T) {
 
 	service := service{secretMetas: secretMetaRepository, rand: rand.Reader}
 
-	result := service.sealAndSave("plain", testCertBytes, meta, committer)
+	result := service.sealAndSave("plain", testCertBytes, meta, "myuser", committer)
 
 	assert.NoError(t, result)
 	assert.EqualValues(t, 1, meta.Revision)
@@ -110,6 +111,7 @@ func Test_sealAndSave(t *testing.T) {
 	assert.Equal(t, 1, secretMetaRepository.CommitCallCount)
 	assert.Len(t, committer.Commits, 1)
 	assert.Equal(t, "Updating secret \"myapp-mysecret-1\" in environment \"myenv\"", committer.Commits[0].Message)
+	assert.Equal(t, &core.CommitMeta{Username: "myuser", App: "myapp", Namespace: "myns", Environment: "myenv"}, committer.Commits[0].Meta)
 	assert.Len(t, committer.Commits[0].Files, 1)
 	assert.Equal(t, "state/riser-managed/myns/secrets/myapp/bitnami.com.sealedsecret.myapp-mysecret-1.yaml", committer.Commits[0].Files[0].Name)
 }
@@ -135,7 +137,42 @@ func Test_sealAndSave_WhenNewerRevisionExists(t *testing.T) {
 
 	service := service{secretMetas: secretMetaRepository, rand: rand.Reader}
 
-	result := service.sealAndSave("plain", testCertBytes, meta, committer)
+	result := service.sealAndSave("plain", testCertBytes, meta, "myuser", committer)
 
 	require.Equal(t, core.ErrConflictNewerVersion, result)
 }
+
+func Test_RenderEnvironment(t *testing.T) {
+	secretMetaRepository := &core.FakeSecretMetaRepository{
+		ListByEnvironmentFn: func(envName string) ([]core.SecretMeta, error) {
+			assert.Equal(t, "myenv", envName)
+			return []core.SecretMeta{
+				{App: core.NewNamespacedName("myapp", "myns"), EnvironmentName: "myenv", Name: "mysecret", Revision: 2, Ciphertext: []byte("sealed")},
+				{App: core.NewNamespacedName("myapp", "myns"), EnvironmentName: "myenv", Name: "old", Revision: 1},
+			}, nil
+		},
+	}
+	service := service{secretMetas: secretMetaRepository}
+
+	files, skipped, err := service.RenderEnvironment("myenv")
+
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "state/riser-managed/myns/secrets/myapp/bitnami.com.sealedsecret.myapp-mysecret-2.yaml", files[0].Name)
+	assert.Contains(t, string(files[0].Contents), "data: c2VhbGVk")
+	require.Len(t, skipped, 1)
+	assert.Equal(t, "old", skipped[0].Name)
+}
+
+func Test_RenderEnvironment_ListErr(t *testing.T) {
+	secretMetaRepository := &core.FakeSecretMetaRepository{
+		ListByEnvironmentFn: func(string) ([]core.SecretMeta, error) {
+			return nil, errors.New("test")
+		},
+	}
+	service := service{secretMetas: secretMetaRepository}
+
+	_, _, err := service.RenderEnvironment("myenv")
+
+	assert.Equal(t, `Error retrieving secrets in environment "myenv": test`, err.Error())
+}
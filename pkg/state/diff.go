@@ -0,0 +1,73 @@
+package state
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/riser-platform/riser-server/pkg/util"
+)
+
+// DiffCommits returns a unified diff for each commit against the current state. Commits are applied in order so that each diff
+// reflects the changes of the previous commits. A deleted folder is diffed as the removal of every file in the folder. Only the
+// ManagedDirs are compared since riser does not render files outside of them.
+func DiffCommits(reader Reader, commits []DryRunCommit) ([]string, error) {
+	current := map[string][]byte{}
+	for _, dir := range ManagedDirs {
+		files, err := reader.ReadFiles(dir)
+		if err != nil {
+			return nil, errors.Wrap(err, "error reading the current state")
+		}
+		for _, file := range files {
+			current[file.Name] = file.Contents
+		}
+	}
+
+	diffs := []string{}
+	for _, commit := range commits {
+		diff := &strings.Builder{}
+		for _, file := range commit.Files {
+			if file.Delete {
+				for _, name := range filesInPath(current, file.Name) {
+					err := writeDiff(diff, name, current[name], nil)
+					if err != nil {
+						return nil, err
+					}
+					delete(current, name)
+				}
+				continue
+			}
+
+			err := writeDiff(diff, file.Name, current[file.Name], file.Contents)
+			if err != nil {
+				return nil, err
+			}
+			current[file.Name] = file.Contents
+		}
+		diffs = append(diffs, diff.String())
+	}
+
+	return diffs, nil
+}
+
+func writeDiff(diff *strings.Builder, name string, from, to []byte) error {
+	fileDiff, err := util.UnifiedDiff(name, from, to)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("error comparing file %q", name))
+	}
+	diff.WriteString(fileDiff)
+	return nil
+}
+
+// filesInPath returns the sorted names of the files that are either the path or in a folder of the path
+func filesInPath(files map[string][]byte, filePath string) []string {
+	names := []string{}
+	for name := range files {
+		if name == filePath || strings.HasPrefix(name, filePath+"/") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
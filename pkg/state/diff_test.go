@@ -0,0 +1,98 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDiffTestReader(files map[string][]core.ResourceFile) Reader {
+	return NewGitCommitter(context.Background(), &git.FakeRepo{
+		ResetHardRemoteFn: func(context.Context) error {
+			return nil
+		},
+		ReadFilesFn: func(dir string) ([]core.ResourceFile, error) {
+			return files[dir], nil
+		},
+	})
+}
+
+func Test_DiffCommits(t *testing.T) {
+	reader := newDiffTestReader(map[string][]core.ResourceFile{
+		"state/riser-managed": {
+			{Name: "state/riser-managed/myns/deployments/myapp/deployment.yaml", Contents: []byte("a: 1\n")},
+			{Name: "state/riser-managed/myns/deployments/old/deployment.yaml", Contents: []byte("b: 1\n")},
+			{Name: "state/riser-managed/myns/deployments/old/service.yaml", Contents: []byte("c: 1\n")},
+		},
+		"riser-config": {
+			{Name: "riser-config/myns/myapp.yaml", Contents: []byte("d: 1\n")},
+		},
+	})
+	commits := []DryRunCommit{
+		{
+			Files: []core.ResourceFile{
+				{Name: "state/riser-managed/myns/deployments/myapp/deployment.yaml", Contents: []byte("a: 2\n")},
+				{Name: "riser-config/myns/myapp.yaml", Contents: []byte("d: 1\n")},
+				{Name: "state/riser-managed/myns/deployments/myapp/route.yaml", Contents: []byte("e: 1\n")},
+			},
+		},
+		{
+			Files: []core.ResourceFile{
+				{Name: "state/riser-managed/myns/deployments/old", Delete: true},
+				// Diffed against the previous commit
+				{Name: "state/riser-managed/myns/deployments/myapp/deployment.yaml", Contents: []byte("a: 2\n")},
+			},
+		},
+	}
+
+	result, err := DiffCommits(reader, commits)
+
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	assert.Equal(t,
+		"--- a/state/riser-managed/myns/deployments/myapp/deployment.yaml\n"+
+			"+++ b/state/riser-managed/myns/deployments/myapp/deployment.yaml\n"+
+			"@@ -1 +1 @@\n-a: 1\n+a: 2\n"+
+			"--- /dev/null\n"+
+			"+++ b/state/riser-managed/myns/deployments/myapp/route.yaml\n"+
+			"@@ -0,0 +1 @@\n+e: 1\n",
+		result[0])
+	assert.Equal(t,
+		"--- a/state/riser-managed/myns/deployments/old/deployment.yaml\n"+
+			"+++ /dev/null\n"+
+			"@@ -1 +0,0 @@\n-b: 1\n"+
+			"--- a/state/riser-managed/myns/deployments/old/service.yaml\n"+
+			"+++ /dev/null\n"+
+			"@@ -1 +0,0 @@\n-c: 1\n",
+		result[1])
+}
+
+func Test_DiffCommits_NoChanges(t *testing.T) {
+	reader := newDiffTestReader(map[string][]core.ResourceFile{
+		"riser-config": {{Name: "riser-config/myns/myapp.yaml", Contents: []byte("d: 1\n")}},
+	})
+	commits := []DryRunCommit{{Files: []core.ResourceFile{{Name: "riser-config/myns/myapp.yaml", Contents: []byte("d: 1\n")}}}}
+
+	result, err := DiffCommits(reader, commits)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{""}, result)
+}
+
+func Test_DiffCommits_ReadErr(t *testing.T) {
+	reader := NewGitCommitter(context.Background(), &git.FakeRepo{
+		ResetHardRemoteFn: func(context.Context) error {
+			return errors.New("test")
+		},
+	})
+
+	result, err := DiffCommits(reader, []DryRunCommit{})
+
+	assert.Nil(t, result)
+	assert.Equal(t, "error reading the current state: error resetting repo: test", err.Error())
+}
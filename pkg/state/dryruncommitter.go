@@ -5,6 +5,7 @@ import "github.com/riser-platform/riser-server/pkg/core"
 type DryRunCommit struct {
 	Message string
 	Files   []core.ResourceFile
+	Meta    *core.CommitMeta
 }
 
 type DryRunCommitter struct {
@@ -17,7 +18,7 @@ func NewDryRunCommitter() *DryRunCommitter {
 	}
 }
 
-func (committer *DryRunCommitter) Commit(message string, files []core.ResourceFile) error {
-	committer.Commits = append(committer.Commits, DryRunCommit{Message: message, Files: files})
+func (committer *DryRunCommitter) Commit(message string, files []core.ResourceFile, meta *core.CommitMeta) error {
+	committer.Commits = append(committer.Commits, DryRunCommit{Message: message, Files: files, Meta: meta})
 	return nil
 }
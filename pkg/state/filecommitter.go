@@ -2,6 +2,7 @@ package state
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 
@@ -19,9 +20,16 @@ func NewFileCommitter(basePath string) *FileCommitter {
 	return &FileCommitter{basePath}
 }
 
-func (committer *FileCommitter) Commit(message string, files []core.ResourceFile) error {
+func (committer *FileCommitter) Commit(message string, files []core.ResourceFile, meta *core.CommitMeta) error {
 	for _, file := range files {
 		fullpath := filepath.Join(committer.basePath, file.Name)
+		if file.Delete {
+			err := os.RemoveAll(fullpath)
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("error deleting %q", fullpath))
+			}
+			continue
+		}
 		err := util.EnsureDir(fullpath, 0755)
 		if err != nil {
 			return errors.Wrap(err, fmt.Sprintf("error creating directory for file %q", fullpath))
@@ -33,3 +41,34 @@ func (committer *FileCommitter) Commit(message string, files []core.ResourceFile
 	}
 	return nil
 }
+
+func (committer *FileCommitter) ReadFiles(dir string) ([]core.ResourceFile, error) {
+	files := []core.ResourceFile{}
+	root := filepath.Join(committer.basePath, dir)
+	err := filepath.WalkDir(root, func(fullpath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && fullpath == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		contents, err := os.ReadFile(fullpath)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("error reading file %q", fullpath))
+		}
+		name, err := filepath.Rel(committer.basePath, fullpath)
+		if err != nil {
+			return err
+		}
+		files = append(files, core.ResourceFile{Name: filepath.ToSlash(name), Contents: contents})
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("error reading directory %q", root))
+	}
+	return files, nil
+}
@@ -0,0 +1,58 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FileCommitter_Commit(t *testing.T) {
+	dir := t.TempDir()
+	committer := NewFileCommitter(dir)
+
+	err := committer.Commit("test", []core.ResourceFile{{Name: "nested/test.yaml", Contents: []byte("contents")}}, nil)
+
+	require.NoError(t, err)
+	contents, err := os.ReadFile(filepath.Join(dir, "nested/test.yaml"))
+	require.NoError(t, err)
+	assert.EqualValues(t, "contents", contents)
+}
+
+func Test_FileCommitter_Commit_Deletes(t *testing.T) {
+	dir := t.TempDir()
+	committer := NewFileCommitter(dir)
+	require.NoError(t, committer.Commit("test", []core.ResourceFile{{Name: "nested/test.yaml", Contents: []byte("contents")}}, nil))
+
+	err := committer.Commit("test", []core.ResourceFile{{Name: "nested", Delete: true}, {Name: "doesnotexist", Delete: true}}, nil)
+
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "nested"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_FileCommitter_ReadFiles(t *testing.T) {
+	dir := t.TempDir()
+	committer := NewFileCommitter(dir)
+	require.NoError(t, committer.Commit("test", []core.ResourceFile{
+		{Name: "nested/a.yaml", Contents: []byte("a")},
+		{Name: "nested/deeper/b.yaml", Contents: []byte("b")},
+		{Name: "other/c.yaml", Contents: []byte("c")},
+	}, nil))
+
+	result, err := committer.ReadFiles("nested")
+
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []core.ResourceFile{
+		{Name: "nested/a.yaml", Contents: []byte("a")},
+		{Name: "nested/deeper/b.yaml", Contents: []byte("b")},
+	}, result)
+
+	result, err = committer.ReadFiles("missing")
+
+	require.NoError(t, err)
+	assert.Empty(t, result)
+}
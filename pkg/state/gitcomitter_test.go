@@ -1,31 +1,43 @@
 package state
 
 import (
+	"context"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/pkg/errors"
+
 	"github.com/riser-platform/riser-server/pkg/core"
 	"github.com/riser-platform/riser-server/pkg/git"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+type testContextKey struct{}
+
 func Test_Commit(t *testing.T) {
+	ctx := context.WithValue(context.Background(), testContextKey{}, "caller")
 	repo := &git.FakeRepo{
-		ResetHardRemoteFn: func() error {
+		ResetHardRemoteFn: func(ctxArg context.Context) error {
+			assert.Equal(t, ctx, ctxArg)
 			return nil
 		},
-		CommitFn: func(message string, resources []core.ResourceFile) error {
+		CommitFn: func(message string, resources []core.ResourceFile, meta *core.CommitMeta) error {
 			assert.Equal(t, "test message", message)
 			assert.Len(t, resources, 1)
 			assert.Equal(t, "test.yaml", resources[0].Name)
 			return nil
 		},
-		PushFn: func() error {
+		PushFn: func(ctxArg context.Context) error {
+			assert.Equal(t, ctx, ctxArg)
 			return nil
 		},
+		HeadShaFn: func() (string, error) {
+			return "abc123", nil
+		},
 	}
-	committer := NewGitCommitter(repo)
+	committer := NewGitCommitter(ctx, repo)
 
 	resources := []core.ResourceFile{
 		{
@@ -33,27 +45,28 @@ func Test_Commit(t *testing.T) {
 		},
 	}
 
-	result := committer.Commit("test message", resources)
+	result := committer.Commit("test message", resources, nil)
 
 	assert.NoError(t, result)
 	assert.Equal(t, 1, repo.ResetHardRemoteCallCount)
 	assert.Equal(t, 1, repo.CommitCallCount)
 	assert.Equal(t, 1, repo.PushCallCount)
+	assert.Equal(t, []string{"abc123"}, committer.CommitShas)
 }
 
 func Test_Commit_NoChanges_DoesNotPush(t *testing.T) {
 	repo := &git.FakeRepo{
-		ResetHardRemoteFn: func() error {
+		ResetHardRemoteFn: func(context.Context) error {
 			return nil
 		},
-		CommitFn: func(message string, resources []core.ResourceFile) error {
+		CommitFn: func(message string, resources []core.ResourceFile, meta *core.CommitMeta) error {
 			return git.ErrNoChanges
 		},
-		PushFn: func() error {
+		PushFn: func(context.Context) error {
 			return nil
 		},
 	}
-	committer := NewGitCommitter(repo)
+	committer := NewGitCommitter(context.Background(), repo)
 
 	resources := []core.ResourceFile{
 		{
@@ -61,7 +74,7 @@ func Test_Commit_NoChanges_DoesNotPush(t *testing.T) {
 		},
 	}
 
-	result := committer.Commit("test message", resources)
+	result := committer.Commit("test message", resources, nil)
 
 	assert.Equal(t, git.ErrNoChanges, result)
 	assert.Equal(t, 0, repo.PushCallCount)
@@ -70,38 +83,178 @@ func Test_Commit_NoChanges_DoesNotPush(t *testing.T) {
 func Test_Commit_Serialized(t *testing.T) {
 	inTransaction := false
 	repo := &git.FakeRepo{
-		ResetHardRemoteFn: func() error {
+		ResetHardRemoteFn: func(context.Context) error {
 			assert.False(t, inTransaction, "Must not reset while a transaction is pending")
 			inTransaction = true
 			time.Sleep(10 * time.Millisecond)
 			return nil
 		},
-		CommitFn: func(message string, resources []core.ResourceFile) error {
+		CommitFn: func(message string, resources []core.ResourceFile, meta *core.CommitMeta) error {
 			assert.True(t, inTransaction, "Must not commit while not inside a transaction")
 			return nil
 		},
-		PushFn: func() error {
+		PushFn: func(context.Context) error {
 			assert.True(t, inTransaction, "Must not push while not inside a transaction")
 			time.Sleep(10 * time.Millisecond)
 			inTransaction = false
 			return nil
 		},
+		HeadShaFn: func() (string, error) {
+			return "abc123", nil
+		},
 	}
 
-	committer := NewGitCommitter(repo)
+	committer := NewGitCommitter(context.Background(), repo)
 
 	wg := sync.WaitGroup{}
 
 	doCommit := func(committer *GitCommitter) {
-		wg.Add(1)
-		err := committer.Commit("", []core.ResourceFile{{}})
+		err := committer.Commit("", []core.ResourceFile{{}}, nil)
 		wg.Done()
 		assert.NoError(t, err)
 	}
 
 	for i := 0; i < 3; i++ {
+		wg.Add(1)
 		go doCommit(committer)
 	}
 
 	wg.Wait()
 }
+
+func Test_Commit_LockTimeout_ReturnsRetryableError(t *testing.T) {
+	repo := &git.FakeRepo{LockTimeout: time.Millisecond}
+	require.NoError(t, repo.Lock())
+	committer := NewGitCommitter(context.Background(), repo)
+
+	result := committer.Commit("test message", []core.ResourceFile{}, nil)
+
+	require.IsType(t, &core.RetryableError{}, result)
+	assert.Equal(t, lockRetryAfter, result.(*core.RetryableError).RetryAfter)
+	assert.Equal(t, 0, repo.ResetHardRemoteCallCount)
+}
+
+func Test_Commit_PushRejected_Retries(t *testing.T) {
+	repo := &git.FakeRepo{
+		ResetHardRemoteFn: func(context.Context) error {
+			return nil
+		},
+		CommitFn: func(string, []core.ResourceFile, *core.CommitMeta) error {
+			return nil
+		},
+		HeadShaFn: func() (string, error) {
+			return "abc123", nil
+		},
+	}
+	repo.PushFn = func(context.Context) error {
+		if repo.PushCallCount < 3 {
+			return errors.Wrap(git.ErrPushRejected, "test")
+		}
+		return nil
+	}
+	sleeps := []time.Duration{}
+	committer := NewGitCommitter(context.Background(), repo)
+	committer.sleep = func(d time.Duration) {
+		sleeps = append(sleeps, d)
+	}
+
+	result := committer.Commit("test message", []core.ResourceFile{}, nil)
+
+	assert.NoError(t, result)
+	assert.Equal(t, 3, repo.ResetHardRemoteCallCount)
+	assert.Equal(t, 3, repo.CommitCallCount)
+	assert.Equal(t, 3, repo.PushCallCount)
+	assert.Equal(t, []time.Duration{pushRetryBackoff, pushRetryBackoff * 2}, sleeps)
+	assert.Equal(t, []string{"abc123"}, committer.CommitShas)
+}
+
+func Test_Commit_PushRejected_GivesUp(t *testing.T) {
+	repo := &git.FakeRepo{
+		ResetHardRemoteFn: func(context.Context) error {
+			return nil
+		},
+		CommitFn: func(string, []core.ResourceFile, *core.CommitMeta) error {
+			return nil
+		},
+		PushFn: func(context.Context) error {
+			return git.ErrPushRejected
+		},
+	}
+	committer := NewGitCommitter(context.Background(), repo)
+	committer.sleep = func(time.Duration) {}
+
+	result := committer.Commit("test message", []core.ResourceFile{}, nil)
+
+	assert.True(t, errors.Is(result, git.ErrPushRejected))
+	assert.Equal(t, "error pushing changes: push rejected by the remote", result.Error())
+	assert.Equal(t, maxPushRetries+1, repo.PushCallCount)
+}
+
+func Test_Commit_PushError_DoesNotRetry(t *testing.T) {
+	repo := &git.FakeRepo{
+		ResetHardRemoteFn: func(context.Context) error {
+			return nil
+		},
+		CommitFn: func(string, []core.ResourceFile, *core.CommitMeta) error {
+			return nil
+		},
+		PushFn: func(context.Context) error {
+			return errors.New("test")
+		},
+	}
+	committer := NewGitCommitter(context.Background(), repo)
+
+	result := committer.Commit("test message", []core.ResourceFile{}, nil)
+
+	assert.Equal(t, "error pushing changes: test", result.Error())
+	assert.Equal(t, 1, repo.PushCallCount)
+}
+
+func Test_Commit_ForDir(t *testing.T) {
+	repo := &git.FakeRepo{
+		ResetHardRemoteFn: func(context.Context) error {
+			return nil
+		},
+		CommitFn: func(message string, resources []core.ResourceFile, meta *core.CommitMeta) error {
+			require.Len(t, resources, 2)
+			assert.Equal(t, "dev/state/test.yaml", resources[0].Name)
+			assert.Equal(t, "dev/state/old", resources[1].Name)
+			assert.True(t, resources[1].Delete)
+			return nil
+		},
+		PushFn: func(context.Context) error {
+			return nil
+		},
+		HeadShaFn: func() (string, error) {
+			return "abc123", nil
+		},
+	}
+	committer := NewGitCommitterForDir(context.Background(), repo, "dev")
+	resources := []core.ResourceFile{{Name: "state/test.yaml"}, {Name: "state/old", Delete: true}}
+
+	result := committer.Commit("test message", resources, nil)
+
+	assert.NoError(t, result)
+	assert.Equal(t, 1, repo.CommitCallCount)
+	// The caller's files must not be modified
+	assert.Equal(t, "state/test.yaml", resources[0].Name)
+}
+
+func Test_ReadFiles_ForDir(t *testing.T) {
+	repo := &git.FakeRepo{
+		ResetHardRemoteFn: func(context.Context) error {
+			return nil
+		},
+		ReadFilesFn: func(dir string) ([]core.ResourceFile, error) {
+			assert.Equal(t, "dev/state", dir)
+			return []core.ResourceFile{{Name: "dev/state/test.yaml", Contents: []byte("test")}}, nil
+		},
+	}
+	committer := NewGitCommitterForDir(context.Background(), repo, "dev")
+
+	result, err := committer.ReadFiles("state")
+
+	require.NoError(t, err)
+	assert.Equal(t, []core.ResourceFile{{Name: "state/test.yaml", Contents: []byte("test")}}, result)
+	assert.Equal(t, 1, repo.ResetHardRemoteCallCount)
+}
@@ -1,6 +1,11 @@
 package state
 
 import (
+	"context"
+	"path"
+	"strings"
+	"time"
+
 	"github.com/riser-platform/riser-server/pkg/core"
 
 	"github.com/riser-platform/riser-server/pkg/git"
@@ -8,51 +13,197 @@ import (
 	"github.com/pkg/errors"
 )
 
+const (
+	// maxPushRetries is the number of times a commit is reapplied when the push is rejected due to a concurrent change to the remote
+	maxPushRetries = 3
+	// pushRetryBackoff is doubled after each rejected push
+	pushRetryBackoff = 250 * time.Millisecond
+	// lockRetryAfter is returned to the client when the repo lock could not be acquired
+	lockRetryAfter = 5 * time.Second
+)
+
 type Committer interface {
-	Commit(message string, files []core.ResourceFile) error
+	Commit(message string, files []core.ResourceFile, meta *core.CommitMeta) error
+}
+
+// PrepareFunc returns the files to commit based on the current state
+type PrepareFunc func(current Reader) ([]core.ResourceFile, error)
+
+// PreparingCommitter is implemented by committers that can guard the current state against concurrent changes. The prepare func is
+// called with the current state before each attempt to commit (e.g. after a rejected push) so that the commit is never derived
+// from a stale state.
+type PreparingCommitter interface {
+	CommitPrepared(message string, prepare PrepareFunc, meta *core.CommitMeta) error
+}
+
+// CommitPrepared commits the files returned by the prepare func. The files are prepared while the state is guarded against concurrent
+// changes when the committer is a PreparingCommitter. Otherwise they are prepared once from the reader, which may be nil when the
+// prepare func does not read the current state. Return git.ErrNoChanges from the prepare func to skip the commit.
+func CommitPrepared(committer Committer, reader Reader, message string, prepare PrepareFunc, meta *core.CommitMeta) error {
+	if preparing, ok := committer.(PreparingCommitter); ok {
+		return preparing.CommitPrepared(message, prepare, meta)
+	}
+
+	files, err := prepare(reader)
+	if err != nil {
+		return err
+	}
+	return committer.Commit(message, files, meta)
+}
+
+// Reader reads the current state of an environment
+type Reader interface {
+	// ReadFiles returns every file in a folder of the environment's state. Names are relative to the root of the environment's state
+	// so that they may be compared with rendered files. Returns no files if the folder does not exist.
+	ReadFiles(dir string) ([]core.ResourceFile, error)
 }
 
 type GitCommitter struct {
+	// ctx cancels the remote git operations of the caller that the committer was created for
+	ctx context.Context
 	git git.Repo
+	// dir is prepended to the name of each file. Empty when the repo only contains the state for a single environment.
+	dir string
+	// CommitShas are the SHAs of the commits pushed by this committer
+	CommitShas []string
+	sleep      func(time.Duration)
+}
+
+func NewGitCommitter(ctx context.Context, gitRepo git.Repo) *GitCommitter {
+	return &GitCommitter{ctx: ctx, git: gitRepo, CommitShas: []string{}, sleep: time.Sleep}
 }
 
-func NewGitCommitter(gitRepo git.Repo) *GitCommitter {
-	return &GitCommitter{gitRepo}
+// NewGitCommitterForDir returns a committer for state that is stored in a directory of the repo (e.g. a repo that contains the state
+// for multiple environments)
+func NewGitCommitterForDir(ctx context.Context, gitRepo git.Repo, dir string) *GitCommitter {
+	committer := NewGitCommitter(ctx, gitRepo)
+	committer.dir = dir
+	return committer
 }
 
 // Commit commits state changes to the state repo. Commits are authoritative i.e. they represent the absolute desired state.
 // No merging takes place for riser managed resources.
-func (committer *GitCommitter) Commit(message string, files []core.ResourceFile) error {
-	/*
-		Commits inside of a riser server instance are atomic as we only keep one instance of the repo in /tmp
-
-		TODO: Timeout this lock and return an error after say 10 seconds.
-		The main scenario is that the repo is slow/unresponsive and we don't want a bunch of goroutines hanging here.
-	*/
-	committer.git.Lock()
+func (committer *GitCommitter) Commit(message string, files []core.ResourceFile, meta *core.CommitMeta) error {
+	return committer.CommitPrepared(message, func(Reader) ([]core.ResourceFile, error) { return files, nil }, meta)
+}
+
+// CommitPrepared is like Commit, except that the files are prepared from the state as it is after each reset to the remote
+func (committer *GitCommitter) CommitPrepared(message string, prepare PrepareFunc, meta *core.CommitMeta) error {
+	// Commits inside of a riser server instance are atomic as we only keep one instance of the repo in /tmp
+	err := committer.lock()
+	if err != nil {
+		return err
+	}
 	defer committer.git.Unlock()
 
+	backoff := pushRetryBackoff
+	for attempt := 0; ; attempt++ {
+		err = committer.commitAndPush(message, prepare, meta)
+		// Another instance pushed between our reset and push. Since commits are authoritative we can safely reapply our changes on top.
+		if errors.Is(err, git.ErrPushRejected) && attempt < maxPushRetries {
+			committer.sleep(backoff)
+			backoff *= 2
+			continue
+		}
+		return err
+	}
+}
+
+// ReadFiles reads the latest state from the remote
+func (committer *GitCommitter) ReadFiles(dir string) ([]core.ResourceFile, error) {
+	err := committer.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer committer.git.Unlock()
+
+	err = committer.git.ResetHardRemote(committer.ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "error resetting repo")
+	}
+
+	return committer.readLocalFiles(dir)
+}
+
+// readLocalFiles reads from the local repo. The caller must hold the lock.
+func (committer *GitCommitter) readLocalFiles(dir string) ([]core.ResourceFile, error) {
+	files, err := committer.git.ReadFiles(path.Join(committer.dir, dir))
+	if err != nil {
+		return nil, err
+	}
+
+	if committer.dir != "" {
+		for i := range files {
+			files[i].Name = strings.TrimPrefix(files[i].Name, committer.dir+"/")
+		}
+	}
+	return files, nil
+}
+
+func (committer *GitCommitter) lock() error {
+	err := committer.git.Lock()
+	if err != nil {
+		if err == git.ErrLockTimeout {
+			return &core.RetryableError{
+				Message:    "The state repo is busy with other changes. Please retry your request.",
+				RetryAfter: lockRetryAfter,
+			}
+		}
+		return errors.Wrap(err, "error locking repo")
+	}
+	return nil
+}
+
+func (committer *GitCommitter) commitAndPush(message string, prepare PrepareFunc, meta *core.CommitMeta) error {
 	// Always reset before committing as commits are authoritative
-	err := committer.git.ResetHardRemote()
+	err := committer.git.ResetHardRemote(committer.ctx)
 	if err != nil {
 		return errors.Wrap(err, "error resetting repo")
 	}
 
-	err = committer.git.Commit(message, files)
-	if err != nil && err != git.ErrNoChanges {
+	files, err := prepare(readerFunc(committer.readLocalFiles))
+	if err != nil {
+		return err
+	}
+
+	err = committer.git.Commit(message, committer.filesInDir(files), meta)
+	if err != nil {
+		if err == git.ErrNoChanges {
+			return err
+		}
 		return errors.Wrap(err, "error committing changes")
 	}
 
-	if err == git.ErrNoChanges {
-		return err
-	} else {
-		// TODO: There's a race condition between the reset and push if a change occurs in the repo. Need to detect this error and retry.
-		err = committer.git.Push()
+	err = committer.git.Push(committer.ctx)
+	if err != nil {
+		return errors.Wrap(err, "error pushing changes")
+	}
 
-		if err != nil {
-			return errors.Wrap(err, "error pushing changes")
-		}
+	// The SHA is informational (e.g. for auditing) so we don't fail a commit that has already been pushed
+	sha, err := committer.git.HeadSha()
+	if err == nil {
+		committer.CommitShas = append(committer.CommitShas, sha)
 	}
 
 	return nil
 }
+
+func (committer *GitCommitter) filesInDir(files []core.ResourceFile) []core.ResourceFile {
+	if committer.dir == "" {
+		return files
+	}
+
+	result := make([]core.ResourceFile, len(files))
+	for i, file := range files {
+		result[i] = file
+		result[i].Name = path.Join(committer.dir, file.Name)
+	}
+	return result
+}
+
+// readerFunc adapts a func to the Reader interface
+type readerFunc func(dir string) ([]core.ResourceFile, error)
+
+func (fn readerFunc) ReadFiles(dir string) ([]core.ResourceFile, error) {
+	return fn(dir)
+}
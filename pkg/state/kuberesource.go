@@ -1,9 +1,5 @@
 package state
 
-import "k8s.io/apimachinery/pkg/runtime/schema"
+import "github.com/riser-platform/riser-server/pkg/state/resources"
 
-type KubeResource interface {
-	GetName() string
-	GetNamespace() string
-	GetObjectKind() schema.ObjectKind
-}
+type KubeResource = resources.KubeResource
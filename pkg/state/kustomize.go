@@ -0,0 +1,137 @@
+package state
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/util"
+)
+
+const kustomizationFileName = "kustomization.yaml"
+
+type kustomization struct {
+	APIVersion string                `json:"apiVersion"`
+	Kind       string                `json:"kind"`
+	Labels     []kustomizationLabels `json:"labels,omitempty"`
+	Resources  []string              `json:"resources"`
+}
+
+type kustomizationLabels struct {
+	Pairs map[string]string `json:"pairs"`
+	// Selectors are immutable for some resources (e.g. a Deployment) so labels are never added to them
+	IncludeSelectors bool `json:"includeSelectors"`
+}
+
+// RenderKustomizations renders a kustomization.yaml so that the riser managed state can be consumed by Kustomize (e.g. the Flux or
+// Argo CD Kustomize controllers). fileNames must include every file in the environment's state. A kustomization is rendered in the
+// riser managed folder, in each namespace folder, and in each folder that contains resources (e.g. a deployment). Each kustomization
+// lists the resources in its folder and the nearest subfolders that have a kustomization.
+func RenderKustomizations(environmentName string, fileNames []string) ([]core.ResourceFile, error) {
+	resourcesByDir := map[string][]string{}
+	for _, fileName := range fileNames {
+		if !isKustomizeResource(fileName) {
+			continue
+		}
+		dir := path.Dir(fileName)
+		resourcesByDir[dir] = append(resourcesByDir[dir], path.Base(fileName))
+		// Namespace folders always have a kustomization so that they can be patched as a whole
+		if namespaceDir := getNamespaceDir(dir); namespaceDir != "" {
+			if _, ok := resourcesByDir[namespaceDir]; !ok {
+				resourcesByDir[namespaceDir] = []string{}
+			}
+		}
+	}
+
+	if len(resourcesByDir) == 0 {
+		return []core.ResourceFile{}, nil
+	}
+	if _, ok := resourcesByDir[riserManagedStatePath]; !ok {
+		resourcesByDir[riserManagedStatePath] = []string{}
+	}
+
+	for dir := range resourcesByDir {
+		if dir == riserManagedStatePath {
+			continue
+		}
+		// Folders without a kustomization (e.g. {namespace}/deployments) are skipped
+		parentDir := path.Dir(dir)
+		for {
+			if _, ok := resourcesByDir[parentDir]; ok {
+				break
+			}
+			parentDir = path.Dir(parentDir)
+		}
+		resourcesByDir[parentDir] = append(resourcesByDir[parentDir], strings.TrimPrefix(dir, parentDir+"/"))
+	}
+
+	dirs := []string{}
+	for dir := range resourcesByDir {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	files := []core.ResourceFile{}
+	for _, dir := range dirs {
+		resources := resourcesByDir[dir]
+		sort.Strings(resources)
+		k := kustomization{
+			APIVersion: "kustomize.config.k8s.io/v1beta1",
+			Kind:       "Kustomization",
+			Resources:  resources,
+		}
+		if labels := getKustomizationLabels(environmentName, dir); labels != nil {
+			k.Labels = []kustomizationLabels{{Pairs: labels}}
+		}
+
+		serialized, err := util.ToYaml(k)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error serializing kustomization for %q", dir))
+		}
+		files = append(files, core.ResourceFile{
+			Name:     path.Join(dir, kustomizationFileName),
+			Contents: serialized,
+		})
+	}
+
+	return files, nil
+}
+
+// getKustomizationLabels returns the labels that are common to every resource in a folder
+func getKustomizationLabels(environmentName, dir string) map[string]string {
+	if dir == riserManagedStatePath {
+		return map[string]string{"riser.dev/environment": environmentName}
+	}
+
+	// e.g. {namespace}/deployments/{deployment}
+	parts := strings.Split(strings.TrimPrefix(dir, riserManagedStatePath+"/"), "/")
+	if len(parts) == 3 {
+		switch parts[1] {
+		case "deployments":
+			return map[string]string{"riser.dev/deployment": parts[2]}
+		case "secrets":
+			return map[string]string{"riser.dev/app": parts[2]}
+		}
+	}
+	return nil
+}
+
+// getNamespaceDir returns the namespace folder that contains a folder. Returns empty if the folder is not in a namespace folder.
+func getNamespaceDir(dir string) string {
+	if !strings.HasPrefix(dir, riserManagedStatePath+"/") {
+		return ""
+	}
+	namespace := strings.Split(strings.TrimPrefix(dir, riserManagedStatePath+"/"), "/")[0]
+	return path.Join(riserManagedStatePath, namespace)
+}
+
+func isKustomizeResource(fileName string) bool {
+	return strings.HasPrefix(fileName, riserManagedStatePath+"/") && path.Ext(fileName) == ".yaml" && !isKustomizationFile(fileName)
+}
+
+func isKustomizationFile(fileName string) bool {
+	return path.Base(fileName) == kustomizationFileName
+}
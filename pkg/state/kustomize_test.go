@@ -0,0 +1,71 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/riser-platform/riser-server/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RenderKustomizations(t *testing.T) {
+	fileNames := []string{
+		"riser-config/myns/myapp.yaml",
+		"state/riser-managed/namespace.myns.yaml",
+		"state/riser-managed/myns/deployments/myapp/service.myapp.yaml",
+		"state/riser-managed/myns/deployments/myapp/apps.deployment.myapp-1.yaml",
+		"state/riser-managed/myns/deployments/myapp/kustomization.yaml",
+		"state/riser-managed/myns/secrets/myapp/bitnami.com.sealedsecret.myapp-mysecret-1.yaml",
+		"state/riser-managed/otherns/deployments/otherapp/service.otherapp.yaml",
+	}
+
+	result, err := RenderKustomizations("dev", fileNames)
+
+	require.NoError(t, err)
+	require.Len(t, result, 6)
+	contents := map[string]string{}
+	for _, file := range result {
+		contents[file.Name] = string(file.Contents)
+	}
+
+	assert.Equal(t, util.YamlContentHeader+`apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+labels:
+- includeSelectors: false
+  pairs:
+    riser.dev/environment: dev
+resources:
+- myns
+- namespace.myns.yaml
+- otherns
+`, contents["state/riser-managed/kustomization.yaml"])
+
+	assert.Equal(t, util.YamlContentHeader+`apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+- deployments/myapp
+- secrets/myapp
+`, contents["state/riser-managed/myns/kustomization.yaml"])
+
+	assert.Equal(t, util.YamlContentHeader+`apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+labels:
+- includeSelectors: false
+  pairs:
+    riser.dev/deployment: myapp
+resources:
+- apps.deployment.myapp-1.yaml
+- service.myapp.yaml
+`, contents["state/riser-managed/myns/deployments/myapp/kustomization.yaml"])
+
+	assert.Contains(t, contents["state/riser-managed/myns/secrets/myapp/kustomization.yaml"], "riser.dev/app: myapp")
+	assert.Contains(t, contents["state/riser-managed/otherns/kustomization.yaml"], "- deployments/otherapp")
+	assert.Contains(t, contents, "state/riser-managed/otherns/deployments/otherapp/kustomization.yaml")
+}
+
+func Test_RenderKustomizations_NoResources(t *testing.T) {
+	result, err := RenderKustomizations("dev", []string{"riser-config/myns/myapp.yaml"})
+
+	require.NoError(t, err)
+	assert.Empty(t, result)
+}
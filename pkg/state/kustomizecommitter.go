@@ -0,0 +1,97 @@
+package state
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/riser-platform/riser-server/pkg/core"
+)
+
+// KustomizeCommitter maintains the kustomization files of an environment's state (see RenderKustomizations). The kustomization
+// files are always rendered from the state as it will be after each commit. When the committer is a PreparingCommitter the
+// kustomization files are rendered while the state is guarded against concurrent changes.
+type KustomizeCommitter struct {
+	environmentName string
+	committer       Committer
+	reader          Reader
+}
+
+func NewKustomizeCommitter(environmentName string, committer Committer, reader Reader) *KustomizeCommitter {
+	return &KustomizeCommitter{environmentName, committer, reader}
+}
+
+// Commit adds the kustomization files to the commit. Kustomization files that are no longer needed (e.g. for a deleted deployment)
+// are deleted. Any kustomization files in the commit are replaced.
+func (committer *KustomizeCommitter) Commit(message string, files []core.ResourceFile, meta *core.CommitMeta) error {
+	return committer.CommitPrepared(message, func(Reader) ([]core.ResourceFile, error) { return files, nil }, meta)
+}
+
+// CommitPrepared is like Commit, except that the files are prepared from the current state before the kustomization files are
+// rendered
+func (committer *KustomizeCommitter) CommitPrepared(message string, prepare PrepareFunc, meta *core.CommitMeta) error {
+	return CommitPrepared(committer.committer, committer.reader, message, func(current Reader) ([]core.ResourceFile, error) {
+		files, err := prepare(current)
+		if err != nil {
+			return nil, err
+		}
+		return committer.withKustomizations(files, current)
+	}, meta)
+}
+
+func (committer *KustomizeCommitter) withKustomizations(files []core.ResourceFile, reader Reader) ([]core.ResourceFile, error) {
+	current, err := reader.ReadFiles(riserManagedStatePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading the current state")
+	}
+
+	fileNames := map[string]bool{}
+	for _, file := range current {
+		fileNames[file.Name] = true
+	}
+
+	commitFiles := []core.ResourceFile{}
+	for _, file := range files {
+		if isKustomizationFile(file.Name) {
+			continue
+		}
+		commitFiles = append(commitFiles, file)
+		if file.Delete {
+			for name := range fileNames {
+				if name == file.Name || strings.HasPrefix(name, file.Name+"/") {
+					delete(fileNames, name)
+				}
+			}
+			continue
+		}
+		fileNames[file.Name] = true
+	}
+
+	names := []string{}
+	for name := range fileNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	kustomizations, err := RenderKustomizations(committer.environmentName, names)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered := map[string]bool{}
+	for _, file := range kustomizations {
+		rendered[file.Name] = true
+	}
+	commitFiles = append(commitFiles, kustomizations...)
+	for _, name := range names {
+		if isKustomizationFile(name) && !rendered[name] {
+			commitFiles = append(commitFiles, core.ResourceFile{Name: name, Delete: true})
+		}
+	}
+
+	return commitFiles, nil
+}
+
+func (committer *KustomizeCommitter) ReadFiles(dir string) ([]core.ResourceFile, error) {
+	return committer.reader.ReadFiles(dir)
+}
@@ -0,0 +1,160 @@
+package state
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_KustomizeCommitter_Commit(t *testing.T) {
+	dir := t.TempDir()
+	fileCommitter := NewFileCommitter(dir)
+	committer := NewKustomizeCommitter("dev", fileCommitter, fileCommitter)
+
+	err := committer.Commit("deploy", []core.ResourceFile{
+		{Name: "state/riser-managed/myns/deployments/myapp/service.myapp.yaml", Contents: []byte("myapp")},
+		{Name: "state/riser-managed/myns/deployments/otherapp/service.otherapp.yaml", Contents: []byte("otherapp")},
+		// Kustomization files are always rendered
+		{Name: "state/riser-managed/myns/kustomization.yaml", Contents: []byte("ignored")},
+	}, nil)
+	require.NoError(t, err)
+
+	contents, err := os.ReadFile(filepath.Join(dir, "state/riser-managed/myns/kustomization.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(contents), "- deployments/myapp\n- deployments/otherapp\n")
+	assert.FileExists(t, filepath.Join(dir, "state/riser-managed/myns/deployments/myapp/kustomization.yaml"))
+
+	err = committer.Commit("delete", []core.ResourceFile{
+		{Name: "state/riser-managed/myns/deployments/myapp", Delete: true},
+	}, nil)
+	require.NoError(t, err)
+
+	contents, err = os.ReadFile(filepath.Join(dir, "state/riser-managed/myns/kustomization.yaml"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(contents), "deployments/myapp")
+	assert.Contains(t, string(contents), "deployments/otherapp")
+	assert.NoDirExists(t, filepath.Join(dir, "state/riser-managed/myns/deployments/myapp"))
+}
+
+func Test_KustomizeCommitter_Commit_DeletesUnusedKustomizations(t *testing.T) {
+	dir := t.TempDir()
+	fileCommitter := NewFileCommitter(dir)
+	committer := NewKustomizeCommitter("dev", fileCommitter, fileCommitter)
+
+	err := committer.Commit("deploy", []core.ResourceFile{
+		{Name: "state/riser-managed/myns/deployments/myapp/service.myapp.yaml", Contents: []byte("myapp")},
+	}, nil)
+	require.NoError(t, err)
+
+	err = committer.Commit("delete", []core.ResourceFile{
+		{Name: "state/riser-managed/myns/deployments/myapp", Delete: true},
+	}, nil)
+	require.NoError(t, err)
+
+	assert.NoFileExists(t, filepath.Join(dir, "state/riser-managed/myns/kustomization.yaml"))
+	assert.NoFileExists(t, filepath.Join(dir, "state/riser-managed/kustomization.yaml"))
+}
+
+func Test_KustomizeCommitter_Commit_ReadErr(t *testing.T) {
+	gitCommitter := NewGitCommitter(context.Background(), &git.FakeRepo{
+		ResetHardRemoteFn: func(context.Context) error {
+			return nil
+		},
+		ReadFilesFn: func(string) ([]core.ResourceFile, error) {
+			return nil, assert.AnError
+		},
+	})
+	committer := NewKustomizeCommitter("dev", gitCommitter, gitCommitter)
+
+	err := committer.Commit("deploy", []core.ResourceFile{}, nil)
+
+	assert.Equal(t, "error reading the current state: "+assert.AnError.Error(), err.Error())
+}
+
+func Test_KustomizeCommitter_Commit_PushRejected_RendersFromLatestState(t *testing.T) {
+	repo := &git.FakeRepo{
+		ResetHardRemoteFn: func(context.Context) error {
+			return nil
+		},
+		HeadShaFn: func() (string, error) {
+			return "abc123", nil
+		},
+	}
+	// Another deployment is pushed to the remote before our first push
+	repo.ReadFilesFn = func(string) ([]core.ResourceFile, error) {
+		if repo.ResetHardRemoteCallCount == 1 {
+			return []core.ResourceFile{}, nil
+		}
+		return []core.ResourceFile{{Name: "state/riser-managed/myns/deployments/otherapp/service.otherapp.yaml"}}, nil
+	}
+	repo.PushFn = func(context.Context) error {
+		if repo.PushCallCount == 1 {
+			return git.ErrPushRejected
+		}
+		return nil
+	}
+	var committed []core.ResourceFile
+	repo.CommitFn = func(message string, files []core.ResourceFile, meta *core.CommitMeta) error {
+		committed = files
+		return nil
+	}
+	gitCommitter := NewGitCommitter(context.Background(), repo)
+	gitCommitter.sleep = func(time.Duration) {}
+	committer := NewKustomizeCommitter("dev", gitCommitter, gitCommitter)
+
+	err := committer.Commit("deploy", []core.ResourceFile{
+		{Name: "state/riser-managed/myns/deployments/myapp/service.myapp.yaml", Contents: []byte("myapp")},
+	}, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, repo.CommitCallCount)
+	var nsKustomization *core.ResourceFile
+	for i := range committed {
+		if committed[i].Name == "state/riser-managed/myns/kustomization.yaml" {
+			nsKustomization = &committed[i]
+		}
+	}
+	require.NotNil(t, nsKustomization)
+	assert.Contains(t, string(nsKustomization.Contents), "- deployments/myapp\n- deployments/otherapp\n")
+}
+
+func Test_KustomizeCommitter_CommitPrepared(t *testing.T) {
+	dir := t.TempDir()
+	fileCommitter := NewFileCommitter(dir)
+	committer := NewKustomizeCommitter("dev", fileCommitter, fileCommitter)
+	require.NoError(t, fileCommitter.Commit("deploy", []core.ResourceFile{
+		{Name: "state/riser-managed/myns/deployments/myapp/service.myapp.yaml", Contents: []byte("myapp")},
+	}, nil))
+
+	// The files are prepared from the current state
+	err := committer.CommitPrepared("gc", func(current Reader) ([]core.ResourceFile, error) {
+		files, err := current.ReadFiles("state/riser-managed/myns/deployments")
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+		return []core.ResourceFile{{Name: "state/riser-managed/myns/deployments/otherapp/service.otherapp.yaml", Contents: []byte("otherapp")}}, nil
+	}, nil)
+	require.NoError(t, err)
+
+	contents, err := os.ReadFile(filepath.Join(dir, "state/riser-managed/myns/kustomization.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(contents), "- deployments/myapp\n- deployments/otherapp\n")
+}
+
+func Test_KustomizeCommitter_CommitPrepared_NoChanges(t *testing.T) {
+	dryRunCommitter := NewDryRunCommitter()
+	committer := NewKustomizeCommitter("dev", dryRunCommitter, nil)
+
+	err := committer.CommitPrepared("gc", func(Reader) ([]core.ResourceFile, error) {
+		return nil, git.ErrNoChanges
+	}, nil)
+
+	assert.Equal(t, git.ErrNoChanges, err)
+	assert.Empty(t, dryRunCommitter.Commits)
+}
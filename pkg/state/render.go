@@ -13,7 +13,13 @@ import (
 	"github.com/riser-platform/riser-server/pkg/util"
 )
 
-const riserManagedStatePath = "state/riser-managed"
+const (
+	riserManagedStatePath = "state/riser-managed"
+	riserConfigPath       = "riser-config"
+)
+
+// ManagedDirs are the folders whose contents are entirely owned by riser
+var ManagedDirs = []string{riserManagedStatePath, riserConfigPath}
 
 type getResourcePathFunc func(resource KubeResource) string
 
@@ -37,6 +43,14 @@ func RenderGeneric(environmentName string, resources ...KubeResource) ([]core.Re
 	}, resources...)
 }
 
+// RenderServiceAccount renders an app's service account. Service accounts are shared by the app's deployments so they are placed in the
+// namespace's service accounts folder rather than a deployment's folder.
+func RenderServiceAccount(serviceAccount KubeResource) ([]core.ResourceFile, error) {
+	return renderKubeResources(func(resource KubeResource) string {
+		return getServiceAccountScmPath(resource)
+	}, serviceAccount)
+}
+
 func RenderSealedSecret(app, environmentName string, sealedSecret *resources.SealedSecret) ([]core.ResourceFile, error) {
 	return renderKubeResources(func(resource KubeResource) string {
 		return getSecretScmPath(app, environmentName, sealedSecret)
@@ -62,11 +76,23 @@ func RenderDeployment(deployment *core.DeploymentConfig, deploymentResources ...
 	return files, nil
 }
 
-// RenderRoute renders just the route resource.
-func RenderRoute(deploymentName, namespace, environmentName string, resource KubeResource) ([]core.ResourceFile, error) {
+// RenderDeleteResources renders the deletion of resources from a deployment's git folder
+func RenderDeleteResources(deploymentName, namespace string, deleteResources ...KubeResource) []core.ResourceFile {
+	files := []core.ResourceFile{}
+	for _, resource := range deleteResources {
+		files = append(files, core.ResourceFile{
+			Name:   getDeploymentScmPath(deploymentName, namespace, "", resource),
+			Delete: true,
+		})
+	}
+	return files
+}
+
+// RenderRoute renders just the resources that route traffic to a deployment (see resources.Renderer.TrafficResources)
+func RenderRoute(deploymentName, namespace, environmentName string, routeResources ...KubeResource) ([]core.ResourceFile, error) {
 	files, err := renderKubeResources(func(resource KubeResource) string {
 		return getDeploymentScmPath(deploymentName, namespace, environmentName, resource)
-	}, resource)
+	}, filterNilResources(routeResources...)...)
 
 	if err != nil {
 		return nil, err
@@ -116,16 +142,30 @@ func getDeploymentScmPath(deploymentName, namespace, environmentName string, res
 
 func getSecretScmPath(app string, environmentName string, sealedSecret KubeResource) string {
 	return strings.ToLower(filepath.Join(
-		riserManagedStatePath,
-		sealedSecret.GetNamespace(),
-		"secrets",
+		SecretsDir(sealedSecret.GetNamespace()),
 		app,
 		getFileNameFromResource(sealedSecret)))
 }
 
+// SecretsDir is the folder that contains the sealed secrets of every app in a namespace
+func SecretsDir(namespace string) string {
+	return strings.ToLower(filepath.Join(riserManagedStatePath, namespace, "secrets"))
+}
+
+// ServiceAccountsDir is the folder that contains the service accounts of every app in a namespace
+func ServiceAccountsDir(namespace string) string {
+	return strings.ToLower(filepath.Join(riserManagedStatePath, namespace, "serviceaccounts"))
+}
+
+func getServiceAccountScmPath(serviceAccount KubeResource) string {
+	return strings.ToLower(filepath.Join(
+		ServiceAccountsDir(serviceAccount.GetNamespace()),
+		getFileNameFromResource(serviceAccount)))
+}
+
 func getAppConfigScmPath(deploymentName, namespace string) string {
 	return strings.ToLower(filepath.Join(
-		"riser-config",
+		riserConfigPath,
 		namespace,
 		fmt.Sprintf("%s.yaml", deploymentName)))
 }
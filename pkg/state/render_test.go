@@ -65,6 +65,15 @@ func Test_getSecretScmPath(t *testing.T) {
 	assert.Equal(t, "state/riser-managed/apps/secrets/myapp/sealedsecret.myapp-mysecret.yaml", result)
 }
 
+func Test_RenderServiceAccount(t *testing.T) {
+	result, err := RenderServiceAccount(resources.CreateServiceAccount("myapp", "apps"))
+
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	assert.Equal(t, "state/riser-managed/apps/serviceaccounts/serviceaccount.myapp.yaml", result[0].Name)
+	assert.Contains(t, string(result[0].Contents), "kind: ServiceAccount")
+}
+
 func Test_RenderDeployment(t *testing.T) {
 	deployment := &core.DeploymentConfig{
 		Name:            "mydeployment",
@@ -3,6 +3,7 @@ package resources
 import (
 	"fmt"
 
+	"github.com/riser-platform/riser-server/api/v1/model"
 	"github.com/riser-platform/riser-server/pkg/core"
 	securityv1beta1 "istio.io/api/security/v1beta1"
 	typev1beta1 "istio.io/api/type/v1beta1"
@@ -15,6 +16,12 @@ func CreateHealthcheckDenyPolicy(dCtx *core.DeploymentContext) *v1beta1.Authoriz
 		return nil
 	}
 
+	// Only HTTP probes expose a path that could be reached through the mesh
+	paths := dCtx.DeploymentConfig.App.HealthCheck.HttpPaths()
+	if len(paths) == 0 {
+		return nil
+	}
+
 	return &v1beta1.AuthorizationPolicy{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        fmt.Sprintf("%s-healthcheck-deny", dCtx.DeploymentConfig.Name),
@@ -38,7 +45,7 @@ func CreateHealthcheckDenyPolicy(dCtx *core.DeploymentContext) *v1beta1.Authoriz
 					To: []*securityv1beta1.Rule_To{
 						{
 							Operation: &securityv1beta1.Operation{
-								Paths: []string{dCtx.DeploymentConfig.App.HealthCheck.Path},
+								Paths: paths,
 							},
 						},
 					},
@@ -47,3 +54,89 @@ func CreateHealthcheckDenyPolicy(dCtx *core.DeploymentContext) *v1beta1.Authoriz
 		},
 	}
 }
+
+// CreateAccessPolicy creates an ALLOW policy for the sources in the app's access config. Once an ALLOW policy exists Istio denies
+// any request to the deployment that the policy does not allow. The systemRules allow the components that route requests to the
+// deployment (e.g. an ingress gateway) or that must otherwise reach it (see systemNamespaceRule). Returns nil when the app does not
+// restrict access.
+func CreateAccessPolicy(dCtx *core.DeploymentContext, systemRules ...*securityv1beta1.Rule) *v1beta1.AuthorizationPolicy {
+	access := dCtx.DeploymentConfig.App.Access
+	if access == nil {
+		return nil
+	}
+
+	rules := []*securityv1beta1.Rule{}
+	for _, source := range access.From {
+		rules = append(rules, createAccessRule(dCtx, source))
+	}
+	rules = append(rules, systemRules...)
+
+	return &v1beta1.AuthorizationPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("%s-access", dCtx.DeploymentConfig.Name),
+			Namespace:   dCtx.DeploymentConfig.Namespace,
+			Labels:      deploymentLabels(dCtx),
+			Annotations: deploymentAnnotations(dCtx),
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "AuthorizationPolicy",
+			APIVersion: "security.istio.io/v1beta1",
+		},
+		Spec: securityv1beta1.AuthorizationPolicy{
+			// ALLOW is the default action so it is omitted from the rendered policy. A policy without rules allows nothing.
+			Action: securityv1beta1.AuthorizationPolicy_ALLOW,
+			Selector: &typev1beta1.WorkloadSelector{
+				MatchLabels: map[string]string{
+					riserLabel("deployment"): dCtx.DeploymentConfig.Name,
+				},
+			},
+			Rules: rules,
+		},
+	}
+}
+
+// systemNamespaceRule allows a system namespace to call the deployment. Any port may be called when ports is empty.
+func systemNamespaceRule(namespace string, ports ...string) *securityv1beta1.Rule {
+	rule := &securityv1beta1.Rule{
+		From: []*securityv1beta1.Rule_From{
+			{Source: &securityv1beta1.Source{Namespaces: []string{namespace}}},
+		},
+	}
+	if len(ports) > 0 {
+		rule.To = []*securityv1beta1.Rule_To{
+			{Operation: &securityv1beta1.Operation{Ports: ports}},
+		}
+	}
+	return rule
+}
+
+func createAccessRule(dCtx *core.DeploymentContext, accessSource model.AppConfigAccessSource) *securityv1beta1.Rule {
+	namespace := accessSource.Namespace
+	if namespace == "" {
+		namespace = dCtx.DeploymentConfig.Namespace
+	}
+
+	// An app is identified by its service account (see CreateServiceAccount). The trust domain is not matched so that the policy
+	// does not depend on the mesh's configuration.
+	source := &securityv1beta1.Source{}
+	if accessSource.App == "" {
+		source.Namespaces = []string{namespace}
+	} else {
+		source.Principals = []string{fmt.Sprintf("*/ns/%s/sa/%s", namespace, accessSource.App)}
+	}
+
+	rule := &securityv1beta1.Rule{
+		From: []*securityv1beta1.Rule_From{{Source: source}},
+	}
+	if len(accessSource.Paths) > 0 || len(accessSource.Methods) > 0 {
+		rule.To = []*securityv1beta1.Rule_To{
+			{
+				Operation: &securityv1beta1.Operation{
+					Paths:   accessSource.Paths,
+					Methods: accessSource.Methods,
+				},
+			},
+		}
+	}
+	return rule
+}
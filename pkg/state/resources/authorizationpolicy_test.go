@@ -6,6 +6,7 @@ import (
 	"github.com/riser-platform/riser-server/api/v1/model"
 	"github.com/riser-platform/riser-server/pkg/core"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_createHealthcheckDenyPolicy(t *testing.T) {
@@ -49,3 +50,137 @@ func Test_createHealthcheckDenyPolicy_NoHealthcheckReturnsNil(t *testing.T) {
 
 	assert.Nil(t, result)
 }
+
+func Test_createHealthcheckDenyPolicy_AllHttpProbes(t *testing.T) {
+	ctx := &core.DeploymentContext{
+		DeploymentConfig: &core.DeploymentConfig{
+			Name:            "myapp-dep",
+			EnvironmentName: "myenv",
+			App: &model.AppConfig{
+				Name: "myapp",
+				HealthCheck: &model.AppConfigHealthCheck{
+					Path: "/ready",
+					Liveness: &model.AppConfigProbe{
+						Path: "/live",
+					},
+					Startup: &model.AppConfigProbe{
+						Mode: model.AppHealthCheckMode_TcpSocket,
+					},
+				},
+			},
+		},
+	}
+
+	result := CreateHealthcheckDenyPolicy(ctx)
+
+	assert.Equal(t, []string{"/ready", "/live"}, result.Spec.Rules[0].To[0].Operation.Paths)
+}
+
+func Test_createHealthcheckDenyPolicy_NoHttpProbesReturnsNil(t *testing.T) {
+	ctx := &core.DeploymentContext{
+		DeploymentConfig: &core.DeploymentConfig{
+			Name:            "myapp-dep",
+			EnvironmentName: "myenv",
+			App: &model.AppConfig{
+				Name: "myapp",
+				HealthCheck: &model.AppConfigHealthCheck{
+					Mode: model.AppHealthCheckMode_Exec,
+					Liveness: &model.AppConfigProbe{
+						Mode: model.AppHealthCheckMode_TcpSocket,
+					},
+				},
+			},
+		},
+	}
+
+	result := CreateHealthcheckDenyPolicy(ctx)
+
+	assert.Nil(t, result)
+}
+
+func Test_CreateAccessPolicy(t *testing.T) {
+	ctx := &core.DeploymentContext{
+		DeploymentConfig: &core.DeploymentConfig{
+			Name:            "myapp-dep",
+			Namespace:       "myns",
+			EnvironmentName: "myenv",
+			App: &model.AppConfig{
+				Name: "myapp",
+				Access: &model.AppConfigAccess{
+					From: []model.AppConfigAccessSource{
+						{App: "otherapp"},
+						{App: "remoteapp", Namespace: "otherns", Paths: []string{"/api/*"}, Methods: []string{"GET"}},
+						{Namespace: "monitoring", Paths: []string{"/metrics"}},
+					},
+				},
+			},
+		},
+	}
+
+	result := CreateAccessPolicy(ctx, systemNamespaceRule("knative-serving", "9090"))
+
+	assert.Equal(t, "myapp-dep-access", result.Name)
+	assert.Equal(t, "myns", result.Namespace)
+	assert.Equal(t, deploymentLabels(ctx), result.Labels)
+	assert.Equal(t, deploymentAnnotations(ctx), result.Annotations)
+	assert.Equal(t, "AuthorizationPolicy", result.TypeMeta.Kind)
+	assert.Equal(t, "security.istio.io/v1beta1", result.TypeMeta.APIVersion)
+	assert.Equal(t, "myapp-dep", result.Spec.Selector.MatchLabels["riser.dev/deployment"])
+	assert.Equal(t, "ALLOW", result.Spec.Action.String())
+	require.Len(t, result.Spec.Rules, 4)
+
+	assert.Equal(t, []string{"*/ns/myns/sa/otherapp"}, result.Spec.Rules[0].From[0].Source.Principals)
+	assert.Empty(t, result.Spec.Rules[0].From[0].Source.Namespaces)
+	assert.Empty(t, result.Spec.Rules[0].To)
+
+	assert.Equal(t, []string{"*/ns/otherns/sa/remoteapp"}, result.Spec.Rules[1].From[0].Source.Principals)
+	assert.Equal(t, []string{"/api/*"}, result.Spec.Rules[1].To[0].Operation.Paths)
+	assert.Equal(t, []string{"GET"}, result.Spec.Rules[1].To[0].Operation.Methods)
+
+	assert.Equal(t, []string{"monitoring"}, result.Spec.Rules[2].From[0].Source.Namespaces)
+	assert.Empty(t, result.Spec.Rules[2].From[0].Source.Principals)
+	assert.Equal(t, []string{"/metrics"}, result.Spec.Rules[2].To[0].Operation.Paths)
+	assert.Empty(t, result.Spec.Rules[2].To[0].Operation.Methods)
+
+	assert.Equal(t, []string{"knative-serving"}, result.Spec.Rules[3].From[0].Source.Namespaces)
+	assert.Equal(t, []string{"9090"}, result.Spec.Rules[3].To[0].Operation.Ports)
+}
+
+func Test_systemNamespaceRule_AnyPort(t *testing.T) {
+	result := systemNamespaceRule("istio-system")
+
+	assert.Equal(t, []string{"istio-system"}, result.From[0].Source.Namespaces)
+	assert.Empty(t, result.To)
+}
+
+func Test_CreateAccessPolicy_NoSourcesDeniesAll(t *testing.T) {
+	ctx := &core.DeploymentContext{
+		DeploymentConfig: &core.DeploymentConfig{
+			Name: "myapp-dep",
+			App: &model.AppConfig{
+				Name:   "myapp",
+				Access: &model.AppConfigAccess{From: []model.AppConfigAccessSource{}},
+			},
+		},
+	}
+
+	result := CreateAccessPolicy(ctx)
+
+	assert.Equal(t, "ALLOW", result.Spec.Action.String())
+	assert.Empty(t, result.Spec.Rules)
+}
+
+func Test_CreateAccessPolicy_NoAccessReturnsNil(t *testing.T) {
+	ctx := &core.DeploymentContext{
+		DeploymentConfig: &core.DeploymentConfig{
+			Name: "myapp-dep",
+			App: &model.AppConfig{
+				Name: "myapp",
+			},
+		},
+	}
+
+	result := CreateAccessPolicy(ctx, systemNamespaceRule("knative-serving"))
+
+	assert.Nil(t, result)
+}
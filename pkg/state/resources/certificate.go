@@ -0,0 +1,57 @@
+package resources
+
+import (
+	"github.com/riser-platform/riser-server/pkg/core"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Certificate is a cert-manager certificate. Only the fields that riser renders are included.
+type Certificate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec CertificateSpec `json:"spec"`
+}
+
+type CertificateSpec struct {
+	SecretName string               `json:"secretName"`
+	DNSNames   []string             `json:"dnsNames"`
+	IssuerRef  CertificateIssuerRef `json:"issuerRef"`
+}
+
+type CertificateIssuerRef struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+// CreateCertificates creates a certificate for each of the app's domains using the environment's certificate issuer
+func CreateCertificates(ctx *core.DeploymentContext) []*Certificate {
+	issuerName := ""
+	if ctx.EnvironmentConfig != nil {
+		issuerName = ctx.EnvironmentConfig.CertificateIssuer
+	}
+	certificates := []*Certificate{}
+	for _, domain := range ctx.DeploymentConfig.App.Domains {
+		certificates = append(certificates, &Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        domain,
+				Namespace:   ctx.DeploymentConfig.Namespace,
+				Labels:      deploymentLabels(ctx),
+				Annotations: deploymentAnnotations(ctx),
+			},
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Certificate",
+				APIVersion: "cert-manager.io/v1",
+			},
+			Spec: CertificateSpec{
+				SecretName: domainTLSSecretName(domain),
+				DNSNames:   []string{domain},
+				IssuerRef: CertificateIssuerRef{
+					Name: issuerName,
+					Kind: "ClusterIssuer",
+				},
+			},
+		})
+	}
+	return certificates
+}
@@ -0,0 +1,28 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CreateCertificates(t *testing.T) {
+	ctx := newDomainTestContext("myapp.example.com")
+
+	result := CreateCertificates(ctx)
+
+	require.Len(t, result, 1)
+	assert.Equal(t, "Certificate", result[0].Kind)
+	assert.Equal(t, "cert-manager.io/v1", result[0].APIVersion)
+	assert.Equal(t, "myapp.example.com", result[0].Name)
+	assert.Equal(t, "myns", result[0].Namespace)
+	assert.Equal(t, deploymentLabels(ctx), result[0].Labels)
+	assert.Equal(t, "myapp.example.com-tls", result[0].Spec.SecretName)
+	assert.Equal(t, []string{"myapp.example.com"}, result[0].Spec.DNSNames)
+	assert.Equal(t, CertificateIssuerRef{Name: "letsencrypt", Kind: "ClusterIssuer"}, result[0].Spec.IssuerRef)
+}
+
+func Test_CreateCertificates_NoDomains(t *testing.T) {
+	assert.Empty(t, CreateCertificates(newDomainTestContext()))
+}
@@ -3,7 +3,9 @@ package resources
 import (
 	"fmt"
 
+	"github.com/riser-platform/riser-server/api/v1/model"
 	"github.com/riser-platform/riser-server/pkg/core"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
 )
@@ -33,9 +35,7 @@ func CreateKNativeConfiguration(ctx *core.DeploymentContext) *servingv1.Configur
 		Spec: servingv1.ConfigurationSpec{
 			Template: servingv1.RevisionTemplateSpec{
 				ObjectMeta: revisionMeta,
-				Spec: servingv1.RevisionSpec{
-					PodSpec: podSpec,
-				},
+				Spec:       createRevisionSpec(ctx, podSpec),
 			},
 		},
 	}
@@ -47,14 +47,51 @@ func createRevisionMeta(ctx *core.DeploymentContext) metav1.ObjectMeta {
 		Labels:      deploymentLabels(ctx),
 		Annotations: deploymentAnnotations(ctx),
 	}
-	if ctx.DeploymentConfig.App.Autoscale != nil {
-		if ctx.DeploymentConfig.App.Autoscale.Min != nil {
-			revisionMeta.Annotations["autoscaling.knative.dev/minScale"] = fmt.Sprintf("%d", *ctx.DeploymentConfig.App.Autoscale.Min)
+	autoscale := ctx.DeploymentConfig.App.Autoscale
+	if autoscale != nil {
+		if autoscale.Min != nil {
+			revisionMeta.Annotations["autoscaling.knative.dev/minScale"] = fmt.Sprintf("%d", *autoscale.Min)
 		}
-		if ctx.DeploymentConfig.App.Autoscale.Max != nil {
-			revisionMeta.Annotations["autoscaling.knative.dev/maxScale"] = fmt.Sprintf("%d", *ctx.DeploymentConfig.App.Autoscale.Max)
+		if autoscale.Max != nil {
+			revisionMeta.Annotations["autoscaling.knative.dev/maxScale"] = fmt.Sprintf("%d", *autoscale.Max)
+		}
+		if autoscale.Metric != "" {
+			revisionMeta.Annotations["autoscaling.knative.dev/metric"] = autoscale.Metric
+		}
+		// The KNative Pod Autoscaler does not support the cpu metric
+		if autoscale.Metric == model.AppAutoscaleMetric_Cpu {
+			revisionMeta.Annotations["autoscaling.knative.dev/class"] = "hpa.autoscaling.knative.dev"
+		}
+		if autoscale.Target != nil {
+			revisionMeta.Annotations["autoscaling.knative.dev/target"] = fmt.Sprintf("%d", *autoscale.Target)
+		}
+		if autoscale.ScaleDownDelaySeconds != nil {
+			revisionMeta.Annotations["autoscaling.knative.dev/scale-down-delay"] = fmt.Sprintf("%ds", *autoscale.ScaleDownDelaySeconds)
+		}
+		if autoscale.PanicWindowPercentage != nil {
+			revisionMeta.Annotations["autoscaling.knative.dev/panic-window-percentage"] = fmt.Sprintf("%d", *autoscale.PanicWindowPercentage)
 		}
 	}
 
+	// Keeps the activator out of the request path of an app with access (see knativeSystemRules)
+	if ctx.DeploymentConfig.App.Access != nil {
+		if autoscale == nil || autoscale.Min == nil || *autoscale.Min < 1 {
+			revisionMeta.Annotations["autoscaling.knative.dev/minScale"] = "1"
+		}
+		revisionMeta.Annotations["autoscaling.knative.dev/target-burst-capacity"] = "0"
+	}
+
 	return revisionMeta
 }
+
+func createRevisionSpec(ctx *core.DeploymentContext, podSpec corev1.PodSpec) servingv1.RevisionSpec {
+	revisionSpec := servingv1.RevisionSpec{
+		PodSpec: podSpec,
+	}
+	if ctx.DeploymentConfig.App.Request != nil {
+		revisionSpec.ContainerConcurrency = ctx.DeploymentConfig.App.Request.ContainerConcurrency
+		revisionSpec.TimeoutSeconds = ctx.DeploymentConfig.App.Request.TimeoutSeconds
+	}
+
+	return revisionSpec
+}
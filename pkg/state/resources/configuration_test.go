@@ -54,3 +54,79 @@ func Test_createRevisionMeta_Autoscale(t *testing.T) {
 	assert.Equal(t, "1", result.Annotations["riser.dev/revision"])
 	assert.Equal(t, util.VersionString, result.Annotations["riser.dev/server-version"])
 }
+
+func Test_createRevisionMeta_Access(t *testing.T) {
+	ctx := newRendererTestContext()
+
+	result := createRevisionMeta(ctx)
+
+	assert.Equal(t, "1", result.Annotations["autoscaling.knative.dev/minScale"], "the activator is required to scale from zero")
+	assert.Equal(t, "0", result.Annotations["autoscaling.knative.dev/target-burst-capacity"])
+
+	ctx.DeploymentConfig.App.Autoscale.Min = util.PtrInt(0)
+
+	result = createRevisionMeta(ctx)
+
+	assert.Equal(t, "1", result.Annotations["autoscaling.knative.dev/minScale"])
+
+	ctx.DeploymentConfig.App.Autoscale.Min = util.PtrInt(3)
+
+	result = createRevisionMeta(ctx)
+
+	assert.Equal(t, "3", result.Annotations["autoscaling.knative.dev/minScale"])
+}
+
+func Test_createRevisionMeta_AutoscaleMetric(t *testing.T) {
+	ctx := newRendererTestContext()
+	ctx.DeploymentConfig.App.Autoscale = &model.AppConfigAutoscale{
+		Metric:                model.AppAutoscaleMetric_Rps,
+		Target:                util.PtrInt(150),
+		ScaleDownDelaySeconds: util.PtrInt(300),
+		PanicWindowPercentage: util.PtrInt(20),
+	}
+
+	result := createRevisionMeta(ctx)
+
+	assert.Equal(t, "rps", result.Annotations["autoscaling.knative.dev/metric"])
+	assert.Equal(t, "150", result.Annotations["autoscaling.knative.dev/target"])
+	assert.Equal(t, "300s", result.Annotations["autoscaling.knative.dev/scale-down-delay"])
+	assert.Equal(t, "20", result.Annotations["autoscaling.knative.dev/panic-window-percentage"])
+	assert.NotContains(t, result.Annotations, "autoscaling.knative.dev/class")
+}
+
+func Test_createRevisionMeta_AutoscaleCpuMetric(t *testing.T) {
+	ctx := newRendererTestContext()
+	ctx.DeploymentConfig.App.Autoscale = &model.AppConfigAutoscale{
+		Metric: model.AppAutoscaleMetric_Cpu,
+		Target: util.PtrInt(75),
+	}
+
+	result := createRevisionMeta(ctx)
+
+	assert.Equal(t, "cpu", result.Annotations["autoscaling.knative.dev/metric"])
+	assert.Equal(t, "hpa.autoscaling.knative.dev", result.Annotations["autoscaling.knative.dev/class"])
+	assert.Equal(t, "75", result.Annotations["autoscaling.knative.dev/target"])
+}
+
+func Test_createRevisionSpec(t *testing.T) {
+	ctx := newRendererTestContext()
+	ctx.DeploymentConfig.App.Request = &model.AppConfigRequest{
+		ContainerConcurrency: util.PtrInt64(10),
+		TimeoutSeconds:       util.PtrInt64(30),
+	}
+
+	result := createRevisionSpec(ctx, createPodSpec(ctx))
+
+	assert.Equal(t, util.PtrInt64(10), result.ContainerConcurrency)
+	assert.Equal(t, util.PtrInt64(30), result.TimeoutSeconds)
+	assert.Equal(t, "myapp", result.Containers[0].Name)
+}
+
+func Test_createRevisionSpec_NoRequest(t *testing.T) {
+	ctx := newRendererTestContext()
+
+	result := createRevisionSpec(ctx, createPodSpec(ctx))
+
+	assert.Nil(t, result.ContainerConcurrency)
+	assert.Nil(t, result.TimeoutSeconds)
+}
@@ -0,0 +1,94 @@
+package resources
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/util"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// CreateDeployment creates a Deployment for a single revision. Each revision has its own Deployment so that traffic can be split
+// between revisions.
+func CreateDeployment(ctx *core.DeploymentContext) *appsv1.Deployment {
+	podSpec := createPodSpec(ctx)
+	// KNative sets the probe port to the container port but Kubernetes does not
+	container := &podSpec.Containers[0]
+	for _, probe := range []*corev1.Probe{container.ReadinessProbe, container.LivenessProbe, container.StartupProbe} {
+		setProbePort(probe, ctx.DeploymentConfig.App.Expose.ContainerPort)
+	}
+
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        revisionName(ctx),
+			Namespace:   ctx.DeploymentConfig.Namespace,
+			Labels:      deploymentLabels(ctx),
+			Annotations: deploymentAnnotations(ctx),
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Deployment",
+			APIVersion: "apps/v1",
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: deploymentReplicas(ctx),
+			Selector: &metav1.LabelSelector{
+				MatchLabels: revisionSelector(ctx),
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      revisionLabels(ctx),
+					Annotations: deploymentAnnotations(ctx),
+				},
+				Spec: podSpec,
+			},
+		},
+	}
+}
+
+// deploymentReplicas returns nil when the replicas are managed by a HorizontalPodAutoscaler
+func deploymentReplicas(ctx *core.DeploymentContext) *int32 {
+	autoscale := ctx.DeploymentConfig.App.Autoscale
+	if autoscale != nil && autoscale.Max != nil {
+		return nil
+	}
+	if autoscale != nil && autoscale.Min != nil && *autoscale.Min > 1 {
+		return util.PtrInt32(int32(*autoscale.Min))
+	}
+	// Unlike KNative a Deployment cannot scale to zero
+	return util.PtrInt32(1)
+}
+
+// revisionName is the name of the resources for a single revision. This matches the KNative revision name.
+func revisionName(ctx *core.DeploymentContext) string {
+	return fmt.Sprintf("%s-%d", ctx.DeploymentConfig.Name, ctx.RiserRevision)
+}
+
+// revisionSelector selects the pods of a single revision
+func revisionSelector(ctx *core.DeploymentContext) map[string]string {
+	return map[string]string{
+		riserLabel("deployment"): ctx.DeploymentConfig.Name,
+		riserLabel("revision"):   strconv.FormatInt(ctx.RiserRevision, 10),
+	}
+}
+
+func revisionLabels(ctx *core.DeploymentContext) map[string]string {
+	labels := deploymentLabels(ctx)
+	labels[riserLabel("revision")] = strconv.FormatInt(ctx.RiserRevision, 10)
+	return labels
+}
+
+func setProbePort(probe *corev1.Probe, containerPort int32) {
+	if probe == nil {
+		return
+	}
+	if probe.HTTPGet != nil {
+		probe.HTTPGet.Port = intstr.FromInt(int(containerPort))
+	}
+	if probe.TCPSocket != nil {
+		probe.TCPSocket.Port = intstr.FromInt(int(containerPort))
+	}
+}
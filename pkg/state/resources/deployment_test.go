@@ -0,0 +1,71 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func Test_CreateDeployment(t *testing.T) {
+	ctx := newRendererTestContext()
+
+	result := CreateDeployment(ctx)
+
+	assert.Equal(t, "myapp-2", result.Name)
+	assert.Equal(t, "myns", result.Namespace)
+	assert.Equal(t, deploymentLabels(ctx), result.Labels)
+	assert.Equal(t, deploymentAnnotations(ctx), result.Annotations)
+	assert.Equal(t, "Deployment", result.TypeMeta.Kind)
+	assert.Equal(t, "apps/v1", result.TypeMeta.APIVersion)
+	assert.Equal(t, map[string]string{"riser.dev/deployment": "myapp", "riser.dev/revision": "2"}, result.Spec.Selector.MatchLabels)
+	assert.Equal(t, "2", result.Spec.Template.Labels["riser.dev/revision"])
+	assert.Equal(t, "myapp", result.Spec.Template.Labels["riser.dev/app"])
+	assert.Equal(t, deploymentAnnotations(ctx), result.Spec.Template.Annotations)
+	assert.Equal(t, "myimage:0.0.1", result.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, intstr.FromInt(8080), result.Spec.Template.Spec.Containers[0].ReadinessProbe.HTTPGet.Port)
+}
+
+func Test_CreateDeployment_ProbePorts(t *testing.T) {
+	ctx := newRendererTestContext()
+	ctx.DeploymentConfig.App.HealthCheck.Liveness = &model.AppConfigProbe{Mode: model.AppHealthCheckMode_TcpSocket}
+	ctx.DeploymentConfig.App.HealthCheck.Startup = &model.AppConfigProbe{Mode: model.AppHealthCheckMode_Exec, Command: []string{"true"}}
+
+	result := CreateDeployment(ctx)
+
+	container := result.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, intstr.FromInt(8080), container.ReadinessProbe.HTTPGet.Port)
+	assert.Equal(t, intstr.FromInt(8080), container.LivenessProbe.TCPSocket.Port)
+	assert.Equal(t, []string{"true"}, container.StartupProbe.Exec.Command)
+}
+
+func Test_deploymentReplicas(t *testing.T) {
+	tests := []struct {
+		autoscale *model.AppConfigAutoscale
+		expected  *int32
+	}{
+		{nil, util.PtrInt32(1)},
+		{&model.AppConfigAutoscale{}, util.PtrInt32(1)},
+		{&model.AppConfigAutoscale{Min: util.PtrInt(0)}, util.PtrInt32(1)},
+		{&model.AppConfigAutoscale{Min: util.PtrInt(3)}, util.PtrInt32(3)},
+		// Managed by the HorizontalPodAutoscaler
+		{&model.AppConfigAutoscale{Min: util.PtrInt(3), Max: util.PtrInt(5)}, nil},
+	}
+
+	for _, tt := range tests {
+		ctx := &core.DeploymentContext{
+			DeploymentConfig: &core.DeploymentConfig{
+				App: &model.AppConfig{
+					OverrideableAppConfig: model.OverrideableAppConfig{
+						Autoscale: tt.autoscale,
+					},
+				},
+			},
+		}
+
+		assert.Equal(t, tt.expected, deploymentReplicas(ctx))
+	}
+}
@@ -0,0 +1,46 @@
+package resources
+
+import (
+	"fmt"
+
+	"github.com/riser-platform/riser-server/pkg/core"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	servingv1beta1 "knative.dev/serving/pkg/apis/serving/v1beta1"
+)
+
+// CreateDomainMappings creates a DomainMapping for each of the app's domains that routes the domain to the deployment's KNative Route
+func CreateDomainMappings(ctx *core.DeploymentContext) []*servingv1beta1.DomainMapping {
+	domainMappings := []*servingv1beta1.DomainMapping{}
+	for _, domain := range ctx.DeploymentConfig.App.Domains {
+		domainMappings = append(domainMappings, &servingv1beta1.DomainMapping{
+			ObjectMeta: metav1.ObjectMeta{
+				// KNative requires the name of a DomainMapping to be the domain
+				Name:        domain,
+				Namespace:   ctx.DeploymentConfig.Namespace,
+				Labels:      deploymentLabels(ctx),
+				Annotations: deploymentAnnotations(ctx),
+			},
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "DomainMapping",
+				APIVersion: "serving.knative.dev/v1beta1",
+			},
+			Spec: servingv1beta1.DomainMappingSpec{
+				Ref: duckv1.KReference{
+					Kind:       "Route",
+					APIVersion: "serving.knative.dev/v1",
+					Name:       ctx.DeploymentConfig.Name,
+				},
+				TLS: &servingv1beta1.SecretTLS{
+					SecretName: domainTLSSecretName(domain),
+				},
+			},
+		})
+	}
+	return domainMappings
+}
+
+// domainTLSSecretName is the name of the secret that cert-manager stores the certificate for a domain in
+func domainTLSSecretName(domain string) string {
+	return fmt.Sprintf("%s-tls", domain)
+}
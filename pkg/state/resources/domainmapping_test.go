@@ -0,0 +1,50 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CreateDomainMappings(t *testing.T) {
+	ctx := newDomainTestContext("a.example.com", "b.example.com")
+
+	result := CreateDomainMappings(ctx)
+
+	require.Len(t, result, 2)
+	assert.Equal(t, "a.example.com", result[0].Name)
+	assert.Equal(t, "myns", result[0].Namespace)
+	assert.Equal(t, deploymentLabels(ctx), result[0].Labels)
+	assert.Equal(t, deploymentAnnotations(ctx), result[0].Annotations)
+	assert.Equal(t, "Route", result[0].Spec.Ref.Kind)
+	assert.Equal(t, "serving.knative.dev/v1", result[0].Spec.Ref.APIVersion)
+	assert.Equal(t, "mydep", result[0].Spec.Ref.Name)
+	assert.Equal(t, "a.example.com-tls", result[0].Spec.TLS.SecretName)
+	assert.Equal(t, "b.example.com", result[1].Name)
+	assert.Equal(t, "b.example.com-tls", result[1].Spec.TLS.SecretName)
+}
+
+func Test_CreateDomainMappings_NoDomains(t *testing.T) {
+	assert.Empty(t, CreateDomainMappings(newDomainTestContext()))
+}
+
+func newDomainTestContext(domains ...string) *core.DeploymentContext {
+	return &core.DeploymentContext{
+		DeploymentConfig: &core.DeploymentConfig{
+			Name:            "mydep",
+			Namespace:       "myns",
+			EnvironmentName: "myenv",
+			App: &model.AppConfig{
+				Name: "myapp",
+				OverrideableAppConfig: model.OverrideableAppConfig{
+					Domains: domains,
+				},
+			},
+		},
+		EnvironmentConfig: &core.EnvironmentConfig{CertificateIssuer: "letsencrypt"},
+		RiserRevision:     3,
+	}
+}
@@ -0,0 +1,78 @@
+package resources
+
+import (
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/util"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// targetCPUUtilizationPercentage is the default average CPU utilization of a revision's pods before the revision is scaled up
+const targetCPUUtilizationPercentage = 80
+
+// CreateHorizontalPodAutoscaler creates an autoscaler for a single revision. Returns nil if the app does not specify a max scale.
+func CreateHorizontalPodAutoscaler(ctx *core.DeploymentContext) *autoscalingv2.HorizontalPodAutoscaler {
+	autoscale := ctx.DeploymentConfig.App.Autoscale
+	if autoscale == nil || autoscale.Max == nil {
+		return nil
+	}
+
+	// Unlike KNative a HorizontalPodAutoscaler cannot scale to zero
+	minReplicas := int32(1)
+	if autoscale.Min != nil && *autoscale.Min > 1 {
+		minReplicas = int32(*autoscale.Min)
+	}
+	maxReplicas := int32(*autoscale.Max)
+	if maxReplicas < minReplicas {
+		maxReplicas = minReplicas
+	}
+	// The kubernetes renderer only supports the cpu metric and requires it with a target, so the target is always a utilization percentage
+	targetUtilization := int32(targetCPUUtilizationPercentage)
+	if autoscale.Target != nil {
+		targetUtilization = int32(*autoscale.Target)
+	}
+	var behavior *autoscalingv2.HorizontalPodAutoscalerBehavior
+	if autoscale.ScaleDownDelaySeconds != nil {
+		behavior = &autoscalingv2.HorizontalPodAutoscalerBehavior{
+			ScaleDown: &autoscalingv2.HPAScalingRules{
+				StabilizationWindowSeconds: util.PtrInt32(int32(*autoscale.ScaleDownDelaySeconds)),
+			},
+		}
+	}
+
+	return &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        revisionName(ctx),
+			Namespace:   ctx.DeploymentConfig.Namespace,
+			Labels:      deploymentLabels(ctx),
+			Annotations: deploymentAnnotations(ctx),
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "HorizontalPodAutoscaler",
+			APIVersion: "autoscaling/v2",
+		},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       revisionName(ctx),
+			},
+			MinReplicas: util.PtrInt32(minReplicas),
+			MaxReplicas: maxReplicas,
+			Metrics: []autoscalingv2.MetricSpec{
+				{
+					Type: autoscalingv2.ResourceMetricSourceType,
+					Resource: &autoscalingv2.ResourceMetricSource{
+						Name: corev1.ResourceCPU,
+						Target: autoscalingv2.MetricTarget{
+							Type:               autoscalingv2.UtilizationMetricType,
+							AverageUtilization: util.PtrInt32(targetUtilization),
+						},
+					},
+				},
+			},
+			Behavior: behavior,
+		},
+	}
+}
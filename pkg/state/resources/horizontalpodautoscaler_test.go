@@ -0,0 +1,66 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/util"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CreateHorizontalPodAutoscaler(t *testing.T) {
+	ctx := newRendererTestContext()
+	ctx.DeploymentConfig.App.Autoscale = &model.AppConfigAutoscale{Min: util.PtrInt(2), Max: util.PtrInt(4)}
+
+	result := CreateHorizontalPodAutoscaler(ctx)
+
+	assert.Equal(t, "myapp-2", result.Name)
+	assert.Equal(t, "myns", result.Namespace)
+	assert.Equal(t, deploymentLabels(ctx), result.Labels)
+	assert.Equal(t, deploymentAnnotations(ctx), result.Annotations)
+	assert.Equal(t, "HorizontalPodAutoscaler", result.TypeMeta.Kind)
+	assert.Equal(t, "autoscaling/v2", result.TypeMeta.APIVersion)
+	assert.Equal(t, autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "myapp-2"}, result.Spec.ScaleTargetRef)
+	assert.Equal(t, util.PtrInt32(2), result.Spec.MinReplicas)
+	assert.EqualValues(t, 4, result.Spec.MaxReplicas)
+	assert.Len(t, result.Spec.Metrics, 1)
+	assert.Equal(t, corev1.ResourceCPU, result.Spec.Metrics[0].Resource.Name)
+	assert.Equal(t, util.PtrInt32(80), result.Spec.Metrics[0].Resource.Target.AverageUtilization)
+	assert.Nil(t, result.Spec.Behavior)
+}
+
+func Test_CreateHorizontalPodAutoscaler_TargetAndScaleDownDelay(t *testing.T) {
+	ctx := newRendererTestContext()
+	ctx.DeploymentConfig.App.Autoscale = &model.AppConfigAutoscale{
+		Max:                   util.PtrInt(4),
+		Metric:                model.AppAutoscaleMetric_Cpu,
+		Target:                util.PtrInt(60),
+		ScaleDownDelaySeconds: util.PtrInt(120),
+	}
+
+	result := CreateHorizontalPodAutoscaler(ctx)
+
+	assert.Equal(t, util.PtrInt32(60), result.Spec.Metrics[0].Resource.Target.AverageUtilization)
+	assert.Equal(t, util.PtrInt32(120), result.Spec.Behavior.ScaleDown.StabilizationWindowSeconds)
+	assert.Nil(t, result.Spec.Behavior.ScaleUp)
+}
+
+func Test_CreateHorizontalPodAutoscaler_ScaleToZero(t *testing.T) {
+	ctx := newRendererTestContext()
+	ctx.DeploymentConfig.App.Autoscale = &model.AppConfigAutoscale{Min: util.PtrInt(0), Max: util.PtrInt(0)}
+
+	result := CreateHorizontalPodAutoscaler(ctx)
+
+	assert.Equal(t, util.PtrInt32(1), result.Spec.MinReplicas)
+	assert.EqualValues(t, 1, result.Spec.MaxReplicas)
+}
+
+func Test_CreateHorizontalPodAutoscaler_NoMaxReturnsNil(t *testing.T) {
+	ctx := newRendererTestContext()
+	ctx.DeploymentConfig.App.Autoscale = &model.AppConfigAutoscale{Min: util.PtrInt(2)}
+
+	assert.Nil(t, CreateHorizontalPodAutoscaler(ctx))
+}
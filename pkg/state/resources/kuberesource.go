@@ -0,0 +1,9 @@
+package resources
+
+import "k8s.io/apimachinery/pkg/runtime/schema"
+
+type KubeResource interface {
+	GetName() string
+	GetNamespace() string
+	GetObjectKind() schema.ObjectKind
+}
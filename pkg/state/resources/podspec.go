@@ -3,6 +3,7 @@ package resources
 import (
 	"fmt"
 
+	validation "github.com/go-ozzo/ozzo-validation/v3"
 	"k8s.io/apimachinery/pkg/api/resource"
 
 	"github.com/riser-platform/riser-server/api/v1/model"
@@ -14,13 +15,19 @@ import (
 
 func createPodSpec(ctx *core.DeploymentContext) corev1.PodSpec {
 	return corev1.PodSpec{
+		ServiceAccountName: serviceAccountName(ctx.DeploymentConfig.App),
 		EnableServiceLinks: util.PtrBool(false),
 		Containers: []corev1.Container{
 			{
 				Name:           ctx.DeploymentConfig.Name,
 				Image:          fmt.Sprintf("%s:%s", ctx.DeploymentConfig.App.Image, ctx.DeploymentConfig.Docker.Tag),
-				Resources:      resources(ctx.DeploymentConfig.App),
+				Command:        ctx.DeploymentConfig.App.Command,
+				Args:           ctx.DeploymentConfig.App.Args,
+				WorkingDir:     ctx.DeploymentConfig.App.WorkingDir,
+				Resources:      resources(ctx),
 				ReadinessProbe: readinessProbe(ctx.DeploymentConfig.App),
+				LivenessProbe:  livenessProbe(ctx.DeploymentConfig.App),
+				StartupProbe:   startupProbe(ctx.DeploymentConfig.App),
 				Env:            k8sEnvVars(ctx),
 				Ports:          createPodPorts(ctx.DeploymentConfig.App.Expose),
 			},
@@ -49,27 +56,162 @@ func readinessProbe(appConfig *model.AppConfig) *corev1.Probe {
 		return nil
 	}
 
-	probe := &corev1.Probe{
-		ProbeHandler: corev1.ProbeHandler{
-			HTTPGet: &corev1.HTTPGetAction{
-				Path: appConfig.HealthCheck.Path,
-			},
-		},
+	return createProbe(appConfig.HealthCheck.Readiness(), appConfig.Expose)
+}
+
+func livenessProbe(appConfig *model.AppConfig) *corev1.Probe {
+	if appConfig.HealthCheck == nil {
+		return nil
+	}
+
+	return createProbe(appConfig.HealthCheck.Liveness, appConfig.Expose)
+}
+
+func startupProbe(appConfig *model.AppConfig) *corev1.Probe {
+	if appConfig.HealthCheck == nil {
+		return nil
+	}
+
+	return createProbe(appConfig.HealthCheck.Startup, appConfig.Expose)
+}
+
+func createProbe(appProbe *model.AppConfigProbe, expose *model.AppConfigExpose) *corev1.Probe {
+	if appProbe == nil {
+		return nil
+	}
+
+	probe := &corev1.Probe{}
+	switch appProbe.Mode {
+	case model.AppHealthCheckMode_TcpSocket:
+		probe.TCPSocket = &corev1.TCPSocketAction{}
+	case model.AppHealthCheckMode_Grpc:
+		probe.GRPC = &corev1.GRPCAction{
+			// Unlike httpGet and tcpSocket the port is required for grpc
+			Port: expose.ContainerPort,
+		}
+		if appProbe.GrpcService != "" {
+			probe.GRPC.Service = util.PtrString(appProbe.GrpcService)
+		}
+	case model.AppHealthCheckMode_Exec:
+		probe.Exec = &corev1.ExecAction{
+			Command: appProbe.Command,
+		}
+	default:
+		probe.HTTPGet = &corev1.HTTPGetAction{
+			Path: appProbe.Path,
+		}
+	}
+
+	if appProbe.InitialDelaySeconds != nil {
+		probe.InitialDelaySeconds = *appProbe.InitialDelaySeconds
+	}
+	if appProbe.PeriodSeconds != nil {
+		probe.PeriodSeconds = *appProbe.PeriodSeconds
+	}
+	if appProbe.TimeoutSeconds != nil {
+		probe.TimeoutSeconds = *appProbe.TimeoutSeconds
+	}
+	if appProbe.FailureThreshold != nil {
+		probe.FailureThreshold = *appProbe.FailureThreshold
 	}
 
 	return probe
 }
 
-func resources(appConfig *model.AppConfig) corev1.ResourceRequirements {
+// resources applies the environment's defaults and maximums to any resources that the app does not specify
+func resources(ctx *core.DeploymentContext) corev1.ResourceRequirements {
+	appResources := ctx.DeploymentConfig.App.Resources
+	environmentResources := &core.EnvironmentResources{}
+	if ctx.EnvironmentConfig != nil && ctx.EnvironmentConfig.Resources != nil {
+		environmentResources = ctx.EnvironmentConfig.Resources
+	}
+
 	res := corev1.ResourceRequirements{}
-	if appConfig.Resources != nil {
-		res.Limits = corev1.ResourceList{}
-		if appConfig.Resources.CpuCores != nil {
-			res.Limits[corev1.ResourceCPU] = *resource.NewScaledQuantity(int64(*appConfig.Resources.CpuCores*float32(1000)), resource.Milli)
+	if appResources != nil {
+		res.Limits = resourceList(appResources.CpuCores, appResources.MemoryMB, appResources.EphemeralStorageMB)
+		if appResources.Requests != nil {
+			res.Requests = resourceList(appResources.Requests.CpuCores, appResources.Requests.MemoryMB, appResources.Requests.EphemeralStorageMB)
 		}
-		if appConfig.Resources.MemoryMB != nil {
-			res.Limits[corev1.ResourceMemory] = *resource.NewScaledQuantity(int64(*appConfig.Resources.MemoryMB), resource.Mega)
+	}
+
+	// A default limit must not be less than the app's request. The request is still subject to the environment's maximums.
+	defaultLimits := environmentResourceList(environmentResources.DefaultLimits)
+	for name, quantity := range defaultLimits {
+		if request, ok := res.Requests[name]; ok && quantity.Cmp(request) < 0 {
+			defaultLimits[name] = request
+		}
+	}
+	res.Limits = mergeResourceList(res.Limits, defaultLimits)
+	res.Limits = mergeResourceList(res.Limits, environmentResourceList(environmentResources.MaxLimits))
+
+	// A default request must not be greater than the app's limit
+	defaultRequests := environmentResourceList(environmentResources.DefaultRequests)
+	for name, quantity := range defaultRequests {
+		if limit, ok := res.Limits[name]; ok && quantity.Cmp(limit) > 0 {
+			defaultRequests[name] = limit
 		}
 	}
+	res.Requests = mergeResourceList(res.Requests, defaultRequests)
+
 	return res
 }
+
+// resourceFields maps each resource to its app config field for validation errors
+var resourceFields = map[corev1.ResourceName]string{
+	corev1.ResourceCPU:              "cpuCores",
+	corev1.ResourceMemory:           "memoryMB",
+	corev1.ResourceEphemeralStorage: "ephemeralStorageMB",
+}
+
+// validateEnvironmentResources ensures that the app's resources (including the environment's defaults) do not exceed the environment's maximums
+func validateEnvironmentResources(ctx *core.DeploymentContext) validation.Errors {
+	validationErrors := validation.Errors{}
+	if ctx.EnvironmentConfig == nil || ctx.EnvironmentConfig.Resources == nil {
+		return validationErrors
+	}
+
+	res := resources(ctx)
+	for name, max := range environmentResourceList(ctx.EnvironmentConfig.Resources.MaxLimits) {
+		if limit, ok := res.Limits[name]; ok && limit.Cmp(max) > 0 {
+			validationErrors["resources."+resourceFields[name]] = fmt.Errorf("must be less than or equal to the environment's maximum of %s", max.String())
+		}
+		if request, ok := res.Requests[name]; ok && request.Cmp(max) > 0 {
+			validationErrors["resources.requests."+resourceFields[name]] = fmt.Errorf("must be less than or equal to the environment's maximum of %s", max.String())
+		}
+	}
+	return validationErrors
+}
+
+func resourceList(cpuCores *float32, memoryMB *int32, ephemeralStorageMB *int32) corev1.ResourceList {
+	list := corev1.ResourceList{}
+	if cpuCores != nil {
+		list[corev1.ResourceCPU] = *resource.NewScaledQuantity(int64(*cpuCores*float32(1000)), resource.Milli)
+	}
+	if memoryMB != nil {
+		list[corev1.ResourceMemory] = *resource.NewScaledQuantity(int64(*memoryMB), resource.Mega)
+	}
+	if ephemeralStorageMB != nil {
+		list[corev1.ResourceEphemeralStorage] = *resource.NewScaledQuantity(int64(*ephemeralStorageMB), resource.Mega)
+	}
+	return list
+}
+
+func environmentResourceList(quantities *core.EnvironmentResourceQuantities) corev1.ResourceList {
+	if quantities == nil {
+		return nil
+	}
+	return resourceList(quantities.CpuCores, quantities.MemoryMB, quantities.EphemeralStorageMB)
+}
+
+// mergeResourceList sets each resource from defaults that is not already in the list
+func mergeResourceList(list corev1.ResourceList, defaults corev1.ResourceList) corev1.ResourceList {
+	for name, quantity := range defaults {
+		if list == nil {
+			list = corev1.ResourceList{}
+		}
+		if _, ok := list[name]; !ok {
+			list[name] = quantity
+		}
+	}
+	return list
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/riser-platform/riser-server/pkg/util"
 
 	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/core"
 
 	"github.com/stretchr/testify/assert"
 
@@ -14,6 +15,35 @@ import (
 
 // Basic podspec tests are covered in knativeservice_test.go.
 
+func Test_createPodSpec_Container(t *testing.T) {
+	ctx := newRendererTestContext()
+	ctx.DeploymentConfig.App.Command = []string{"myapp"}
+	ctx.DeploymentConfig.App.Args = []string{"worker", "--verbose"}
+	ctx.DeploymentConfig.App.WorkingDir = "/app"
+
+	result := createPodSpec(ctx)
+
+	assert.Equal(t, []string{"myapp"}, result.Containers[0].Command)
+	assert.Equal(t, []string{"worker", "--verbose"}, result.Containers[0].Args)
+	assert.Equal(t, "/app", result.Containers[0].WorkingDir)
+}
+
+func Test_createPodSpec_ServiceAccount(t *testing.T) {
+	result := createPodSpec(newRendererTestContext())
+
+	assert.Equal(t, "myapp", result.ServiceAccountName)
+}
+
+// A caller is identified by its service account even when it does not set access itself (see createAccessRule)
+func Test_createPodSpec_ServiceAccount_NoAccess(t *testing.T) {
+	ctx := newRendererTestContext()
+	ctx.DeploymentConfig.App.Access = nil
+
+	result := createPodSpec(ctx)
+
+	assert.Equal(t, "myapp", result.ServiceAccountName)
+}
+
 func Test_readinessProbe_nilDeploy(t *testing.T) {
 	app := &model.AppConfig{}
 
@@ -44,6 +74,101 @@ func Test_readinessProbe_httpGet(t *testing.T) {
 	assert.Empty(t, result.HTTPGet.Port)
 }
 
+func Test_readinessProbe_tcpSocket(t *testing.T) {
+	app := &model.AppConfig{
+		HealthCheck: &model.AppConfigHealthCheck{
+			Mode: model.AppHealthCheckMode_TcpSocket,
+		},
+	}
+
+	result := readinessProbe(app)
+
+	assert.Nil(t, result.HTTPGet)
+	assert.NotNil(t, result.TCPSocket)
+	assert.Empty(t, result.TCPSocket.Port)
+}
+
+func Test_livenessProbe_nil(t *testing.T) {
+	app := &model.AppConfig{
+		HealthCheck: &model.AppConfigHealthCheck{
+			Path: "/health",
+		},
+	}
+
+	result := livenessProbe(app)
+
+	assert.Nil(t, result)
+}
+
+func Test_livenessProbe_exec(t *testing.T) {
+	app := &model.AppConfig{
+		HealthCheck: &model.AppConfigHealthCheck{
+			Path: "/health",
+			Liveness: &model.AppConfigProbe{
+				Mode:    model.AppHealthCheckMode_Exec,
+				Command: []string{"cat", "/tmp/healthy"},
+			},
+		},
+	}
+
+	result := livenessProbe(app)
+
+	assert.Nil(t, result.HTTPGet)
+	assert.Equal(t, []string{"cat", "/tmp/healthy"}, result.Exec.Command)
+}
+
+func Test_startupProbe_grpc(t *testing.T) {
+	app := &model.AppConfig{
+		Expose: &model.AppConfigExpose{
+			ContainerPort: 8000,
+		},
+		HealthCheck: &model.AppConfigHealthCheck{
+			Path: "/health",
+			Startup: &model.AppConfigProbe{
+				Mode:        model.AppHealthCheckMode_Grpc,
+				GrpcService: "myservice",
+			},
+		},
+	}
+
+	result := startupProbe(app)
+
+	assert.Nil(t, result.HTTPGet)
+	assert.EqualValues(t, 8000, result.GRPC.Port)
+	assert.Equal(t, "myservice", *result.GRPC.Service)
+}
+
+func Test_createProbe_timing(t *testing.T) {
+	appProbe := &model.AppConfigProbe{
+		Path:                "/health",
+		InitialDelaySeconds: util.PtrInt32(5),
+		PeriodSeconds:       util.PtrInt32(10),
+		TimeoutSeconds:      util.PtrInt32(2),
+		FailureThreshold:    util.PtrInt32(3),
+	}
+
+	result := createProbe(appProbe, &model.AppConfigExpose{})
+
+	assert.Equal(t, "/health", result.HTTPGet.Path)
+	assert.EqualValues(t, 5, result.InitialDelaySeconds)
+	assert.EqualValues(t, 10, result.PeriodSeconds)
+	assert.EqualValues(t, 2, result.TimeoutSeconds)
+	assert.EqualValues(t, 3, result.FailureThreshold)
+}
+
+func Test_createProbe_defaultTiming(t *testing.T) {
+	appProbe := &model.AppConfigProbe{
+		Path: "/health",
+	}
+
+	result := createProbe(appProbe, &model.AppConfigExpose{})
+
+	assert.Zero(t, result.InitialDelaySeconds)
+	assert.Zero(t, result.PeriodSeconds)
+	assert.Zero(t, result.TimeoutSeconds)
+	assert.Zero(t, result.FailureThreshold)
+}
+
 func Test_resources(t *testing.T) {
 	app := &model.AppConfig{
 		OverrideableAppConfig: model.OverrideableAppConfig{
@@ -54,10 +179,131 @@ func Test_resources(t *testing.T) {
 		},
 	}
 
-	result := resources(app)
+	result := resources(newResourcesTestContext(app, nil))
 
 	assert.EqualValues(t, 1500, result.Limits.Cpu().MilliValue(), "millicores")
 	assert.EqualValues(t, 4096000000, result.Limits.Memory().Value(), "bytes")
+	assert.Nil(t, result.Requests)
+}
+
+func Test_resources_NoResources(t *testing.T) {
+	result := resources(newResourcesTestContext(&model.AppConfig{}, &core.EnvironmentResources{}))
+
+	assert.Equal(t, corev1.ResourceRequirements{}, result)
+}
+
+func Test_resources_Requests(t *testing.T) {
+	app := &model.AppConfig{
+		OverrideableAppConfig: model.OverrideableAppConfig{
+			Resources: &model.AppConfigResources{
+				CpuCores:           util.PtrFloat32(2),
+				EphemeralStorageMB: util.PtrInt32(2048),
+				Requests: &model.AppConfigResourceRequests{
+					CpuCores:           util.PtrFloat32(0.5),
+					MemoryMB:           util.PtrInt32(256),
+					EphemeralStorageMB: util.PtrInt32(1024),
+				},
+			},
+		},
+	}
+
+	result := resources(newResourcesTestContext(app, nil))
+
+	assert.EqualValues(t, 2000, result.Limits.Cpu().MilliValue(), "millicores")
+	assert.EqualValues(t, 2048000000, result.Limits.StorageEphemeral().Value(), "bytes")
+	assert.EqualValues(t, 500, result.Requests.Cpu().MilliValue(), "millicores")
+	assert.EqualValues(t, 256000000, result.Requests.Memory().Value(), "bytes")
+	assert.EqualValues(t, 1024000000, result.Requests.StorageEphemeral().Value(), "bytes")
+}
+
+func Test_resources_EnvironmentDefaults(t *testing.T) {
+	app := &model.AppConfig{
+		OverrideableAppConfig: model.OverrideableAppConfig{
+			Resources: &model.AppConfigResources{
+				CpuCores: util.PtrFloat32(0.2),
+				Requests: &model.AppConfigResourceRequests{
+					MemoryMB: util.PtrInt32(128),
+				},
+			},
+		},
+	}
+	environmentResources := &core.EnvironmentResources{
+		DefaultRequests: &core.EnvironmentResourceQuantities{CpuCores: util.PtrFloat32(0.5), MemoryMB: util.PtrInt32(256)},
+		DefaultLimits:   &core.EnvironmentResourceQuantities{CpuCores: util.PtrFloat32(1), MemoryMB: util.PtrInt32(512)},
+		MaxLimits:       &core.EnvironmentResourceQuantities{CpuCores: util.PtrFloat32(4), EphemeralStorageMB: util.PtrInt32(4096)},
+	}
+
+	result := resources(newResourcesTestContext(app, environmentResources))
+
+	assert.Len(t, result.Limits, 3)
+	assert.EqualValues(t, 200, result.Limits.Cpu().MilliValue(), "app limit")
+	assert.EqualValues(t, 512000000, result.Limits.Memory().Value(), "default limit")
+	assert.EqualValues(t, 4096000000, result.Limits.StorageEphemeral().Value(), "max limit")
+	assert.Len(t, result.Requests, 2)
+	assert.EqualValues(t, 200, result.Requests.Cpu().MilliValue(), "default request capped by the app limit")
+	assert.EqualValues(t, 128000000, result.Requests.Memory().Value(), "app request")
+}
+
+func Test_resources_DefaultLimitLessThanAppRequest(t *testing.T) {
+	app := &model.AppConfig{
+		OverrideableAppConfig: model.OverrideableAppConfig{
+			Resources: &model.AppConfigResources{
+				Requests: &model.AppConfigResourceRequests{
+					MemoryMB: util.PtrInt32(512),
+				},
+			},
+		},
+	}
+	environmentResources := &core.EnvironmentResources{
+		DefaultLimits: &core.EnvironmentResourceQuantities{MemoryMB: util.PtrInt32(256)},
+	}
+
+	result := resources(newResourcesTestContext(app, environmentResources))
+
+	assert.EqualValues(t, 512000000, result.Limits.Memory().Value(), "default limit raised to the app request")
+	assert.EqualValues(t, 512000000, result.Requests.Memory().Value(), "app request")
+}
+
+func Test_validateEnvironmentResources(t *testing.T) {
+	app := &model.AppConfig{
+		OverrideableAppConfig: model.OverrideableAppConfig{
+			Resources: &model.AppConfigResources{
+				CpuCores: util.PtrFloat32(2),
+				MemoryMB: util.PtrInt32(512),
+				Requests: &model.AppConfigResourceRequests{
+					EphemeralStorageMB: util.PtrInt32(2048),
+				},
+			},
+		},
+	}
+	environmentResources := &core.EnvironmentResources{
+		MaxLimits: &core.EnvironmentResourceQuantities{CpuCores: util.PtrFloat32(1), MemoryMB: util.PtrInt32(512), EphemeralStorageMB: util.PtrInt32(1024)},
+	}
+
+	result := validateEnvironmentResources(newResourcesTestContext(app, environmentResources))
+
+	assert.Len(t, result, 2)
+	assert.Equal(t, "must be less than or equal to the environment's maximum of 1", result["resources.cpuCores"].Error())
+	assert.Equal(t, "must be less than or equal to the environment's maximum of 1024M", result["resources.requests.ephemeralStorageMB"].Error())
+}
+
+func Test_validateEnvironmentResources_NoEnvironmentResources(t *testing.T) {
+	app := &model.AppConfig{
+		OverrideableAppConfig: model.OverrideableAppConfig{
+			Resources: &model.AppConfigResources{
+				CpuCores: util.PtrFloat32(64),
+			},
+		},
+	}
+
+	assert.Empty(t, validateEnvironmentResources(newResourcesTestContext(app, nil)))
+}
+
+func newResourcesTestContext(app *model.AppConfig, environmentResources *core.EnvironmentResources) *core.DeploymentContext {
+	return &core.DeploymentContext{
+		DeploymentConfig:  &core.DeploymentConfig{App: app},
+		EnvironmentConfig: &core.EnvironmentConfig{Resources: environmentResources},
+	}
 }
 
 func Test_createPodPorts_http(t *testing.T) {
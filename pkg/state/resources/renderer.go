@@ -0,0 +1,203 @@
+package resources
+
+import (
+	"fmt"
+
+	validation "github.com/go-ozzo/ozzo-validation/v3"
+	"github.com/pkg/errors"
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/core"
+	securityv1beta1 "istio.io/api/security/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Renderer creates the resources that a deployment is rendered as in an environment
+type Renderer interface {
+	// Validate returns a core.ValidationError when the deployment cannot be rendered in the environment (e.g. the app config uses a
+	// feature that the renderer does not support)
+	Validate(ctx *core.DeploymentContext) error
+	// DeploymentResources returns every resource for the current revision of a deployment
+	DeploymentResources(ctx *core.DeploymentContext) []KubeResource
+	// TrafficResources returns only the resources that route traffic between the revisions in the deployment's traffic config
+	TrafficResources(ctx *core.DeploymentContext) []KubeResource
+}
+
+// RevisionRenderer is implemented by renderers that render a separate set of resources for each revision that receives traffic.
+// The resources for previous revisions must be rendered from their own deployment context.
+type RevisionRenderer interface {
+	RevisionResources(ctx *core.DeploymentContext) []KubeResource
+	// RetiredRevisionResources returns the resources of each of the ctx's retired revisions. Only the kind and name of each resource
+	// are set since they are only rendered to be deleted.
+	RetiredRevisionResources(ctx *core.DeploymentContext) []KubeResource
+}
+
+// NewRenderer returns the renderer for an environment
+func NewRenderer(environmentConfig *core.EnvironmentConfig) (Renderer, error) {
+	rendererName := ""
+	if environmentConfig != nil {
+		rendererName = environmentConfig.Renderer
+	}
+
+	switch rendererName {
+	case "", model.EnvironmentRenderer_KNative:
+		return &knativeRenderer{}, nil
+	case model.EnvironmentRenderer_Kubernetes:
+		return &kubernetesRenderer{}, nil
+	}
+	return nil, fmt.Errorf("unknown renderer %q", rendererName)
+}
+
+type knativeRenderer struct{}
+
+func (r *knativeRenderer) Validate(ctx *core.DeploymentContext) error {
+	app := ctx.DeploymentConfig.App
+	validationErrors := validateEnvironmentResources(ctx)
+	if len(app.Domains) > 0 && (ctx.EnvironmentConfig == nil || ctx.EnvironmentConfig.CertificateIssuer == "") {
+		validationErrors["domains"] = errors.New("requires the environment to have a certificate issuer")
+	}
+	if app.HealthCheck == nil {
+		return newRendererValidationError(validationErrors)
+	}
+
+	if app.HealthCheck.Startup != nil {
+		validationErrors["healthcheck.startup"] = errors.New("is not supported by KNative")
+	}
+	probes := map[string]*model.AppConfigProbe{
+		"healthcheck":          app.HealthCheck.Readiness(),
+		"healthcheck.liveness": app.HealthCheck.Liveness,
+	}
+	for field, probe := range probes {
+		if probe != nil && probe.Mode == model.AppHealthCheckMode_Grpc {
+			validationErrors[field+".mode"] = errors.New("grpc is not supported by KNative")
+		}
+	}
+	// KNative uses an aggressive readiness probe when the period is not set which does not allow a timeout or failure threshold
+	if app.HealthCheck.PeriodSeconds == nil {
+		if app.HealthCheck.TimeoutSeconds != nil {
+			validationErrors["healthcheck.timeoutSeconds"] = errors.New("requires healthcheck.periodSeconds to be set")
+		}
+		if app.HealthCheck.FailureThreshold != nil {
+			validationErrors["healthcheck.failureThreshold"] = errors.New("requires healthcheck.periodSeconds to be set")
+		}
+	}
+
+	return newRendererValidationError(validationErrors)
+}
+
+func (r *knativeRenderer) DeploymentResources(ctx *core.DeploymentContext) []KubeResource {
+	deploymentResources := []KubeResource{
+		CreateHealthcheckDenyPolicy(ctx),
+		CreateAccessPolicy(ctx, knativeSystemRules(ctx.DeploymentConfig.App)...),
+		CreateKNativeConfiguration(ctx),
+		CreateKNativeRoute(ctx),
+	}
+	for _, domainMapping := range CreateDomainMappings(ctx) {
+		deploymentResources = append(deploymentResources, domainMapping)
+	}
+	for _, certificate := range CreateCertificates(ctx) {
+		deploymentResources = append(deploymentResources, certificate)
+	}
+	return deploymentResources
+}
+
+// queueProxyMetricsPort is the port of the KNative queue-proxy that the autoscaler scrapes
+const queueProxyMetricsPort = "9090"
+
+// knativeSystemRules allow the KNative components that must reach a revision of an app with access. The activator in knative-serving
+// proxies requests for any caller so it must never be in the request path of an app with access (see createRevisionMeta). Only the
+// autoscaler's scraping of the queue-proxy's metrics port is allowed from knative-serving. External requests are routed by the
+// ingress gateway in istio-system.
+//
+// Limitation: an app with access never scales to zero since the activator is required to scale from zero.
+func knativeSystemRules(app *model.AppConfig) []*securityv1beta1.Rule {
+	rules := []*securityv1beta1.Rule{systemNamespaceRule("knative-serving", queueProxyMetricsPort)}
+	if app.Expose != nil && app.Expose.Scope != model.AppExposeScope_Cluster {
+		rules = append(rules, systemNamespaceRule("istio-system"))
+	}
+	return rules
+}
+
+func (r *knativeRenderer) TrafficResources(ctx *core.DeploymentContext) []KubeResource {
+	return []KubeResource{CreateKNativeRoute(ctx)}
+}
+
+// kubernetesRenderer renders a Deployment per revision and uses an Istio VirtualService to split traffic between revisions
+type kubernetesRenderer struct{}
+
+func (r *kubernetesRenderer) Validate(ctx *core.DeploymentContext) error {
+	app := ctx.DeploymentConfig.App
+	validationErrors := validateEnvironmentResources(ctx)
+	if app.Autoscale != nil {
+		if app.Autoscale.Metric != "" && app.Autoscale.Metric != model.AppAutoscaleMetric_Cpu {
+			validationErrors["autoscale.metric"] = errors.New("only cpu is supported by the kubernetes renderer")
+		} else if app.Autoscale.Metric == "" && app.Autoscale.Target != nil {
+			// The target defaults to a concurrency value which must not be rendered as a cpu utilization percentage
+			validationErrors["autoscale.metric"] = errors.New("must be cpu when a target is set with the kubernetes renderer")
+		}
+		if app.Autoscale.PanicWindowPercentage != nil {
+			validationErrors["autoscale.panicWindowPercentage"] = errors.New("is not supported by the kubernetes renderer")
+		}
+	}
+	if app.Request != nil && app.Request.ContainerConcurrency != nil {
+		validationErrors["request.containerConcurrency"] = errors.New("is not supported by the kubernetes renderer")
+	}
+	if len(app.Domains) > 0 {
+		validationErrors["domains"] = errors.New("is not supported by the kubernetes renderer")
+	}
+	// The VirtualService only routes traffic within the mesh
+	if app.Expose != nil && app.Expose.Scope != model.AppExposeScope_Cluster {
+		validationErrors["expose.scope"] = fmt.Errorf("only %s is supported by the kubernetes renderer", model.AppExposeScope_Cluster)
+	}
+
+	return newRendererValidationError(validationErrors)
+}
+
+func newRendererValidationError(validationErrors validation.Errors) error {
+	if len(validationErrors) > 0 {
+		return core.NewValidationError("The app config is not supported by the environment", validationErrors)
+	}
+	return nil
+}
+
+func (r *kubernetesRenderer) DeploymentResources(ctx *core.DeploymentContext) []KubeResource {
+	deploymentResources := []KubeResource{
+		CreateHealthcheckDenyPolicy(ctx),
+		CreateAccessPolicy(ctx),
+		CreateService(ctx),
+	}
+	deploymentResources = append(deploymentResources, r.RevisionResources(ctx)...)
+	return append(deploymentResources, r.TrafficResources(ctx)...)
+}
+
+func (r *kubernetesRenderer) RevisionResources(ctx *core.DeploymentContext) []KubeResource {
+	return []KubeResource{
+		CreateDeployment(ctx),
+		CreateRevisionService(ctx),
+		CreateHorizontalPodAutoscaler(ctx),
+	}
+}
+
+func (r *kubernetesRenderer) RetiredRevisionResources(ctx *core.DeploymentContext) []KubeResource {
+	retiredResources := []KubeResource{}
+	for _, riserRevision := range ctx.RetiredRevisions {
+		objectMeta := metav1.ObjectMeta{
+			Name:      revisionName(&core.DeploymentContext{DeploymentConfig: ctx.DeploymentConfig, RiserRevision: riserRevision}),
+			Namespace: ctx.DeploymentConfig.Namespace,
+		}
+		retiredResources = append(retiredResources,
+			&appsv1.Deployment{ObjectMeta: objectMeta, TypeMeta: metav1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"}},
+			&corev1.Service{ObjectMeta: objectMeta, TypeMeta: metav1.TypeMeta{Kind: "Service", APIVersion: "v1"}},
+			&autoscalingv2.HorizontalPodAutoscaler{
+				ObjectMeta: objectMeta,
+				TypeMeta:   metav1.TypeMeta{Kind: "HorizontalPodAutoscaler", APIVersion: "autoscaling/v2"},
+			})
+	}
+	return retiredResources
+}
+
+func (r *kubernetesRenderer) TrafficResources(ctx *core.DeploymentContext) []KubeResource {
+	return []KubeResource{CreateVirtualService(ctx)}
+}
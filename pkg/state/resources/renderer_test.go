@@ -0,0 +1,315 @@
+package resources
+
+import (
+	"testing"
+
+	validation "github.com/go-ozzo/ozzo-validation/v3"
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	securityv1beta1 "istio.io/api/security/v1beta1"
+)
+
+func Test_NewRenderer(t *testing.T) {
+	tests := []struct {
+		environmentConfig *core.EnvironmentConfig
+		expected          Renderer
+	}{
+		{nil, &knativeRenderer{}},
+		{&core.EnvironmentConfig{}, &knativeRenderer{}},
+		{&core.EnvironmentConfig{Renderer: model.EnvironmentRenderer_KNative}, &knativeRenderer{}},
+		{&core.EnvironmentConfig{Renderer: model.EnvironmentRenderer_Kubernetes}, &kubernetesRenderer{}},
+	}
+
+	for _, tt := range tests {
+		result, err := NewRenderer(tt.environmentConfig)
+
+		assert.NoError(t, err)
+		assert.IsType(t, tt.expected, result)
+	}
+}
+
+func Test_knativeSystemRules(t *testing.T) {
+	tests := []struct {
+		scope    string
+		expected []*securityv1beta1.Rule
+	}{
+		{model.AppExposeScope_External, []*securityv1beta1.Rule{systemNamespaceRule("knative-serving", "9090"), systemNamespaceRule("istio-system")}},
+		{model.AppExposeScope_Cluster, []*securityv1beta1.Rule{systemNamespaceRule("knative-serving", "9090")}},
+	}
+
+	for _, tt := range tests {
+		app := &model.AppConfig{Expose: &model.AppConfigExpose{Scope: tt.scope}}
+		assert.Equal(t, tt.expected, knativeSystemRules(app), tt.scope)
+	}
+}
+
+func Test_NewRenderer_Unknown(t *testing.T) {
+	result, err := NewRenderer(&core.EnvironmentConfig{Renderer: "bad"})
+
+	assert.Nil(t, result)
+	assert.Equal(t, `unknown renderer "bad"`, err.Error())
+}
+
+func Test_knativeRenderer(t *testing.T) {
+	ctx := newRendererTestContext()
+	renderer := &knativeRenderer{}
+
+	deploymentResources := renderer.DeploymentResources(ctx)
+	require.Len(t, deploymentResources, 4)
+	assert.Equal(t, "AuthorizationPolicy", deploymentResources[0].GetObjectKind().GroupVersionKind().Kind)
+	assert.Equal(t, "myapp-healthcheck-deny", deploymentResources[0].GetName())
+	assert.Equal(t, "AuthorizationPolicy", deploymentResources[1].GetObjectKind().GroupVersionKind().Kind)
+	assert.Equal(t, "myapp-access", deploymentResources[1].GetName())
+	assert.Equal(t, "Configuration", deploymentResources[2].GetObjectKind().GroupVersionKind().Kind)
+	assert.Equal(t, "Route", deploymentResources[3].GetObjectKind().GroupVersionKind().Kind)
+
+	trafficResources := renderer.TrafficResources(ctx)
+	require.Len(t, trafficResources, 1)
+	assert.Equal(t, "Route", trafficResources[0].GetObjectKind().GroupVersionKind().Kind)
+
+	_, isRevisionRenderer := interface{}(renderer).(RevisionRenderer)
+	assert.False(t, isRevisionRenderer)
+}
+
+func Test_knativeRenderer_Domains(t *testing.T) {
+	ctx := newRendererTestContext()
+	ctx.DeploymentConfig.App.Domains = []string{"myapp.example.com"}
+	ctx.EnvironmentConfig = &core.EnvironmentConfig{CertificateIssuer: "letsencrypt"}
+	renderer := &knativeRenderer{}
+
+	assert.NoError(t, renderer.Validate(ctx))
+	deploymentResources := renderer.DeploymentResources(ctx)
+	require.Len(t, deploymentResources, 6)
+	assert.Equal(t, "DomainMapping", deploymentResources[4].GetObjectKind().GroupVersionKind().Kind)
+	assert.Equal(t, "Certificate", deploymentResources[5].GetObjectKind().GroupVersionKind().Kind)
+}
+
+func Test_kubernetesRenderer(t *testing.T) {
+	ctx := newRendererTestContext()
+	renderer := &kubernetesRenderer{}
+
+	deploymentResources := renderer.DeploymentResources(ctx)
+	require.Len(t, deploymentResources, 7)
+	assert.Equal(t, "AuthorizationPolicy", deploymentResources[0].GetObjectKind().GroupVersionKind().Kind)
+	assert.Equal(t, "myapp-healthcheck-deny", deploymentResources[0].GetName())
+	assert.Equal(t, "AuthorizationPolicy", deploymentResources[1].GetObjectKind().GroupVersionKind().Kind)
+	assert.Equal(t, "myapp-access", deploymentResources[1].GetName())
+	assert.Equal(t, "Service", deploymentResources[2].GetObjectKind().GroupVersionKind().Kind)
+	assert.Equal(t, "myapp", deploymentResources[2].GetName())
+	assert.Equal(t, "Deployment", deploymentResources[3].GetObjectKind().GroupVersionKind().Kind)
+	assert.Equal(t, "Service", deploymentResources[4].GetObjectKind().GroupVersionKind().Kind)
+	assert.Equal(t, "myapp-2", deploymentResources[4].GetName())
+	assert.Equal(t, "HorizontalPodAutoscaler", deploymentResources[5].GetObjectKind().GroupVersionKind().Kind)
+	assert.Equal(t, "VirtualService", deploymentResources[6].GetObjectKind().GroupVersionKind().Kind)
+
+	trafficResources := renderer.TrafficResources(ctx)
+	require.Len(t, trafficResources, 1)
+	assert.Equal(t, "VirtualService", trafficResources[0].GetObjectKind().GroupVersionKind().Kind)
+
+	revisionResources := renderer.RevisionResources(ctx)
+	require.Len(t, revisionResources, 3)
+	for _, resource := range revisionResources {
+		assert.Equal(t, "myapp-2", resource.GetName())
+	}
+}
+
+func Test_kubernetesRenderer_RetiredRevisionResources(t *testing.T) {
+	ctx := newRendererTestContext()
+	ctx.RetiredRevisions = []int64{1}
+	renderer := &kubernetesRenderer{}
+
+	retiredResources := renderer.RetiredRevisionResources(ctx)
+
+	revisionResources := renderer.RevisionResources(&core.DeploymentContext{
+		DeploymentConfig:  ctx.DeploymentConfig,
+		EnvironmentConfig: ctx.EnvironmentConfig,
+		RiserRevision:     1,
+	})
+	require.Len(t, retiredResources, len(revisionResources))
+	for idx, resource := range retiredResources {
+		assert.Equal(t, revisionResources[idx].GetObjectKind(), resource.GetObjectKind())
+		assert.Equal(t, "myapp-1", resource.GetName())
+		assert.Equal(t, revisionResources[idx].GetNamespace(), resource.GetNamespace())
+	}
+}
+
+func Test_knativeRenderer_Validate(t *testing.T) {
+	tests := []struct {
+		healthCheck *model.AppConfigHealthCheck
+		errors      []string
+	}{
+		{nil, nil},
+		{&model.AppConfigHealthCheck{Path: "/health", Liveness: &model.AppConfigProbe{Mode: model.AppHealthCheckMode_TcpSocket}}, nil},
+		{&model.AppConfigHealthCheck{Path: "/health", PeriodSeconds: util.PtrInt32(5), TimeoutSeconds: util.PtrInt32(1), FailureThreshold: util.PtrInt32(3)}, nil},
+		{&model.AppConfigHealthCheck{Path: "/health", Startup: &model.AppConfigProbe{Path: "/health"}}, []string{"healthcheck.startup"}},
+		{&model.AppConfigHealthCheck{Mode: model.AppHealthCheckMode_Grpc}, []string{"healthcheck.mode"}},
+		{&model.AppConfigHealthCheck{Path: "/health", Liveness: &model.AppConfigProbe{Mode: model.AppHealthCheckMode_Grpc}}, []string{"healthcheck.liveness.mode"}},
+		{&model.AppConfigHealthCheck{Path: "/health", TimeoutSeconds: util.PtrInt32(1), FailureThreshold: util.PtrInt32(3)},
+			[]string{"healthcheck.timeoutSeconds", "healthcheck.failureThreshold"}},
+	}
+
+	for _, tt := range tests {
+		err := (&knativeRenderer{}).Validate(newValidateTestContext(&model.AppConfig{HealthCheck: tt.healthCheck}))
+
+		if tt.errors == nil {
+			assert.NoError(t, err)
+		} else {
+			require.IsType(t, &core.ValidationError{}, err)
+			validationErrors := err.(*core.ValidationError).ValidationError.(validation.Errors)
+			assert.Len(t, validationErrors, len(tt.errors))
+			for _, field := range tt.errors {
+				assert.Contains(t, validationErrors, field)
+			}
+		}
+	}
+}
+
+func Test_knativeRenderer_Validate_DomainsRequireCertificateIssuer(t *testing.T) {
+	app := &model.AppConfig{
+		OverrideableAppConfig: model.OverrideableAppConfig{Domains: []string{"myapp.example.com"}},
+	}
+
+	err := (&knativeRenderer{}).Validate(newValidateTestContext(app))
+
+	require.IsType(t, &core.ValidationError{}, err)
+	validationErrors := err.(*core.ValidationError).ValidationError.(validation.Errors)
+	assert.Len(t, validationErrors, 1)
+	assert.Equal(t, "requires the environment to have a certificate issuer", validationErrors["domains"].Error())
+}
+
+func Test_kubernetesRenderer_Validate(t *testing.T) {
+	app := &model.AppConfig{
+		Expose: &model.AppConfigExpose{
+			Scope: model.AppExposeScope_Cluster,
+		},
+		HealthCheck: &model.AppConfigHealthCheck{
+			Mode:           model.AppHealthCheckMode_Grpc,
+			TimeoutSeconds: util.PtrInt32(1),
+			Startup:        &model.AppConfigProbe{Path: "/health"},
+		},
+		OverrideableAppConfig: model.OverrideableAppConfig{
+			Autoscale: &model.AppConfigAutoscale{
+				Metric:                model.AppAutoscaleMetric_Cpu,
+				Target:                util.PtrInt(50),
+				ScaleDownDelaySeconds: util.PtrInt(60),
+			},
+			Request: &model.AppConfigRequest{
+				TimeoutSeconds: util.PtrInt64(30),
+			},
+		},
+	}
+
+	assert.NoError(t, (&kubernetesRenderer{}).Validate(newValidateTestContext(app)))
+}
+
+func Test_kubernetesRenderer_Validate_Unsupported(t *testing.T) {
+	app := &model.AppConfig{
+		Expose: &model.AppConfigExpose{
+			Scope: model.AppExposeScope_External,
+		},
+		OverrideableAppConfig: model.OverrideableAppConfig{
+			Autoscale: &model.AppConfigAutoscale{
+				Metric:                model.AppAutoscaleMetric_Concurrency,
+				PanicWindowPercentage: util.PtrInt(10),
+			},
+			Request: &model.AppConfigRequest{
+				ContainerConcurrency: util.PtrInt64(10),
+			},
+			Domains: []string{"myapp.example.com"},
+		},
+	}
+
+	err := (&kubernetesRenderer{}).Validate(newValidateTestContext(app))
+
+	require.IsType(t, &core.ValidationError{}, err)
+	validationErrors := err.(*core.ValidationError).ValidationError.(validation.Errors)
+	assert.Len(t, validationErrors, 5)
+	assert.Equal(t, "only cpu is supported by the kubernetes renderer", validationErrors["autoscale.metric"].Error())
+	assert.Equal(t, "only cluster is supported by the kubernetes renderer", validationErrors["expose.scope"].Error())
+	assert.Equal(t, "is not supported by the kubernetes renderer", validationErrors["autoscale.panicWindowPercentage"].Error())
+	assert.Equal(t, "is not supported by the kubernetes renderer", validationErrors["request.containerConcurrency"].Error())
+	assert.Equal(t, "is not supported by the kubernetes renderer", validationErrors["domains"].Error())
+}
+
+func Test_kubernetesRenderer_Validate_TargetWithoutMetric(t *testing.T) {
+	app := &model.AppConfig{
+		OverrideableAppConfig: model.OverrideableAppConfig{
+			Autoscale: &model.AppConfigAutoscale{
+				Max:    util.PtrInt(5),
+				Target: util.PtrInt(200),
+			},
+		},
+	}
+
+	err := (&kubernetesRenderer{}).Validate(newValidateTestContext(app))
+
+	require.IsType(t, &core.ValidationError{}, err)
+	validationErrors := err.(*core.ValidationError).ValidationError.(validation.Errors)
+	assert.Len(t, validationErrors, 1)
+	assert.Equal(t, "must be cpu when a target is set with the kubernetes renderer", validationErrors["autoscale.metric"].Error())
+
+	app.Autoscale.Target = nil
+	assert.NoError(t, (&kubernetesRenderer{}).Validate(newValidateTestContext(app)))
+}
+
+func Test_Validate_EnvironmentResources(t *testing.T) {
+	ctx := newValidateTestContext(&model.AppConfig{})
+	ctx.EnvironmentConfig.Resources = &core.EnvironmentResources{
+		DefaultLimits: &core.EnvironmentResourceQuantities{MemoryMB: util.PtrInt32(1024)},
+		MaxLimits:     &core.EnvironmentResourceQuantities{MemoryMB: util.PtrInt32(512)},
+	}
+
+	for _, renderer := range []Renderer{&knativeRenderer{}, &kubernetesRenderer{}} {
+		err := renderer.Validate(ctx)
+
+		require.IsType(t, &core.ValidationError{}, err)
+		validationErrors := err.(*core.ValidationError).ValidationError.(validation.Errors)
+		assert.Len(t, validationErrors, 1)
+		assert.Contains(t, validationErrors, "resources.memoryMB")
+	}
+}
+
+func newValidateTestContext(app *model.AppConfig) *core.DeploymentContext {
+	return &core.DeploymentContext{
+		DeploymentConfig:  &core.DeploymentConfig{App: app},
+		EnvironmentConfig: &core.EnvironmentConfig{},
+	}
+}
+
+func newRendererTestContext() *core.DeploymentContext {
+	return &core.DeploymentContext{
+		DeploymentConfig: &core.DeploymentConfig{
+			Name:            "myapp",
+			Namespace:       "myns",
+			EnvironmentName: "myenv",
+			Docker:          core.DeploymentDocker{Tag: "0.0.1"},
+			App: &model.AppConfig{
+				Name:  "myapp",
+				Image: "myimage",
+				Access: &model.AppConfigAccess{
+					From: []model.AppConfigAccessSource{{App: "otherapp"}},
+				},
+				Expose: &model.AppConfigExpose{
+					ContainerPort: 8080,
+					Protocol:      "http",
+				},
+				HealthCheck: &model.AppConfigHealthCheck{
+					Path: "/health",
+				},
+				OverrideableAppConfig: model.OverrideableAppConfig{
+					Autoscale: &model.AppConfigAutoscale{
+						Max: util.PtrInt(2),
+					},
+				},
+			},
+			Traffic: core.TrafficConfig{
+				{RiserRevision: 1, RevisionName: "myapp-1", Percent: 50},
+				{RiserRevision: 2, RevisionName: "myapp-2", Percent: 50},
+			},
+		},
+		RiserRevision: 2,
+	}
+}
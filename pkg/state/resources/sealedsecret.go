@@ -34,21 +34,25 @@ func CreateSealedSecret(plaintextSecret string, secretMeta *core.SecretMeta, cer
 	if err != nil {
 		return nil, errors.Wrap(err, "Error parsing public key")
 	}
-	objectMeta := metav1.ObjectMeta{
-		Name:      fmt.Sprintf("%s-%s-%d", secretMeta.App.Name, secretMeta.Name, secretMeta.Revision),
-		Namespace: secretMeta.App.Namespace,
-		Annotations: map[string]string{
-			riserLabel("revision"):       fmt.Sprintf("%d", secretMeta.Revision),
-			riserLabel("server-version"): util.VersionString,
-		},
-		Labels: map[string]string{
-			riserLabel("app"): secretMeta.App.Name,
-		},
-	}
+	objectMeta := sealedSecretObjectMeta(secretMeta)
 	ciphertext, err := sealSecret(objectMeta, publicKey, []byte(plaintextSecret), rand)
 	if err != nil {
 		return nil, errors.Wrap(err, "Error sealing secret")
 	}
+	return newSealedSecret(objectMeta, ciphertext), nil
+}
+
+// CreateSealedSecretFromCiphertext creates a sealed secret from the ciphertext of a previously created sealed secret
+func CreateSealedSecretFromCiphertext(secretMeta *core.SecretMeta, ciphertext []byte) *SealedSecret {
+	return newSealedSecret(sealedSecretObjectMeta(secretMeta), ciphertext)
+}
+
+// Ciphertext returns the sealed secret data
+func (sealedSecret *SealedSecret) Ciphertext() []byte {
+	return sealedSecret.Spec.EncryptedData["data"]
+}
+
+func newSealedSecret(objectMeta metav1.ObjectMeta, ciphertext []byte) *SealedSecret {
 	return &SealedSecret{
 		ObjectMeta: objectMeta,
 		TypeMeta: metav1.TypeMeta{
@@ -60,7 +64,22 @@ func CreateSealedSecret(plaintextSecret string, secretMeta *core.SecretMeta, cer
 				"data": ciphertext,
 			},
 		},
-	}, nil
+	}
+}
+
+// sealedSecretObjectMeta must not change for existing secrets since the name and namespace are part of the sealed data
+func sealedSecretObjectMeta(secretMeta *core.SecretMeta) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      fmt.Sprintf("%s-%s-%d", secretMeta.App.Name, secretMeta.Name, secretMeta.Revision),
+		Namespace: secretMeta.App.Namespace,
+		Annotations: map[string]string{
+			riserLabel("revision"):       fmt.Sprintf("%d", secretMeta.Revision),
+			riserLabel("server-version"): util.VersionString,
+		},
+		Labels: map[string]string{
+			riserLabel("app"): secretMeta.App.Name,
+		},
+	}
 }
 
 // Derived from https://github.com/bitnami-labs/sealed-secrets/blob/d875137740275f7dea36c54f981a90c795e7e681/cmd/kubeseal/main.go#L75
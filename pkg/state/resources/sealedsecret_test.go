@@ -66,6 +66,21 @@ func Test_CreateSealedSecret(t *testing.T) {
 	assert.NotEmpty(t, result.Spec.EncryptedData["data"])
 }
 
+func Test_CreateSealedSecretFromCiphertext(t *testing.T) {
+	secret := &core.SecretMeta{
+		Name:            "mysecretname",
+		App:             core.NewNamespacedName("myapp", "apps"),
+		EnvironmentName: "dev",
+		Revision:        1,
+	}
+	sealed, err := CreateSealedSecret("mysecretvalue", secret, []byte(testSealedSecretCert), rand.Reader)
+	require.NoError(t, err)
+
+	result := CreateSealedSecretFromCiphertext(secret, sealed.Ciphertext())
+
+	assert.Equal(t, sealed, result)
+}
+
 func Test_parsePublicKey(t *testing.T) {
 	result, err := parsePublicKey([]byte(testSealedSecretCert))
 
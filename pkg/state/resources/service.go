@@ -0,0 +1,49 @@
+package resources
+
+import (
+	"github.com/riser-platform/riser-server/pkg/core"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// CreateService creates the Service that clients address a deployment by. It selects the pods of every revision, but traffic
+// within the mesh is split between revisions by the deployment's VirtualService.
+func CreateService(ctx *core.DeploymentContext) *corev1.Service {
+	return createService(ctx, ctx.DeploymentConfig.Name, map[string]string{
+		riserLabel("deployment"): ctx.DeploymentConfig.Name,
+	})
+}
+
+// CreateRevisionService creates a Service that selects the pods of a single revision
+func CreateRevisionService(ctx *core.DeploymentContext) *corev1.Service {
+	return createService(ctx, revisionName(ctx), revisionSelector(ctx))
+}
+
+func createService(ctx *core.DeploymentContext, name string, selector map[string]string) *corev1.Service {
+	expose := ctx.DeploymentConfig.App.Expose
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   ctx.DeploymentConfig.Namespace,
+			Labels:      deploymentLabels(ctx),
+			Annotations: deploymentAnnotations(ctx),
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Service",
+			APIVersion: "v1",
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: selector,
+			Ports: []corev1.ServicePort{
+				{
+					// Istio uses the port name to determine the protocol
+					Name:       expose.Protocol,
+					Protocol:   corev1.ProtocolTCP,
+					Port:       80,
+					TargetPort: intstr.FromInt(int(expose.ContainerPort)),
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,35 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func Test_CreateService(t *testing.T) {
+	ctx := newRendererTestContext()
+
+	result := CreateService(ctx)
+
+	assert.Equal(t, "myapp", result.Name)
+	assert.Equal(t, "myns", result.Namespace)
+	assert.Equal(t, deploymentLabels(ctx), result.Labels)
+	assert.Equal(t, deploymentAnnotations(ctx), result.Annotations)
+	assert.Equal(t, "Service", result.TypeMeta.Kind)
+	assert.Equal(t, "v1", result.TypeMeta.APIVersion)
+	assert.Equal(t, map[string]string{"riser.dev/deployment": "myapp"}, result.Spec.Selector)
+	assert.Equal(t, []corev1.ServicePort{{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80, TargetPort: intstr.FromInt(8080)}}, result.Spec.Ports)
+}
+
+func Test_CreateRevisionService(t *testing.T) {
+	ctx := newRendererTestContext()
+	ctx.DeploymentConfig.App.Expose.Protocol = "http2"
+
+	result := CreateRevisionService(ctx)
+
+	assert.Equal(t, "myapp-2", result.Name)
+	assert.Equal(t, map[string]string{"riser.dev/deployment": "myapp", "riser.dev/revision": "2"}, result.Spec.Selector)
+	assert.Equal(t, "http2", result.Spec.Ports[0].Name)
+}
@@ -0,0 +1,34 @@
+package resources
+
+import (
+	"github.com/riser-platform/riser-server/api/v1/model"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CreateServiceAccount creates the service account that every deployment of an app runs as (see serviceAccountName). The
+// service account is the app's identity within the mesh (see CreateAccessPolicy). It is shared by the app's deployments so it must not
+// contain deployment specific values.
+func CreateServiceAccount(appName, namespace string) *corev1.ServiceAccount {
+	return &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      appName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				riserLabel("app"): appName,
+			},
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ServiceAccount",
+			APIVersion: "v1",
+		},
+	}
+}
+
+// serviceAccountName returns the service account of an app's pods. Every app runs as its own service account so that it may be
+// identified as the source of a call to an app with access (see AppConfigAccessSource.App). Image pull secrets and RBAC must be bound
+// to the app's service account rather than the namespace's default service account.
+func serviceAccountName(app *model.AppConfig) string {
+	return string(app.Name)
+}
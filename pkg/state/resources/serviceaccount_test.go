@@ -0,0 +1,17 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CreateServiceAccount(t *testing.T) {
+	result := CreateServiceAccount("myapp", "myns")
+
+	assert.Equal(t, "myapp", result.Name)
+	assert.Equal(t, "myns", result.Namespace)
+	assert.Equal(t, map[string]string{"riser.dev/app": "myapp"}, result.Labels)
+	assert.Equal(t, "ServiceAccount", result.Kind)
+	assert.Equal(t, "v1", result.APIVersion)
+}
@@ -0,0 +1,53 @@
+package resources
+
+import (
+	"time"
+
+	"github.com/riser-platform/riser-server/pkg/core"
+	"google.golang.org/protobuf/types/known/durationpb"
+	networkingv1beta1 "istio.io/api/networking/v1beta1"
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CreateVirtualService creates a VirtualService that splits traffic within the mesh between the revision services in the deployment's
+// traffic config
+func CreateVirtualService(ctx *core.DeploymentContext) *v1beta1.VirtualService {
+	route := &networkingv1beta1.HTTPRoute{
+		Route: createRouteDestinations(ctx.DeploymentConfig.Traffic),
+	}
+	if ctx.DeploymentConfig.App.Request != nil && ctx.DeploymentConfig.App.Request.TimeoutSeconds != nil {
+		route.Timeout = durationpb.New(time.Duration(*ctx.DeploymentConfig.App.Request.TimeoutSeconds) * time.Second)
+	}
+
+	return &v1beta1.VirtualService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        ctx.DeploymentConfig.Name,
+			Namespace:   ctx.DeploymentConfig.Namespace,
+			Labels:      deploymentLabels(ctx),
+			Annotations: deploymentAnnotations(ctx),
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "VirtualService",
+			APIVersion: "networking.istio.io/v1beta1",
+		},
+		Spec: networkingv1beta1.VirtualService{
+			// Short names are resolved in the namespace of the VirtualService
+			Hosts: []string{ctx.DeploymentConfig.Name},
+			Http:  []*networkingv1beta1.HTTPRoute{route},
+		},
+	}
+}
+
+func createRouteDestinations(trafficConfig core.TrafficConfig) []*networkingv1beta1.HTTPRouteDestination {
+	destinations := []*networkingv1beta1.HTTPRouteDestination{}
+	for _, rule := range trafficConfig {
+		destinations = append(destinations, &networkingv1beta1.HTTPRouteDestination{
+			Destination: &networkingv1beta1.Destination{
+				Host: rule.RevisionName,
+			},
+			Weight: int32(rule.Percent),
+		})
+	}
+	return destinations
+}
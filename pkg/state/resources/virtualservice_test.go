@@ -0,0 +1,46 @@
+package resources
+
+import (
+	"testing"
+	"time"
+
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/util"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CreateVirtualService(t *testing.T) {
+	ctx := newRendererTestContext()
+
+	result := CreateVirtualService(ctx)
+
+	assert.Equal(t, "myapp", result.Name)
+	assert.Equal(t, "myns", result.Namespace)
+	assert.Equal(t, deploymentLabels(ctx), result.Labels)
+	assert.Equal(t, deploymentAnnotations(ctx), result.Annotations)
+	assert.Equal(t, "VirtualService", result.TypeMeta.Kind)
+	assert.Equal(t, "networking.istio.io/v1beta1", result.TypeMeta.APIVersion)
+	assert.Equal(t, []string{"myapp"}, result.Spec.Hosts)
+	require.Len(t, result.Spec.Http, 1)
+	routes := result.Spec.Http[0].Route
+	require.Len(t, routes, 2)
+	assert.Equal(t, "myapp-1", routes[0].Destination.Host)
+	assert.EqualValues(t, 50, routes[0].Weight)
+	assert.Equal(t, "myapp-2", routes[1].Destination.Host)
+	assert.EqualValues(t, 50, routes[1].Weight)
+	assert.Nil(t, result.Spec.Http[0].Timeout)
+}
+
+func Test_CreateVirtualService_Timeout(t *testing.T) {
+	ctx := newRendererTestContext()
+	ctx.DeploymentConfig.App.Request = &model.AppConfigRequest{
+		TimeoutSeconds: util.PtrInt64(30),
+	}
+
+	result := CreateVirtualService(ctx)
+
+	require.Len(t, result.Spec.Http, 1)
+	assert.Equal(t, 30*time.Second, result.Spec.Http[0].Timeout.AsDuration())
+}
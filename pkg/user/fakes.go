@@ -0,0 +1,51 @@
+package user
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/riser-platform/riser-server/pkg/core"
+)
+
+type FakeService struct {
+	CreateFn                  func(username string) (*core.User, error)
+	CreateCallCount           int
+	DisableFn                 func(username string) error
+	DisableCallCount          int
+	ListApiKeysFn             func(username string) ([]core.ApiKey, error)
+	CreateApiKeyFn            func(username string, name string, expires *time.Time) (*core.ApiKey, string, error)
+	CreateApiKeyCallCount     int
+	RevokeApiKeyFn            func(username string, id uuid.UUID) error
+	RevokeApiKeyCallCount     int
+	LinkOidcIdentityFn        func(username string, identity *core.OidcIdentity) error
+	LinkOidcIdentityCallCount int
+}
+
+func (fake *FakeService) Create(username string) (*core.User, error) {
+	fake.CreateCallCount++
+	return fake.CreateFn(username)
+}
+
+func (fake *FakeService) Disable(username string) error {
+	fake.DisableCallCount++
+	return fake.DisableFn(username)
+}
+
+func (fake *FakeService) ListApiKeys(username string) ([]core.ApiKey, error) {
+	return fake.ListApiKeysFn(username)
+}
+
+func (fake *FakeService) CreateApiKey(username string, name string, expires *time.Time) (*core.ApiKey, string, error) {
+	fake.CreateApiKeyCallCount++
+	return fake.CreateApiKeyFn(username, name, expires)
+}
+
+func (fake *FakeService) RevokeApiKey(username string, id uuid.UUID) error {
+	fake.RevokeApiKeyCallCount++
+	return fake.RevokeApiKeyFn(username, id)
+}
+
+func (fake *FakeService) LinkOidcIdentity(username string, identity *core.OidcIdentity) error {
+	fake.LinkOidcIdentityCallCount++
+	return fake.LinkOidcIdentityFn(username, identity)
+}
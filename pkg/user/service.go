@@ -0,0 +1,159 @@
+package user
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/login"
+)
+
+type Service interface {
+	Create(username string) (*core.User, error)
+	// Disable prevents the user from logging in. The user's API keys are retained.
+	Disable(username string) error
+	ListApiKeys(username string) ([]core.ApiKey, error)
+	// CreateApiKey creates an API key and returns the plain text key. The plain text key cannot be retrieved again.
+	CreateApiKey(username string, name string, expires *time.Time) (apiKey *core.ApiKey, apiKeyPlainText string, err error)
+	RevokeApiKey(username string, id uuid.UUID) error
+	// LinkOidcIdentity binds an existing user (e.g. a user that was created for an API key) to an OIDC identity so that the user may log
+	// in with an OIDC token
+	LinkOidcIdentity(username string, identity *core.OidcIdentity) error
+}
+
+type service struct {
+	users   core.UserRepository
+	apikeys core.ApiKeyRepository
+}
+
+func NewService(users core.UserRepository, apikeys core.ApiKeyRepository) Service {
+	return &service{users, apikeys}
+}
+
+func (s *service) Create(username string) (*core.User, error) {
+	_, err := s.users.GetByUsername(username)
+	if err == nil {
+		return nil, core.NewValidationErrorMessage(fmt.Sprintf("The user %q already exists", username))
+	}
+	if err != core.ErrNotFound {
+		return nil, errors.Wrap(err, "Error retrieving user")
+	}
+
+	err = s.users.Create(&core.NewUser{Id: uuid.New(), Username: username})
+	if err != nil {
+		return nil, errors.Wrap(err, "Error creating user")
+	}
+
+	return s.users.GetByUsername(username)
+}
+
+func (s *service) Disable(username string) error {
+	if username == login.RootUsername {
+		return core.NewValidationErrorMessage("The root user may not be disabled. Revoke the root user's API keys instead.")
+	}
+
+	user, err := s.getUser(username)
+	if err != nil {
+		return err
+	}
+
+	return s.users.Disable(user.Id)
+}
+
+func (s *service) ListApiKeys(username string) ([]core.ApiKey, error) {
+	user, err := s.getUser(username)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.apikeys.GetByUserId(user.Id)
+}
+
+func (s *service) CreateApiKey(username string, name string, expires *time.Time) (apiKey *core.ApiKey, apiKeyPlainText string, err error) {
+	user, err := s.getUser(username)
+	if err != nil {
+		return nil, "", err
+	}
+
+	existingKeys, err := s.apikeys.GetByUserId(user.Id)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "Error retrieving API keys")
+	}
+	for _, existingKey := range existingKeys {
+		if existingKey.Name == name {
+			return nil, "", core.NewValidationErrorMessage(fmt.Sprintf("The user %q already has an API key named %q", username, name))
+		}
+	}
+
+	apiKeyPlainText, keyHash, err := login.GenerateApiKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	apiKey = &core.ApiKey{
+		Id:      uuid.New(),
+		UserId:  user.Id,
+		Name:    name,
+		KeyHash: keyHash,
+		Created: time.Now().UTC(),
+		Expires: expires,
+	}
+	err = s.apikeys.Create(apiKey)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "Error creating API key")
+	}
+
+	return apiKey, apiKeyPlainText, nil
+}
+
+func (s *service) RevokeApiKey(username string, id uuid.UUID) error {
+	user, err := s.getUser(username)
+	if err != nil {
+		return err
+	}
+
+	return s.apikeys.Delete(user.Id, id)
+}
+
+func (s *service) LinkOidcIdentity(username string, identity *core.OidcIdentity) error {
+	if username == login.RootUsername {
+		return core.NewValidationErrorMessage("The root user may not be linked to an OIDC identity")
+	}
+
+	user, err := s.getUser(username)
+	if err != nil {
+		return err
+	}
+
+	linkedUser, err := s.users.GetByOidcIdentity(identity)
+	if err == nil {
+		if linkedUser.Id == user.Id {
+			return nil
+		}
+		return core.NewValidationErrorMessage(fmt.Sprintf("The OIDC identity is already linked to the user %q", linkedUser.Username))
+	}
+	if err != core.ErrNotFound {
+		return errors.Wrap(err, "Error retrieving user")
+	}
+
+	err = s.users.SetOidcIdentity(user.Id, identity)
+	if err != nil {
+		return errors.Wrap(err, "Error linking OIDC identity")
+	}
+	return nil
+}
+
+// getUser returns a ValidationError when the user does not exist
+func (s *service) getUser(username string) (*core.User, error) {
+	user, err := s.users.GetByUsername(username)
+	if err != nil {
+		if err == core.ErrNotFound {
+			return nil, core.NewValidationErrorMessage(fmt.Sprintf("The user %q does not exist", username))
+		}
+		return nil, errors.Wrap(err, "Error retrieving user")
+	}
+	return user, nil
+}
@@ -0,0 +1,247 @@
+package user
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Create(t *testing.T) {
+	var userId uuid.UUID
+	users := &core.FakeUserRepository{
+		GetByUsernameFn: func(username string) (*core.User, error) {
+			assert.Equal(t, "myuser", username)
+			if userId == uuid.Nil {
+				return nil, core.ErrNotFound
+			}
+			return &core.User{Id: userId, Username: username}, nil
+		},
+		CreateFn: func(newUser *core.NewUser) error {
+			assert.NotEqual(t, uuid.Nil, newUser.Id)
+			assert.Equal(t, "myuser", newUser.Username)
+			userId = newUser.Id
+			return nil
+		},
+	}
+	svc := NewService(users, nil)
+
+	result, err := svc.Create("myuser")
+
+	assert.NoError(t, err)
+	assert.Equal(t, userId, result.Id)
+	assert.Equal(t, 1, users.CreateCallCount)
+}
+
+func Test_Create_AlreadyExists(t *testing.T) {
+	users := &core.FakeUserRepository{
+		GetByUsernameFn: func(username string) (*core.User, error) {
+			return &core.User{Username: username}, nil
+		},
+	}
+	svc := NewService(users, nil)
+
+	result, err := svc.Create("myuser")
+
+	assert.Nil(t, result)
+	assert.IsType(t, &core.ValidationError{}, err)
+	assert.Equal(t, `The user "myuser" already exists`, err.Error())
+	assert.Equal(t, 0, users.CreateCallCount)
+}
+
+func Test_Disable(t *testing.T) {
+	userId := uuid.New()
+	users := &core.FakeUserRepository{
+		GetByUsernameFn: func(username string) (*core.User, error) {
+			return &core.User{Id: userId, Username: username}, nil
+		},
+		DisableFn: func(userIdArg uuid.UUID) error {
+			assert.Equal(t, userId, userIdArg)
+			return nil
+		},
+	}
+	svc := NewService(users, nil)
+
+	err := svc.Disable("myuser")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, users.DisableCallCount)
+}
+
+func Test_Disable_Root(t *testing.T) {
+	svc := NewService(&core.FakeUserRepository{}, nil)
+
+	err := svc.Disable("root")
+
+	assert.IsType(t, &core.ValidationError{}, err)
+}
+
+func Test_Disable_UserDoesNotExist(t *testing.T) {
+	users := &core.FakeUserRepository{
+		GetByUsernameFn: func(string) (*core.User, error) {
+			return nil, core.ErrNotFound
+		},
+	}
+	svc := NewService(users, nil)
+
+	err := svc.Disable("myuser")
+
+	assert.IsType(t, &core.ValidationError{}, err)
+	assert.Equal(t, `The user "myuser" does not exist`, err.Error())
+}
+
+func Test_CreateApiKey(t *testing.T) {
+	userId := uuid.New()
+	expires := time.Now().Add(time.Hour)
+	users := &core.FakeUserRepository{
+		GetByUsernameFn: func(username string) (*core.User, error) {
+			return &core.User{Id: userId, Username: username}, nil
+		},
+	}
+	var created *core.ApiKey
+	apikeys := &core.FakeApiKeyRepository{
+		GetByUserIdFn: func(userIdArg uuid.UUID) ([]core.ApiKey, error) {
+			assert.Equal(t, userId, userIdArg)
+			return []core.ApiKey{{Name: "other"}}, nil
+		},
+		CreateFn: func(apiKey *core.ApiKey) error {
+			created = apiKey
+			return nil
+		},
+	}
+	svc := NewService(users, apikeys)
+
+	apiKey, plainText, err := svc.CreateApiKey("myuser", "ci", &expires)
+
+	assert.NoError(t, err)
+	require.Equal(t, 1, apikeys.CreateCallCount)
+	assert.Equal(t, created, apiKey)
+	assert.NotEqual(t, uuid.Nil, apiKey.Id)
+	assert.Equal(t, userId, apiKey.UserId)
+	assert.Equal(t, "ci", apiKey.Name)
+	assert.Equal(t, &expires, apiKey.Expires)
+	assert.NotEmpty(t, plainText)
+	assert.NotEmpty(t, apiKey.KeyHash)
+	assert.InDelta(t, time.Now().UTC().Unix(), apiKey.Created.Unix(), 3)
+}
+
+func Test_CreateApiKey_DuplicateName(t *testing.T) {
+	users := &core.FakeUserRepository{
+		GetByUsernameFn: func(username string) (*core.User, error) {
+			return &core.User{Username: username}, nil
+		},
+	}
+	apikeys := &core.FakeApiKeyRepository{
+		GetByUserIdFn: func(uuid.UUID) ([]core.ApiKey, error) {
+			return []core.ApiKey{{Name: "ci"}}, nil
+		},
+	}
+	svc := NewService(users, apikeys)
+
+	_, _, err := svc.CreateApiKey("myuser", "ci", nil)
+
+	assert.IsType(t, &core.ValidationError{}, err)
+	assert.Equal(t, `The user "myuser" already has an API key named "ci"`, err.Error())
+	assert.Equal(t, 0, apikeys.CreateCallCount)
+}
+
+func Test_CreateApiKey_ReturnsErr(t *testing.T) {
+	users := &core.FakeUserRepository{
+		GetByUsernameFn: func(username string) (*core.User, error) {
+			return &core.User{Username: username}, nil
+		},
+	}
+	apikeys := &core.FakeApiKeyRepository{
+		GetByUserIdFn: func(uuid.UUID) ([]core.ApiKey, error) {
+			return nil, nil
+		},
+		CreateFn: func(*core.ApiKey) error {
+			return errors.New("test")
+		},
+	}
+	svc := NewService(users, apikeys)
+
+	_, plainText, err := svc.CreateApiKey("myuser", "ci", nil)
+
+	assert.Empty(t, plainText)
+	assert.Equal(t, "Error creating API key: test", err.Error())
+}
+
+func Test_RevokeApiKey(t *testing.T) {
+	userId := uuid.New()
+	keyId := uuid.New()
+	users := &core.FakeUserRepository{
+		GetByUsernameFn: func(username string) (*core.User, error) {
+			return &core.User{Id: userId, Username: username}, nil
+		},
+	}
+	apikeys := &core.FakeApiKeyRepository{
+		DeleteFn: func(userIdArg uuid.UUID, id uuid.UUID) error {
+			assert.Equal(t, userId, userIdArg)
+			assert.Equal(t, keyId, id)
+			return nil
+		},
+	}
+	svc := NewService(users, apikeys)
+
+	err := svc.RevokeApiKey("myuser", keyId)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, apikeys.DeleteCallCount)
+}
+
+func Test_LinkOidcIdentity(t *testing.T) {
+	userId := uuid.New()
+	identity := &core.OidcIdentity{Issuer: "https://issuer", Subject: "1234"}
+	users := &core.FakeUserRepository{
+		GetByUsernameFn: func(username string) (*core.User, error) {
+			assert.Equal(t, "myuser", username)
+			return &core.User{Id: userId, Username: username}, nil
+		},
+		GetByOidcIdentityFn: func(identityArg *core.OidcIdentity) (*core.User, error) {
+			assert.Equal(t, identity, identityArg)
+			return nil, core.ErrNotFound
+		},
+		SetOidcIdentityFn: func(userIdArg uuid.UUID, identityArg *core.OidcIdentity) error {
+			assert.Equal(t, userId, userIdArg)
+			assert.Equal(t, identity, identityArg)
+			return nil
+		},
+	}
+	svc := NewService(users, nil)
+
+	err := svc.LinkOidcIdentity("myuser", identity)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, users.SetOidcIdentityCallCount)
+}
+
+func Test_LinkOidcIdentity_LinkedToOtherUser(t *testing.T) {
+	users := &core.FakeUserRepository{
+		GetByUsernameFn: func(username string) (*core.User, error) {
+			return &core.User{Id: uuid.New(), Username: username}, nil
+		},
+		GetByOidcIdentityFn: func(*core.OidcIdentity) (*core.User, error) {
+			return &core.User{Id: uuid.New(), Username: "otheruser"}, nil
+		},
+	}
+	svc := NewService(users, nil)
+
+	err := svc.LinkOidcIdentity("myuser", &core.OidcIdentity{Issuer: "https://issuer", Subject: "1234"})
+
+	assert.IsType(t, &core.ValidationError{}, err)
+	assert.Equal(t, `The OIDC identity is already linked to the user "otheruser"`, err.Error())
+	assert.Equal(t, 0, users.SetOidcIdentityCallCount)
+}
+
+func Test_LinkOidcIdentity_Root(t *testing.T) {
+	svc := NewService(&core.FakeUserRepository{}, nil)
+
+	err := svc.LinkOidcIdentity("root", &core.OidcIdentity{Issuer: "https://issuer", Subject: "1234"})
+
+	assert.IsType(t, &core.ValidationError{}, err)
+}
@@ -0,0 +1,38 @@
+package util
+
+import (
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// UnifiedDiff returns a unified diff between two versions of a file. Empty contents represent a file that does not exist.
+// Returns an empty string if there are no differences.
+func UnifiedDiff(fileName string, from, to []byte) (string, error) {
+	fromFile, toFile := "a/"+fileName, "b/"+fileName
+	if len(from) == 0 {
+		fromFile = "/dev/null"
+	}
+	if len(to) == 0 {
+		toFile = "/dev/null"
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(from),
+		B:        splitLines(to),
+		FromFile: fromFile,
+		ToFile:   toFile,
+		Context:  3,
+	})
+}
+
+// splitLines splits contents into lines that each end with a newline. Unlike difflib.SplitLines a trailing newline does not
+// produce an extra empty line.
+func splitLines(contents []byte) []string {
+	lines := strings.SplitAfter(string(contents), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		lines[len(lines)-1] += "\n"
+	}
+	return lines
+}
@@ -0,0 +1,29 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_UnifiedDiff(t *testing.T) {
+	result, err := UnifiedDiff("test.yaml", []byte("a: 1\nb: 2\n"), []byte("a: 1\nb: 3\n"))
+
+	require.NoError(t, err)
+	assert.Equal(t, "--- a/test.yaml\n+++ b/test.yaml\n@@ -1,2 +1,2 @@\n a: 1\n-b: 2\n+b: 3\n", result)
+}
+
+func Test_UnifiedDiff_NewFile(t *testing.T) {
+	result, err := UnifiedDiff("test.yaml", nil, []byte("a: 1\n"))
+
+	require.NoError(t, err)
+	assert.Equal(t, "--- /dev/null\n+++ b/test.yaml\n@@ -0,0 +1 @@\n+a: 1\n", result)
+}
+
+func Test_UnifiedDiff_NoChanges(t *testing.T) {
+	result, err := UnifiedDiff("test.yaml", []byte("a: 1\n"), []byte("a: 1\n"))
+
+	require.NoError(t, err)
+	assert.Empty(t, result)
+}
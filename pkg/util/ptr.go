@@ -19,3 +19,7 @@ func PtrFloat32(v float32) *float32 {
 func PtrBool(v bool) *bool {
 	return &v
 }
+
+func PtrString(v string) *string {
+	return &v
+}